@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// maxBenchDiffs is the number of differing templates listed per config pair
+// without -verbose
+const maxBenchDiffs = 10
+
+// benchEntry is a parser setup compared by the bench subcommand
+type benchEntry struct {
+	name      string
+	algorithm string
+	newParser func() parser.LogParser
+}
+
+// benchRun is the measurement of one entry
+type benchRun struct {
+	name       string
+	algorithm  string
+	elapsed    time.Duration // Fastest run
+	allocated  uint64        // Bytes allocated by the fastest run
+	templates  map[string]int
+	evaluation *parser.Evaluation // Accuracy against the ground truth, if any
+}
+
+// benchResult is the machine-readable form of a benchRun
+type benchResult struct {
+	Name           string             `json:"name"`
+	Algorithm      string             `json:"algorithm"`
+	Lines          int                `json:"lines"`
+	Templates      int                `json:"templates"`
+	Seconds        float64            `json:"seconds"`
+	LinesPerSecond float64            `json:"lines_per_second"`
+	AllocatedBytes uint64             `json:"allocated_bytes"`
+	Accuracy       *parser.Evaluation `json:"accuracy,omitempty"`
+}
+
+// runBench runs the bench command: it compares the speed and results of
+// configurations or algorithms on the input or the lines of -truth
+func runBench(args []string) {
+	fs := newFlagSet("bench")
+	input := addInputFlags(fs, true)
+	brain := addParserFlags(fs)
+	algorithms := addAlgorithmFlags(fs, false)
+	var (
+		configs      = fs.String("configs", "", "Comma-separated JSON, YAML or TOML configuration files to compare")
+		algos        = fs.String("algorithms", "", "Comma-separated algorithms to compare: brain, drain, spell")
+		runs         = fs.Int("runs", 3, "Runs per configuration, the fastest is reported")
+		truthFile    = fs.String("truth", "", "LogHub structured CSV file parsed instead of -input, reporting accuracy against its ground truth")
+		outputFormat = fs.String("format", "table", "Output format: table, json, csv")
+		verbose      = fs.Bool("verbose", false, "List all templates differing between configurations")
+	)
+	_ = fs.Parse(args) // Exits on error
+	switch {
+	case *configs == "" && *algos == "":
+		log.Fatal("bench requires -configs or -algorithms")
+	case *truthFile != "" && *input.input != "":
+		log.Fatal("bench cannot be combined with both -input and -truth")
+	case *outputFormat != "table" && *outputFormat != "json" && *outputFormat != "csv":
+		log.Fatal("bench supports only table, json and csv output")
+	}
+
+	status := io.Writer(os.Stdout)
+	if *outputFormat != "table" {
+		status = os.Stderr // Keep stdout parseable
+	}
+	var logLines []string
+	var truth *parser.GroundTruth
+	if *truthFile != "" {
+		fmt.Fprintf(status, "Benchmarking against the ground truth of %s...\n", *truthFile)
+		var err error
+		if truth, err = readGroundTruth(*truthFile); err != nil {
+			log.Fatalf("Error running benchmark: %v", err)
+		}
+		logLines = truth.Lines
+	} else {
+		input.requirePipedInput(fs)
+		inputs, err := readInputs(*input.input, input.options())
+		if err != nil {
+			log.Fatalf("Error reading input file: %v", err)
+		}
+		if logLines = mergeInputs(inputs).lines; len(logLines) == 0 {
+			fmt.Fprintln(status, "No log lines found in input file")
+			return
+		}
+		fmt.Fprintf(status, "Processing %d log lines...\n", len(logLines))
+	}
+
+	config := brain.config()
+	if features := enhancedFeatures(config, len(logLines)); features != "" {
+		fmt.Fprintf(status, "Enhanced features enabled: %s\n", features)
+	}
+	entries, err := benchConfigEntries(splitList(*configs), brain, config)
+	if err != nil {
+		log.Fatalf("Error running benchmark: %v", err)
+	}
+	factory := algorithms.factory(config)
+	for _, algorithm := range splitList(*algos) {
+		newParser, err := factory(algorithm)
+		if err != nil {
+			log.Fatalf("Error running benchmark: %v", err)
+		}
+		entries = append(entries, benchEntry{name: algorithm, algorithm: algorithm, newParser: newParser})
+	}
+	if err := benchmark(logLines, truth, entries, *runs, *outputFormat, *verbose); err != nil {
+		log.Fatalf("Error running benchmark: %v", err)
+	}
+}
+
+// benchConfigEntries returns a Brain entry for every configuration file.
+// Parser flags set explicitly take precedence over the files, as with
+// -config.
+func benchConfigEntries(configFiles []string, flags *parserFlags, flagConfig parser.Config) ([]benchEntry, error) {
+	var entries []benchEntry
+	for _, filename := range configFiles {
+		config, err := parser.LoadConfig(filename)
+		if err != nil {
+			return nil, fmt.Errorf("config %s: %w", filename, err)
+		}
+		flags.applySet(&config, flagConfig)
+		if err := config.Validate(); err != nil {
+			return nil, fmt.Errorf("config %s: %w", filename, err)
+		}
+		entries = append(entries, benchEntry{
+			name:      filepath.Base(filename),
+			algorithm: parser.AlgorithmBrain,
+			newParser: func() parser.LogParser {
+				return parser.New(config)
+			},
+		})
+	}
+	return entries, nil
+}
+
+// benchmark parses logLines with every entry and reports throughput, memory,
+// template counts and, given a ground truth of logLines, accuracy. The table
+// format also lists the template differences of every pair, json and csv
+// write one record per entry.
+func benchmark(logLines []string, truth *parser.GroundTruth, entries []benchEntry, runs int, format string, verbose bool) error {
+	if runs < 1 {
+		runs = 1
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("-configs and -algorithms list nothing to compare")
+	}
+	if len(logLines) == 0 {
+		return fmt.Errorf("no log lines to parse")
+	}
+
+	measured := make([]benchRun, len(entries))
+	for i, entry := range entries {
+		run, err := measureParser(entry, logLines, truth, runs)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.name, err)
+		}
+		measured[i] = run
+	}
+
+	switch format {
+	case "json", "csv":
+		results := make([]benchResult, len(measured))
+		for i, run := range measured {
+			results[i] = benchResult{
+				Name:           run.name,
+				Algorithm:      run.algorithm,
+				Lines:          len(logLines),
+				Templates:      len(run.templates),
+				Seconds:        run.elapsed.Seconds(),
+				LinesPerSecond: float64(len(logLines)) / run.elapsed.Seconds(),
+				AllocatedBytes: run.allocated,
+				Accuracy:       run.evaluation,
+			}
+		}
+		if format == "csv" {
+			return writeBenchCSV(os.Stdout, results)
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	}
+
+	if truth != nil {
+		fmt.Printf("%-24s %10s %12s %14s %12s %8s %8s %8s\n", "CONFIG", "TEMPLATES", "TIME", "LINES/S", "ALLOC_MB", "GA", "PA", "F1")
+		fmt.Println(strings.Repeat("-", 103))
+	} else {
+		fmt.Printf("%-24s %10s %12s %14s %12s\n", "CONFIG", "TEMPLATES", "TIME", "LINES/S", "ALLOC_MB")
+		fmt.Println(strings.Repeat("-", 76))
+	}
+	for _, run := range measured {
+		throughput := float64(len(logLines)) / run.elapsed.Seconds()
+		fmt.Printf("%-24s %10d %12s %14.0f %12.1f", run.name, len(run.templates),
+			run.elapsed.Round(time.Microsecond), throughput, float64(run.allocated)/(1024*1024))
+		if run.evaluation != nil {
+			fmt.Printf(" %8.4f %8.4f %8.4f", run.evaluation.GroupingAccuracy, run.evaluation.ParsingAccuracy, run.evaluation.F1)
+		}
+		fmt.Println()
+	}
+
+	for i := 0; i < len(measured); i++ {
+		for j := i + 1; j < len(measured); j++ {
+			printBenchDiff(measured[i], measured[j], verbose)
+		}
+	}
+	return nil
+}
+
+// measureParser parses logLines runs times with a fresh parser of entry and
+// keeps the fastest run, evaluating the results of the first against truth
+func measureParser(entry benchEntry, logLines []string, truth *parser.GroundTruth, runs int) (benchRun, error) {
+	run := benchRun{name: entry.name, algorithm: entry.algorithm}
+	var before, after runtime.MemStats
+	for i := 0; i < runs; i++ {
+		logParser := entry.newParser()
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		results := logParser.Parse(logLines)
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		if i == 0 || elapsed < run.elapsed {
+			run.elapsed = elapsed
+			run.allocated = after.TotalAlloc - before.TotalAlloc
+		}
+		if run.templates != nil {
+			continue
+		}
+		run.templates = make(map[string]int, len(results))
+		for _, result := range results {
+			run.templates[result.Template] += result.Count
+		}
+		if truth != nil {
+			evaluating, ok := logParser.(evaluator)
+			if !ok {
+				return run, errors.New("parser cannot evaluate templates")
+			}
+			evaluation, err := evaluating.Evaluate(results, truth)
+			if err != nil {
+				return run, err
+			}
+			run.evaluation = evaluation
+		}
+	}
+	return run, nil
+}
+
+// writeBenchCSV writes one row per bench result, with empty accuracy
+// columns without a ground truth
+func writeBenchCSV(w io.Writer, results []benchResult) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"name", "algorithm", "lines", "templates", "seconds", "lines_per_second", "allocated_bytes",
+		"grouping_accuracy", "parsing_accuracy", "precision", "recall", "f1"})
+	for _, result := range results {
+		record := []string{
+			result.Name,
+			result.Algorithm,
+			strconv.Itoa(result.Lines),
+			strconv.Itoa(result.Templates),
+			strconv.FormatFloat(result.Seconds, 'f', -1, 64),
+			strconv.FormatFloat(result.LinesPerSecond, 'f', 0, 64),
+			strconv.FormatUint(result.AllocatedBytes, 10),
+			"", "", "", "", "",
+		}
+		if accuracy := result.Accuracy; accuracy != nil {
+			for i, value := range []float64{accuracy.GroupingAccuracy, accuracy.ParsingAccuracy, accuracy.Precision, accuracy.Recall, accuracy.F1} {
+				record[7+i] = strconv.FormatFloat(value, 'f', 4, 64)
+			}
+		}
+		_ = writer.Write(record)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// printBenchDiff prints the templates found by only one of two runs
+func printBenchDiff(a, b benchRun, verbose bool) {
+	onlyA := benchOnlyIn(a.templates, b.templates)
+	onlyB := benchOnlyIn(b.templates, a.templates)
+	shared := len(a.templates) - len(onlyA)
+	fmt.Printf("\n%s vs %s: %d shared, %d only in %s, %d only in %s\n",
+		a.name, b.name, shared, len(onlyA), a.name, len(onlyB), b.name)
+
+	for _, side := range []struct {
+		marker    string
+		templates []string
+		counts    map[string]int
+	}{{"-", onlyA, a.templates}, {"+", onlyB, b.templates}} {
+		for i, template := range side.templates {
+			if !verbose && i == maxBenchDiffs {
+				fmt.Printf("  %s ... %d more (use -verbose)\n", side.marker, len(side.templates)-i)
+				break
+			}
+			fmt.Printf("  %s %6d %s\n", side.marker, side.counts[template], template)
+		}
+	}
+}
+
+// benchOnlyIn returns the templates of a missing in b, by count descending
+func benchOnlyIn(a, b map[string]int) []string {
+	var only []string
+	for template := range a {
+		if _, ok := b[template]; !ok {
+			only = append(only, template)
+		}
+	}
+	sort.Slice(only, func(i, j int) bool {
+		if a[only[i]] != a[only[j]] {
+			return a[only[i]] > a[only[j]]
+		}
+		return only[i] < only[j]
+	})
+	return only
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// command is a subcommand of brain-cli
+type command struct {
+	name    string
+	usage   string // Synopsis
+	summary string // One-line description
+}
+
+// defaultCommand runs without a subcommand name
+const defaultCommand = "parse"
+
+// commands lists the subcommands of brain-cli
+var commands = []command{
+	{
+		name:    "parse",
+		usage:   "brain-cli [parse] [flags]",
+		summary: "Learn templates from log input and output them (default)",
+	},
+	{
+		name:    "match",
+		usage:   "brain-cli match -load-state FILE [flags]",
+		summary: "Assign every input line to a template of a saved state without learning",
+	},
+	{
+		name:    "diff",
+		usage:   "brain-cli diff [flags] OLD NEW",
+		summary: "Compare the templates of two saved states or log inputs",
+	},
+	{
+		name:    "serve",
+		usage:   "brain-cli serve [flags]",
+		summary: "Learn templates from REST API requests and GELF messages, or serve the catalog of an input",
+	},
+	{
+		name:    "evaluate",
+		usage:   "brain-cli evaluate [flags] file_structured.csv",
+		summary: "Measure grouping accuracy against the ground truth of a LogHub structured CSV file",
+	},
+	{
+		name:    "bench",
+		usage:   "brain-cli bench -configs a.json,b.json | -algorithms brain,drain,spell [flags]",
+		summary: "Compare the speed and results of configurations or algorithms",
+	},
+	{
+		name:    "rpc",
+		usage:   "brain-cli rpc [flags]",
+		summary: "Serve JSON-RPC over stdin/stdout",
+	},
+}
+
+// lookupCommand returns the subcommand called name
+func lookupCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet returns an empty flag set of the subcommand called name. Its
+// usage prints the synopsis and the flags of the command; the default command
+// also lists the subcommands.
+func newFlagSet(name string) *flag.FlagSet {
+	c, _ := lookupCommand(name)
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s\n\n%s\n", c.usage, c.summary)
+		if c.name == defaultCommand {
+			fmt.Fprintf(out, "\nCommands:\n")
+			for _, other := range commands {
+				fmt.Fprintf(out, "  %-9s %s\n", other.name, other.summary)
+			}
+			fmt.Fprintf(out, "\nRun brain-cli COMMAND -h for the flags of a command.\n")
+		}
+		fmt.Fprintf(out, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	return fs
+}
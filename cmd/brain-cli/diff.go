@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// runDiff runs the diff command: it compares the templates of two saved
+// states or log inputs and exits with 1 if they changed, like diff(1)
+func runDiff(args []string) {
+	fs := newFlagSet("diff")
+	input := addInputFlags(fs, false)
+	brain := addParserFlags(fs)
+	algorithms := addAlgorithmFlags(fs, true)
+	var (
+		minShift     = fs.Float64("min-shift", 2, "Factor by which the share of lines of a template must change to be reported")
+		outputFormat = fs.String("format", "table", "Output format: table, json")
+	)
+	_ = fs.Parse(args) // Exits on error
+	switch {
+	case fs.NArg() != 2:
+		log.Fatal("diff requires two saved states or log inputs: brain-cli diff [flags] OLD NEW")
+	case *minShift < 1:
+		log.Fatal("-min-shift must be at least 1")
+	case *outputFormat != "table" && *outputFormat != "json":
+		log.Fatal("diff supports only table and json output")
+	}
+
+	status := io.Writer(os.Stdout)
+	if *outputFormat != "table" {
+		status = os.Stderr // Keep stdout parseable
+	}
+	fmt.Fprintf(status, "Comparing the templates of %s and %s...\n", fs.Arg(0), fs.Arg(1))
+	newParser := algorithms.newParser(brain.config())
+	changed, err := compareTemplates(fs.Arg(0), fs.Arg(1), input.options(), newParser, *minShift, *outputFormat == "json", status)
+	if err != nil {
+		log.Fatalf("Error comparing templates: %v", err)
+	}
+	if changed {
+		os.Exit(1)
+	}
+}
+
+// compareTemplates compares the templates of two runs, each a state saved
+// with -save-state or log input parsed by a parser of newParser, prints the
+// templates added, removed or shifted by at least the factor minShift and
+// reports whether there were any
+func compareTemplates(oldSpec, newSpec string, options inputOptions, newParser func() parser.LogParser, minShift float64, asJSON bool, status io.Writer) (bool, error) {
+	old, err := diffResults(oldSpec, options, newParser)
+	if err != nil {
+		return false, err
+	}
+	new, err := diffResults(newSpec, options, newParser)
+	if err != nil {
+		return false, err
+	}
+	diff := parser.DiffTemplates(old, new).Significant(minShift)
+
+	fmt.Fprintf(status, "Found %d added, %d removed and %d templates shifted by %gx or more (%d lines before, %d after):\n\n",
+		len(diff.Added), len(diff.Removed), len(diff.Shifted), minShift, diff.OldLines, diff.NewLines)
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false) // Keep <*> readable
+		encoder.SetIndent("", "  ")
+		return diff.Changed(), encoder.Encode(diff)
+	}
+
+	fmt.Printf("%-8s %10s %10s %8s  %s\n", "CHANGE", "OLD", "NEW", "SHIFT", "TEMPLATE")
+	fmt.Println(strings.Repeat("-", 96))
+	for _, drift := range diff.Added {
+		fmt.Printf("%-8s %10s %10d %8s  %s\n", "added", "-", drift.NewCount, "-", drift.Template)
+	}
+	for _, drift := range diff.Removed {
+		fmt.Printf("%-8s %10d %10s %8s  %s\n", "removed", drift.OldCount, "-", "-", drift.Template)
+	}
+	for _, drift := range diff.Shifted {
+		fmt.Printf("%-8s %10d %10d %7.2fx  %s\n", "shifted", drift.OldCount, drift.NewCount, drift.Ratio, drift.Template)
+	}
+	return diff.Changed(), nil
+}
+
+// diffResults returns the templates of a state saved with -save-state of
+// either algorithm, or parses log input with a parser of newParser
+func diffResults(spec string, options inputOptions, newParser func() parser.LogParser) ([]*parser.ParseResult, error) {
+	if spec != "-" {
+		if loaded, err := loadParserFile(spec); err == nil {
+			if state, ok := loaded.(snapshotter); ok {
+				if results := state.Snapshot(); len(results) > 0 {
+					return results, nil
+				}
+			}
+		}
+	}
+	inputs, err := readInputs(spec, options)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", spec, err)
+	}
+	lines := mergeInputs(inputs).lines
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: no log lines found", spec)
+	}
+	return newParser().Parse(lines), nil
+}
+
+// snapshotter is a parser reporting its learned templates with their
+// accumulated counts
+type snapshotter interface {
+	Snapshot() []*parser.ParseResult
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// runEvaluate runs the evaluate command: it measures the accuracy of the
+// templates of a LogHub structured CSV file against its ground truth
+func runEvaluate(args []string) {
+	fs := newFlagSet("evaluate")
+	brain := addParserFlags(fs)
+	algorithms := addAlgorithmFlags(fs, true)
+	outputFormat := fs.String("format", "table", "Output format: table, json")
+	_ = fs.Parse(args) // Exits on error
+	switch {
+	case fs.NArg() != 1:
+		log.Fatal("evaluate requires a LogHub structured CSV file: brain-cli evaluate [flags] file_structured.csv")
+	case *outputFormat != "table" && *outputFormat != "json":
+		log.Fatal("evaluate supports only table and json output")
+	}
+
+	status := io.Writer(os.Stdout)
+	if *outputFormat != "table" {
+		status = os.Stderr // Keep stdout parseable
+	}
+	fmt.Fprintf(status, "Evaluating templates against the ground truth of %s...\n", fs.Arg(0))
+	newParser := algorithms.newParser(brain.config())
+	if err := evaluateTemplates(fs.Arg(0), newParser, *outputFormat == "json"); err != nil {
+		log.Fatalf("Error evaluating templates: %v", err)
+	}
+}
+
+// evaluateTemplates parses the lines of a LogHub structured CSV file with a
+// parser of newParser and prints the accuracy of the templates against its
+// ground truth
+func evaluateTemplates(filename string, newParser func() parser.LogParser, asJSON bool) error {
+	truth, err := readGroundTruth(filename)
+	if err != nil {
+		return err
+	}
+
+	logParser, ok := newParser().(evaluator)
+	if !ok {
+		return errors.New("parser cannot evaluate templates")
+	}
+	evaluation, err := logParser.Evaluate(logParser.Parse(truth.Lines), truth)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(evaluation)
+	}
+
+	fmt.Printf("%-20s %d\n", "Lines:", evaluation.Lines)
+	fmt.Printf("%-20s %d (ground truth: %d)\n", "Templates:", evaluation.Templates, evaluation.TruthTemplates)
+	fmt.Printf("%-20s %.4f\n", "Grouping accuracy:", evaluation.GroupingAccuracy)
+	fmt.Printf("%-20s %.4f\n", "Parsing accuracy:", evaluation.ParsingAccuracy)
+	fmt.Printf("%-20s %.4f\n", "Precision:", evaluation.Precision)
+	fmt.Printf("%-20s %.4f\n", "Recall:", evaluation.Recall)
+	fmt.Printf("%-20s %.4f\n", "F1:", evaluation.F1)
+	return nil
+}
+
+// readGroundTruth reads a LogHub structured CSV file
+func readGroundTruth(filename string) (*parser.GroundTruth, error) {
+	file, err := os.Open(filename) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ground truth: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return parser.ReadLogHubStructured(file)
+}
+
+// evaluator is a parser measuring its results against a ground truth
+type evaluator interface {
+	parser.LogParser
+	Evaluate(results []*parser.ParseResult, truth *parser.GroundTruth) (*parser.Evaluation, error)
+}
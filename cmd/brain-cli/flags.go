@@ -0,0 +1,468 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// inputFlags select and decode log input
+type inputFlags struct {
+	input         *string // nil for commands naming their inputs as arguments
+	fileType      *string
+	csvColumn     *string
+	csvDelimiter  *string
+	csvLazyQuotes *bool
+	noHeader      *bool
+	csvLenient    *bool
+	jsonMessage   *string
+	jsonFields    *string
+	logfmtMessage *string
+	logRegex      *string
+}
+
+// addInputFlags defines the input flags on fs. Without withInput, -input is
+// left out for commands naming their inputs as arguments.
+func addInputFlags(fs *flag.FlagSet, withInput bool) *inputFlags {
+	f := &inputFlags{}
+	if withInput {
+		f.input = fs.String("input", "", "Input files: comma-separated paths or glob patterns, - or empty to read from stdin")
+	}
+	f.fileType = fs.String("type", "auto", "File type: auto, text, csv, tsv, psv, json, logfmt, syslog, gelf, cef, leef")
+	f.csvColumn = fs.String("csv-column", "message", "CSV/TSV/PSV column name or 1-based number containing log messages")
+	f.csvDelimiter = fs.String("csv-delimiter", "", "Field delimiter of CSV/TSV/PSV files overriding the type's: comma, tab, pipe, semicolon, space or a single character")
+	f.csvLazyQuotes = fs.Bool("csv-lazy-quotes", false, "Keep stray quotes in CSV fields as-is instead of failing (TSV/PSV always do)")
+	f.noHeader = fs.Bool("no-header", false, "CSV/TSV/PSV files have no header row; select the message column by number with -csv-column")
+	f.csvLenient = fs.Bool("csv-lenient", false, "Accept ragged rows, stray quotes and multi-line messages in CSV/TSV/PSV files, skipping malformed rows with a warning")
+	f.jsonMessage = fs.String("json-message", "message", "Dot path of the message field of JSON logs, e.g. log.message")
+	f.jsonFields = fs.String("json-fields", "", "Comma-separated dot paths of JSON log fields carried through to json output, e.g. timestamp,level")
+	f.logfmtMessage = fs.String("logfmt-message", "msg", "Key of the message in logfmt logs; other pairs are carried through to json output")
+	f.logRegex = fs.String("log-regex", "", "Regex to extract message from structured logs (must have 'message' capture group)")
+	return f
+}
+
+// options returns the input options of the flags, exiting if they are invalid
+func (f *inputFlags) options() inputOptions {
+	var delimiter rune
+	if *f.csvDelimiter != "" {
+		var err error
+		if delimiter, err = parser.ParseTabularDelimiter(*f.csvDelimiter); err != nil {
+			log.Fatalf("Invalid -csv-delimiter: %v", err)
+		}
+	}
+	return inputOptions{
+		fileType:  *f.fileType,
+		csvColumn: *f.csvColumn,
+		logRegex:  *f.logRegex,
+		tabular:   parser.TabularOptions{Lenient: *f.csvLenient, Delimiter: delimiter, LazyQuotes: *f.csvLazyQuotes, NoHeader: *f.noHeader},
+		json:      parser.JSONLogOptions{MessageField: *f.jsonMessage, Fields: splitList(*f.jsonFields)},
+		logfmt:    parser.LogfmtOptions{MessageKey: *f.logfmtMessage},
+		remote:    newRemoteOpener(),
+	}
+}
+
+// requirePipedInput exits with the usage of fs if -input reads stdin from a
+// terminal
+func (f *inputFlags) requirePipedInput(fs *flag.FlagSet) {
+	if (*f.input == "" || *f.input == "-") && isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "Error: input file is required when stdin is not piped\n")
+		fs.Usage()
+		os.Exit(1)
+	}
+}
+
+// parserFlags configure the Brain parser
+type parserFlags struct {
+	fs *flag.FlagSet // Flag set the flags are defined on, for applySet
+
+	delimiters     *string
+	threshold      *int
+	useDynamic     *bool
+	dynamicFactor  *float64
+	ignorePos      *string
+	ignoreTokens   *string
+	allowTemplates *string
+	denyTemplates  *string
+	deterministic  *bool
+	seed           *int64
+	profile        *bool
+	maxGroups      *int
+	highCard       *int
+	foldUnicode    *bool
+	variableStats  *bool
+	anomalyScore   *float64
+	approxMatch    *float64
+	positions      *bool
+	unicodeDigits  *bool
+	stablePart     *bool
+	pruneColumns   *bool
+	mergeSubsumed  *bool
+	showExamples   *int
+	varLength      *int
+	quality        *string
+	tsRegex        *string
+	tsLayout       *string
+	configFile     *string
+	saveConfig     *string
+
+	// Enhanced Features (Drain+ Improvements)
+	enhancedPost         *bool
+	statisticalThreshold *bool
+	parallelThreshold    *int
+	enableAllEnhanced    *bool
+
+	// Enhanced Features Tuning Parameters
+	entropyThreshold        *float64
+	minEntropyLength        *int
+	maxConsecutiveWildcards *int
+	minContentWordsRatio    *float64
+	timestampMinDigits      *int
+	timestampMinSeparators  *int
+}
+
+// addParserFlags defines the flags configuring the Brain parser on fs
+func addParserFlags(fs *flag.FlagSet) *parserFlags {
+	return &parserFlags{
+		fs:             fs,
+		delimiters:     fs.String("delimiters", defaultDelimiters, "Regex pattern for token delimiters"),
+		threshold:      fs.Int("threshold", defaultChildBranchThreshold, "Child branch threshold"),
+		useDynamic:     fs.Bool("dynamic", true, "Use dynamic threshold calculation"),
+		dynamicFactor:  fs.Float64("dynamic-factor", defaultDynamicThresholdFactor, "Dynamic threshold factor"),
+		ignorePos:      fs.String("ignore-positions", "", "Comma-separated token positions to exclude from grouping (e.g. 0,2)"),
+		ignoreTokens:   fs.String("ignore-tokens", "", "Regex of tokens to exclude from grouping"),
+		allowTemplates: fs.String("allow-templates", "", "Regex of templates to keep, all others are dropped from results"),
+		denyTemplates:  fs.String("deny-templates", "", "Regex of templates to drop from results (e.g. healthcheck)"),
+		deterministic:  fs.Bool("deterministic", false, "Produce identical results and ordering across runs"),
+		seed:           fs.Int64("seed", 0, "With -deterministic, break ties between equally ranked columns by a permutation of this seed (0 = position order)"),
+		profile:        fs.Bool("profile", false, "Print per-phase timing and memory profile to stderr"),
+		maxGroups:      fs.Int("max-groups", 0, "Soft cap on initial group count, overflow is merged by length (0 = no limit)"),
+		highCard:       fs.Int("high-cardinality-limit", 0, "Distinct words from which a column is marked variable without splitting (0 = 1000)"),
+		foldUnicode:    fs.Bool("fold-unicode", false, "Fold Unicode spaces, full-width ASCII, smart quotes and dashes to ASCII before tokenizing"),
+		variableStats:  fs.Bool("variable-stats", false, "Learn numeric slot value statistics so rpc match flags outlier values"),
+		anomalyScore:   fs.Float64("anomaly-threshold", 4, "Log-scale z-score above which -variable-stats flags a slot value"),
+		approxMatch:    fs.Float64("approximate-match", 0, "Minimum similarity (0-1) of the closest template rpc match falls back to (0 = exact only)"),
+		positions:      fs.Bool("positions", false, "Include per-token metadata with inferred variable types in json output"),
+		unicodeDigits:  fs.Bool("unicode-digits", false, "Detect Unicode digits and digit group separators in numeric variables"),
+		stablePart:     fs.Bool("stable-partitioning", false, "Route groups to parallel workers by a stable hash for reproducible parallel runs"),
+		pruneColumns:   fs.Bool("prune-constant-columns", false, "Exclude leading columns constant across all lines from processing and re-insert them into templates"),
+		mergeSubsumed:  fs.Bool("merge-subsumed", false, "Merge templates into a template equal but for <*> at one position where they have a constant"),
+		showExamples:   fs.Int("show-examples", 0, "Show up to N sampled example lines per template in table and json output (0 = table none, json the first 3)"),
+		varLength:      fs.Int("variable-length", 0, "Merge templates extending a shorter template by up to N trailing tokens into it, ending in <*>... (0 = off)"),
+		quality:        fs.String("quality", "", "Quality policy gating templates by shape and support: strict, balanced or lenient (replaces the defaults of -max-consecutive-wildcards and -min-content-ratio)"),
+		tsRegex:        fs.String("timestamp-regex", "", "Regex finding the timestamp of a line (its 'timestamp' group or the whole match), so last-seen times, -expire-after and the audit log follow log time"),
+		tsLayout:       fs.String("timestamp-layout", time.RFC3339, "Go time layout of -timestamp-regex timestamps, or unix or unixms"),
+		configFile:     fs.String("config", "", "Load parser configuration from a JSON, YAML or TOML file, explicitly set flags take precedence"),
+		saveConfig:     fs.String("save-config", "", "Write the effective parser configuration to a JSON file"),
+
+		enhancedPost:         fs.Bool("enhanced-post", false, "Enable enhanced post-processing for advanced variable detection"),
+		statisticalThreshold: fs.Bool("statistical-threshold", false, "Use statistical analysis for adaptive threshold calculation"),
+		parallelThreshold:    fs.Int("parallel-threshold", 1000, "Minimum log count in group to enable parallel processing"),
+		enableAllEnhanced:    fs.Bool("enhanced", false, "Enable all enhanced features (equivalent to --enhanced-post --statistical-threshold)"),
+
+		entropyThreshold:        fs.Float64("entropy-threshold", 0.85, "Threshold for entropy-based variable detection (lower = more aggressive)"),
+		minEntropyLength:        fs.Int("min-entropy-length", 10, "Minimum word length for entropy analysis"),
+		maxConsecutiveWildcards: fs.Int("max-consecutive-wildcards", 5, "Maximum consecutive <*> tokens in template (0 = no limit)"),
+		minContentWordsRatio:    fs.Float64("min-content-ratio", 0.25, "Minimum ratio of non-<*> words in template"),
+		timestampMinDigits:      fs.Int("timestamp-min-digits", 8, "Minimum digits for timestamp detection"),
+		timestampMinSeparators:  fs.Int("timestamp-min-separators", 2, "Minimum separators for timestamp detection"),
+	}
+}
+
+// config returns the parser configuration of the flags merged into -config
+// and writes it to -save-config, exiting if it is invalid
+func (f *parserFlags) config() parser.Config {
+	if *f.enableAllEnhanced {
+		*f.enhancedPost = true
+		*f.statisticalThreshold = true
+	}
+	ignorePositions, err := parsePositions(*f.ignorePos)
+	if err != nil {
+		log.Fatalf("Invalid -ignore-positions: %v", err)
+	}
+	var ignoreTokenPatterns []string
+	if *f.ignoreTokens != "" {
+		if _, err := regexp.Compile(*f.ignoreTokens); err != nil {
+			log.Fatalf("Invalid -ignore-tokens: %v", err)
+		}
+		ignoreTokenPatterns = []string{*f.ignoreTokens}
+	}
+	allowPatterns, err := templatePatterns("allow-templates", *f.allowTemplates)
+	if err != nil {
+		log.Fatal(err)
+	}
+	denyPatterns, err := templatePatterns("deny-templates", *f.denyTemplates)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	config := parser.Config{
+		Delimiters:                  *f.delimiters,
+		ChildBranchThreshold:        *f.threshold,
+		UseDynamicThreshold:         *f.useDynamic,
+		DynamicThresholdFactor:      *f.dynamicFactor,
+		Weight:                      0.0, // Offline mode
+		UseEnhancedPostProcessing:   *f.enhancedPost,
+		UseStatisticalThreshold:     *f.statisticalThreshold,
+		ParallelProcessingThreshold: *f.parallelThreshold,
+		IgnorePositions:             ignorePositions,
+		IgnoreTokenPatterns:         ignoreTokenPatterns,
+		TemplateAllowPatterns:       allowPatterns,
+		TemplateDenyPatterns:        denyPatterns,
+		Deterministic:               *f.deterministic,
+		Seed:                        *f.seed,
+		EnableProfiling:             *f.profile,
+		MaxInitialGroups:            *f.maxGroups,
+		HighCardinalityLimit:        *f.highCard,
+		UnicodeDigits:               *f.unicodeDigits,
+		FoldUnicode:                 *f.foldUnicode,
+		TemplatePositions:           *f.positions,
+		VariableStatistics:          *f.variableStats,
+		AnomalyThreshold:            *f.anomalyScore,
+		ApproximateMatch:            *f.approxMatch,
+		StablePartitioning:          *f.stablePart,
+		PruneConstantColumns:        *f.pruneColumns,
+		MergeSubsumedTemplates:      *f.mergeSubsumed,
+		VariableLengthTokens:        *f.varLength,
+		ExamplesPerTemplate:         *f.showExamples,
+		QualityPolicy:               *f.quality,
+
+		// Enhanced Features Tuning Parameters
+		EntropyThreshold:        *f.entropyThreshold,
+		MinEntropyLength:        *f.minEntropyLength,
+		MaxConsecutiveWildcards: *f.maxConsecutiveWildcards,
+		MinContentWordsRatio:    *f.minContentWordsRatio,
+		TimestampMinDigits:      *f.timestampMinDigits,
+		TimestampMinSeparators:  *f.timestampMinSeparators,
+	}
+
+	if *f.quality != "" {
+		// Thresholds not set explicitly are taken from the policy
+		flagConfig := config
+		config.MaxConsecutiveWildcards, config.MinContentWordsRatio = 0, 0
+		f.applySet(&config, flagConfig)
+	}
+
+	if *f.configFile != "" {
+		fileConfig, err := parser.LoadConfig(*f.configFile)
+		if err != nil {
+			log.Fatalf("Error loading config: %v", err)
+		}
+		f.applySet(&fileConfig, config)
+		config = fileConfig
+	}
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	if *f.tsRegex != "" {
+		if config.Timestamps, err = parser.NewTimestampExtractor(*f.tsRegex, *f.tsLayout); err != nil {
+			log.Fatalf("Invalid -timestamp-regex: %v", err)
+		}
+	}
+	if *f.saveConfig != "" {
+		if err := saveConfigFile(*f.saveConfig, config); err != nil {
+			log.Fatalf("Error saving config: %v", err)
+		}
+	}
+	return config
+}
+
+// enhancedFeatures describes the enhanced features config enables for
+// lineCount lines, empty if none
+func enhancedFeatures(config parser.Config, lineCount int) string {
+	var enabled []string
+	if config.UseEnhancedPostProcessing {
+		enabled = append(enabled, "enhanced-post-processing")
+	}
+	if config.UseStatisticalThreshold {
+		enabled = append(enabled, "statistical-threshold")
+	}
+	if config.ParallelProcessingThreshold < 1000 {
+		enabled = append(enabled, fmt.Sprintf("parallel-processing(threshold=%d)", config.ParallelProcessingThreshold))
+	} else if lineCount >= config.ParallelProcessingThreshold {
+		enabled = append(enabled, "parallel-processing")
+	}
+	return strings.Join(enabled, ", ")
+}
+
+// algorithmFlags configure the Drain and Spell parsers and, for commands
+// parsing with one algorithm, select it
+type algorithmFlags struct {
+	algorithm     *string // nil for commands comparing algorithms
+	drainDepth    *int
+	drainSim      *float64
+	drainChildren *int
+	spellSim      *float64
+}
+
+// addAlgorithmFlags defines the Drain and Spell flags on fs. With choose,
+// -algorithm selects the algorithm of the command.
+func addAlgorithmFlags(fs *flag.FlagSet, choose bool) *algorithmFlags {
+	f := &algorithmFlags{}
+	if choose {
+		f.algorithm = fs.String("algorithm", parser.AlgorithmBrain, "Parsing algorithm: brain, drain or spell")
+	}
+	f.drainDepth = fs.Int("drain-depth", 4, "Depth of the Drain parse tree, at least 3 (-algorithm drain)")
+	f.drainSim = fs.Float64("drain-similarity", 0.4, "Minimum share of equal tokens for a line to join a Drain template (-algorithm drain)")
+	f.drainChildren = fs.Int("drain-max-children", 100, "Maximum children of a Drain tree node (-algorithm drain)")
+	f.spellSim = fs.Float64("spell-similarity", 0.5, "Minimum common subsequence as a share of a line's tokens for the line to join a Spell template (-algorithm spell)")
+	return f
+}
+
+// factory returns a function returning a constructor of parsers of an
+// algorithm, sharing delimiters, variables and IDs with config
+func (f *algorithmFlags) factory(config parser.Config) func(algorithm string) (func() parser.LogParser, error) {
+	drainConfig := parser.DrainConfig{
+		Delimiters:          config.Delimiters,
+		CommonVariables:     config.CommonVariables,
+		Depth:               *f.drainDepth,
+		SimilarityThreshold: *f.drainSim,
+		MaxChildren:         *f.drainChildren,
+		TemplateID:          config.TemplateID,
+	}
+	spellConfig := parser.SpellConfig{
+		Delimiters:          config.Delimiters,
+		CommonVariables:     config.CommonVariables,
+		SimilarityThreshold: *f.spellSim,
+		TemplateID:          config.TemplateID,
+	}
+	return func(algorithm string) (func() parser.LogParser, error) {
+		switch algorithm {
+		case parser.AlgorithmBrain:
+			return func() parser.LogParser {
+				return parser.New(config)
+			}, nil
+		case parser.AlgorithmDrain:
+			if _, err := parser.NewDrain(drainConfig); err != nil {
+				return nil, fmt.Errorf("invalid Drain configuration: %w", err)
+			}
+			return func() parser.LogParser {
+				drainParser, _ := parser.NewDrain(drainConfig) // Validated above
+				return drainParser
+			}, nil
+		case parser.AlgorithmSpell:
+			if _, err := parser.NewSpell(spellConfig); err != nil {
+				return nil, fmt.Errorf("invalid Spell configuration: %w", err)
+			}
+			return func() parser.LogParser {
+				spellParser, _ := parser.NewSpell(spellConfig) // Validated above
+				return spellParser
+			}, nil
+		default:
+			return nil, fmt.Errorf("unknown algorithm %q: must be brain, drain or spell", algorithm)
+		}
+	}
+}
+
+// newParser returns a constructor of parsers of the -algorithm flag,
+// exiting if the algorithm or its configuration is invalid
+func (f *algorithmFlags) newParser(config parser.Config) func() parser.LogParser {
+	newParser, err := f.factory(config)(*f.algorithm)
+	if err != nil {
+		log.Fatalf("Error creating parser: %v", err)
+	}
+	return newParser
+}
+
+// addApprovedFlag defines -approved-templates on fs
+func addApprovedFlag(fs *flag.FlagSet) *string {
+	return fs.String("approved-templates", "", "Curated catalog file with one approved template per line that is never merged, renamed or expired; violations are reported to stderr")
+}
+
+// readApproved reads the catalog of -approved-templates, nil without one,
+// exiting if it cannot be read
+func readApproved(filename string) []string {
+	if filename == "" {
+		return nil
+	}
+	approved, err := readTemplateCatalog(filename)
+	if err != nil {
+		log.Fatalf("Error loading approved templates: %v", err)
+	}
+	return approved
+}
+
+// applySet copies parser settings of flags set explicitly on the command line
+// from flagConfig into config
+func (f *parserFlags) applySet(config *parser.Config, flagConfig parser.Config) {
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "delimiters":
+			config.Delimiters = flagConfig.Delimiters
+		case "threshold":
+			config.ChildBranchThreshold = flagConfig.ChildBranchThreshold
+		case "dynamic":
+			config.UseDynamicThreshold = flagConfig.UseDynamicThreshold
+		case "dynamic-factor":
+			config.DynamicThresholdFactor = flagConfig.DynamicThresholdFactor
+		case "enhanced":
+			config.UseEnhancedPostProcessing = flagConfig.UseEnhancedPostProcessing
+			config.UseStatisticalThreshold = flagConfig.UseStatisticalThreshold
+		case "enhanced-post":
+			config.UseEnhancedPostProcessing = flagConfig.UseEnhancedPostProcessing
+		case "statistical-threshold":
+			config.UseStatisticalThreshold = flagConfig.UseStatisticalThreshold
+		case "parallel-threshold":
+			config.ParallelProcessingThreshold = flagConfig.ParallelProcessingThreshold
+		case "ignore-positions":
+			config.IgnorePositions = flagConfig.IgnorePositions
+		case "ignore-tokens":
+			config.IgnoreTokenPatterns = flagConfig.IgnoreTokenPatterns
+		case "allow-templates":
+			config.TemplateAllowPatterns = flagConfig.TemplateAllowPatterns
+		case "deny-templates":
+			config.TemplateDenyPatterns = flagConfig.TemplateDenyPatterns
+		case "deterministic":
+			config.Deterministic = flagConfig.Deterministic
+		case "seed":
+			config.Seed = flagConfig.Seed
+		case "profile":
+			config.EnableProfiling = flagConfig.EnableProfiling
+		case "max-groups":
+			config.MaxInitialGroups = flagConfig.MaxInitialGroups
+		case "high-cardinality-limit":
+			config.HighCardinalityLimit = flagConfig.HighCardinalityLimit
+		case "unicode-digits":
+			config.UnicodeDigits = flagConfig.UnicodeDigits
+		case "fold-unicode":
+			config.FoldUnicode = flagConfig.FoldUnicode
+		case "variable-stats":
+			config.VariableStatistics = flagConfig.VariableStatistics
+		case "anomaly-threshold":
+			config.AnomalyThreshold = flagConfig.AnomalyThreshold
+		case "approximate-match":
+			config.ApproximateMatch = flagConfig.ApproximateMatch
+		case "positions":
+			config.TemplatePositions = flagConfig.TemplatePositions
+		case "stable-partitioning":
+			config.StablePartitioning = flagConfig.StablePartitioning
+		case "prune-constant-columns":
+			config.PruneConstantColumns = flagConfig.PruneConstantColumns
+		case "merge-subsumed":
+			config.MergeSubsumedTemplates = flagConfig.MergeSubsumedTemplates
+		case "show-examples":
+			config.ExamplesPerTemplate = flagConfig.ExamplesPerTemplate
+		case "variable-length":
+			config.VariableLengthTokens = flagConfig.VariableLengthTokens
+		case "quality":
+			config.QualityPolicy = flagConfig.QualityPolicy
+		case "entropy-threshold":
+			config.EntropyThreshold = flagConfig.EntropyThreshold
+		case "min-entropy-length":
+			config.MinEntropyLength = flagConfig.MinEntropyLength
+		case "max-consecutive-wildcards":
+			config.MaxConsecutiveWildcards = flagConfig.MaxConsecutiveWildcards
+		case "min-content-ratio":
+			config.MinContentWordsRatio = flagConfig.MinContentWordsRatio
+		case "timestamp-min-digits":
+			config.TimestampMinDigits = flagConfig.TimestampMinDigits
+		case "timestamp-min-separators":
+			config.TimestampMinSeparators = flagConfig.TimestampMinSeparators
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// gelfBatchInterval is how often received GELF messages are learned
+const gelfBatchInterval = time.Second
+
+// receiveGELF receives GELF messages on conn and learns their short_message
+// in batches with online until ctx is done
+func receiveGELF(ctx context.Context, conn net.PacketConn, online *parser.OnlineParser) error {
+	var mu sync.Mutex
+	var pending []string
+	received := make(chan error, 1)
+	go func() {
+		received <- parser.ListenGELF(ctx, conn, func(message string, _ map[string]string) {
+			mu.Lock()
+			pending = append(pending, message)
+			mu.Unlock()
+		}, func(err error) {
+			fmt.Fprintf(os.Stderr, "Warning: dropped GELF datagram: %v\n", err)
+		})
+	}()
+
+	ticker := time.NewTicker(gelfBatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			<-received
+			return nil
+		case err := <-received:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			mu.Lock()
+			batch := pending
+			pending = nil
+			mu.Unlock()
+			if len(batch) == 0 {
+				continue
+			}
+			if _, err := online.AddContext(ctx, batch); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// liveEntry is the ndjson output of -live for one line
+type liveEntry struct {
+	Line       int     `json:"line"`
+	Template   string  `json:"template,omitempty"`
+	TemplateID string  `json:"template_id,omitempty"`
+	Count      int     `json:"count,omitempty"`
+	Severity   string  `json:"severity"`
+	Learned    bool    `json:"learned"`
+	LatencyMS  float64 `json:"latency_ms"`
+	Text       string  `json:"text,omitempty"` // With -verbose
+}
+
+// runLive assigns every line of a text file or stdin to a template as it
+// arrives and writes one ndjson object per line to stdout
+func runLive(filename string, config parser.Config, verbose bool) error {
+	file := os.Stdin
+	if filename != "" && filename != "-" {
+		var err error
+		file, err = os.Open(filename) // #nosec G304
+		if err != nil {
+			return fmt.Errorf("failed to open file: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+			}
+		}()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+	var writeErr error
+	processor := parser.NewStreamingProcessor(config, parser.StreamingConfig{LowLatency: true})
+	err := processor.ProcessLive(context.Background(), file, func(assignment parser.LiveAssignment) {
+		entry := liveEntry{
+			Line:      assignment.Line,
+			Severity:  parser.InferLineSeverity(assignment.Text).String(),
+			Learned:   assignment.Learned,
+			LatencyMS: float64(assignment.Latency) / float64(time.Millisecond),
+		}
+		if result := assignment.Result; result != nil {
+			entry.Template, entry.TemplateID, entry.Count = result.Template, result.ID, result.Count
+		}
+		if verbose {
+			entry.Text = assignment.Text
+		}
+		if err := encoder.Encode(entry); err != nil && writeErr == nil {
+			writeErr = fmt.Errorf("failed to write output: %w", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
@@ -3,14 +3,23 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/csv"
-	"flag"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/n0madic/go-brain/parser"
 )
@@ -19,161 +28,494 @@ const (
 	defaultDelimiters             = `[\s,:=]+`
 	defaultChildBranchThreshold   = 3
 	defaultDynamicThresholdFactor = 2.0
+
+	// otherTemplate labels the row aggregating templates hidden by -min-coverage
+	otherTemplate = "<other>"
+
+	// maxRowWarnings is the number of malformed -csv-lenient rows reported individually
+	maxRowWarnings = 10
 )
 
+// main runs the subcommand named by the first argument. Subcommands are
+// listed in commands.go and parse their own flags.
 func main() {
-	var (
-		inputFile     = flag.String("input", "", "Input file path (required)")
-		fileType      = flag.String("type", "auto", "File type: auto, text, csv")
-		csvColumn     = flag.String("csv-column", "message", "CSV column name containing log messages")
-		delimiters    = flag.String("delimiters", defaultDelimiters, "Regex pattern for token delimiters")
-		threshold     = flag.Int("threshold", defaultChildBranchThreshold, "Child branch threshold")
-		useDynamic    = flag.Bool("dynamic", true, "Use dynamic threshold calculation")
-		dynamicFactor = flag.Float64("dynamic-factor", defaultDynamicThresholdFactor, "Dynamic threshold factor")
-		verbose       = flag.Bool("verbose", false, "Verbose output with log IDs")
-		outputFormat  = flag.String("format", "table", "Output format: table, json, csv")
-		minCount      = flag.Int("min-count", 1, "Minimum template count to display")
-		logRegex      = flag.String("log-regex", "", "Regex to extract message from structured logs (must have 'message' capture group)")
-
-		// Enhanced Features (Drain+ Improvements)
-		enhancedPost         = flag.Bool("enhanced-post", false, "Enable enhanced post-processing for advanced variable detection")
-		statisticalThreshold = flag.Bool("statistical-threshold", false, "Use statistical analysis for adaptive threshold calculation")
-		parallelThreshold    = flag.Int("parallel-threshold", 1000, "Minimum log count in group to enable parallel processing")
-		enableAllEnhanced    = flag.Bool("enhanced", false, "Enable all enhanced features (equivalent to --enhanced-post --statistical-threshold)")
-
-		// Enhanced Features Tuning Parameters
-		entropyThreshold        = flag.Float64("entropy-threshold", 0.85, "Threshold for entropy-based variable detection (lower = more aggressive)")
-		minEntropyLength        = flag.Int("min-entropy-length", 10, "Minimum word length for entropy analysis")
-		maxConsecutiveWildcards = flag.Int("max-consecutive-wildcards", 5, "Maximum consecutive <*> tokens in template (0 = no limit)")
-		minContentWordsRatio    = flag.Float64("min-content-ratio", 0.25, "Minimum ratio of non-<*> words in template")
-		timestampMinDigits      = flag.Int("timestamp-min-digits", 8, "Minimum digits for timestamp detection")
-		timestampMinSeparators  = flag.Int("timestamp-min-separators", 2, "Minimum separators for timestamp detection")
-	)
-	flag.Parse()
-
-	if *inputFile == "" {
-		fmt.Fprintf(os.Stderr, "Error: input file is required\n")
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	// Read input file
-	logLines, err := readInputFile(*inputFile, *fileType, *csvColumn, *logRegex)
-	if err != nil {
-		log.Fatalf("Error reading input file: %v", err)
+	// Without a subcommand name, brain-cli parses
+	name, args := defaultCommand, os.Args[1:]
+	if len(args) > 0 {
+		if _, ok := lookupCommand(args[0]); ok {
+			name, args = args[0], args[1:]
+		}
+	}
+	switch name {
+	case "parse":
+		runParse(args)
+	case "match":
+		runMatch(args)
+	case "diff":
+		runDiff(args)
+	case "serve":
+		runServe(args)
+	case "evaluate":
+		runEvaluate(args)
+	case "bench":
+		runBench(args)
+	case "rpc":
+		runRPC(args)
 	}
+}
 
-	if len(logLines) == 0 {
-		fmt.Println("No log lines found in input file")
-		return
+// splitCounts strips the repeat count prefix of 'uniq -c' output from every
+// line in place and returns the counts
+func splitCounts(lines []string) ([]int, error) {
+	weights := make([]int, len(lines))
+	for i, line := range lines {
+		countField, message, _ := strings.Cut(strings.TrimLeft(line, " \t"), " ")
+		count, err := strconv.Atoi(countField)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("line %d has no repeat count: %q", i+1, line)
+		}
+		weights[i] = count
+		lines[i] = message
 	}
+	return weights, nil
+}
 
-	fmt.Printf("Processing %d log lines...\n", len(logLines))
+// parsePositions parses a comma-separated list of token positions
+func parsePositions(list string) ([]int, error) {
+	if list == "" {
+		return nil, nil
+	}
+	var positions []int
+	for _, part := range strings.Split(list, ",") {
+		pos, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || pos < 0 {
+			return nil, fmt.Errorf("invalid position %q", part)
+		}
+		positions = append(positions, pos)
+	}
+	return positions, nil
+}
 
-	// Handle enhanced features flag
-	if *enableAllEnhanced {
-		*enhancedPost = true
-		*statisticalThreshold = true
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
 
-	// Show enabled enhanced features
-	var enabledFeatures []string
-	if *enhancedPost {
-		enabledFeatures = append(enabledFeatures, "enhanced-post-processing")
+// templatePatterns validates an optional template filter regex flag
+func templatePatterns(name, pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return nil, fmt.Errorf("invalid -%s: %w", name, err)
 	}
-	if *statisticalThreshold {
-		enabledFeatures = append(enabledFeatures, "statistical-threshold")
+	return []string{pattern}, nil
+}
+
+// inputSource is the log lines read from one input, or from all inputs merged
+type inputSource struct {
+	name    string
+	lines   []string
+	labels  []map[string]string // Named -log-regex groups, -json-fields, logfmt pairs, syslog, GELF or CEF/LEEF header fields per line (nil otherwise)
+	weights []int               // Repeat counts per line with -counted
+}
+
+// inputOptions selects how input files are read
+type inputOptions struct {
+	fileType  string // auto, text, csv, tsv, psv, json, logfmt, syslog, gelf, cef or leef
+	csvColumn string // Message column of tabular files
+	logRegex  string // Message regex of text files
+	tabular   parser.TabularOptions
+	json      parser.JSONLogOptions
+	logfmt    parser.LogfmtOptions
+	remote    parser.RemoteOpener // Opener of s3://, gs:// and http(s):// inputs
+}
+
+// readInputs reads every file named by a comma-separated list of paths and
+// glob patterns in order; empty or "-" reads stdin
+func readInputs(spec string, options inputOptions) ([]inputSource, error) {
+	filenames, err := expandInputs(spec)
+	if err != nil {
+		return nil, err
 	}
-	if *parallelThreshold < 1000 {
-		enabledFeatures = append(enabledFeatures, fmt.Sprintf("parallel-processing(threshold=%d)", *parallelThreshold))
-	} else if len(logLines) >= *parallelThreshold {
-		enabledFeatures = append(enabledFeatures, "parallel-processing")
+	inputs := make([]inputSource, 0, len(filenames))
+	for _, filename := range filenames {
+		if filename != "" && filename != "-" && parser.IsArchive(inputPath(filename, options)) {
+			members, err := readArchiveInputs(filename, options)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", filename, err)
+			}
+			inputs = append(inputs, members...)
+			continue
+		}
+		lines, labels, err := readInputFile(filename, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		name := filename
+		if name == "" || name == "-" {
+			name = "stdin"
+		}
+		inputs = append(inputs, inputSource{name: name, lines: lines, labels: labels})
 	}
+	return inputs, nil
+}
 
-	if len(enabledFeatures) > 0 {
-		fmt.Printf("Enhanced features enabled: %s\n", strings.Join(enabledFeatures, ", "))
+// expandInputs splits a comma-separated input list and expands glob patterns.
+// Patterns must match at least one file; plain paths and URLs are kept as-is.
+func expandInputs(spec string) ([]string, error) {
+	if spec == "" || spec == "-" {
+		return []string{spec}, nil
 	}
+	var filenames []string
+	for _, pattern := range strings.Split(spec, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") || strings.Contains(pattern, "://") {
+			filenames = append(filenames, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", pattern)
+		}
+		filenames = append(filenames, matches...) // Sorted by filepath.Glob
+	}
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no input files in %q", spec)
+	}
+	return filenames, nil
+}
 
-	// Configure Brain parser
-	config := parser.Config{
-		Delimiters:                  *delimiters,
-		ChildBranchThreshold:        *threshold,
-		UseDynamicThreshold:         *useDynamic,
-		DynamicThresholdFactor:      *dynamicFactor,
-		Weight:                      0.0, // Offline mode
-		UseEnhancedPostProcessing:   *enhancedPost,
-		UseStatisticalThreshold:     *statisticalThreshold,
-		ParallelProcessingThreshold: *parallelThreshold,
+// mergeInputs concatenates inputs into one
+func mergeInputs(inputs []inputSource) inputSource {
+	if len(inputs) == 1 {
+		return inputs[0]
+	}
+	merged := inputSource{name: "all inputs"}
+	labeled := false
+	for _, input := range inputs {
+		labeled = labeled || input.labels != nil
+	}
+	for _, input := range inputs {
+		merged.lines = append(merged.lines, input.lines...)
+		if input.labels != nil {
+			merged.labels = append(merged.labels, input.labels...)
+		} else if labeled {
+			merged.labels = append(merged.labels, make([]map[string]string, len(input.lines))...) // Keep labels aligned with lines
+		}
+		merged.weights = append(merged.weights, input.weights...)
+	}
+	return merged
+}
 
-		// Enhanced Features Tuning Parameters
-		EntropyThreshold:        *entropyThreshold,
-		MinEntropyLength:        *minEntropyLength,
-		MaxConsecutiveWildcards: *maxConsecutiveWildcards,
-		MinContentWordsRatio:    *minContentWordsRatio,
-		TimestampMinDigits:      *timestampMinDigits,
-		TimestampMinSeparators:  *timestampMinSeparators,
+// readArchiveInputs reads every member of a zip, tar or tar.gz archive as a
+// separate input named archive:member, with the member name as "file" label
+// of its lines. With -type auto the type of every member is detected from
+// its name.
+func readArchiveInputs(filename string, options inputOptions) ([]inputSource, error) {
+	file, err := openInput(filename, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
 
-	// Create parser and process logs
-	brainParser := parser.New(config)
-	results := brainParser.Parse(logLines)
+	var inputs []inputSource
+	err = parser.WalkArchive(file, func(member string, reader io.Reader) error {
+		fileType := options.fileType
+		if fileType == "auto" {
+			fileType = detectFileType(strings.TrimSuffix(strings.ToLower(member), ".gz"))
+		}
+		lines, labels, err := readLogs(reader, fileType, options)
+		if err != nil {
+			return fmt.Errorf("%s: %w", member, err)
+		}
+		if labels == nil {
+			labels = make([]map[string]string, len(lines))
+		}
+		for i := range labels {
+			if labels[i] == nil {
+				labels[i] = make(map[string]string, 1)
+			}
+			labels[i][parser.ArchiveFileField] = member
+		}
+		inputs = append(inputs, inputSource{name: filename + ":" + member, lines: lines, labels: labels})
+		return nil
+	})
+	return inputs, err
+}
 
-	// Filter results by minimum count
-	var filteredResults []*parser.ParseResult
-	for _, result := range results {
-		if result.Count >= *minCount {
-			filteredResults = append(filteredResults, result)
+// readInputFile reads log lines from various file formats, from stdin if
+// filename is empty or "-". Labels are only returned for text files parsed
+// with a log regex, JSON files read with carried fields, logfmt, syslog, GELF,
+// CEF and LEEF files.
+func readInputFile(filename string, options inputOptions) ([]string, []map[string]string, error) {
+	file := io.ReadCloser(os.Stdin)
+	if filename != "" && filename != "-" {
+		var err error
+		file, err = openInput(filename, options)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open file: %w", err)
 		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+			}
+		}()
 	}
 
-	fmt.Printf("Found %d unique templates (showing %d with count >= %d):\n\n",
-		len(results), len(filteredResults), *minCount)
+	// Auto-detect file type if not specified
+	fileType := options.fileType
+	if fileType == "auto" {
+		fileType = detectFileType(inputPath(filename, options))
+	}
+	return readLogs(file, fileType, options)
+}
+
+// openInput opens a local file, or streams a URL handled by options.remote
+func openInput(filename string, options inputOptions) (io.ReadCloser, error) {
+	if options.remote.Handles(filename) {
+		return options.remote.Open(context.Background(), filename)
+	}
+	return os.Open(filename) // #nosec G304
+}
+
+// inputPath returns the path of an input URL without query, e.g. of a
+// pre-signed URL, to detect its type by extension, or filename itself
+func inputPath(filename string, options inputOptions) string {
+	if !options.remote.Handles(filename) {
+		return filename
+	}
+	if u, err := url.Parse(filename); err == nil {
+		return u.Path
+	}
+	return filename
+}
 
-	// Output results in specified format
-	switch *outputFormat {
+// newRemoteOpener returns the openers of remote inputs: http(s):// URLs,
+// s3:// objects signed with the AWS_* credentials of the environment and
+// gs:// objects authorized with GOOGLE_OAUTH_ACCESS_TOKEN
+func newRemoteOpener() parser.RemoteOpener {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return parser.RemoteOpener{
+		"http":  parser.HTTPOpener{},
+		"https": parser.HTTPOpener{},
+		"s3": parser.S3Opener{
+			Region:          region,
+			Endpoint:        os.Getenv("AWS_ENDPOINT_URL"),
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		"gs": parser.GCSOpener{Token: os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")},
+	}
+}
+
+// readLogs reads log lines of the given file type from reader
+func readLogs(file io.Reader, fileType string, options inputOptions) ([]string, []map[string]string, error) {
+	switch fileType {
+	case "text":
+		return readTextFile(file, options.logRegex)
 	case "json":
-		outputJSON(filteredResults, *verbose)
-	case "csv":
-		outputCSV(filteredResults, *verbose)
+		logs, err := parser.ReadJSONLogs(file, options.json)
+		if err != nil {
+			return nil, nil, err
+		}
+		if logs.Skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %d lines without a JSON object or %q field\n", logs.Skipped, options.json.MessageField)
+		}
+		return logs.Lines, logs.Fields, nil
+	case "logfmt":
+		logs, err := parser.ReadLogfmt(file, options.logfmt)
+		if err != nil {
+			return nil, nil, err
+		}
+		if logs.Skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %d lines without a %q key\n", logs.Skipped, options.logfmt.MessageKey)
+		}
+		return logs.Lines, logs.Fields, nil
+	case "gelf":
+		logs, err := parser.ReadGELF(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if logs.Skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %d lines without a GELF message\n", logs.Skipped)
+		}
+		return logs.Lines, logs.Fields, nil
+	case "cef", "leef":
+		events, err := parser.ReadSecurityEvents(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if events.Unparsed > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d lines without a CEF or LEEF event were parsed whole\n", events.Unparsed)
+		}
+		return events.Lines, events.Fields, nil
+	case "syslog":
+		logs, err := parser.ReadSyslog(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if logs.Unparsed > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: %d lines without a syslog header were parsed whole\n", logs.Unparsed)
+		}
+		return logs.Lines, logs.Fields, nil
+	}
+
+	format, err := parser.ParseTabularFormat(fileType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported file type: %s", fileType)
+	}
+	column, err := parser.ReadTabular(file, format, options.csvColumn, options.tabular)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s file: %w", format, err)
+	}
+	for i, rowErr := range column.Errors {
+		if i == maxRowWarnings {
+			fmt.Fprintf(os.Stderr, "Warning: skipped %d more malformed rows\n", len(column.Errors)-i)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "Warning: skipped malformed row at %v\n", rowErr)
+	}
+	return column.Lines, nil, nil
+}
+
+// saveConfigFile writes a parser configuration as indented JSON
+func saveConfigFile(filename string, config parser.Config) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0o600)
+}
+
+// loadStateFile creates a Brain parser from a state file written by
+// -save-state
+func loadStateFile(filename string) (*parser.BrainParser, error) {
+	return loadFile(filename, parser.LoadState)
+}
+
+// newBrainParser creates a Brain parser from the state file loadState, or
+// with config without one, and approves templates, exiting on error
+func newBrainParser(loadState string, config parser.Config, approved []string) *parser.BrainParser {
+	var brainParser *parser.BrainParser
+	if loadState != "" {
+		var err error
+		if brainParser, err = loadStateFile(loadState); err != nil {
+			log.Fatalf("Error loading state: %v", err)
+		}
+	} else {
+		brainParser = parser.New(config)
+	}
+	if err := brainParser.Approve(approved...); err != nil {
+		log.Fatalf("Invalid approved templates: %v", err)
+	}
+	return brainParser
+}
+
+// loadParserFile creates a parser of the algorithm of a state file written
+// by -save-state
+func loadParserFile(filename string) (parser.LogParser, error) {
+	return loadFile(filename, parser.LoadParser)
+}
+
+// templateLearner is a parser reporting the templates it learned
+type templateLearner interface {
+	parser.LogParser
+	Templates() []string
+}
+
+// algorithmOf returns the algorithm of a parser
+func algorithmOf(logParser parser.LogParser) string {
+	switch logParser.(type) {
+	case *parser.DrainParser:
+		return parser.AlgorithmDrain
+	case *parser.SpellParser:
+		return parser.AlgorithmSpell
 	default:
-		outputTable(filteredResults, *verbose)
+		return parser.AlgorithmBrain
 	}
 }
 
-// readInputFile reads log lines from various file formats
-func readInputFile(filename, fileType, csvColumn, logRegex string) ([]string, error) {
+// loadFile opens a file and decodes it with load
+func loadFile[T any](filename string, load func(io.Reader) (T, error)) (T, error) {
 	file, err := os.Open(filename) // #nosec G304
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		var zero T
+		return zero, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
 		}
 	}()
+	return load(file)
+}
 
-	// Auto-detect file type if not specified
-	if fileType == "auto" {
-		if strings.HasSuffix(strings.ToLower(filename), ".csv") {
-			fileType = "csv"
-		} else {
-			fileType = "text"
-		}
+// saveStateFile writes the learned templates and configuration of a parser
+func saveStateFile(filename string, brainParser interface{ SaveState(io.Writer) error }) error {
+	var buf bytes.Buffer
+	if err := brainParser.SaveState(&buf); err != nil {
+		return err
 	}
+	return writeFileAtomic(filename, buf.Bytes())
+}
 
-	switch fileType {
-	case "csv":
-		return readCSVFile(file, csvColumn)
-	case "text":
-		return readTextFile(file, logRegex)
+// detectFileType detects the file type from the file extension
+func detectFileType(filename string) string {
+	lower := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(lower, ".csv"):
+		return "csv"
+	case strings.HasSuffix(lower, ".tsv"), strings.HasSuffix(lower, ".tab"):
+		return "tsv"
+	case strings.HasSuffix(lower, ".psv"):
+		return "psv"
+	case strings.HasSuffix(lower, ".json"), strings.HasSuffix(lower, ".jsonl"), strings.HasSuffix(lower, ".ndjson"):
+		return "json"
+	case strings.HasSuffix(lower, ".logfmt"):
+		return "logfmt"
+	case strings.HasSuffix(lower, ".gelf"):
+		return "gelf"
+	case strings.HasSuffix(lower, ".cef"):
+		return "cef"
+	case strings.HasSuffix(lower, ".leef"):
+		return "leef"
 	default:
-		return nil, fmt.Errorf("unsupported file type: %s", fileType)
+		return "text"
 	}
 }
 
-// readTextFile reads plain text log files (one log per line)
-func readTextFile(reader io.Reader, logRegex string) ([]string, error) {
+// isTerminal checks whether file is an interactive terminal
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// readTextFile reads plain text log files (one log per line). Named capture
+// groups of the log regex other than "message" are returned as line labels.
+func readTextFile(reader io.Reader, logRegex string) ([]string, []map[string]string, error) {
 	var lines []string
+	var labels []map[string]string
 	scanner := bufio.NewScanner(reader)
 
 	// Compile regex if provided
@@ -182,7 +524,7 @@ func readTextFile(reader io.Reader, logRegex string) ([]string, error) {
 	if logRegex != "" {
 		regex, err = regexp.Compile(logRegex)
 		if err != nil {
-			return nil, fmt.Errorf("invalid log regex: %w", err)
+			return nil, nil, fmt.Errorf("invalid log regex: %w", err)
 		}
 	}
 
@@ -194,86 +536,233 @@ func readTextFile(reader io.Reader, logRegex string) ([]string, error) {
 
 		// Extract message using regex if provided
 		if regex != nil {
-			matches := regex.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				// Look for named capture group "message"
-				names := regex.SubexpNames()
-				for i, name := range names {
-					if name == "message" && i < len(matches) {
-						line = matches[i]
-						break
-					}
-				}
-			} else {
+			message, lineLabels, ok := extractMessage(regex, line)
+			if !ok {
 				// If regex doesn't match, skip the line
 				continue
 			}
+			line = message
+			labels = append(labels, lineLabels)
 		}
 
 		lines = append(lines, line)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading text file: %w", err)
+		return nil, nil, fmt.Errorf("error reading text file: %w", err)
 	}
 
-	return lines, nil
+	return lines, labels, nil
 }
 
-// readCSVFile reads CSV files and extracts the specified message column
-func readCSVFile(reader io.Reader, columnName string) ([]string, error) {
-	csvReader := csv.NewReader(reader)
+// followOptions holds the settings of followFile
+type followOptions struct {
+	interval    time.Duration        // How often the file is checked for new lines
+	auditLog    string               // NDJSON file template state changes are appended to (empty = none)
+	expireAfter time.Duration        // Idle time after which templates are dropped (0 = never)
+	alerts      bool                 // Print lines of new or rare templates to stderr
+	rarity      parser.RarityOptions // Which lines -alerts prints
+	approved    []string             // Templates protected from merging and expiry
+}
 
-	// Read header
-	header, err := csvReader.Read()
-	if err != nil {
-		return nil, fmt.Errorf("error reading CSV header: %w", err)
-	}
+// printAnomaly prints a line of a new or rare template to stderr
+func printAnomaly(anomaly parser.TemplateAnomaly) {
+	fmt.Fprintf(os.Stderr, "ALERT %s %s template %s (%d lines, %.3f%%): %s\n",
+		anomaly.Time.Format(time.DateTime), anomaly.Kind, anomaly.TemplateID, anomaly.Count, anomaly.Frequency*100, anomaly.Line)
+}
 
-	// Find the message column index
-	messageIndex := -1
-	for i, col := range header {
-		if strings.EqualFold(strings.TrimSpace(col), columnName) {
-			messageIndex = i
-			break
+// followFile tails filename, feeds the new lines to an online parser every
+// interval and renders the learned templates after each change until SIGINT
+// or SIGTERM
+func followFile(filename, logRegex string, config parser.Config, options followOptions, render func(results []*parser.ParseResult, lines int)) (*parser.OnlineParser, error) {
+	var regex *regexp.Regexp
+	if logRegex != "" {
+		var err error
+		if regex, err = regexp.Compile(logRegex); err != nil {
+			return nil, fmt.Errorf("invalid log regex: %w", err)
 		}
 	}
 
-	if messageIndex == -1 {
-		return nil, fmt.Errorf("column '%s' not found in CSV. Available columns: %v",
-			columnName, header)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	online := parser.NewOnlineParser(config)
+	if err := online.Approve(options.approved...); err != nil {
+		return nil, fmt.Errorf("invalid approved templates: %w", err)
 	}
+	if options.auditLog != "" {
+		file, err := os.OpenFile(options.auditLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) // #nosec G302 G304
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+		defer func() {
+			if closeErr := file.Close(); closeErr != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close audit log: %v\n", closeErr)
+			}
+		}()
+		online.SetAuditLog(file)
+	}
+	if options.alerts {
+		online.OnAnomaly(options.rarity, printAnomaly)
+	}
+	tailer := &fileTailer{filename: filename}
+	defer tailer.close()
+	ticker := time.NewTicker(options.interval)
+	defer ticker.Stop()
 
-	// Read all records
-	var lines []string
 	for {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
+		raw, err := tailer.poll()
 		if err != nil {
-			return nil, fmt.Errorf("error reading CSV record: %w", err)
+			return online, err
 		}
-
-		if messageIndex < len(record) {
-			message := strings.TrimSpace(record[messageIndex])
-			if message != "" { // Skip empty messages
-				lines = append(lines, message)
+		var batch []string
+		for _, line := range raw {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if regex != nil {
+				message, _, ok := extractMessage(regex, line)
+				if !ok {
+					continue
+				}
+				line = message
+			}
+			batch = append(batch, line)
+		}
+		changed := false
+		if len(batch) > 0 {
+			if _, err := online.AddContext(ctx, batch); err != nil {
+				if ctx.Err() != nil {
+					return online, nil // Interrupted
+				}
+				return online, err
 			}
+			changed = true
+		}
+		if options.expireAfter > 0 {
+			expired, err := online.Expire(options.expireAfter)
+			if err != nil {
+				return online, err
+			}
+			changed = changed || len(expired) > 0
+		}
+		if changed {
+			render(online.Snapshot(), online.Lines())
+		}
+
+		select {
+		case <-ctx.Done():
+			return online, nil
+		case <-ticker.C:
 		}
 	}
+}
+
+// fileTailer reads the lines appended to a file like 'tail -F': it starts
+// over after truncation and switches to the new file after rotation
+type fileTailer struct {
+	filename string
+	file     *os.File
+	offset   int64  // Bytes of file consumed
+	partial  []byte // Incomplete last line
+}
 
+// poll returns the complete lines appended since the last call. A missing
+// file is waited for.
+func (t *fileTailer) poll() ([]string, error) {
+	if t.file == nil {
+		file, err := os.Open(t.filename) // #nosec G304
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open file: %w", err)
+		}
+		t.file, t.offset, t.partial = file, 0, nil
+	}
+
+	info, err := t.file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if info.Size() < t.offset { // Truncated
+		t.offset, t.partial = 0, nil
+	}
+	lines, err := t.read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rotated or removed: the old file is drained, continue with the new one
+	if current, err := os.Stat(t.filename); os.IsNotExist(err) || err == nil && !os.SameFile(info, current) {
+		if len(t.partial) > 0 {
+			lines = append(lines, string(t.partial))
+		}
+		t.close()
+		more, err := t.poll()
+		return append(lines, more...), err
+	}
 	return lines, nil
 }
 
+// read returns the complete lines between the offset and the end of the file
+func (t *fileTailer) read() ([]string, error) {
+	if _, err := t.file.Seek(t.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek file: %w", err)
+	}
+	data, err := io.ReadAll(t.file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	t.offset += int64(len(data))
+
+	data = append(t.partial, data...)
+	end := bytes.LastIndexByte(data, '\n')
+	if end < 0 {
+		t.partial = data
+		return nil, nil
+	}
+	t.partial = append([]byte(nil), data[end+1:]...)
+	return strings.Split(string(data[:end]), "\n"), nil
+}
+
+// close closes the followed file
+func (t *fileTailer) close() {
+	if t.file != nil {
+		_ = t.file.Close()
+		t.file = nil
+	}
+}
+
+// extractMessage returns the "message" group of a -log-regex match of line
+// (the whole line if there is none) and the other named groups as labels
+func extractMessage(regex *regexp.Regexp, line string) (string, map[string]string, bool) {
+	matches := regex.FindStringSubmatch(line)
+	if len(matches) <= 1 {
+		return "", nil, false
+	}
+	labels := make(map[string]string)
+	for i, name := range regex.SubexpNames() {
+		switch {
+		case name == "" || i >= len(matches):
+		case name == "message":
+			line = matches[i]
+		default:
+			labels[name] = matches[i]
+		}
+	}
+	return line, labels, true
+}
+
 // outputTable outputs results in a formatted table
 func outputTable(results []*parser.ParseResult, verbose bool) {
-	fmt.Printf("%-6s %-80s", "COUNT", "TEMPLATE")
+	fmt.Printf("%-6s %-9s %-80s", "COUNT", "SEVERITY", "TEMPLATE")
 	if verbose {
 		fmt.Printf(" %s", "LOG_IDS")
 	}
 	fmt.Println()
-	fmt.Println(strings.Repeat("-", 86+func() int {
+	fmt.Println(strings.Repeat("-", 96+func() int {
 		if verbose {
 			return 20
 		}
@@ -281,31 +770,148 @@ func outputTable(results []*parser.ParseResult, verbose bool) {
 	}()))
 
 	for _, result := range results {
-		fmt.Printf("%-6d %-80s", result.Count, result.Template)
+		fmt.Printf("%-6d %-9s %-80s", result.Count, result.Severity, result.Template)
 		if verbose {
 			fmt.Printf(" %v", result.LogIDs)
 		}
 		fmt.Println()
+		for i, values := range result.Params {
+			fmt.Printf("       %6d: %s\n", result.LogIDs[i], strings.Join(values, " | "))
+		}
+		for _, example := range result.Examples {
+			fmt.Printf("       e.g. %s\n", example)
+		}
 	}
 }
 
-// outputJSON outputs results in JSON format
-func outputJSON(results []*parser.ParseResult, verbose bool) {
-	fmt.Println("[")
+// jsonTemplate is one template of the json and ndjson output formats
+type jsonTemplate struct {
+	File       string               `json:"file,omitempty"`
+	Template   string               `json:"template"`
+	TemplateID string               `json:"template_id,omitempty"`
+	Count      int                  `json:"count"`
+	Ratio      float64              `json:"ratio"`
+	Severity   string               `json:"severity"`
+	LogIDs     []int                `json:"log_ids,omitempty"`
+	Examples   []string             `json:"examples,omitempty"`
+	Params     [][]string           `json:"params,omitempty"`
+	Positions  []parser.TokenInfo   `json:"positions,omitempty"`
+	Slots      []parser.ParamSlot   `json:"slots,omitempty"`
+	Fields     map[string]jsonField `json:"fields,omitempty"`
+}
+
+// jsonField summarizes a carried field (-json-fields or named -log-regex
+// group) over the lines of a template
+type jsonField struct {
+	First  string         `json:"first"`            // Value of the earliest line
+	Last   string         `json:"last"`             // Value of the latest line
+	Values map[string]int `json:"values,omitempty"` // Line count per value, if there are few distinct values
+}
+
+// maxJSONExamples is the number of example lines per template in json output
+const maxJSONExamples = 3
+
+// maxJSONFieldValues is the number of distinct values up to which a carried
+// field lists value counts
+const maxJSONFieldValues = 10
+
+// newJSONTemplate converts a result to the json output form. Ratio is the
+// share of totalLines covered by the template; log IDs are set with verbose.
+func newJSONTemplate(result *parser.ParseResult, logLines []string, totalLines int, verbose bool) jsonTemplate {
+	entry := jsonTemplate{
+		Template:   result.Template,
+		TemplateID: result.ID,
+		Count:      result.Count,
+		Severity:   result.Severity.String(),
+		Params:     result.Params,
+		Positions:  result.Positions,
+	}
+	if totalLines > 0 {
+		entry.Ratio = float64(result.Count) / float64(totalLines)
+	}
+	if verbose {
+		entry.LogIDs = result.LogIDs
+	}
+	entry.Examples = result.Examples
+	if entry.Examples == nil {
+		for _, id := range result.LogIDs[:min(len(result.LogIDs), maxJSONExamples)] {
+			if id >= 0 && id < len(logLines) {
+				entry.Examples = append(entry.Examples, logLines[id])
+			}
+		}
+	}
+	return entry
+}
+
+// summarizeFields summarizes the labels of the member lines of a result by
+// label name, or returns nil if there are none
+func summarizeFields(result *parser.ParseResult, labels []map[string]string) map[string]jsonField {
+	if len(labels) == 0 {
+		return nil
+	}
+	fields := make(map[string]jsonField)
+	first := make(map[string]int)
+	last := make(map[string]int)
+	for _, id := range result.LogIDs {
+		if id < 0 || id >= len(labels) {
+			continue
+		}
+		for name, value := range labels[id] {
+			field, seen := fields[name]
+			if !seen {
+				field.Values = make(map[string]int)
+			}
+			if !seen || id < first[name] {
+				field.First, first[name] = value, id
+			}
+			if !seen || id > last[name] {
+				field.Last, last[name] = value, id
+			}
+			if field.Values != nil {
+				field.Values[value]++
+				if len(field.Values) > maxJSONFieldValues {
+					field.Values = nil
+				}
+			}
+			fields[name] = field
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
+
+// outputJSON outputs results as a JSON array, or with ndjson as one JSON
+// object per line. Ratio is the share of totalLines covered by a template;
+// a non-empty file is set as the input file of every template. Line labels
+// are summarized per template in fields and slots, if set, summarizes the
+// <*> slots of every template.
+func outputJSON(results []*parser.ParseResult, logLines []string, labels []map[string]string, slots func(*parser.ParseResult) []parser.ParamSlot, totalLines int, verbose, ndjson bool, file string) {
+	templates := make([]jsonTemplate, len(results))
 	for i, result := range results {
-		fmt.Printf("  {\n")
-		fmt.Printf("    \"template\": \"%s\",\n", escapeJSON(result.Template))
-		fmt.Printf("    \"count\": %d", result.Count)
-		if verbose {
-			fmt.Printf(",\n    \"log_ids\": %v", result.LogIDs)
+		templates[i] = newJSONTemplate(result, logLines, totalLines, verbose)
+		templates[i].File = file
+		templates[i].Fields = summarizeFields(result, labels)
+		if slots != nil {
+			templates[i].Slots = slots(result)
 		}
-		fmt.Printf("\n  }")
-		if i < len(results)-1 {
-			fmt.Printf(",")
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+	if ndjson {
+		for _, entry := range templates {
+			if err := encoder.Encode(entry); err != nil {
+				log.Fatalf("Error writing JSON: %v", err)
+			}
 		}
-		fmt.Println()
+		return
+	}
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(templates); err != nil {
+		log.Fatalf("Error writing JSON: %v", err)
 	}
-	fmt.Println("]")
 }
 
 // outputCSV outputs results in CSV format
@@ -314,7 +920,7 @@ func outputCSV(results []*parser.ParseResult, verbose bool) {
 	defer writer.Flush()
 
 	// Write header
-	header := []string{"template", "count"}
+	header := []string{"template", "count", "severity"}
 	if verbose {
 		header = append(header, "log_ids")
 	}
@@ -324,7 +930,7 @@ func outputCSV(results []*parser.ParseResult, verbose bool) {
 
 	// Write data
 	for _, result := range results {
-		record := []string{result.Template, fmt.Sprintf("%d", result.Count)}
+		record := []string{result.Template, fmt.Sprintf("%d", result.Count), result.Severity.String()}
 		if verbose {
 			record = append(record, fmt.Sprintf("%v", result.LogIDs))
 		}
@@ -334,12 +940,284 @@ func outputCSV(results []*parser.ParseResult, verbose bool) {
 	}
 }
 
-// escapeJSON escapes special characters for JSON output
-func escapeJSON(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\r", "\\r")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
+// outputTimeSeries outputs the counts of the results per time window as CSV
+// or JSON
+func outputTimeSeries(timestamps parser.TimestampExtractor, results []*parser.ParseResult, logLines []string, window time.Duration, asJSON bool) {
+	var templates []*parser.ParseResult
+	for _, result := range results {
+		if result.Template != otherTemplate { // Coverage summary row
+			templates = append(templates, result)
+		}
+	}
+	series, err := parser.BucketByTime(templates, logLines, timestamps, window)
+	if err != nil {
+		log.Fatalf("Error bucketing templates by time: %v", err)
+	}
+	if series.Untimed > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d lines without a timestamp are not counted\n", series.Untimed)
+	}
+	if asJSON {
+		err = parser.ExportTimeSeriesJSON(os.Stdout, series)
+	} else {
+		err = parser.ExportTimeSeriesCSV(os.Stdout, series)
+	}
+	if err != nil {
+		log.Printf("Error writing time series: %v", err)
+	}
+}
+
+// outputSigma outputs rare and error-class templates as Sigma rule skeletons
+// with the named placeholders of the parser
+func outputSigma(results []*parser.ParseResult, maxCount int, placeholders []string) {
+	opts := parser.SigmaOptions{MaxCount: maxCount, Placeholders: placeholders}
+	selected := parser.SelectSigmaTemplates(results, opts)
+	if err := parser.ExportSigmaRules(os.Stdout, selected, opts); err != nil {
+		log.Printf("Error writing Sigma rules: %v", err)
+	}
+}
+
+// outputLoki outputs a LogQL line filter per template for Grafana Loki
+func outputLoki(results []*parser.ParseResult) {
+	if err := parser.ExportLokiPatterns(os.Stdout, results); err != nil {
+		log.Printf("Error writing Loki patterns: %v", err)
+	}
+}
+
+// indexElastic creates the -elastic indices if needed and bulk-indexes the
+// templates and the lines assigned to them
+func indexElastic(url, prefix string, results []*parser.ParseResult, lines []string, status io.Writer) error {
+	sink := &parser.ElasticSink{
+		URL:            url,
+		TemplatesIndex: prefix + "-templates",
+		LinesIndex:     prefix + "-lines",
+		APIKey:         os.Getenv("ELASTIC_API_KEY"),
+	}
+	var templates []*parser.ParseResult
+	assigned := 0
+	for _, result := range results {
+		if result.Template != otherTemplate { // Coverage summary row
+			templates = append(templates, result)
+			assigned += len(result.LogIDs)
+		}
+	}
+
+	ctx := context.Background()
+	if err := sink.CreateIndices(ctx); err != nil {
+		return err
+	}
+	if err := sink.IndexTemplates(ctx, templates); err != nil {
+		return err
+	}
+	if err := sink.IndexLines(ctx, templates, lines); err != nil {
+		return err
+	}
+	fmt.Fprintf(status, "Indexed %d templates into %s and %d lines into %s\n",
+		len(templates), sink.TemplatesIndex, assigned, sink.LinesIndex)
+	return nil
+}
+
+// newTemplateEvents returns a created event for every result whose template
+// is not among known
+func newTemplateEvents(results []*parser.ParseResult, known []string) []parser.AuditEvent {
+	seen := make(map[string]bool, len(known))
+	for _, template := range known {
+		seen[template] = true
+	}
+	now := time.Now()
+	var events []parser.AuditEvent
+	for _, result := range results {
+		if result.ID == "" || seen[result.Template] {
+			continue // The <other> row or a known template
+		}
+		events = append(events, parser.AuditEvent{
+			Time:       now,
+			Event:      parser.AuditCreated,
+			Template:   result.Template,
+			TemplateID: result.ID,
+			Count:      result.Count,
+		})
+	}
+	return events
+}
+
+// outputGrafana outputs results as a Grafana table and events as annotations
+func outputGrafana(results []*parser.ParseResult, events []parser.AuditEvent, totalLines int) {
+	if err := parser.ExportGrafana(os.Stdout, results, events, parser.GrafanaOptions{TotalLines: totalLines}); err != nil {
+		log.Printf("Error writing Grafana export: %v", err)
+	}
+}
+
+// printProfile prints self-profiling data to stderr
+func printProfile(profile *parser.ParseProfile) {
+	fmt.Fprintf(os.Stderr, "%-12s %12s %12s %14s %14s\n", "PHASE", "TIME", "ALLOCS", "ALLOC_BYTES", "HEAP_BYTES")
+	for _, phase := range profile.Phases {
+		fmt.Fprintf(os.Stderr, "%-12s %12s %12d %14d %14d\n",
+			phase.Name, phase.Duration.Round(time.Microsecond), phase.Allocs, phase.AllocBytes, phase.HeapAlloc)
+	}
+	fmt.Fprintf(os.Stderr, "Total: %s, peak heap: %d bytes\n\n", profile.Total.Round(time.Microsecond), profile.PeakHeap)
+}
+
+// printProgress returns a progress callback printing the percentage to stderr
+// whenever it changes
+func printProgress() parser.ProgressFunc {
+	last := -1
+	return func(done, total int, _ string) {
+		percent := 100
+		if total > 0 {
+			percent = done * 100 / total
+		}
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(os.Stderr, "\rParsing: %3d%%", percent)
+		if done == total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+// validateRegexes checks template regexes with the named placeholders of the
+// parser against example lines and prints issues to stderr. It returns false
+// if any miss or collision was found.
+func validateRegexes(results []*parser.ParseResult, logLines []string, placeholders []string) bool {
+	report, err := parser.ValidateTemplateRegexes(results, logLines, 0, placeholders...)
+	if err != nil {
+		log.Fatalf("Error validating regexes: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Regex validation: %d templates, %d examples, %d misses, %d collisions\n",
+		report.Templates, report.Examples, len(report.Misses), len(report.Collisions))
+	for _, miss := range report.Misses {
+		fmt.Fprintf(os.Stderr, "MISS line %d: %q not matched by %q\n", miss.LogID, miss.Line, miss.Template)
+	}
+	for _, collision := range report.Collisions {
+		fmt.Fprintf(os.Stderr, "COLLISION line %d: %q (%q) also matched by %q\n",
+			collision.LogID, collision.Line, collision.Template, collision.Matches)
+	}
+	return report.OK()
+}
+
+// printLabelAlarms prints templates with unusual label cardinality to stderr
+func printLabelAlarms(results []*parser.ParseResult, labels []map[string]string) {
+	if len(labels) == 0 {
+		fmt.Fprintln(os.Stderr, "Label alarms: no labels (add named groups besides 'message' to -log-regex)")
+		return
+	}
+
+	var alarms []parser.LabelCardinality
+	for _, entry := range parser.AnalyzeLabelCardinality(results, labels, parser.LabelCardinalityOptions{}) {
+		if entry.Alarm != parser.LabelAlarmNone {
+			alarms = append(alarms, entry)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Label alarms: %d\n", len(alarms))
+	for _, alarm := range alarms {
+		fmt.Fprintf(os.Stderr, "%-6s %s=%d/%d lines (z=%.2f) %s\n",
+			alarm.Alarm, alarm.Key, alarm.Distinct, alarm.Count, alarm.ZScore, alarm.Template)
+	}
+}
+
+// serveCatalog serves the web template catalog until the process is stopped
+func serveCatalog(addr string, results []*parser.ParseResult, logLines []string) {
+	catalog := parser.NewCatalogServer(&parser.ResultsSource{Results: results, LogLines: logLines}, 0)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           catalog,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(os.Stderr, "Serving template catalog on %s\n", addr)
+	log.Fatal(server.ListenAndServe())
+}
+
+// readTemplateCatalog reads a curated template catalog file
+func readTemplateCatalog(filename string) ([]string, error) {
+	file, err := os.Open(filename) // #nosec G304
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to close file: %v\n", closeErr)
+		}
+	}()
+	return parser.ReadTemplateCatalog(file)
+}
+
+// printViolations prints the automatic changes approved templates were
+// protected from to stderr
+func printViolations(violations []parser.TemplateViolation) {
+	if len(violations) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Approved template violations: %d\n", len(violations))
+	for _, violation := range violations {
+		if violation.By != "" {
+			fmt.Fprintf(os.Stderr, "[%s] %s <- %s\n", violation.Action, violation.Template, violation.By)
+		} else {
+			fmt.Fprintf(os.Stderr, "[%s] %s\n", violation.Action, violation.Template)
+		}
+	}
+}
+
+// outputRetired outputs the templates of a retirement report as a table or
+// as a JSON array
+func outputRetired(templates []parser.RetiredTemplate, asJSON bool) {
+	if asJSON {
+		if templates == nil {
+			templates = []parser.RetiredTemplate{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false) // Keep <*> readable
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(templates); err != nil {
+			log.Fatalf("Error writing JSON: %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("%-20s %-6s %-9s %s\n", "LAST_SEEN", "COUNT", "APPROVED", "TEMPLATE")
+	fmt.Println(strings.Repeat("-", 96))
+	for _, template := range templates {
+		lastSeen := "unknown"
+		if template.LastSeen != nil {
+			lastSeen = template.LastSeen.Local().Format(time.DateTime)
+		}
+		approved := ""
+		if template.Approved {
+			approved = "yes"
+		}
+		fmt.Printf("%-20s %-6d %-9s %s\n", lastSeen, template.Count, approved, template.Template)
+	}
+}
+
+// printClusters prints the template clusters with more than one member to stderr
+func printClusters(clusters []parser.TemplateCluster) {
+	var similar []parser.TemplateCluster
+	for _, cluster := range clusters {
+		if len(cluster.Templates) > 1 {
+			similar = append(similar, cluster)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Template clusters: %d with similar templates\n", len(similar))
+	for _, cluster := range similar {
+		fmt.Fprintf(os.Stderr, "[%d] %s\n", cluster.Count, cluster.Representative)
+		for i, template := range cluster.Templates[1:] {
+			fmt.Fprintf(os.Stderr, "    ~ %s (%d)\n", template, cluster.Counts[i+1])
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+// printMergeAudit prints the template merge audit log to stderr
+func printMergeAudit(entries []parser.MergeAuditEntry) {
+	fmt.Fprintf(os.Stderr, "Merge audit: %d merges\n", len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(os.Stderr, "[%s] %s\n", entry.Reason, entry.Result)
+		for i, source := range entry.Sources {
+			fmt.Fprintf(os.Stderr, "    <- %s (%d)\n", source, entry.Counts[i])
+		}
+	}
+	fmt.Fprintln(os.Stderr)
 }
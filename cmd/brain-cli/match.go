@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// lineMatch is the template a line was assigned to by the match command
+type lineMatch struct {
+	Line       string  `json:"line"`
+	TemplateID string  `json:"template_id,omitempty"`
+	Template   string  `json:"template,omitempty"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// runMatch runs the match command: it assigns every input line to a template
+// of a saved state without learning
+func runMatch(args []string) {
+	fs := newFlagSet("match")
+	input := addInputFlags(fs, true)
+	var (
+		loadState     = fs.String("load-state", "", "State saved with -save-state whose templates lines are matched against (required)")
+		outputFormat  = fs.String("format", "table", "Output format: table, json, ndjson")
+		outputFile    = fs.String("output", "", "Write matches to this file, replaced atomically once complete, instead of stdout")
+		unmatchedOnly = fs.Bool("unmatched-only", false, "Print only the lines no template matches")
+	)
+	_ = fs.Parse(args) // Exits on error
+	switch {
+	case *loadState == "":
+		log.Fatal("match requires -load-state: brain-cli match -load-state FILE [flags]")
+	case *outputFormat != "table" && *outputFormat != "json" && *outputFormat != "ndjson":
+		log.Fatal("match supports only table, json and ndjson output")
+	}
+	input.requirePipedInput(fs)
+
+	status := io.Writer(os.Stdout)
+	if *outputFormat != "table" {
+		status = os.Stderr // Keep stdout parseable
+	}
+	inputs, err := readInputs(*input.input, input.options())
+	if err != nil {
+		log.Fatalf("Error reading input file: %v", err)
+	}
+	logLines := mergeInputs(inputs).lines
+	if len(logLines) == 0 {
+		fmt.Fprintln(status, "No log lines found in input file")
+		return
+	}
+	brainParser, err := loadStateFile(*loadState)
+	if err != nil {
+		log.Fatalf("Error loading state: %v", err)
+	}
+	fmt.Fprintf(status, "Matching %d log lines against the templates of %s...\n", len(logLines), *loadState)
+
+	// Capture matches written to stdout for -output
+	var output *fileOutput
+	if *outputFile != "" {
+		if output, err = captureOutput(*outputFile); err != nil {
+			log.Fatalf("Error writing output: %v", err)
+		}
+	}
+	unmatched, err := printMatches(brainParser, logLines, *outputFormat, *unmatchedOnly)
+	if err != nil {
+		log.Fatalf("Error writing matches: %v", err)
+	}
+	fmt.Fprintf(status, "%d of %d lines matched no template\n", unmatched, len(logLines))
+	if output != nil {
+		if err := output.commit(); err != nil {
+			log.Fatalf("Error writing output: %v", err)
+		}
+	}
+}
+
+// printMatches assigns every line to a template learned by brainParser
+// without learning new ones and prints the assignments in format (table, json
+// or ndjson). With unmatchedOnly, only lines no template matches are printed.
+// It returns the number of unmatched lines.
+func printMatches(brainParser *parser.BrainParser, logLines []string, format string, unmatchedOnly bool) (int, error) {
+	matches := make([]lineMatch, 0, len(logLines))
+	unmatched := 0
+	for _, line := range logLines {
+		result, ok := brainParser.Match(line)
+		if !ok {
+			unmatched++
+			matches = append(matches, lineMatch{Line: line})
+			continue
+		}
+		if !unmatchedOnly {
+			matches = append(matches, lineMatch{Line: line, TemplateID: result.ID, Template: result.Template, Similarity: result.Similarity})
+		}
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false) // Keep <*> readable
+		encoder.SetIndent("", "  ")
+		return unmatched, encoder.Encode(matches)
+	case "ndjson":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		for _, match := range matches {
+			if err := encoder.Encode(match); err != nil {
+				return unmatched, err
+			}
+		}
+		return unmatched, nil
+	}
+
+	for _, match := range matches {
+		fmt.Println(match.Line)
+		if match.Template == "" {
+			fmt.Println("  -> no template")
+			continue
+		}
+		fmt.Printf("  -> [%s %.2f] %s\n", match.TemplateID, match.Similarity, match.Template)
+	}
+	return unmatched, nil
+}
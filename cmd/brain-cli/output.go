@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// fileOutput captures everything written to stdout and replaces a file with
+// it on commit, so readers of the file never see partial results and a
+// failed run leaves a previous file in place
+type fileOutput struct {
+	path   string
+	stdout *os.File // Original stdout, restored on commit
+	writer *os.File // Write end of the pipe replacing stdout
+	data   bytes.Buffer
+	done   chan error
+}
+
+// captureOutput redirects stdout into memory until commit writes it to path
+func captureOutput(path string) (*fileOutput, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture output: %w", err)
+	}
+	out := &fileOutput{path: path, stdout: os.Stdout, writer: writer, done: make(chan error, 1)}
+	go func() {
+		_, err := out.data.ReadFrom(reader)
+		_ = reader.Close()
+		out.done <- err
+	}()
+	os.Stdout = writer
+	return out, nil
+}
+
+// commit restores stdout and atomically replaces the output file with the
+// captured data
+func (out *fileOutput) commit() error {
+	os.Stdout = out.stdout
+	if err := out.writer.Close(); err != nil {
+		return fmt.Errorf("failed to capture output: %w", err)
+	}
+	if err := <-out.done; err != nil {
+		return fmt.Errorf("failed to capture output: %w", err)
+	}
+
+	return writeFileAtomic(out.path, out.data.Bytes())
+}
+
+// writeFileAtomic replaces a file with data by writing a temporary file next
+// to it and renaming it, so the file is never left partially written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpName := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,617 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// parseFlags are the flags of the parse command
+type parseFlags struct {
+	fs         *flag.FlagSet
+	input      *inputFlags
+	brain      *parserFlags
+	algorithms *algorithmFlags
+
+	approvedFile  *string
+	verbose       *bool
+	follow        *bool
+	followEvery   *time.Duration
+	live          *bool
+	auditLog      *string
+	expireAfter   *time.Duration
+	alerts        *bool
+	alertRare     *float64
+	alertWarmup   *int
+	perFile       *bool
+	counted       *bool
+	params        *bool
+	paramExamples *int
+	outputFormat  *string
+	sortBy        *string
+	outputFile    *string
+	sigmaMaxCount *int
+	minCount      *int
+	minCoverage   *float64
+	minSeverity   *string
+	redact        *bool
+	redactPattern *string
+	progress      *bool
+	timeout       *time.Duration
+	twoPass       *int
+	validateRegex *bool
+	mergeAudit    *bool
+	labelAlarms   *bool
+	clusterSim    *float64
+	loadState     *string
+	saveState     *string
+	stateFile     *string
+	timeWindow    *time.Duration
+	retired       *bool
+	elasticURL    *string
+	elasticIndex  *string
+}
+
+// addParseFlags defines the flags of the parse command on fs
+func addParseFlags(fs *flag.FlagSet) *parseFlags {
+	return &parseFlags{
+		fs:         fs,
+		input:      addInputFlags(fs, true),
+		brain:      addParserFlags(fs),
+		algorithms: addAlgorithmFlags(fs, true),
+
+		approvedFile:  addApprovedFlag(fs),
+		verbose:       fs.Bool("verbose", false, "Verbose output with log IDs"),
+		follow:        fs.Bool("follow", false, "Follow a growing text file like 'tail -F' and re-print the templates as lines arrive"),
+		followEvery:   fs.Duration("follow-interval", 2*time.Second, "How often -follow checks the file for new lines"),
+		live:          fs.Bool("live", false, "Assign every text line of the input to a template as it arrives, writing one ndjson object per line (e.g. for tail -F pipes)"),
+		auditLog:      fs.String("audit-log", "", "Append every template state change of -follow to this NDJSON file"),
+		expireAfter:   fs.Duration("expire-after", 0, "Drop -follow templates not seen for this duration, e.g. 1h (0 = never)"),
+		alerts:        fs.Bool("alerts", false, "Print every -follow line whose template is new or rare to stderr"),
+		alertRare:     fs.Float64("alert-rare", 0, "Share of all lines below which -alerts reports a known template as rare, e.g. 0.001 (0 = new templates only)"),
+		alertWarmup:   fs.Int("alert-warmup", 0, "Lines -follow reads before -alerts reports anything"),
+		perFile:       fs.Bool("per-file", false, "With several -input files, parse and output every file separately instead of merged"),
+		counted:       fs.Bool("counted", false, "Input lines are prefixed with a repeat count as produced by 'uniq -c'"),
+		params:        fs.Bool("params", false, "Show the values of the <*> slots of every log in table and json output"),
+		paramExamples: fs.Int("param-examples", 5, "Example values per <*> slot in json output with -params"),
+		outputFormat:  fs.String("format", "table", "Output format: table, json, ndjson, csv, sigma, grafana, loki"),
+		sortBy:        fs.String("sort", parser.SortByCount, "Order of the shown templates: count, template, first-seen or coverage (lines × template tokens); ties are ordered by template"),
+		outputFile:    fs.String("output", "", "Write results to this file, replaced atomically once complete, instead of stdout"),
+		sigmaMaxCount: fs.Int("sigma-max-count", 0, "Export templates with count <= N as rare in sigma format (0 = error keywords only)"),
+		minCount:      fs.Int("min-count", 1, "Minimum template count to display"),
+		minCoverage:   fs.Float64("min-coverage", 0, "Pick the count threshold so displayed templates cover this fraction of lines, e.g. 0.99 (overrides -min-count)"),
+		minSeverity:   fs.String("min-severity", "", "Minimum inferred template severity to display: debug, info, warning, error, critical"),
+		redact:        fs.Bool("redact", false, "Replace emails, IPs and credit-card-like numbers in templates, slot values and examples with <EMAIL>, <IP> and <CARD>"),
+		redactPattern: fs.String("redact-pattern", "", "Additional redaction as NAME=REGEX, matches become <NAME> (implies -redact)"),
+		progress:      fs.Bool("progress", false, "Print parse progress percentage to stderr"),
+		timeout:       fs.Duration("timeout", 0, "Abort parsing after this duration, e.g. 5m (0 = no limit)"),
+		twoPass:       fs.Int("two-pass", 0, "Learn templates on a sample of N lines, then count all lines exactly (0 = single pass)"),
+		validateRegex: fs.Bool("validate-regex", false, "Check displayed template regexes for misses and collisions, exit 1 on issues"),
+		mergeAudit:    fs.Bool("merge-audit", false, "Print which templates were merged and why to stderr"),
+		labelAlarms:   fs.Bool("label-alarms", false, "Flag templates with unusually broad or narrow label cardinality (labels are extra -log-regex named groups or -json-fields)"),
+		clusterSim:    fs.Float64("cluster-similarity", 0, "Print clusters of shown templates with at least this token similarity (0-1) to stderr (0 = off)"),
+		loadState:     fs.String("load-state", "", "Resume from templates and configuration saved with -save-state"),
+		saveState:     fs.String("save-state", "", "Save learned templates and configuration to a JSON file"),
+		stateFile:     fs.String("state", "", "Template database kept across runs: resume from this file if it exists and save the updated templates back to it"),
+		timeWindow:    fs.Duration("time-window", 0, "Output the count of every template per window of this length (e.g. 5m) by the -timestamp-regex timestamps as a csv or json time series"),
+		retired:       fs.Bool("retired", false, "With -load-state, report the saved templates no input line matches any longer with their last occurrence instead of parsing"),
+		elasticURL:    fs.String("elastic", "", "Bulk-index templates and per-line assignments into the Elasticsearch or OpenSearch cluster at this URL (API key from ELASTIC_API_KEY)"),
+		elasticIndex:  fs.String("elastic-index", "brain", "Index name prefix of -elastic: <prefix>-templates and <prefix>-lines"),
+	}
+}
+
+// validate exits if flags of different modes are combined. It resolves
+// -state into -load-state and -save-state.
+func (f *parseFlags) validate() {
+	// -state is -load-state and -save-state of the same file, loaded only
+	// once the first run has created it
+	if *f.stateFile != "" {
+		switch {
+		case *f.loadState != "" || *f.saveState != "":
+			log.Fatal("-state cannot be combined with -load-state or -save-state")
+		case *f.follow || *f.live || *f.retired || *f.perFile:
+			log.Fatal("-state cannot be combined with -follow, -live, -retired or -per-file")
+		}
+		if _, err := os.Stat(*f.stateFile); err == nil {
+			*f.loadState = *f.stateFile
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			log.Fatalf("Error loading state: %v", err)
+		}
+		*f.saveState = *f.stateFile
+	}
+
+	if err := parser.SortResults(nil, *f.sortBy); err != nil {
+		log.Fatalf("Invalid -sort: %v", err)
+	}
+	if *f.outputFile != "" && (*f.follow || *f.live) {
+		log.Fatal("-output cannot be combined with -follow or -live")
+	}
+	if *f.retired {
+		switch {
+		case *f.loadState == "":
+			log.Fatal("-retired requires -load-state")
+		case *f.follow || *f.live || *f.perFile:
+			log.Fatal("-retired cannot be combined with -follow, -live or -per-file")
+		case *f.outputFormat != "table" && *f.outputFormat != "json":
+			log.Fatal("-retired supports only table and json output")
+		}
+	}
+	if *f.timeWindow != 0 {
+		switch {
+		case *f.timeWindow < 0:
+			log.Fatal("-time-window must be positive")
+		case *f.brain.tsRegex == "":
+			log.Fatal("-time-window requires -timestamp-regex")
+		case *f.outputFormat != "csv" && *f.outputFormat != "json":
+			log.Fatal("-time-window supports only csv and json output")
+		case *f.follow || *f.live || *f.retired:
+			log.Fatal("-time-window cannot be combined with -follow, -live or -retired")
+		}
+	}
+	switch algorithm := *f.algorithms.algorithm; algorithm {
+	case parser.AlgorithmBrain:
+	case parser.AlgorithmDrain, parser.AlgorithmSpell:
+		switch {
+		case *f.follow || *f.live || *f.retired:
+			log.Fatalf("-algorithm %s cannot be combined with -follow, -live or -retired", algorithm)
+		case *f.counted || *f.params || *f.twoPass > 0 || *f.timeout > 0 || *f.progress || *f.approvedFile != "":
+			log.Fatalf("-algorithm %s cannot be combined with -counted, -params, -two-pass, -timeout, -progress or -approved-templates", algorithm)
+		}
+	default:
+		log.Fatalf("Unknown -algorithm %q: must be brain, drain or spell", algorithm)
+	}
+	if *f.twoPass > 0 && (*f.counted || *f.params) || *f.counted && *f.params {
+		log.Fatal("-two-pass, -counted and -params cannot be combined")
+	}
+	if *f.elasticURL != "" && (*f.follow || *f.live || *f.retired) {
+		log.Fatal("-elastic cannot be combined with -follow, -live or -retired")
+	}
+	if *f.perFile && (*f.follow || *f.saveState != "") {
+		log.Fatal("-per-file cannot be combined with -follow or -save-state")
+	}
+	if (*f.redact || *f.redactPattern != "") && (*f.follow || *f.live) {
+		log.Fatal("-redact cannot be combined with -follow or -live")
+	}
+
+	input := *f.input.input
+	fileType := *f.input.fileType
+	nonText := fileType != "auto" && fileType != "text" || fileType == "auto" && detectFileType(input) != "text" || parser.IsArchive(input)
+	remote := newRemoteOpener()
+	if *f.follow {
+		switch {
+		case input == "" || input == "-" || remote.Handles(input):
+			log.Fatal("-follow requires a local input file")
+		case strings.Contains(input, ",") || strings.ContainsAny(input, "*?["):
+			log.Fatal("-follow requires a single input file")
+		case nonText:
+			log.Fatal("-follow supports only text input")
+		case *f.counted || *f.params || *f.twoPass > 0 || *f.loadState != "":
+			log.Fatal("-follow cannot be combined with -counted, -params, -two-pass or -load-state")
+		case *f.followEvery <= 0:
+			log.Fatal("-follow-interval must be positive")
+		case *f.alertRare < 0 || *f.alertRare >= 1 || *f.alertWarmup < 0:
+			log.Fatal("-alert-rare must be in [0, 1) and -alert-warmup must not be negative")
+		}
+	} else if *f.auditLog != "" || *f.expireAfter != 0 || *f.alerts {
+		log.Fatal("-audit-log, -expire-after and -alerts require -follow")
+	}
+	if *f.live {
+		switch {
+		case *f.follow || *f.perFile:
+			log.Fatal("-live cannot be combined with -follow or -per-file")
+		case strings.Contains(input, ",") || strings.ContainsAny(input, "*?["):
+			log.Fatal("-live requires a single input")
+		case remote.Handles(input):
+			log.Fatal("-live requires a local input")
+		case nonText:
+			log.Fatal("-live supports only text input")
+		case *f.input.logRegex != "" || *f.counted || *f.params || *f.twoPass > 0 || *f.loadState != "" || *f.saveState != "":
+			log.Fatal("-live cannot be combined with -log-regex, -counted, -params, -two-pass, -load-state or -save-state")
+		}
+	}
+	f.input.requirePipedInput(f.fs)
+}
+
+// parseRun is one run of the parse command
+type parseRun struct {
+	*parseFlags
+	status      io.Writer
+	config      parser.Config
+	approved    []string
+	minSeverity parser.Severity
+	redactor    *parser.Redactor
+	newParser   func() parser.LogParser // Parser of -algorithm
+}
+
+// runParse runs the parse command: it learns templates from log input and
+// outputs them, or keeps learning with -follow or -live
+func runParse(args []string) {
+	f := addParseFlags(newFlagSet("parse"))
+	_ = f.fs.Parse(args) // Exits on error
+	f.validate()
+
+	// Keep stdout parseable for machine-readable formats
+	status := io.Writer(os.Stdout)
+	if *f.outputFormat != "table" || *f.live {
+		status = os.Stderr
+	}
+
+	// Read input files
+	var inputs []inputSource
+	readInput := !*f.follow && !*f.live
+	if readInput {
+		var err error
+		if inputs, err = readInputs(*f.input.input, f.input.options()); err != nil {
+			log.Fatalf("Error reading input file: %v", err)
+		}
+		if *f.counted {
+			for i := range inputs {
+				if inputs[i].weights, err = splitCounts(inputs[i].lines); err != nil {
+					log.Fatalf("Invalid -counted input in %s: %v", inputs[i].name, err)
+				}
+			}
+		}
+	}
+	merged := mergeInputs(inputs)
+	if readInput && len(merged.lines) == 0 {
+		fmt.Fprintln(status, "No log lines found in input file")
+		return
+	}
+
+	switch {
+	case *f.follow:
+		fmt.Fprintf(status, "Following %s...\n", *f.input.input)
+	case *f.live:
+		fmt.Fprintln(status, "Assigning lines as they arrive...")
+	case len(inputs) > 1:
+		fmt.Fprintf(status, "Processing %d log lines from %d files...\n", len(merged.lines), len(inputs))
+	default:
+		fmt.Fprintf(status, "Processing %d log lines...\n", len(merged.lines))
+	}
+
+	run := &parseRun{parseFlags: f, status: status, config: f.brain.config(), approved: readApproved(*f.approvedFile)}
+	if features := enhancedFeatures(run.config, len(merged.lines)); features != "" {
+		fmt.Fprintf(status, "Enhanced features enabled: %s\n", features)
+	}
+	run.newParser = f.algorithms.newParser(run.config)
+	if *f.minSeverity != "" {
+		var err error
+		if run.minSeverity, err = parser.ParseSeverity(*f.minSeverity); err != nil {
+			log.Fatalf("Invalid -min-severity: %v", err)
+		}
+	}
+	if *f.redact || *f.redactPattern != "" {
+		patterns := make(map[string]string)
+		if *f.redactPattern != "" {
+			name, pattern, ok := strings.Cut(*f.redactPattern, "=")
+			if !ok {
+				log.Fatalf("Invalid -redact-pattern %q: must be NAME=REGEX", *f.redactPattern)
+			}
+			patterns[name] = pattern
+		}
+		var err error
+		if run.redactor, err = parser.NewRedactor(patterns); err != nil {
+			log.Fatalf("Invalid -redact-pattern: %v", err)
+		}
+	}
+
+	switch {
+	case *f.live:
+		if err := runLive(*f.input.input, run.config, *f.verbose); err != nil {
+			log.Fatalf("Error processing live input: %v", err)
+		}
+		return
+	case *f.follow:
+		run.followInput()
+		return
+	}
+
+	// Capture results written to stdout for -output; status messages keep
+	// going to the terminal
+	var output *fileOutput
+	if *f.outputFile != "" {
+		var err error
+		if output, err = captureOutput(*f.outputFile); err != nil {
+			log.Fatalf("Error writing output: %v", err)
+		}
+	}
+	commitOutput := func() {
+		if output != nil {
+			if err := output.commit(); err != nil {
+				log.Fatalf("Error writing output: %v", err)
+			}
+		}
+	}
+
+	if *f.retired {
+		templates := run.newBrainParser().RetirementReport(merged.lines)
+		fmt.Fprintf(status, "Found %d templates of the state no longer occurring:\n\n", len(templates))
+		outputRetired(templates, *f.outputFormat == "json")
+		commitOutput()
+		return
+	}
+
+	valid := true
+	if *f.perFile {
+		for i, input := range inputs {
+			if i > 0 {
+				fmt.Fprintln(status)
+			}
+			fmt.Fprintf(status, "==> %s (%d lines) <==\n", input.name, len(input.lines))
+			if len(input.lines) == 0 {
+				continue
+			}
+			valid = run.processInput(input) && valid
+		}
+	} else {
+		valid = run.processInput(merged)
+	}
+	commitOutput()
+	if !valid {
+		os.Exit(1)
+	}
+}
+
+// followInput re-prints the templates of the -follow file as lines arrive
+// until interrupted
+func (r *parseRun) followInput() {
+	render := func(results []*parser.ParseResult, lines int) {
+		var shown []*parser.ParseResult
+		for _, result := range results {
+			if result.Count >= *r.minCount && result.Severity >= r.minSeverity {
+				shown = append(shown, result)
+			}
+		}
+		switch *r.outputFormat {
+		case "json", "ndjson":
+			outputJSON(shown, nil, nil, nil, lines, false, *r.outputFormat == "ndjson", "")
+		case "csv":
+			outputCSV(shown, false)
+		case "sigma":
+			outputSigma(shown, *r.sigmaMaxCount, slices.Sorted(maps.Values(r.config.Placeholders)))
+		case "loki":
+			outputLoki(shown)
+		case "grafana":
+			outputGrafana(shown, nil, lines)
+		default:
+			if isTerminal(os.Stdout) {
+				fmt.Print("\033[H\033[2J") // Redraw in place
+			}
+			fmt.Printf("%s: %d unique templates from %d lines (showing %d with count >= %d):\n\n",
+				time.Now().Format(time.TimeOnly), len(results), lines, len(shown), *r.minCount)
+			outputTable(shown, false)
+		}
+	}
+	online, err := followFile(*r.input.input, *r.input.logRegex, r.config, followOptions{
+		interval:    *r.followEvery,
+		auditLog:    *r.auditLog,
+		expireAfter: *r.expireAfter,
+		alerts:      *r.alerts,
+		rarity:      parser.RarityOptions{RareFrequency: *r.alertRare, MinLines: *r.alertWarmup},
+		approved:    r.approved,
+	}, render)
+	if err != nil {
+		log.Fatalf("Error following input file: %v", err)
+	}
+	r.finishOnline(online)
+}
+
+// finishOnline reports the approved template violations of an online parser
+// and saves its state to -save-state
+func (r *parseRun) finishOnline(online *parser.OnlineParser) {
+	printViolations(online.Violations())
+	if *r.saveState != "" {
+		if err := saveStateFile(*r.saveState, online); err != nil {
+			log.Fatalf("Error saving state: %v", err)
+		}
+	}
+}
+
+// newBrainParser creates a Brain parser resuming from -load-state and
+// reporting -progress
+func (r *parseRun) newBrainParser() *parser.BrainParser {
+	brainParser := newBrainParser(*r.loadState, r.config, r.approved)
+	if *r.progress {
+		brainParser.OnProgress(printProgress())
+	}
+	return brainParser
+}
+
+// newTemplateLearner creates a parser of an algorithm other than Brain
+// resuming from -load-state
+func (r *parseRun) newTemplateLearner() templateLearner {
+	if *r.loadState == "" {
+		return r.newParser().(templateLearner)
+	}
+	loaded, err := loadParserFile(*r.loadState)
+	if err != nil {
+		log.Fatalf("Error loading state: %v", err)
+	}
+	if algorithm := *r.algorithms.algorithm; algorithmOf(loaded) != algorithm {
+		log.Fatalf("Error loading state: %s is a %s state, not %s", *r.loadState, algorithmOf(loaded), algorithm)
+	}
+	return loaded.(templateLearner)
+}
+
+// parseBrain parses one input with the Brain variant selected by flags,
+// aborting after -timeout
+func (r *parseRun) parseBrain(brainParser *parser.BrainParser, logLines []string, weights []int) *parser.ParseReport {
+	ctx := context.Background()
+	if *r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *r.timeout)
+		defer cancel()
+	}
+	var report *parser.ParseReport
+	var err error
+	switch {
+	case *r.twoPass > 0:
+		report, err = brainParser.ParseTwoPassContext(ctx, logLines, parser.TwoPassOptions{SampleSize: *r.twoPass})
+	case weights != nil:
+		report, err = brainParser.ParseWeightedContext(ctx, logLines, weights)
+	case *r.params:
+		report, err = brainParser.ParseWithParamsContext(ctx, logLines)
+	default:
+		report, err = brainParser.ParseWithReportContext(ctx, logLines)
+	}
+	if err != nil {
+		log.Fatalf("Error parsing logs: %v", err)
+	}
+	return report
+}
+
+// processInput parses one input and outputs its templates, it reports
+// whether the template regexes passed -validate-regex
+func (r *parseRun) processInput(input inputSource) bool {
+	logLines, labels, weights := input.lines, input.labels, input.weights
+	var brainParser *parser.BrainParser
+	var stateParser parser.LogParser
+	var known []string        // Loaded with -load-state
+	var placeholders []string // Named placeholders of the Brain parser
+	var report *parser.ParseReport
+	if *r.algorithms.algorithm != parser.AlgorithmBrain {
+		learner := r.newTemplateLearner()
+		known = learner.Templates()
+		report = &parser.ParseReport{Results: learner.Parse(logLines)}
+		stateParser = learner
+	} else {
+		brainParser = r.newBrainParser()
+		known = brainParser.Templates()
+		placeholders = brainParser.Placeholders()
+		report = r.parseBrain(brainParser, logLines, weights)
+		stateParser = brainParser
+	}
+	results := report.Results
+	if report.Profile != nil {
+		printProfile(report.Profile)
+	}
+	if *r.saveState != "" {
+		if err := saveStateFile(*r.saveState, stateParser); err != nil {
+			log.Fatalf("Error saving state: %v", err)
+		}
+	}
+	if r.config.FoldUnicode {
+		fmt.Fprintf(os.Stderr, "Unicode folding changed %d lines\n", report.FoldedLines)
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if *r.mergeAudit {
+		printMergeAudit(report.MergeAudit)
+	}
+	if *r.approvedFile != "" {
+		printViolations(brainParser.Violations())
+	}
+
+	// Filter results by severity, then by minimum count, so -min-coverage
+	// picks its threshold among the templates that can be displayed
+	var eligible []*parser.ParseResult
+	totalLines := 0
+	for _, result := range results {
+		totalLines += result.Count
+		if result.Severity >= r.minSeverity {
+			eligible = append(eligible, result)
+		}
+	}
+	minShown := *r.minCount
+	if *r.minCoverage > 0 {
+		var err error
+		minShown, err = parser.CoverageThreshold(eligible, *r.minCoverage)
+		if err != nil {
+			log.Fatalf("Invalid -min-coverage: %v", err)
+		}
+	}
+	var filteredResults []*parser.ParseResult
+	shownLines := 0
+	for _, result := range eligible {
+		if result.Count >= minShown {
+			filteredResults = append(filteredResults, result)
+			shownLines += result.Count
+		}
+	}
+
+	fmt.Fprintf(r.status, "Found %d unique templates (showing %d with count >= %d):\n\n",
+		len(results), len(filteredResults), minShown)
+
+	// Redact personal data before anything is output
+	if r.redactor != nil {
+		r.redactor.RedactResults(filteredResults)
+		logLines = r.redactor.RedactLines(logLines)
+	}
+	if *r.clusterSim > 0 {
+		clusters, err := parser.ClusterTemplates(filteredResults, *r.clusterSim, placeholders...)
+		if err != nil {
+			log.Fatalf("Invalid -cluster-similarity: %v", err)
+		}
+		printClusters(clusters)
+	}
+
+	if err := parser.SortResults(filteredResults, *r.sortBy); err != nil {
+		log.Fatalf("Invalid -sort: %v", err)
+	}
+
+	// Summarize hidden templates as a single "other" row in coverage mode
+	if *r.minCoverage > 0 && *r.outputFormat != "sigma" && *r.outputFormat != "loki" && shownLines < totalLines {
+		filteredResults = append(filteredResults, &parser.ParseResult{
+			Template: otherTemplate,
+			Count:    totalLines - shownLines,
+		})
+	}
+
+	// Output results in specified format, or their counts over time
+	if *r.timeWindow > 0 {
+		outputTimeSeries(r.config.Timestamps, filteredResults, logLines, *r.timeWindow, *r.outputFormat == "json")
+	} else {
+		switch *r.outputFormat {
+		case "json", "ndjson":
+			file := ""
+			if *r.perFile {
+				file = input.name
+			}
+			var slots func(*parser.ParseResult) []parser.ParamSlot
+			if *r.params {
+				slots = func(result *parser.ParseResult) []parser.ParamSlot {
+					return brainParser.ParamSlots(result, *r.paramExamples)
+				}
+			}
+			outputJSON(filteredResults, logLines, labels, slots, totalLines, *r.verbose, *r.outputFormat == "ndjson", file)
+		case "csv":
+			outputCSV(filteredResults, *r.verbose)
+		case "sigma":
+			outputSigma(filteredResults, *r.sigmaMaxCount, placeholders)
+		case "loki":
+			outputLoki(filteredResults)
+		case "grafana":
+			var events []parser.AuditEvent
+			if *r.loadState != "" {
+				events = newTemplateEvents(filteredResults, known)
+			}
+			outputGrafana(filteredResults, events, totalLines)
+		default:
+			outputTable(filteredResults, *r.verbose)
+		}
+	}
+
+	if *r.labelAlarms {
+		printLabelAlarms(filteredResults, labels)
+	}
+
+	if *r.elasticURL != "" {
+		if err := indexElastic(*r.elasticURL, *r.elasticIndex, filteredResults, logLines, r.status); err != nil {
+			log.Fatalf("Error indexing into Elasticsearch: %v", err)
+		}
+	}
+
+	return !*r.validateRegex || validateRegexes(filteredResults, logLines, placeholders)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// maxRPCMessageSize is the longest request line the rpc mode accepts
+const maxRPCMessageSize = 64 * 1024 * 1024
+
+// rpcRequest is a JSON-RPC 2.0 request
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error object of a JSON-RPC 2.0 response
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Parameters of the rpc methods
+type (
+	rpcParseParams struct {
+		Lines []string `json:"lines"`
+	}
+	rpcMatchParams struct {
+		Line string `json:"line"`
+	}
+	rpcStateParams struct {
+		Path  string          `json:"path,omitempty"`  // State file
+		State json.RawMessage `json:"state,omitempty"` // Inline state document (load only)
+	}
+)
+
+// rpcMatchResult is the result of the match method
+type rpcMatchResult struct {
+	Matched    bool                 `json:"matched"`
+	Template   *jsonTemplate        `json:"template,omitempty"`
+	Anomalies  []parser.SlotAnomaly `json:"anomalies,omitempty"`  // Outlier slot values (with -variable-stats)
+	Similarity float64              `json:"similarity,omitempty"` // Below 1 for approximate matches (with -approximate-match)
+}
+
+// rpcSession holds the parser driven by an rpc client
+type rpcSession struct {
+	parser *parser.BrainParser
+}
+
+// runRPC runs the rpc command: it serves JSON-RPC on stdin/stdout until stdin
+// is closed
+func runRPC(args []string) {
+	fs := newFlagSet("rpc")
+	brain := addParserFlags(fs)
+	var (
+		loadState    = fs.String("load-state", "", "Resume from templates and configuration saved with -save-state")
+		approvedFile = addApprovedFlag(fs)
+	)
+	_ = fs.Parse(args) // Exits on error
+
+	fmt.Fprintln(os.Stderr, "Serving JSON-RPC on stdin/stdout...")
+	config := brain.config()
+	if features := enhancedFeatures(config, 0); features != "" {
+		fmt.Fprintf(os.Stderr, "Enhanced features enabled: %s\n", features)
+	}
+	brainParser := newBrainParser(*loadState, config, readApproved(*approvedFile))
+	if err := serveRPC(os.Stdin, os.Stdout, brainParser); err != nil {
+		log.Fatalf("Error serving JSON-RPC: %v", err)
+	}
+}
+
+// serveRPC reads one JSON-RPC 2.0 request per line from r and writes one
+// response per line to w until r is closed. Notifications get no response.
+func serveRPC(r io.Reader, w io.Writer, brainParser *parser.BrainParser) error {
+	session := &rpcSession{parser: brainParser}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxRPCMessageSize)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		response, reply := session.handle(line)
+		if !reply {
+			continue
+		}
+		if err := encoder.Encode(response); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read request: %w", err)
+	}
+	return nil
+}
+
+// handle runs one request and reports whether a response must be sent
+func (s *rpcSession) handle(line []byte) (rpcResponse, bool) {
+	response := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+
+	var request rpcRequest
+	if err := json.Unmarshal(line, &request); err != nil {
+		response.Error = &rpcError{Code: rpcParseError, Message: "parse error: " + err.Error()}
+		return response, true
+	}
+	if request.ID != nil {
+		response.ID = request.ID
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		response.Error = &rpcError{Code: rpcInvalidRequest, Message: `invalid request: jsonrpc must be "2.0" and method is required`}
+		return response, true
+	}
+
+	result, err := s.call(request.Method, request.Params)
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: rpcServerError, Message: err.Error()}
+		}
+		response.Error = rpcErr
+	} else {
+		response.Result = result
+	}
+	return response, request.ID != nil
+}
+
+// call dispatches a method
+func (s *rpcSession) call(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case "parse":
+		var p rpcParseParams
+		if err := decodeRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		results := s.parser.Parse(p.Lines)
+		templates := make([]jsonTemplate, len(results))
+		for i, result := range results {
+			templates[i] = newJSONTemplate(result, p.Lines, len(p.Lines), true)
+		}
+		return templates, nil
+
+	case "match":
+		var p rpcMatchParams
+		if err := decodeRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		result, ok := s.parser.Match(p.Line)
+		if !ok {
+			return rpcMatchResult{}, nil
+		}
+		entry := newJSONTemplate(result, nil, 0, false)
+		return rpcMatchResult{Matched: true, Template: &entry, Anomalies: result.Anomalies, Similarity: result.Similarity}, nil
+
+	case "save":
+		var p rpcStateParams
+		if err := decodeRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Path != "" {
+			if err := saveStateFile(p.Path, s.parser); err != nil {
+				return nil, err
+			}
+			return true, nil
+		}
+		var buf bytes.Buffer
+		if err := s.parser.SaveState(&buf); err != nil {
+			return nil, err
+		}
+		return json.RawMessage(buf.Bytes()), nil
+
+	case "load":
+		var p rpcStateParams
+		if err := decodeRPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		var loaded *parser.BrainParser
+		var err error
+		switch {
+		case p.Path != "" && p.State == nil:
+			loaded, err = loadStateFile(p.Path)
+		case p.Path == "" && p.State != nil:
+			loaded, err = parser.LoadState(bytes.NewReader(p.State))
+		default:
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "invalid params: exactly one of path and state is required"}
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.parser = loaded
+		return true, nil
+
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// decodeRPCParams decodes named method params into dst, rejecting unknown
+// fields. Missing params leave dst unchanged.
+func decodeRPCParams(params json.RawMessage, dst any) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(params))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: "invalid params: " + err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+)
+
+// defaultServeAddr is the REST API address of the serve subcommand
+const defaultServeAddr = ":8080"
+
+// maxAPIRequestSize is the largest request body the REST API accepts
+const maxAPIRequestSize = maxRPCMessageSize
+
+// apiTemplateStats is the body of GET /api/templates/{id}
+type apiTemplateStats struct {
+	jsonTemplate
+	LastSeen *time.Time `json:"last_seen,omitempty"` // Time of the last batch containing the template
+}
+
+// runServe runs the serve command: it learns templates from REST API requests
+// and -gelf-udp messages until SIGINT or SIGTERM, or with -catalog serves the
+// web template catalog of the input
+func runServe(args []string) {
+	fs := newFlagSet("serve")
+	input := addInputFlags(fs, true)
+	brain := addParserFlags(fs)
+	var (
+		serveAddr    = fs.String("serve", defaultServeAddr, "Address of the REST API, or of the catalog with -catalog")
+		catalog      = fs.Bool("catalog", false, "Parse the input and serve a read-only web template catalog of the results instead of the REST API")
+		gelfUDP      = fs.String("gelf-udp", "", "Also learn templates from GELF messages received on this UDP address (e.g. :12201)")
+		saveState    = fs.String("save-state", "", "Save learned templates and configuration to a JSON file on shutdown")
+		approvedFile = addApprovedFlag(fs)
+	)
+	_ = fs.Parse(args) // Exits on error
+	if *catalog {
+		if *gelfUDP != "" || *saveState != "" {
+			log.Fatal("-catalog cannot be combined with -gelf-udp or -save-state")
+		}
+		input.requirePipedInput(fs)
+		serveInputCatalog(*serveAddr, *input.input, input.options(), brain.config(), readApproved(*approvedFile))
+		return
+	}
+	if *input.input != "" {
+		log.Fatal("-input requires -catalog")
+	}
+
+	if *gelfUDP != "" {
+		fmt.Fprintln(os.Stderr, "Learning templates from REST API requests and GELF messages...")
+	} else {
+		fmt.Fprintln(os.Stderr, "Learning templates from REST API requests...")
+	}
+	config := brain.config()
+	if features := enhancedFeatures(config, 0); features != "" {
+		fmt.Fprintf(os.Stderr, "Enhanced features enabled: %s\n", features)
+	}
+	online, err := serveAPI(*serveAddr, *gelfUDP, config, readApproved(*approvedFile))
+	if err != nil {
+		log.Fatalf("Error serving API: %v", err)
+	}
+	printViolations(online.Violations())
+	if *saveState != "" {
+		if err := saveStateFile(*saveState, online); err != nil {
+			log.Fatalf("Error saving state: %v", err)
+		}
+	}
+}
+
+// serveInputCatalog parses the input and serves the web template catalog of
+// the results on addr until the process is stopped
+func serveInputCatalog(addr, spec string, options inputOptions, config parser.Config, approved []string) {
+	inputs, err := readInputs(spec, options)
+	if err != nil {
+		log.Fatalf("Error reading input file: %v", err)
+	}
+	logLines := mergeInputs(inputs).lines
+	if len(logLines) == 0 {
+		fmt.Fprintln(os.Stderr, "No log lines found in input file")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Processing %d log lines...\n", len(logLines))
+	if features := enhancedFeatures(config, len(logLines)); features != "" {
+		fmt.Fprintf(os.Stderr, "Enhanced features enabled: %s\n", features)
+	}
+	results := newBrainParser("", config, approved).Parse(logLines)
+	serveCatalog(addr, results, logLines)
+}
+
+// serveAPI serves the REST API of an online parser on addr until SIGINT or
+// SIGTERM. With gelfAddr set, the parser also learns the GELF messages
+// received on that UDP address. Approved templates are protected from
+// merging and expiry.
+func serveAPI(addr, gelfAddr string, config parser.Config, approved []string) (*parser.OnlineParser, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	online := parser.NewOnlineParser(config)
+	if err := online.Approve(approved...); err != nil {
+		return nil, fmt.Errorf("invalid approved templates: %w", err)
+	}
+	var received chan error // Result of receiveGELF, nil without gelfAddr
+	if gelfAddr != "" {
+		conn, err := net.ListenPacket("udp", gelfAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen for GELF: %w", err)
+		}
+		received = make(chan error, 1)
+		go func() {
+			received <- receiveGELF(ctx, conn, online)
+		}()
+		fmt.Fprintf(os.Stderr, "Receiving GELF on %s\n", conn.LocalAddr())
+	}
+	catalog := parser.NewCatalogServer(online, 0)
+	go catalog.Run(ctx)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           newAPIHandler(online, catalog),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "Serving REST API on %s\n", addr)
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-served:
+		err = fmt.Errorf("failed to serve API: %w", err)
+	case err = <-received:
+		if err != nil {
+			err = fmt.Errorf("failed to receive GELF: %w", err)
+		}
+		received = nil // Already returned
+	}
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = server.Shutdown(shutdownCtx)
+	if received != nil {
+		<-received
+	}
+	return online, err
+}
+
+// newAPIHandler returns the REST API of online: POST /api/parse learns a
+// batch of lines, GET /api/templates/{id} returns the stats of a template and
+// POST /api/match classifies a line. Other requests, including the template
+// table at GET /api/templates, are served by catalog.
+func newAPIHandler(online *parser.OnlineParser, catalog http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", catalog)
+	mux.HandleFunc("POST /api/parse", func(w http.ResponseWriter, r *http.Request) {
+		var request rpcParseParams
+		if err := decodeAPIRequest(w, r, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		results, err := online.AddContext(r.Context(), request.Lines)
+		if err != nil {
+			http.Error(w, "failed to parse lines: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		templates := make([]jsonTemplate, len(results))
+		for i, result := range results {
+			templates[i] = newJSONTemplate(result, request.Lines, len(request.Lines), true)
+		}
+		writeAPIResponse(w, templates)
+	})
+	mux.HandleFunc("GET /api/templates/{id}", func(w http.ResponseWriter, r *http.Request) {
+		for _, result := range online.Snapshot() {
+			if result.ID != r.PathValue("id") {
+				continue
+			}
+			stats := apiTemplateStats{jsonTemplate: newJSONTemplate(result, nil, online.Lines(), false)}
+			if seen, ok := online.LastSeen(result.Template); ok {
+				stats.LastSeen = &seen
+			}
+			writeAPIResponse(w, stats)
+			return
+		}
+		http.Error(w, "template not found", http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /api/match", func(w http.ResponseWriter, r *http.Request) {
+		var request rpcMatchParams
+		if err := decodeAPIRequest(w, r, &request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, ok := online.Match(request.Line)
+		if !ok {
+			writeAPIResponse(w, rpcMatchResult{})
+			return
+		}
+		entry := newJSONTemplate(result, nil, 0, false)
+		writeAPIResponse(w, rpcMatchResult{Matched: true, Template: &entry, Anomalies: result.Anomalies, Similarity: result.Similarity})
+	})
+	return mux
+}
+
+// decodeAPIRequest decodes a JSON request body into dst, rejecting unknown
+// fields. A text/plain body of POST /api/parse is read as one line per line.
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, dst any) error {
+	body := http.MaxBytesReader(w, r.Body, maxAPIRequestSize)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if request, ok := dst.(*rpcParseParams); ok && mediaType == "text/plain" {
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 64*1024), maxAPIRequestSize)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				request.Lines = append(request.Lines, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+		return nil
+	}
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	return nil
+}
+
+// writeAPIResponse writes value as a JSON response
+func writeAPIResponse(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+	if err := encoder.Encode(value); err != nil {
+		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	}
+}
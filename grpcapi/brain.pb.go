@@ -0,0 +1,434 @@
+// Protocol buffer definition of the Brain log parser service.
+//
+// The service wraps one parser.OnlineParser per server: ParseBatch learns
+// templates from a batch of lines, MatchLine classifies a line against the
+// templates learned so far and StreamTemplates streams every template once it
+// becomes stable (see OnlineParser.OnStable). Server in server.go implements
+// it.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.29.3
+// source: brain.proto
+
+package grpcapi
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Template struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Template      string                 `protobuf:"bytes,2,opt,name=template,proto3" json:"template,omitempty"`
+	Count         int64                  `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Severity      string                 `protobuf:"bytes,4,opt,name=severity,proto3" json:"severity,omitempty"`
+	LogIds        []int64                `protobuf:"varint,5,rep,packed,name=log_ids,json=logIds,proto3" json:"log_ids,omitempty"` // Indices into ParseBatchRequest.lines
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Template) Reset() {
+	*x = Template{}
+	mi := &file_brain_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Template) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Template) ProtoMessage() {}
+
+func (x *Template) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Template.ProtoReflect.Descriptor instead.
+func (*Template) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Template) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Template) GetTemplate() string {
+	if x != nil {
+		return x.Template
+	}
+	return ""
+}
+
+func (x *Template) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Template) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *Template) GetLogIds() []int64 {
+	if x != nil {
+		return x.LogIds
+	}
+	return nil
+}
+
+type ParseBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lines         []string               `protobuf:"bytes,1,rep,name=lines,proto3" json:"lines,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseBatchRequest) Reset() {
+	*x = ParseBatchRequest{}
+	mi := &file_brain_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseBatchRequest) ProtoMessage() {}
+
+func (x *ParseBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseBatchRequest.ProtoReflect.Descriptor instead.
+func (*ParseBatchRequest) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ParseBatchRequest) GetLines() []string {
+	if x != nil {
+		return x.Lines
+	}
+	return nil
+}
+
+type ParseBatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Templates     []*Template            `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ParseBatchResponse) Reset() {
+	*x = ParseBatchResponse{}
+	mi := &file_brain_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ParseBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ParseBatchResponse) ProtoMessage() {}
+
+func (x *ParseBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ParseBatchResponse.ProtoReflect.Descriptor instead.
+func (*ParseBatchResponse) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ParseBatchResponse) GetTemplates() []*Template {
+	if x != nil {
+		return x.Templates
+	}
+	return nil
+}
+
+type MatchLineRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Line          string                 `protobuf:"bytes,1,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchLineRequest) Reset() {
+	*x = MatchLineRequest{}
+	mi := &file_brain_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchLineRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchLineRequest) ProtoMessage() {}
+
+func (x *MatchLineRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchLineRequest.ProtoReflect.Descriptor instead.
+func (*MatchLineRequest) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MatchLineRequest) GetLine() string {
+	if x != nil {
+		return x.Line
+	}
+	return ""
+}
+
+type MatchLineResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matched       bool                   `protobuf:"varint,1,opt,name=matched,proto3" json:"matched,omitempty"`
+	Template      *Template              `protobuf:"bytes,2,opt,name=template,proto3" json:"template,omitempty"`
+	Similarity    float64                `protobuf:"fixed64,3,opt,name=similarity,proto3" json:"similarity,omitempty"` // Below 1 for approximate matches
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchLineResponse) Reset() {
+	*x = MatchLineResponse{}
+	mi := &file_brain_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchLineResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchLineResponse) ProtoMessage() {}
+
+func (x *MatchLineResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchLineResponse.ProtoReflect.Descriptor instead.
+func (*MatchLineResponse) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MatchLineResponse) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+func (x *MatchLineResponse) GetTemplate() *Template {
+	if x != nil {
+		return x.Template
+	}
+	return nil
+}
+
+func (x *MatchLineResponse) GetSimilarity() float64 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+type StreamTemplatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinCount      int64                  `protobuf:"varint,1,opt,name=min_count,json=minCount,proto3" json:"min_count,omitempty"` // Skip templates with fewer lines
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTemplatesRequest) Reset() {
+	*x = StreamTemplatesRequest{}
+	mi := &file_brain_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTemplatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTemplatesRequest) ProtoMessage() {}
+
+func (x *StreamTemplatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_brain_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTemplatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamTemplatesRequest) Descriptor() ([]byte, []int) {
+	return file_brain_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamTemplatesRequest) GetMinCount() int64 {
+	if x != nil {
+		return x.MinCount
+	}
+	return 0
+}
+
+var File_brain_proto protoreflect.FileDescriptor
+
+const file_brain_proto_rawDesc = "" +
+	"\n" +
+	"\vbrain.proto\x12\bbrain.v1\"\x81\x01\n" +
+	"\bTemplate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\btemplate\x18\x02 \x01(\tR\btemplate\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x12\x1a\n" +
+	"\bseverity\x18\x04 \x01(\tR\bseverity\x12\x17\n" +
+	"\alog_ids\x18\x05 \x03(\x03R\x06logIds\")\n" +
+	"\x11ParseBatchRequest\x12\x14\n" +
+	"\x05lines\x18\x01 \x03(\tR\x05lines\"F\n" +
+	"\x12ParseBatchResponse\x120\n" +
+	"\ttemplates\x18\x01 \x03(\v2\x12.brain.v1.TemplateR\ttemplates\"&\n" +
+	"\x10MatchLineRequest\x12\x12\n" +
+	"\x04line\x18\x01 \x01(\tR\x04line\"}\n" +
+	"\x11MatchLineResponse\x12\x18\n" +
+	"\amatched\x18\x01 \x01(\bR\amatched\x12.\n" +
+	"\btemplate\x18\x02 \x01(\v2\x12.brain.v1.TemplateR\btemplate\x12\x1e\n" +
+	"\n" +
+	"similarity\x18\x03 \x01(\x01R\n" +
+	"similarity\"5\n" +
+	"\x16StreamTemplatesRequest\x12\x1b\n" +
+	"\tmin_count\x18\x01 \x01(\x03R\bminCount2\xe1\x01\n" +
+	"\x05Brain\x12G\n" +
+	"\n" +
+	"ParseBatch\x12\x1b.brain.v1.ParseBatchRequest\x1a\x1c.brain.v1.ParseBatchResponse\x12D\n" +
+	"\tMatchLine\x12\x1a.brain.v1.MatchLineRequest\x1a\x1b.brain.v1.MatchLineResponse\x12I\n" +
+	"\x0fStreamTemplates\x12 .brain.v1.StreamTemplatesRequest\x1a\x12.brain.v1.Template0\x01B%Z#github.com/n0madic/go-brain/grpcapib\x06proto3"
+
+var (
+	file_brain_proto_rawDescOnce sync.Once
+	file_brain_proto_rawDescData []byte
+)
+
+func file_brain_proto_rawDescGZIP() []byte {
+	file_brain_proto_rawDescOnce.Do(func() {
+		file_brain_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_brain_proto_rawDesc), len(file_brain_proto_rawDesc)))
+	})
+	return file_brain_proto_rawDescData
+}
+
+var file_brain_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_brain_proto_goTypes = []any{
+	(*Template)(nil),               // 0: brain.v1.Template
+	(*ParseBatchRequest)(nil),      // 1: brain.v1.ParseBatchRequest
+	(*ParseBatchResponse)(nil),     // 2: brain.v1.ParseBatchResponse
+	(*MatchLineRequest)(nil),       // 3: brain.v1.MatchLineRequest
+	(*MatchLineResponse)(nil),      // 4: brain.v1.MatchLineResponse
+	(*StreamTemplatesRequest)(nil), // 5: brain.v1.StreamTemplatesRequest
+}
+var file_brain_proto_depIdxs = []int32{
+	0, // 0: brain.v1.ParseBatchResponse.templates:type_name -> brain.v1.Template
+	0, // 1: brain.v1.MatchLineResponse.template:type_name -> brain.v1.Template
+	1, // 2: brain.v1.Brain.ParseBatch:input_type -> brain.v1.ParseBatchRequest
+	3, // 3: brain.v1.Brain.MatchLine:input_type -> brain.v1.MatchLineRequest
+	5, // 4: brain.v1.Brain.StreamTemplates:input_type -> brain.v1.StreamTemplatesRequest
+	2, // 5: brain.v1.Brain.ParseBatch:output_type -> brain.v1.ParseBatchResponse
+	4, // 6: brain.v1.Brain.MatchLine:output_type -> brain.v1.MatchLineResponse
+	0, // 7: brain.v1.Brain.StreamTemplates:output_type -> brain.v1.Template
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_brain_proto_init() }
+func file_brain_proto_init() {
+	if File_brain_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_brain_proto_rawDesc), len(file_brain_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_brain_proto_goTypes,
+		DependencyIndexes: file_brain_proto_depIdxs,
+		MessageInfos:      file_brain_proto_msgTypes,
+	}.Build()
+	File_brain_proto = out.File
+	file_brain_proto_goTypes = nil
+	file_brain_proto_depIdxs = nil
+}
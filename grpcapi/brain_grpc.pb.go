@@ -0,0 +1,219 @@
+// Protocol buffer definition of the Brain log parser service.
+//
+// The service wraps one parser.OnlineParser per server: ParseBatch learns
+// templates from a batch of lines, MatchLine classifies a line against the
+// templates learned so far and StreamTemplates streams every template once it
+// becomes stable (see OnlineParser.OnStable). Server in server.go implements
+// it.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: brain.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Brain_ParseBatch_FullMethodName      = "/brain.v1.Brain/ParseBatch"
+	Brain_MatchLine_FullMethodName       = "/brain.v1.Brain/MatchLine"
+	Brain_StreamTemplates_FullMethodName = "/brain.v1.Brain/StreamTemplates"
+)
+
+// BrainClient is the client API for Brain service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BrainClient interface {
+	// ParseBatch learns templates from lines and returns the templates the
+	// lines were assigned to.
+	ParseBatch(ctx context.Context, in *ParseBatchRequest, opts ...grpc.CallOption) (*ParseBatchResponse, error)
+	// MatchLine classifies a line without learning.
+	MatchLine(ctx context.Context, in *MatchLineRequest, opts ...grpc.CallOption) (*MatchLineResponse, error)
+	// StreamTemplates sends the known templates, then every template that
+	// becomes stable until the client cancels.
+	StreamTemplates(ctx context.Context, in *StreamTemplatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Template], error)
+}
+
+type brainClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBrainClient(cc grpc.ClientConnInterface) BrainClient {
+	return &brainClient{cc}
+}
+
+func (c *brainClient) ParseBatch(ctx context.Context, in *ParseBatchRequest, opts ...grpc.CallOption) (*ParseBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ParseBatchResponse)
+	err := c.cc.Invoke(ctx, Brain_ParseBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brainClient) MatchLine(ctx context.Context, in *MatchLineRequest, opts ...grpc.CallOption) (*MatchLineResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MatchLineResponse)
+	err := c.cc.Invoke(ctx, Brain_MatchLine_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *brainClient) StreamTemplates(ctx context.Context, in *StreamTemplatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Template], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Brain_ServiceDesc.Streams[0], Brain_StreamTemplates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTemplatesRequest, Template]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Brain_StreamTemplatesClient = grpc.ServerStreamingClient[Template]
+
+// BrainServer is the server API for Brain service.
+// All implementations must embed UnimplementedBrainServer
+// for forward compatibility.
+type BrainServer interface {
+	// ParseBatch learns templates from lines and returns the templates the
+	// lines were assigned to.
+	ParseBatch(context.Context, *ParseBatchRequest) (*ParseBatchResponse, error)
+	// MatchLine classifies a line without learning.
+	MatchLine(context.Context, *MatchLineRequest) (*MatchLineResponse, error)
+	// StreamTemplates sends the known templates, then every template that
+	// becomes stable until the client cancels.
+	StreamTemplates(*StreamTemplatesRequest, grpc.ServerStreamingServer[Template]) error
+	mustEmbedUnimplementedBrainServer()
+}
+
+// UnimplementedBrainServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBrainServer struct{}
+
+func (UnimplementedBrainServer) ParseBatch(context.Context, *ParseBatchRequest) (*ParseBatchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ParseBatch not implemented")
+}
+func (UnimplementedBrainServer) MatchLine(context.Context, *MatchLineRequest) (*MatchLineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MatchLine not implemented")
+}
+func (UnimplementedBrainServer) StreamTemplates(*StreamTemplatesRequest, grpc.ServerStreamingServer[Template]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTemplates not implemented")
+}
+func (UnimplementedBrainServer) mustEmbedUnimplementedBrainServer() {}
+func (UnimplementedBrainServer) testEmbeddedByValue()               {}
+
+// UnsafeBrainServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BrainServer will
+// result in compilation errors.
+type UnsafeBrainServer interface {
+	mustEmbedUnimplementedBrainServer()
+}
+
+func RegisterBrainServer(s grpc.ServiceRegistrar, srv BrainServer) {
+	// If the following call pancis, it indicates UnimplementedBrainServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Brain_ServiceDesc, srv)
+}
+
+func _Brain_ParseBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ParseBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrainServer).ParseBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Brain_ParseBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrainServer).ParseBatch(ctx, req.(*ParseBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Brain_MatchLine_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchLineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BrainServer).MatchLine(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Brain_MatchLine_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BrainServer).MatchLine(ctx, req.(*MatchLineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Brain_StreamTemplates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTemplatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BrainServer).StreamTemplates(m, &grpc.GenericServerStream[StreamTemplatesRequest, Template]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Brain_StreamTemplatesServer = grpc.ServerStreamingServer[Template]
+
+// Brain_ServiceDesc is the grpc.ServiceDesc for Brain service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Brain_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "brain.v1.Brain",
+	HandlerType: (*BrainServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ParseBatch",
+			Handler:    _Brain_ParseBatch_Handler,
+		},
+		{
+			MethodName: "MatchLine",
+			Handler:    _Brain_MatchLine_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTemplates",
+			Handler:       _Brain_StreamTemplates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "brain.proto",
+}
@@ -0,0 +1,88 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/n0madic/go-brain/parser"
+	"google.golang.org/grpc"
+)
+
+// Client calls a Brain service with the result types of the parser package,
+// so code written against a local parser can use a remote one.
+type Client struct {
+	client BrainClient
+}
+
+// NewClient creates a client of the Brain service reachable through cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{client: NewBrainClient(cc)}
+}
+
+// Parse learns templates from lines on the server and returns the templates
+// the lines were assigned to, like OnlineParser.Add. LogIDs index into lines.
+func (c *Client) Parse(ctx context.Context, lines []string) ([]*parser.ParseResult, error) {
+	resp, err := c.client.ParseBatch(ctx, &ParseBatchRequest{Lines: lines})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*parser.ParseResult, len(resp.GetTemplates()))
+	for i, template := range resp.GetTemplates() {
+		results[i] = fromTemplate(template)
+	}
+	return results, nil
+}
+
+// Match classifies a line by the templates learned on the server without
+// learning from it, like OnlineParser.Match.
+func (c *Client) Match(ctx context.Context, line string) (*parser.ParseResult, bool, error) {
+	resp, err := c.client.MatchLine(ctx, &MatchLineRequest{Line: line})
+	if err != nil || !resp.GetMatched() {
+		return nil, false, err
+	}
+	result := fromTemplate(resp.GetTemplate())
+	result.Similarity = resp.GetSimilarity()
+	return result, true, nil
+}
+
+// StreamTemplates calls handle with every known template with at least
+// minCount lines, then with every template that becomes stable, until ctx is
+// canceled or the stream fails. It returns nil if the server ends the stream.
+func (c *Client) StreamTemplates(ctx context.Context, minCount int, handle func(result *parser.ParseResult)) error {
+	stream, err := c.client.StreamTemplates(ctx, &StreamTemplatesRequest{MinCount: int64(minCount)})
+	if err != nil {
+		return err
+	}
+	for {
+		template, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		handle(fromTemplate(template))
+	}
+}
+
+// fromTemplate converts a protocol buffer template into a parse result
+func fromTemplate(template *Template) *parser.ParseResult {
+	severity, err := parser.ParseSeverity(template.GetSeverity())
+	if err != nil {
+		severity = parser.SeverityUnknown
+	}
+	result := &parser.ParseResult{
+		ID:       template.GetId(),
+		Template: template.GetTemplate(),
+		Count:    int(template.GetCount()),
+		Severity: severity,
+	}
+	if ids := template.GetLogIds(); len(ids) > 0 {
+		result.LogIDs = make([]int, len(ids))
+		for i, id := range ids {
+			result.LogIDs[i] = int(id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,13 @@
+// Package grpcapi serves the Brain parser as a gRPC service (brain.proto):
+// ParseBatch, MatchLine and StreamTemplates.
+//
+// Server implements the service on top of one parser.OnlineParser and Client
+// wraps the generated client with the result types of the parser package.
+// The package is a separate module, so the parser module keeps depending on
+// the standard library only. Regenerate brain.pb.go and brain_grpc.pb.go after
+// changing brain.proto with protoc, protoc-gen-go and protoc-gen-go-grpc:
+//
+//	go generate ./...
+package grpcapi
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative brain.proto
@@ -0,0 +1,136 @@
+package grpcapi
+
+import (
+	"context"
+	"sync"
+
+	"github.com/n0madic/go-brain/parser"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// subscriberBuffer is the number of stable templates buffered per
+// StreamTemplates call before a slow client is disconnected
+const subscriberBuffer = 1024
+
+// Server implements BrainServer on top of one parser.OnlineParser.
+type Server struct {
+	UnimplementedBrainServer
+
+	parser *parser.OnlineParser
+
+	mu          sync.Mutex
+	subscribers map[chan *parser.ParseResult]struct{}
+}
+
+// NewServer creates a server learning with op. Templates are streamed once
+// they were part of minBatches batches; NewServer installs the OnStable
+// handler of op, which must not be replaced afterwards.
+func NewServer(op *parser.OnlineParser, minBatches int) *Server {
+	s := &Server{parser: op, subscribers: make(map[chan *parser.ParseResult]struct{})}
+	op.OnStable(minBatches, s.publish)
+	return s
+}
+
+// ParseBatch learns templates from the lines of req. A canceled call does not
+// change the learned templates.
+func (s *Server) ParseBatch(ctx context.Context, req *ParseBatchRequest) (*ParseBatchResponse, error) {
+	results, err := s.parser.AddContext(ctx, req.GetLines())
+	defer parser.Results(results).Release()
+	if err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	resp := &ParseBatchResponse{Templates: make([]*Template, len(results))}
+	for i, result := range results {
+		resp.Templates[i] = toTemplate(result)
+	}
+	return resp, nil
+}
+
+// MatchLine classifies the line of req without learning from it.
+func (s *Server) MatchLine(_ context.Context, req *MatchLineRequest) (*MatchLineResponse, error) {
+	result, ok := s.parser.Match(req.GetLine())
+	if !ok {
+		return &MatchLineResponse{}, nil
+	}
+	return &MatchLineResponse{Matched: true, Template: toTemplate(result), Similarity: result.Similarity}, nil
+}
+
+// StreamTemplates sends the known templates, then every template that becomes
+// stable until the client cancels. A template stabilizing while the known
+// ones are sent may be sent twice.
+func (s *Server) StreamTemplates(req *StreamTemplatesRequest, stream Brain_StreamTemplatesServer) error {
+	updates := make(chan *parser.ParseResult, subscriberBuffer)
+	s.mu.Lock()
+	s.subscribers[updates] = struct{}{}
+	s.mu.Unlock()
+	defer s.unsubscribe(updates)
+
+	send := func(result *parser.ParseResult) error {
+		if int64(result.Count) < req.GetMinCount() {
+			return nil
+		}
+		return stream.Send(toTemplate(result))
+	}
+	for _, result := range s.parser.Snapshot() {
+		if err := send(result); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case result, ok := <-updates:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "client too slow to receive templates")
+			}
+			if err := send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// publish hands a stable template to every subscriber, dropping subscribers
+// whose buffer is full so learning never waits for a client
+func (s *Server) publish(result *parser.ParseResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for updates := range s.subscribers {
+		select {
+		case updates <- result:
+		default:
+			delete(s.subscribers, updates)
+			close(updates)
+		}
+	}
+}
+
+// unsubscribe removes a subscriber unless publish already dropped it
+func (s *Server) unsubscribe(updates chan *parser.ParseResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subscribers[updates]; ok {
+		delete(s.subscribers, updates)
+		close(updates)
+	}
+}
+
+// toTemplate converts a parse result into its protocol buffer message
+func toTemplate(result *parser.ParseResult) *Template {
+	template := &Template{
+		Id:       result.ID,
+		Template: result.Template,
+		Count:    int64(result.Count),
+		Severity: result.Severity.String(),
+	}
+	if len(result.LogIDs) > 0 {
+		template.LogIds = make([]int64, len(result.LogIDs))
+		for i, id := range result.LogIDs {
+			template.LogIds[i] = int64(id)
+		}
+	}
+	return template
+}
@@ -0,0 +1,106 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/n0madic/go-brain/parser"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer serves a Brain service over an in-memory connection
+func startServer(t *testing.T, minBatches int) *Client {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	RegisterBrainServer(server, NewServer(parser.NewOnlineParser(parser.Config{Delimiters: `\s+`}), minBatches))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient error: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return NewClient(conn)
+}
+
+func TestParseAndMatch(t *testing.T) {
+	client := startServer(t, 1)
+	ctx := context.Background()
+
+	results, err := client.Parse(ctx, []string{
+		"User alice logged in",
+		"User bob logged in",
+		"User carol logged in",
+		"ERROR disk full",
+	})
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(results) != 2 || results[0].Template != "User <*> logged in" || results[0].Count != 3 {
+		t.Fatalf("Unexpected results: %+v", results)
+	}
+	if results[0].ID == "" || len(results[0].LogIDs) != 3 || results[1].Severity != parser.SeverityError {
+		t.Errorf("Result fields were not transferred: %+v %+v", results[0], results[1])
+	}
+
+	result, ok, err := client.Match(ctx, "User dave logged in")
+	if err != nil || !ok {
+		t.Fatalf("Expected a match, got %v, %v", ok, err)
+	}
+	if result.Template != "User <*> logged in" || result.Count != 3 || result.Similarity != 1 {
+		t.Errorf("Unexpected match: %+v", result)
+	}
+	if _, ok, err := client.Match(ctx, "User dave logged in twice"); ok || err != nil {
+		t.Errorf("Expected no match, got %v, %v", ok, err)
+	}
+}
+
+func TestStreamTemplates(t *testing.T) {
+	client := startServer(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := client.Parse(ctx, []string{"Disk full", "Disk full"}); err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	received := make(chan *parser.ParseResult, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.StreamTemplates(ctx, 2, func(result *parser.ParseResult) { received <- result })
+	}()
+
+	// Known templates are sent first
+	if result := <-received; result.Template != "Disk full" || result.Count != 2 {
+		t.Fatalf("Unexpected known template: %+v", result)
+	}
+
+	// A template is streamed once it was part of two batches. The stream may
+	// not be subscribed yet, so batches are added until it arrives.
+	for {
+		if _, err := client.Parse(ctx, []string{"Cache cleared", "Cache cleared"}); err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		select {
+		case result := <-received:
+			if result.Template == "Cache cleared" && result.Count >= 4 {
+				cancel()
+				if err := <-done; err == nil {
+					t.Error("Expected an error after cancellation")
+				}
+				return
+			}
+		case <-time.After(100 * time.Millisecond):
+		case err := <-done:
+			t.Fatalf("Stream ended: %v", err)
+		}
+	}
+}
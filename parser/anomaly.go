@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// Defaults of the slot anomaly detection
+const (
+	defaultAnomalyThreshold = 4.0  // Z-score above which a value is flagged
+	minAnomalySamples       = 10   // Numeric values a slot needs before values are judged
+	minAnomalySpread        = 0.25 // Floor of the slot standard deviation on the log scale
+)
+
+// numericPrefix matches the number a slot value starts with, e.g. 1024 of
+// "1024ms" or -3.5 of "-3.5%"
+var numericPrefix = regexp.MustCompile(`^[-+]?\d+(\.\d+)?`)
+
+// SlotAnomaly describes a variable value of a matched line that is an
+// outlier for its template slot.
+type SlotAnomaly struct {
+	Slot    int     `json:"slot"`    // Index of the <*> slot in the template
+	Value   string  `json:"value"`   // Value of the line
+	Score   float64 `json:"score"`   // Z-score of the value on the log scale; the sign tells above or below
+	Typical float64 `json:"typical"` // Typical (geometric mean) value of the slot
+}
+
+// slotStats accumulates the numeric values of one template slot with
+// Welford's algorithm. Values are compared on a signed log scale so that
+// "1000x the norm" stands out regardless of the magnitude of the column.
+type slotStats struct {
+	n    int
+	mean float64
+	m2   float64
+}
+
+// add records one numeric value
+func (s *slotStats) add(x float64) {
+	s.n++
+	delta := x - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (x - s.mean)
+}
+
+// score returns the z-score of x, or false if the slot has too few values
+func (s *slotStats) score(x float64) (float64, bool) {
+	if s.n < minAnomalySamples {
+		return 0, false
+	}
+	stddev := max(math.Sqrt(s.m2/float64(s.n-1)), minAnomalySpread)
+	return (x - s.mean) / stddev, true
+}
+
+// slotNumber returns the signed log of the number a value starts with
+func slotNumber(value string) (float64, bool) {
+	prefix := numericPrefix.FindString(value)
+	if prefix == "" {
+		return 0, false
+	}
+	x, err := strconv.ParseFloat(prefix, 64)
+	if err != nil {
+		return 0, false
+	}
+	return math.Copysign(math.Log1p(math.Abs(x)), x), true
+}
+
+// recordSlotStats adds the numeric slot values of the member lines of every
+// result to the state
+func (p *BrainParser) recordSlotStats(results []*ParseResult, logLines []string) {
+	if p.state == nil {
+		return
+	}
+	for _, result := range results {
+		extractor := newParamExtractor(p.preprocessor, result.Template)
+		if extractor.slots == 0 {
+			continue
+		}
+		values := make([][]float64, extractor.slots)
+		for _, id := range result.LogIDs {
+			if id < 0 || id >= len(logLines) {
+				continue
+			}
+			params, ok := extractor.extract(logLines[id])
+			if !ok || len(params) != extractor.slots {
+				continue
+			}
+			for slot, value := range params {
+				if x, ok := slotNumber(value); ok {
+					values[slot] = append(values[slot], x)
+				}
+			}
+		}
+		p.state.addSlotValues(result.Template, values)
+	}
+}
+
+// slotAnomalies returns the slot values of line that are outliers for
+// template, or nil if there are none
+func (p *BrainParser) slotAnomalies(template, line string) []SlotAnomaly {
+	stats := p.state.slotStats(template)
+	if stats == nil {
+		return nil
+	}
+	params, ok := newParamExtractor(p.preprocessor, template).extract(line)
+	if !ok || len(params) != len(stats) {
+		return nil
+	}
+	threshold := p.config.AnomalyThreshold
+	if threshold <= 0 {
+		threshold = defaultAnomalyThreshold
+	}
+
+	var anomalies []SlotAnomaly
+	for slot, value := range params {
+		x, ok := slotNumber(value)
+		if !ok {
+			continue
+		}
+		if score, ok := stats[slot].score(x); ok && math.Abs(score) > threshold {
+			typical := math.Copysign(math.Expm1(math.Abs(stats[slot].mean)), stats[slot].mean)
+			anomalies = append(anomalies, SlotAnomaly{Slot: slot, Value: value, Score: score, Typical: typical})
+		}
+	}
+	return anomalies
+}
+
+// addSlotValues adds numeric values per slot to the statistics of template
+func (s *templateState) addSlotValues(template string, values [][]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stats == nil {
+		s.stats = make(map[string][]slotStats)
+	}
+	stats := s.stats[template]
+	if len(stats) != len(values) {
+		stats = make([]slotStats, len(values))
+	}
+	for slot, slotValues := range values {
+		for _, x := range slotValues {
+			stats[slot].add(x)
+		}
+	}
+	s.stats[template] = stats
+}
+
+// slotStats returns a copy of the slot statistics of template
+func (s *templateState) slotStats(template string) []slotStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slotStats(nil), s.stats[template]...)
+}
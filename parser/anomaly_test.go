@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatchSlotAnomalies(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 50; i++ {
+		logLines = append(logLines, fmt.Sprintf("Sent %d bytes to host%d", 1000+i*10, i%5))
+	}
+
+	p := New(Config{Delimiters: `\s+`, VariableStatistics: true})
+	if results := p.Parse(logLines); len(results) != 1 || results[0].Template != "Sent <*> bytes to <*>" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	result, ok := p.Match("Sent 1200 bytes to host3")
+	if !ok {
+		t.Fatal("Expected line to match")
+	}
+	if len(result.Anomalies) != 0 {
+		t.Errorf("Expected no anomalies for a typical value, got %+v", result.Anomalies)
+	}
+
+	result, ok = p.Match("Sent 1500000 bytes to host3")
+	if !ok {
+		t.Fatal("Expected line to match")
+	}
+	if len(result.Anomalies) != 1 {
+		t.Fatalf("Expected 1 anomaly, got %+v", result.Anomalies)
+	}
+	anomaly := result.Anomalies[0]
+	if anomaly.Slot != 0 || anomaly.Value != "1500000" || anomaly.Score <= defaultAnomalyThreshold {
+		t.Errorf("Unexpected anomaly %+v", anomaly)
+	}
+	if anomaly.Typical < 1000 || anomaly.Typical > 1500 {
+		t.Errorf("Expected typical value between 1000 and 1500, got %f", anomaly.Typical)
+	}
+
+	// Statistics are opt-in
+	plain := New(Config{Delimiters: `\s+`})
+	plain.Parse(logLines)
+	if result, ok := plain.Match("Sent 1500000 bytes to host3"); !ok || result.Anomalies != nil {
+		t.Errorf("Expected no anomalies without VariableStatistics, got %+v", result)
+	}
+}
+
+func TestSlotNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{"1024", true},
+		{"1024ms", true},
+		{"-3.5%", true},
+		{"host3", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if _, ok := slotNumber(tt.value); ok != tt.ok {
+			t.Errorf("slotNumber(%q) ok = %v, want %v", tt.value, ok, tt.ok)
+		}
+	}
+}
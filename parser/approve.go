@@ -0,0 +1,154 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Violation actions recorded in TemplateViolation.Action
+const (
+	ViolationExpire     = "expire"     // OnlineParser.Expire kept an idle approved template
+	ViolationGeneralize = "generalize" // A newly learned template also matches an approved template
+)
+
+// TemplateViolation reports an automatic change an approved template was
+// protected from.
+type TemplateViolation struct {
+	Action   string // See Violation* constants
+	Template string // Approved template
+	By       string // Learned template that generalizes it (ViolationGeneralize)
+}
+
+// ReadTemplateCatalog reads a curated template catalog with one template per
+// line, e.g. for Approve. Empty lines and lines starting with # are ignored.
+func ReadTemplateCatalog(r io.Reader) ([]string, error) {
+	var templates []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		templates = append(templates, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading template catalog: %w", err)
+	}
+	return templates, nil
+}
+
+// Approve marks templates as approved, e.g. the templates of a curated
+// catalog backing alerting rules. Unknown templates are added to the learned
+// state with a count of 0 and later Parse calls resume from the known
+// templates like after LoadState: lines matching an approved template are
+// assigned to it before the Brain algorithm runs, so it is never merged or
+// renamed, and OnlineParser.Expire never removes it. Automatic changes an
+// approved template was protected from are reported by Violations. Approved
+// templates are kept by SaveState.
+func (p *BrainParser) Approve(templates ...string) error {
+	if len(templates) == 0 {
+		return nil
+	}
+	if p.state == nil {
+		return fmt.Errorf("parser has no template state")
+	}
+	if _, err := NewTemplateMatcher(templates); err != nil {
+		return err
+	}
+	p.state.approve(templates)
+	return nil
+}
+
+// Approved returns the approved templates in the order they were approved.
+func (p *BrainParser) Approved() []string {
+	if p.state == nil {
+		return nil
+	}
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([]string(nil), p.state.approvedOrder...)
+}
+
+// Violations returns every distinct automatic change approved templates were
+// protected from since they were approved, in the order they were detected.
+func (p *BrainParser) Violations() []TemplateViolation {
+	if p.state == nil {
+		return nil
+	}
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([]TemplateViolation(nil), p.state.violations...)
+}
+
+// auditEvent returns the violation as an audit log event
+func (v TemplateViolation) auditEvent() AuditEvent {
+	event := AuditEvent{Event: AuditProtected, Template: v.Template, Reason: v.Action}
+	if v.By != "" {
+		event.Sources = []string{v.By}
+	}
+	return event
+}
+
+// approve adds templates to the approved set and the known templates
+func (s *templateState) approve(templates []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.approved == nil {
+		s.approved = make(map[string]bool)
+	}
+	for _, template := range templates {
+		if s.approved[template] {
+			continue
+		}
+		s.approved[template] = true
+		s.approvedOrder = append(s.approvedOrder, template)
+		if _, ok := s.counts[template]; !ok {
+			s.order = append(s.order, template)
+			s.counts[template] = 0
+			s.matcher = nil
+		}
+	}
+	s.resume = true
+}
+
+// violate records a violation unless it was recorded before and reports
+// whether it is new. The caller must hold s.mu.
+func (s *templateState) violate(violation TemplateViolation) bool {
+	for _, recorded := range s.violations {
+		if recorded == violation {
+			return false
+		}
+	}
+	s.violations = append(s.violations, violation)
+	return true
+}
+
+// generalizations records and returns the new violations of results not yet
+// known that match the text of an approved template
+func (s *templateState) generalizations(results []*ParseResult) []TemplateViolation {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.approved) == 0 {
+		return nil
+	}
+	var violations []TemplateViolation
+	for _, result := range results {
+		if _, known := s.counts[result.Template]; known {
+			continue
+		}
+		tokens := strings.Fields(result.Template)
+		for _, approved := range s.approvedOrder {
+			violation := TemplateViolation{Action: ViolationGeneralize, Template: approved, By: result.Template}
+			if tokensMatch(tokens, strings.Fields(approved), s.slots) && s.violate(violation) {
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadTemplateCatalog(t *testing.T) {
+	catalog := "# Alerting templates\nUser <*> logged in\n\n  Disk <*> full  \n"
+	templates, err := ReadTemplateCatalog(strings.NewReader(catalog))
+	if err != nil {
+		t.Fatalf("ReadTemplateCatalog error: %v", err)
+	}
+	if !reflect.DeepEqual(templates, []string{"User <*> logged in", "Disk <*> full"}) {
+		t.Errorf("Unexpected templates %q", templates)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`, ChildBranchThreshold: 3})
+	if err := p.Approve("User <*> logged in", "Disk sda full", "User <*> logged in"); err != nil {
+		t.Fatalf("Approve error: %v", err)
+	}
+	if approved := p.Approved(); !reflect.DeepEqual(approved, []string{"User <*> logged in", "Disk sda full"}) {
+		t.Errorf("Approved() = %q", approved)
+	}
+
+	report := p.ParseWithReport([]string{
+		"User alice logged in", "User bob logged in",
+		"Disk sda full", "Disk sdb full", "Disk sdc full", "Disk sdd full",
+	})
+	counts := make(map[string]int)
+	for _, result := range report.Results {
+		counts[result.Template] = result.Count
+	}
+	expectedCounts := map[string]int{"User <*> logged in": 2, "Disk sda full": 1, "Disk <*> full": 3}
+	if !reflect.DeepEqual(counts, expectedCounts) {
+		t.Errorf("Counts = %v, want %v", counts, expectedCounts)
+	}
+
+	expected := []TemplateViolation{{Action: ViolationGeneralize, Template: "Disk sda full", By: "Disk <*> full"}}
+	if !reflect.DeepEqual(report.Violations, expected) {
+		t.Errorf("Report violations = %+v, want %+v", report.Violations, expected)
+	}
+
+	// Known violations are not reported again
+	if report := p.ParseWithReport([]string{"Disk sda full", "Disk sde full"}); len(report.Violations) != 0 {
+		t.Errorf("Expected no new violations, got %+v", report.Violations)
+	}
+	if violations := p.Violations(); !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Violations() = %+v, want %+v", violations, expected)
+	}
+
+	var buf bytes.Buffer
+	if err := p.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	restored, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	if !reflect.DeepEqual(restored.Approved(), p.Approved()) {
+		t.Errorf("Restored approved templates %q, want %q", restored.Approved(), p.Approved())
+	}
+}
+
+func TestOnlineParserApprovedNotExpired(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	op := NewOnlineParser(Config{Delimiters: `\s+`})
+	op.now = func() time.Time { return now }
+	var buf bytes.Buffer
+	op.SetAuditLog(&buf)
+	if err := op.Approve("User <*> logged in"); err != nil {
+		t.Fatalf("Approve error: %v", err)
+	}
+
+	op.Add([]string{"User alice logged in", "Disk sda full", "Disk sdb full", "Disk sdc full"})
+	now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		expired, err := op.Expire(time.Minute)
+		if err != nil {
+			t.Fatalf("Expire error: %v", err)
+		}
+		if i == 0 && !reflect.DeepEqual(expired, []string{"Disk <*> full"}) {
+			t.Errorf("Expired %q, want only the unapproved template", expired)
+		}
+	}
+	if snapshot := op.Snapshot(); len(snapshot) != 1 || snapshot[0].Template != "User <*> logged in" {
+		t.Errorf("Unexpected snapshot after expiry: %+v", snapshot)
+	}
+
+	expected := []TemplateViolation{{Action: ViolationExpire, Template: "User <*> logged in"}}
+	if violations := op.Violations(); !reflect.DeepEqual(violations, expected) {
+		t.Errorf("Violations() = %+v, want %+v", violations, expected)
+	}
+
+	protected := 0
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event AuditEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", line, err)
+		}
+		if event.Event == AuditProtected {
+			protected++
+			if event.Template != "User <*> logged in" || event.Reason != ViolationExpire {
+				t.Errorf("Unexpected protected event %+v", event)
+			}
+		}
+	}
+	if protected != 1 {
+		t.Errorf("Expected 1 protected event, got %d", protected)
+	}
+}
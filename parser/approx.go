@@ -0,0 +1,88 @@
+package parser
+
+import "regexp"
+
+// approxDelimiters splits lines for TemplateMatcher.MatchClosest like the
+// default Config.Delimiters
+var approxDelimiters = regexp.MustCompile(`[\s,:=]+`)
+
+// MatchClosest behaves like Match, but if no template matches exactly it
+// returns the template closest to line by token-level edit distance, where a
+// <*> token stands for any one word. Similarity is 1 - distance / token count
+// of the longer side, 1 for exact matches. Lines are split at whitespace and
+// the default delimiters. It returns -1 if no template reaches minSimilarity.
+func (m *TemplateMatcher) MatchClosest(line string, minSimilarity float64) (int, float64) {
+	if i := m.Match(line); i >= 0 {
+		return i, 1
+	}
+	return m.closest(splitApprox(line), minSimilarity)
+}
+
+// closest returns the most similar template to words and its similarity, or
+// -1 if none reaches minSimilarity. Ties go to the more specific template.
+func (m *TemplateMatcher) closest(words []string, minSimilarity float64) (int, float64) {
+	best, bestSimilarity := -1, 0.0
+	for _, i := range m.order {
+		similarity := tokenSimilarity(m.tokens[i], words, m.slots)
+		if similarity >= minSimilarity && similarity > bestSimilarity {
+			best, bestSimilarity = i, similarity
+		}
+	}
+	return best, bestSimilarity
+}
+
+// splitApprox splits a line into words at whitespace and default delimiters
+func splitApprox(line string) []string {
+	var words []string
+	for _, word := range approxDelimiters.Split(line, -1) {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// tokenSimilarity returns 1 - the token edit distance between template tokens
+// and words relative to the longer sequence
+func tokenSimilarity(tokens, words []string, slots placeholderSlots) float64 {
+	longest := max(len(tokens), len(words))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(tokenEditDistance(tokens, words, slots))/float64(longest)
+}
+
+// tokenEditDistance is the Levenshtein distance over tokens; a <*> token
+// substitutes any word and a named placeholder of slots any word of its
+// variable at no cost
+func tokenEditDistance(tokens, words []string, slots placeholderSlots) int {
+	return editDistance(tokens, words, slots.matches)
+}
+
+// editDistance is the Levenshtein distance over tokens, where tokens that
+// are equal by equal substitute each other at no cost
+func editDistance(tokens, words []string, equal func(token, word string) bool) int {
+	previous := make([]int, len(words)+1)
+	current := make([]int, len(words)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i, token := range tokens {
+		current[0] = i + 1
+		for j, word := range words {
+			cost := 1
+			if equal(token, word) {
+				cost = 0
+			}
+			current[j+1] = min(previous[j]+cost, previous[j+1]+1, current[j]+1)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(words)]
+}
+
+// lineWords tokenizes line like the member lines of a template
+func (p *BrainParser) lineWords(line string) []string {
+	normalized, _ := p.preprocessor.normalizeLine(line)
+	return p.preprocessor.tokenize(normalized)
+}
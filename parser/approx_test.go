@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestTemplateMatcherMatchClosest(t *testing.T) {
+	matcher, err := NewTemplateMatcher([]string{
+		"User <*> logged in",
+		"Connection to <*> failed after <*> retries",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateMatcher error: %v", err)
+	}
+
+	tests := []struct {
+		line       string
+		expected   int
+		similarity float64
+	}{
+		{"User alice logged in", 0, 1},
+		{"User alice logged in twice", 0, 0.8},                     // One extra token
+		{"Connection to db failed after 3 attempts", 1, 6.0 / 7.0}, // One substituted token
+		{"Disk full on /dev/sda", -1, 0},
+	}
+	for _, tt := range tests {
+		got, similarity := matcher.MatchClosest(tt.line, 0.5)
+		if got != tt.expected || math.Abs(similarity-tt.similarity) > 1e-9 {
+			t.Errorf("MatchClosest(%q) = %d, %f, want %d, %f", tt.line, got, similarity, tt.expected, tt.similarity)
+		}
+	}
+
+	if got, _ := matcher.MatchClosest("User alice logged in twice", 0.9); got != -1 {
+		t.Errorf("Expected no match below the minimum similarity, got %d", got)
+	}
+}
+
+func TestTokenEditDistance(t *testing.T) {
+	tests := []struct {
+		tokens, words []string
+		expected      int
+	}{
+		{nil, nil, 0},
+		{[]string{"a", "<*>", "c"}, []string{"a", "b", "c"}, 0},
+		{[]string{"a", "b"}, []string{"a", "x", "b"}, 1},
+		{[]string{"a", "b", "c"}, []string{"c"}, 2},
+		{nil, []string{"a", "b"}, 2},
+	}
+	for _, tt := range tests {
+		if got := tokenEditDistance(tt.tokens, tt.words, nil); got != tt.expected {
+			t.Errorf("tokenEditDistance(%q, %q) = %d, want %d", tt.tokens, tt.words, got, tt.expected)
+		}
+	}
+}
+
+func TestMatchApproximate(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 10; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in from host%d", i, i%3))
+	}
+
+	p := New(Config{Delimiters: `\s+`, ApproximateMatch: 0.8})
+	if results := p.Parse(logLines); len(results) != 1 || results[0].Template != "User <*> logged in from <*>" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	result, ok := p.Match("User bob logged in from host1")
+	if !ok || result.Similarity != 1 {
+		t.Fatalf("Expected exact match with similarity 1, got %+v", result)
+	}
+
+	result, ok = p.Match("User bob signed in from host1")
+	if !ok || result.Template != "User <*> logged in from <*>" {
+		t.Fatalf("Expected approximate match, got %+v", result)
+	}
+	if math.Abs(result.Similarity-5.0/6.0) > 1e-9 {
+		t.Errorf("Expected similarity 5/6, got %f", result.Similarity)
+	}
+	if result.Count != 10 {
+		t.Errorf("Expected count 10, got %d", result.Count)
+	}
+
+	if _, ok := p.Match("Disk full"); ok {
+		t.Error("Expected unrelated line not to match")
+	}
+
+	// Approximate matching is opt-in
+	exact := New(Config{Delimiters: `\s+`})
+	exact.Parse(logLines)
+	if _, ok := exact.Match("User bob signed in from host1"); ok {
+		t.Error("Expected no match without ApproximateMatch")
+	}
+}
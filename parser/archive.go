@@ -0,0 +1,201 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// ArchiveFileField is the per-line metadata field ReadArchive sets to the
+// name of the archive member a line was read from.
+const ArchiveFileField = "file"
+
+// Magic bytes of the archive formats read by WalkArchive
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+	tarMagic  = []byte("ustar") // At offset 257 of the first header
+)
+
+// ArchiveLogs is the content of an archive read by ReadArchive.
+type ArchiveLogs struct {
+	Lines  []string            // Text lines of all members in archive order
+	Fields []map[string]string // Member name per line (ArchiveFileField), aligned with Lines
+	Files  []string            // Names of the members read, in archive order
+}
+
+// IsArchive reports whether name has the extension of an archive WalkArchive
+// reads: .zip, .tar, .tar.gz, .tgz or .gz.
+func IsArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tgz", ".gz"} {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkArchive calls fn with the name and content of every regular file of a
+// zip, tar or gzip-compressed tar archive in archive order, e.g. the log
+// bundle of a support ticket. The format is detected from the content.
+// Members compressed with gzip themselves, like rotated app.log.1.gz, are
+// decompressed; their name keeps the .gz extension. A gzip file that is no
+// tar archive is a single member named after the original file name in its
+// header, or "-" if it has none. zip archives are read into memory unless
+// reader is an io.ReaderAt of known size, like *bytes.Reader or a regular
+// *os.File. Reading stops at the first error fn returns.
+func WalkArchive(reader io.Reader, fn func(name string, member io.Reader) error) error {
+	buffered := bufio.NewReaderSize(reader, 512)
+	head, _ := buffered.Peek(len(zipMagic))
+	switch {
+	case bytes.HasPrefix(head, zipMagic):
+		return walkZip(reader, buffered, fn)
+	case bytes.HasPrefix(head, gzipMagic):
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return fmt.Errorf("invalid gzip archive: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		content := bufio.NewReaderSize(gz, 512)
+		if !isTar(content) {
+			name := gz.Name
+			if name == "" {
+				name = "-"
+			}
+			return fn(name, content)
+		}
+		return walkTar(content, fn)
+	case isTar(buffered):
+		return walkTar(buffered, fn)
+	}
+	return fmt.Errorf("unsupported archive format: no zip, tar or gzip file")
+}
+
+// ReadArchive reads the text lines of every member of an archive (see
+// WalkArchive) with the name of its member as per-line metadata, so
+// templates can be traced back to the files of a log bundle.
+func ReadArchive(reader io.Reader) (*ArchiveLogs, error) {
+	logs := &ArchiveLogs{}
+	err := WalkArchive(reader, func(name string, member io.Reader) error {
+		logs.Files = append(logs.Files, name)
+		scanner := bufio.NewScanner(member)
+		scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			logs.Lines = append(logs.Lines, line)
+			logs.Fields = append(logs.Fields, map[string]string{ArchiveFileField: name})
+		}
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading archive: %w", err)
+	}
+	return logs, nil
+}
+
+// sizedReaderAt is random access content of known size, like *bytes.Reader
+type sizedReaderAt interface {
+	io.ReaderAt
+	Size() int64
+}
+
+// statReaderAt is random access content with file info, like *os.File
+type statReaderAt interface {
+	io.ReaderAt
+	Stat() (fs.FileInfo, error)
+}
+
+// isTar reports whether the buffered content starts with a POSIX tar header
+func isTar(content *bufio.Reader) bool {
+	header, _ := content.Peek(257 + len(tarMagic))
+	return len(header) == 257+len(tarMagic) && bytes.Equal(header[257:], tarMagic)
+}
+
+// walkTar calls fn for every regular file of a tar archive
+func walkTar(reader io.Reader, fn func(name string, member io.Reader) error) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := walkMember(header.Name, archive, fn); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip calls fn for every regular file of a zip archive
+func walkZip(original, buffered io.Reader, fn func(name string, member io.Reader) error) error {
+	var readerAt io.ReaderAt
+	var size int64
+	switch source := original.(type) {
+	case sizedReaderAt:
+		readerAt, size = source, source.Size()
+	case statReaderAt:
+		if info, err := source.Stat(); err == nil && info.Mode().IsRegular() {
+			readerAt, size = source, info.Size()
+		}
+	}
+	if readerAt == nil {
+		data, err := io.ReadAll(buffered)
+		if err != nil {
+			return fmt.Errorf("error reading zip archive: %w", err)
+		}
+		readerAt, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	archive, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return fmt.Errorf("invalid zip archive: %w", err)
+	}
+	for _, file := range archive.File {
+		if !file.Mode().IsRegular() || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+		member, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file.Name, err)
+		}
+		err = walkMember(file.Name, member, fn)
+		_ = member.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkMember calls fn with a member, decompressing it if it is compressed
+// with gzip
+func walkMember(name string, member io.Reader, fn func(name string, member io.Reader) error) error {
+	if !strings.EqualFold(path.Ext(name), ".gz") {
+		return fn(name, member)
+	}
+	gz, err := gzip.NewReader(member)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer func() { _ = gz.Close() }()
+	return fn(name, gz)
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gzipBytes compresses data with gzip
+func gzipBytes(t *testing.T, name string, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Name = name
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// archiveMembers are the log files of a test bundle, a rotated one gzipped
+var archiveMembers = []struct {
+	name    string
+	content string
+}{
+	{"logs/app.log", "User alice logged in\n\nUser bob logged in\n"},
+	{"logs/app.log.1.gz", "Disk sda full\n"},
+}
+
+func memberContent(t *testing.T, i int) []byte {
+	member := archiveMembers[i]
+	if strings.HasSuffix(member.name, ".gz") {
+		return gzipBytes(t, "", []byte(member.content))
+	}
+	return []byte(member.content)
+}
+
+func TestReadArchive(t *testing.T) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	if err := tw.WriteHeader(&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for i, member := range archiveMembers {
+		data := memberContent(t, i)
+		if err := tw.WriteHeader(&tar.Header{Name: member.name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for i, member := range archiveMembers {
+		w, err := zw.Create(member.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(memberContent(t, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantLines := []string{"User alice logged in", "User bob logged in", "Disk sda full"}
+	wantFiles := []string{"logs/app.log", "logs/app.log.1.gz"}
+	archives := map[string]io.Reader{
+		"tar":          bytes.NewReader(tarBuf.Bytes()),
+		"tar.gz":       bytes.NewReader(gzipBytes(t, "", tarBuf.Bytes())),
+		"zip":          bytes.NewReader(zipBuf.Bytes()),
+		"zip (stream)": io.MultiReader(bytes.NewReader(zipBuf.Bytes())), // No io.ReaderAt
+	}
+	for name, archive := range archives {
+		logs, err := ReadArchive(archive)
+		if err != nil {
+			t.Fatalf("%s: ReadArchive error: %v", name, err)
+		}
+		if !reflect.DeepEqual(logs.Lines, wantLines) || !reflect.DeepEqual(logs.Files, wantFiles) {
+			t.Errorf("%s: unexpected lines %q from files %q", name, logs.Lines, logs.Files)
+		}
+		if len(logs.Fields) != 3 || logs.Fields[2][ArchiveFileField] != "logs/app.log.1.gz" {
+			t.Errorf("%s: unexpected fields %v", name, logs.Fields)
+		}
+	}
+
+	// A gzip file that is no tar archive is a single member
+	logs, err := ReadArchive(bytes.NewReader(gzipBytes(t, "app.log", []byte("Job done\n"))))
+	if err != nil || !reflect.DeepEqual(logs.Files, []string{"app.log"}) || !reflect.DeepEqual(logs.Lines, []string{"Job done"}) {
+		t.Errorf("Unexpected single gzip member: %+v, %v", logs, err)
+	}
+
+	if _, err := ReadArchive(strings.NewReader("plain text log\n")); err == nil {
+		t.Error("Expected error for content that is no archive")
+	}
+	if !IsArchive("bundle.TGZ") || !IsArchive("app.log.gz") || IsArchive("app.log") {
+		t.Error("Unexpected IsArchive result")
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Events recorded in AuditEvent.Event
+const (
+	AuditCreated      = "created"       // Template learned for the first time
+	AuditCountUpdated = "count_updated" // Lines of a batch added to a known template
+	AuditMerged       = "merged"        // Sources combined into a template or group bucket (see MergeAuditEntry)
+	AuditExpired      = "expired"       // Template removed by OnlineParser.Expire
+	AuditProtected    = "protected"     // Automatic change of an approved template prevented (see TemplateViolation)
+)
+
+// AuditEvent is one template state change of an OnlineParser, written as a
+// line of the NDJSON audit log.
+type AuditEvent struct {
+	Time         time.Time `json:"time"`
+	Batch        int       `json:"batch"` // Number of batches added when the change happened
+	Event        string    `json:"event"` // See Audit* constants
+	Template     string    `json:"template"`
+	TemplateID   string    `json:"template_id,omitempty"`
+	Count        int       `json:"count,omitempty"`         // Accumulated count after the change (for expired: before removal)
+	Delta        int       `json:"delta,omitempty"`         // Count added by the batch
+	Reason       string    `json:"reason,omitempty"`        // Merge reason (see MergeReason* constants) or violation action (see Violation* constants)
+	Sources      []string  `json:"sources,omitempty"`       // Merged templates or group patterns, learned template generalizing a protected one
+	SourceCounts []int     `json:"source_counts,omitempty"` // Log counts of the merged sources
+}
+
+// SetAuditLog makes the parser append every template state change (created,
+// count updated, merged, expired, protected) to w as one JSON object per line. Open
+// files with os.O_APPEND to keep an append-only audit trail that allows
+// replaying the template history. A nil w disables the audit log.
+func (op *OnlineParser) SetAuditLog(w io.Writer) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if w == nil {
+		op.audit = nil
+		return
+	}
+	op.audit = json.NewEncoder(w)
+	op.audit.SetEscapeHTML(false) // Keep <*> readable
+}
+
+// observe records a template state change of the running batch
+func (op *OnlineParser) observe(template string, previous, count int) {
+	event := AuditEvent{Event: AuditCountUpdated, Template: template, Count: count, Delta: count - previous}
+	if previous == 0 {
+		event.Event = AuditCreated
+	}
+	op.changes = append(op.changes, event)
+}
+
+// writeAudit stamps events and appends them to the audit log
+func (op *OnlineParser) writeAudit(now time.Time, events []AuditEvent) error {
+	if op.audit == nil {
+		return nil
+	}
+	for _, event := range events {
+		event.Time = now
+		event.Batch = op.batches
+		if event.Event != AuditMerged {
+			event.TemplateID = op.parser.config.TemplateID(event.Template)
+		}
+		if err := op.audit.Encode(event); err != nil {
+			return fmt.Errorf("failed to write audit log: %w", err)
+		}
+	}
+	return nil
+}
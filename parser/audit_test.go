@@ -0,0 +1,72 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOnlineParserAuditLog(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	op := NewOnlineParser(Config{Delimiters: `\s+`})
+	op.now = func() time.Time { return now }
+	var buf bytes.Buffer
+	op.SetAuditLog(&buf)
+
+	if _, err := op.AddContext(context.Background(), []string{"User alice logged in", "User bob logged in", "User carol logged in"}); err != nil {
+		t.Fatalf("AddContext failed: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := op.AddContext(context.Background(), []string{"User dave logged in", "Disk sda full", "Disk sdb full", "Disk sdc full"}); err != nil {
+		t.Fatalf("AddContext failed: %v", err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := op.AddContext(context.Background(), []string{"Disk sdd full"}); err != nil {
+		t.Fatalf("AddContext failed: %v", err)
+	}
+	now = now.Add(time.Minute)
+	expired, err := op.Expire(90 * time.Second)
+	if err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if len(expired) != 1 || expired[0] != "User <*> logged in" {
+		t.Errorf("Expected the user template to expire, got %q", expired)
+	}
+	if snapshot := op.Snapshot(); len(snapshot) != 1 || snapshot[0].Template != "Disk <*> full" {
+		t.Errorf("Unexpected snapshot after expiry: %+v", snapshot)
+	}
+
+	var events []AuditEvent
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event AuditEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid audit line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+
+	expected := []AuditEvent{
+		{Batch: 1, Event: AuditCreated, Template: "User <*> logged in", Count: 3, Delta: 3},
+		{Batch: 2, Event: AuditCreated, Template: "Disk <*> full", Count: 3, Delta: 3},
+		{Batch: 2, Event: AuditCountUpdated, Template: "User <*> logged in", Count: 4, Delta: 1},
+		{Batch: 3, Event: AuditCountUpdated, Template: "Disk <*> full", Count: 4, Delta: 1},
+		{Batch: 3, Event: AuditExpired, Template: "User <*> logged in", Count: 4},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d audit events, got %+v", len(expected), events)
+	}
+	for i, want := range expected {
+		got := events[i]
+		if got.Batch != want.Batch || got.Event != want.Event || got.Template != want.Template ||
+			got.Count != want.Count || got.Delta != want.Delta || got.TemplateID != HashTemplateID(want.Template) {
+			t.Errorf("Event %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if !events[4].Time.Equal(now) {
+		t.Errorf("Expected expiry time %v, got %v", now, events[3].Time)
+	}
+}
@@ -2,9 +2,14 @@
 package parser
 
 import (
+	"context"
+	"fmt"
+	"hash/fnv"
 	"math"
+	"slices"
 	"sort"
 	"sync"
+	"time"
 	"unique"
 )
 
@@ -14,14 +19,32 @@ const (
 	MaxParentWordsLength = 500  // Maximum length for ParentWords slice
 )
 
+// maxDynamicThreshold caps the dynamic child branch threshold
+const maxDynamicThreshold = 10
+
 // BrainParser - main parser structure.
+//
+// A BrainParser is safe for concurrent use: Parse and its variants, Match,
+// Approve, SaveState and Freeze may be called from multiple goroutines. The
+// preprocessor and template filter are read-only after New, the learned
+// templates are guarded by a mutex and the memory pools are sync.Pools.
+// Templates learned by concurrent Parse calls are recorded in the order the
+// calls finish, so Config.Deterministic ordering only holds for sequential
+// calls. Config.TemplateID and Config.Hooks must be safe for concurrent use
+// if Parse is called concurrently.
 type BrainParser struct {
-	config       Config
-	preprocessor *Preprocessor // Cached preprocessor with compiled regexes
+	config         Config
+	preprocessor   *Preprocessor   // Cached preprocessor with compiled regexes
+	templateFilter *templateFilter // Compiled template allow/deny lists (nil = keep all)
+	quality        *QualityPolicy  // Quality gate of Config.QualityPolicy (nil = keep all)
+	state          *templateState  // Templates learned across Parse calls (nil for internal reparsing)
 }
 
 // New creates a new BrainParser instance with the given configuration.
+// It panics if a regex of the configuration does not compile; use
+// NewWithError or Config.Validate for configurations from untrusted input.
 func New(config Config) *BrainParser {
+	quality := applyQualityPolicy(&config) // Before the defaults of the thresholds it sets
 	if config.Delimiters == "" {
 		// Default value as per the paper (space, colon, comma, equals)
 		config.Delimiters = `[\s,:=]`
@@ -38,6 +61,9 @@ func New(config Config) *BrainParser {
 	if config.ParallelProcessingThreshold == 0 {
 		config.ParallelProcessingThreshold = 1000 // Default: enable parallel processing for groups with 1000+ logs
 	}
+	if config.HighCardinalityLimit == 0 {
+		config.HighCardinalityLimit = 1000 // Default: columns with 1000+ distinct values are not split
+	}
 
 	// Enhanced Features Tuning Parameters defaults
 	if config.EntropyThreshold == 0 {
@@ -59,6 +85,13 @@ func New(config Config) *BrainParser {
 		config.TimestampMinSeparators = 2 // Same as original
 	}
 
+	if config.TemplateID == nil {
+		config.TemplateID = HashTemplateID
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
 	// Add default CommonVariables patterns if none provided
 	if config.CommonVariables == nil {
 		config.CommonVariables = getDefaultCommonVariables()
@@ -66,11 +99,40 @@ func New(config Config) *BrainParser {
 
 	// Create preprocessor once with compiled regexes for performance
 	preprocessor := NewPreprocessor(config.Delimiters, config.CommonVariables)
+	preprocessor.setIgnoreRules(config.IgnorePositions, config.IgnoreTokenPatterns)
+	preprocessor.unicodeDigits = config.UnicodeDigits
+	preprocessor.foldUnicode = config.FoldUnicode
+	preprocessor.preTokenize = config.PreTokenizeHooks
+	preprocessor.postTokenize = config.PostTokenizeHooks
+	preprocessor.setPlaceholders(config.Placeholders)
+	if !config.isReparsing {
+		preprocessor.frequencies = config.Frequencies // Reparsing uses the frequencies of its subset
+	}
 
-	return &BrainParser{
-		config:       config,
-		preprocessor: preprocessor,
+	parser := &BrainParser{
+		config:         config,
+		preprocessor:   preprocessor,
+		templateFilter: newTemplateFilter(config.TemplateAllowPatterns, config.TemplateDenyPatterns),
+		quality:        quality,
+	}
+	if !config.isReparsing {
+		parser.state = newTemplateState(preprocessor.slots)
 	}
+	return parser
+}
+
+// Placeholders returns the named placeholders of Config.Placeholders in
+// sorted order, to export templates with TemplateToRegex, SigmaOptions or
+// ClusterTemplates.
+func (p *BrainParser) Placeholders() []string {
+	return p.preprocessor.slots.tokens()
+}
+
+// DefaultCommonVariables returns a copy of the default common variable
+// patterns, e.g. to disable single patterns by name before passing them as
+// Config.CommonVariables.
+func DefaultCommonVariables() map[string]string {
+	return getDefaultCommonVariables()
 }
 
 // getDefaultCommonVariables returns default patterns for common variable types
@@ -112,23 +174,26 @@ func getDefaultCommonVariables() map[string]string {
 		"hostname_port": `^[a-zA-Z0-9.-]+:\d+$`,                      // hostname:port combinations
 
 		// File and system patterns (before pure numbers)
-		"file_sizes":   `^\d+[KMGT]?B$`,                       // File sizes like 123KB, 4GB
-		"unix_path":    `^(/[a-zA-Z0-9._-]+)+/?$`,             // Unix file paths
-		"windows_path": `^[A-Za-z]:\\(\\[^\\/:*?"<>|]+)*\\?$`, // Windows file paths
-		"filename_ext": `^[a-zA-Z0-9._-]+\.[a-zA-Z]{2,4}$`,    // Filenames with extensions
+		"file_sizes":         `^\d+(\.\d+)?([KMGTPE]i?)?B$`,                     // File sizes like 123KB, 4GB, 3.5GiB
+		"file_sizes_grouped": `^\d{1,3}(,\d{3})+(\.\d+)?([KMGTPE]i?)?B$`,        // File sizes with thousand separators like 1,024KB
+		"file_sizes_spaced":  `^(\d{1,3}(,\d{3})+|\d+)(\.\d+)? ([KMGTPE]i?)?B$`, // File sizes with spaced units like 3.5 GiB
+		"unix_path":          `^(/[a-zA-Z0-9._-]+)+/?$`,                         // Unix file paths
+		"windows_path":       `^[A-Za-z]:\\(\\[^\\/:*?"<>|]+)*\\?$`,             // Windows file paths
+		"filename_ext":       `^[a-zA-Z0-9._-]+\.[a-zA-Z]{2,4}$`,                // Filenames with extensions
 
 		// Web and email patterns
 		"url":   `^https?://[^\s]+$`,                                // URLs
 		"email": `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`, // Email addresses
 
 		// Identifiers and special numbers (before pure numbers)
-		"hex_numbers": `^0x[a-fA-F0-9]+$`,                                                              // Hexadecimal numbers
-		"uuid":        `^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$`, // UUIDs
-		"block_ids":   `^blk_[-]?\d+$`,                                                                 // Block IDs like blk_123
-		"session_id":  `^[a-zA-Z0-9]{16,}$`,                                                            // Session IDs (16+ alphanumeric)
-		"version":     `^v?\d+\.\d+(\.\d+)?(-[a-zA-Z0-9._-]+)?$`,                                       // Software versions
-		"percentages": `^\d{1,3}%$`,                                                                    // Percentage values
-		"memory_addr": `^0x[0-9a-fA-F]+$`,                                                              // Memory addresses
+		"hex_numbers":        `^0x[a-fA-F0-9]+$`,                                                              // Hexadecimal numbers
+		"uuid":               `^[a-fA-F0-9]{8}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{4}-[a-fA-F0-9]{12}$`, // UUIDs
+		"block_ids":          `^blk_[-]?\d+$`,                                                                 // Block IDs like blk_123
+		"session_id":         `^[a-zA-Z0-9]{16,}$`,                                                            // Session IDs (16+ alphanumeric)
+		"version":            `^v?\d+\.\d+(\.\d+)?(-[a-zA-Z0-9._-]+)?$`,                                       // Software versions
+		"percentages":        `^\d{1,3}(\.\d+)?%$`,                                                            // Percentage values like 95%, 99.9%
+		"percentages_spaced": `^\d{1,3}(\.\d+)? %$`,                                                           // Percentages with spaced unit like 12 %
+		"memory_addr":        `^0x[0-9a-fA-F]+$`,                                                              // Memory addresses
 
 		// Common log datetime fragments (after being split by tokenizer)
 		// Month names for syslog format
@@ -145,18 +210,167 @@ func getDefaultCommonVariables() map[string]string {
 
 // Parse analyzes a slice of log lines and returns found patterns.
 func (p *BrainParser) Parse(logLines []string) []*ParseResult {
-	// Use cached preprocessor with pre-compiled regexes for performance
-	processedLogs := p.preprocessor.PreprocessLogs(logLines)
+	return p.ParseWithReport(logLines).Results
+}
 
-	initialGroups := CreateInitialGroups(processedLogs, &p.config)
+// ParseWithReport behaves like Parse and additionally returns run metadata
+// such as the self-profiling data when Config.EnableProfiling is set.
+func (p *BrainParser) ParseWithReport(logLines []string) *ParseReport {
+	report, _ := p.parseReport(context.Background(), logLines, nil, &ParseReport{})
+	return report
+}
+
+// ParseContext behaves like Parse but stops early when ctx is canceled or its
+// deadline expires, returning ctx.Err(). A canceled parse returns no results
+// and does not add templates to the parser state.
+func (p *BrainParser) ParseContext(ctx context.Context, logLines []string) ([]*ParseResult, error) {
+	report, err := p.ParseWithReportContext(ctx, logLines)
+	if err != nil {
+		return nil, err
+	}
+	return report.Results, nil
+}
+
+// ParseWithReportContext behaves like ParseWithReport but stops early when
+// ctx is canceled or its deadline expires, returning ctx.Err() and no report.
+func (p *BrainParser) ParseWithReportContext(ctx context.Context, logLines []string) (*ParseReport, error) {
+	return p.parseWeightedReport(ctx, logLines, nil)
+}
+
+// parseWeightedReport runs a top-level parse of weighted lines, returning no
+// report if ctx is canceled
+func (p *BrainParser) parseWeightedReport(ctx context.Context, logLines []string, weights []int) (*ParseReport, error) {
+	report, err := p.parseReport(ctx, logLines, weights, &ParseReport{})
+	if err != nil {
+		Results(report.Results).Release()
+		return nil, err
+	}
+	return report, nil
+}
+
+// parseReport runs a top-level parse of weighted lines (nil weights = 1 per
+// line) recording run metadata into report. If ctx is canceled before the
+// results are complete, ctx.Err() is returned.
+func (p *BrainParser) parseReport(ctx context.Context, logLines []string, weights []int, report *ParseReport) (*ParseReport, error) {
+	if report.progress == nil && !p.config.isReparsing {
+		report.progress = newProgressTracker(p.config.Progress, len(logLines))
+	}
+	defer report.progress.finish()
+	if templates, matcher := p.state.resumeMatcher(); matcher != nil {
+		return p.parseResumed(ctx, logLines, weights, templates, matcher, report)
+	}
+
+	if p.config.EnableProfiling && !p.config.isReparsing {
+		report.Profile = &ParseProfile{}
+		report.profiler = newPhaseProfiler(report.Profile)
+	}
+
+	templates := p.generateTemplates(ctx, logLines, weights, report)
+	if err := ctx.Err(); err != nil {
+		Results(templates).Release()
+		return report, err
+	}
+
+	// Aggregate identical templates
+	report.Results = p.aggregateResultsInto(templates, nil, report)
+	report.endPhase(PhaseAggregation)
+
+	report.Results = p.finalizeResults(report.Results, logLines)
+	report.endPhase(PhaseFinalize)
+
+	return report, nil
+}
+
+// ParseInto behaves like Parse but writes into a caller-provided buffer,
+// reusing its result structs and LogIDs slices. Surplus entries of dst are
+// released back to the pools. Intended for services calling Parse repeatedly
+// to keep steady-state allocations low.
+func (p *BrainParser) ParseInto(logLines []string, dst Results) Results {
+	if _, matcher := p.state.resumeMatcher(); matcher != nil {
+		dst.Release()
+		return p.ParseWithReport(logLines).Results
+	}
+
+	templates := p.generateTemplates(context.Background(), logLines, nil, nil)
+	results := p.aggregateResultsInto(templates, dst, nil)
+
+	// Intermediate templates were copied during aggregation
+	Results(templates).Release()
+
+	return p.finalizeResults(results, logLines)
+}
+
+// finalizeResults drops templates rejected by the allow/deny lists and the
+// quality policy, enriches the remaining aggregated results with per-template
+// metadata and records them in the learned templates. Dropped templates never
+// reach the state, so Templates, SaveState and Match do not know them.
+func (p *BrainParser) finalizeResults(results Results, logLines []string) Results {
+	if p.config.isReparsing {
+		return results // Filtering and metadata are applied once by the top-level parse
+	}
+	results = p.templateFilter.apply(results)
+	results = p.gateQuality(results)
+	inferSeverities(results, logLines)
+	p.assignTemplateIDs(results)
+	if p.config.TemplatePositions {
+		p.setTemplatePositions(results, logLines)
+	}
+	if p.config.VariableStatistics {
+		p.recordSlotStats(results, logLines)
+	}
+	if p.config.ExamplesPerTemplate > 0 {
+		p.setExamples(results, logLines)
+	}
+	results = p.beforeTemplateEmit(results)
+	p.state.record(results, p.seenTimes(results, logLines))
+	return results
+}
+
+// generateTemplates runs all algorithm steps and returns per-group templates
+// before aggregation. Run metadata is recorded into report if it is not nil.
+// Remaining groups are skipped once ctx is canceled.
+func (p *BrainParser) generateTemplates(ctx context.Context, logLines []string, weights []int, report *ParseReport) []*ParseResult {
+	// Use cached preprocessor with pre-compiled regexes for performance
+	processedLogs, folded := p.preprocessor.preprocessWeightedLogs(logLines, weights)
+	if report != nil {
+		report.FoldedLines = folded
+	}
+	var header string
+	if p.config.PruneConstantColumns {
+		header = p.pruneConstantColumns(processedLogs)
+	}
+	report.endPhase(PhasePreprocess)
+	report.advance(len(logLines), PhasePreprocess)
+
+	initialGroups, overflowAudit := createInitialGroups(p.afterPreprocess(processedLogs), &p.config)
+	if len(overflowAudit) > 0 && report != nil {
+		overflow := 0
+		for _, entry := range overflowAudit {
+			overflow += len(entry.Sources)
+		}
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"initial group count exceeded MaxInitialGroups=%d: %d groups merged into length buckets",
+			p.config.MaxInitialGroups, overflow))
+		report.MergeAudit = append(report.MergeAudit, overflowAudit...)
+	}
+	report.endPhase(PhaseGrouping)
+	report.advance(len(logLines), PhaseGrouping)
 
 	var allTemplates []*ParseResult
+	highCardinality := 0 // Columns marked variable by the high-cardinality shortcut
 
 	// Convert map to slice for processing
 	groupSlice := make([]*LogGroup, 0, len(initialGroups))
-	for _, group := range initialGroups {
-		groupSlice = append(groupSlice, group)
+	if p.ordered() {
+		for _, key := range sortedKeys(initialGroups) {
+			groupSlice = append(groupSlice, initialGroups[key])
+		}
+	} else {
+		for _, group := range initialGroups {
+			groupSlice = append(groupSlice, group)
+		}
 	}
+	groupSlice = p.afterGrouping(groupSlice)
 
 	// Determine if we should use parallel processing
 	shouldUseParallel := false
@@ -169,12 +383,17 @@ func (p *BrainParser) Parse(logLines []string) []*ParseResult {
 
 	if shouldUseParallel {
 		// Parallel processing for large groups
-		allTemplates = p.processGroupsParallel(groupSlice, processedLogs)
+		allTemplates, highCardinality = p.processGroupsParallel(ctx, groupSlice, processedLogs, report)
 	} else {
 		// Sequential processing for small groups
 		for _, group := range groupSlice {
+			if ctx.Err() != nil {
+				break
+			}
+
 			// Steps 3 and 4: Build tree for each group
 			tree := p.BuildTreeForGroup(group)
+			highCardinality += len(tree.HighCardinality)
 
 			// Step 5: Generate templates from tree
 			// GenerateTemplatesFromTree performs complete template extraction with full
@@ -184,54 +403,114 @@ func (p *BrainParser) Parse(logLines []string) []*ParseResult {
 
 			// Release tree resources back to pools after processing
 			ReleaseBidirectionalTree(tree)
+			report.advance(len(group.Logs), PhaseTrees)
 		}
 	}
+	if header != "" {
+		// Re-insert the pruned constant columns
+		for _, template := range allTemplates {
+			template.Template = header + " " + template.Template
+		}
+	}
+	if highCardinality > 0 && report != nil {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"columns with at least %d distinct words marked variable without splitting (HighCardinalityLimit): %d",
+			p.highCardinalityLimit(), highCardinality))
+	}
+	report.endPhase(PhaseTrees)
 
-	// Aggregate identical templates
-	return p.aggregateResults(allTemplates)
+	return allTemplates
 }
 
 // aggregateResults combines duplicate templates into one.
 func (p *BrainParser) aggregateResults(results []*ParseResult) []*ParseResult {
+	return p.aggregateResultsInto(results, nil, nil)
+}
+
+// aggregateResultsInto combines duplicate templates into one, reusing the
+// result structs and LogIDs slices of dst where possible. Merges are recorded
+// in the audit log of report if it is not nil.
+func (p *BrainParser) aggregateResultsInto(results []*ParseResult, dst Results, report *ParseReport) Results {
 	aggMap := make(map[string]*ParseResult)
+	var sourceCounts map[string][]int
+	var sourceOrigins map[string][]string
+	if report != nil {
+		sourceCounts = make(map[string][]int)
+		sourceOrigins = make(map[string][]string)
+	}
+	finalList := dst[:0]
 	for _, res := range results {
+		if sourceCounts != nil {
+			origin := res.origin
+			if origin == "" {
+				origin = res.Template
+			}
+			sourceCounts[res.Template] = append(sourceCounts[res.Template], res.Count)
+			sourceOrigins[res.Template] = append(sourceOrigins[res.Template], origin)
+			if res.reparsed != nil {
+				entry := *res.reparsed
+				entry.Result = res.Template
+				report.MergeAudit = append(report.MergeAudit, entry)
+			}
+		}
 		if existing, ok := aggMap[res.Template]; ok {
+			mergeExamples(existing, res, p.config.ExamplesPerTemplate)
 			existing.Count += res.Count
-			// Use pooled int slice for better memory management
-			if cap(existing.LogIDs) < len(existing.LogIDs)+len(res.LogIDs) {
-				newSlice := GetIntSlice()
-				// Ensure capacity
-				for cap(newSlice) < len(existing.LogIDs)+len(res.LogIDs) {
-					PutIntSlice(newSlice)
-					newSlice = make([]int, 0, len(existing.LogIDs)+len(res.LogIDs))
-				}
-				newSlice = append(newSlice, existing.LogIDs...)
-				existing.LogIDs = newSlice
-			}
 			existing.LogIDs = append(existing.LogIDs, res.LogIDs...)
-		} else {
-			// Copy to avoid modifying the original slice
-			newRes := *res
-			// Use pooled int slice for LogIDs
-			logIDsCopy := GetIntSlice()
-			// Ensure capacity
-			if cap(logIDsCopy) < len(res.LogIDs) {
-				PutIntSlice(logIDsCopy)
-				logIDsCopy = make([]int, 0, len(res.LogIDs))
+			if res.Severity > existing.Severity {
+				existing.Severity = res.Severity
 			}
-			logIDsCopy = append(logIDsCopy, res.LogIDs...)
-			newRes.LogIDs = logIDsCopy
-			aggMap[res.Template] = &newRes
+			continue
+		}
+
+		// Copy to avoid modifying the original result, reusing dst entries first
+		var newRes *ParseResult
+		if len(finalList) < len(dst) && dst[len(finalList)] != nil {
+			newRes = dst[len(finalList)]
+			logIDs := newRes.LogIDs
+			*newRes = *res
+			newRes.LogIDs = logIDs[:0]
+		} else {
+			newRes = GetParseResult()
+			logIDs := newRes.LogIDs
+			*newRes = *res
+			newRes.LogIDs = logIDs
 		}
+		// Use pooled int slice for LogIDs
+		if newRes.LogIDs == nil {
+			newRes.LogIDs = GetIntSlice()
+		}
+		newRes.LogIDs = append(newRes.LogIDs, res.LogIDs...)
+
+		aggMap[res.Template] = newRes
+		finalList = append(finalList, newRes)
 	}
 
-	finalList := make([]*ParseResult, 0, len(aggMap))
-	for _, res := range aggMap {
-		finalList = append(finalList, res)
+	// Release surplus entries of the destination buffer
+	if len(finalList) < len(dst) {
+		dst[len(finalList):].Release()
 	}
 
+	// Record templates that were combined from several sources
+	for _, res := range finalList {
+		if counts := sourceCounts[res.Template]; len(counts) > 1 {
+			report.MergeAudit = append(report.MergeAudit, MergeAuditEntry{
+				Reason:  MergeReasonAggregation,
+				Sources: sourceOrigins[res.Template],
+				Counts:  counts,
+				Result:  res.Template,
+			})
+		}
+	}
+
+	finalList = p.mergeSubsumed(finalList, report)
+	finalList = p.mergeVariableLength(finalList, report)
+
 	// Sort by popularity for nice output
 	sort.Slice(finalList, func(i, j int) bool {
+		if p.ordered() && finalList[i].Count == finalList[j].Count {
+			return finalList[i].Template < finalList[j].Template
+		}
 		return finalList[i].Count > finalList[j].Count
 	})
 
@@ -262,8 +541,8 @@ func (p *BrainParser) calculateDynamicThreshold(uniqueWordsCount int) int {
 	}
 
 	// Cap at reasonable maximum to avoid too conservative splitting
-	if dynamicThreshold > 10 {
-		dynamicThreshold = 10
+	if dynamicThreshold > maxDynamicThreshold {
+		dynamicThreshold = maxDynamicThreshold
 	}
 
 	return dynamicThreshold
@@ -300,16 +579,23 @@ func (p *BrainParser) calculateStatisticalThreshold(uniqueWordsCount int) int {
 	return int(smoothedThreshold)
 }
 
-// processGroupsParallel processes log groups in parallel for better performance on large datasets
-func (p *BrainParser) processGroupsParallel(groups []*LogGroup, allLogs []*LogMessage) []*ParseResult {
+// processGroupsParallel processes log groups in parallel for better performance on large datasets.
+// Progress of finished groups is reported from the calling goroutine. It also returns the number
+// of columns marked variable by the high-cardinality shortcut.
+func (p *BrainParser) processGroupsParallel(ctx context.Context, groups []*LogGroup, allLogs []*LogMessage, report *ParseReport) ([]*ParseResult, int) {
 	// Create channels for work distribution and result collection
 	type workItem struct {
 		group *LogGroup
 		index int
 	}
 
-	workChan := make(chan workItem, len(groups))
-	resultsChan := make(chan []*ParseResult, len(groups))
+	type resultItem struct {
+		templates       []*ParseResult
+		index           int
+		highCardinality int
+	}
+
+	resultsChan := make(chan resultItem, len(groups))
 
 	// Use a WaitGroup to track completion
 	var wg sync.WaitGroup
@@ -317,28 +603,52 @@ func (p *BrainParser) processGroupsParallel(groups []*LogGroup, allLogs []*LogMe
 	// Determine optimal number of workers
 	numWorkers := p.getOptimalWorkerCount(groups)
 
+	// Workers share one queue, unless stable partitioning gives each its own
+	workChans := make([]chan workItem, numWorkers)
+	for i := range workChans {
+		if i == 0 || p.config.StablePartitioning {
+			workChans[i] = make(chan workItem, len(groups))
+		} else {
+			workChans[i] = workChans[0]
+		}
+	}
+
 	// Start worker goroutines
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
-		go func() {
+		go func(workChan <-chan workItem) {
 			defer wg.Done()
 			for work := range workChan {
+				if ctx.Err() != nil {
+					// Skip remaining groups of a canceled parse
+					resultsChan <- resultItem{index: work.index}
+					continue
+				}
+
 				// Process the group
 				tree := p.BuildTreeForGroup(work.group)
 				templates := p.GenerateTemplatesFromTree(tree, allLogs)
-				resultsChan <- templates
+				resultsChan <- resultItem{templates: templates, index: work.index, highCardinality: len(tree.HighCardinality)}
 
 				// Release tree resources back to pools after processing
 				ReleaseBidirectionalTree(tree)
 			}
-		}()
+		}(workChans[i])
 	}
 
 	// Send work to workers
 	for i, group := range groups {
-		workChan <- workItem{group: group, index: i}
+		worker := 0
+		if p.config.StablePartitioning {
+			worker = partitionWorker(group, numWorkers)
+		}
+		workChans[worker] <- workItem{group: group, index: i}
+	}
+	for i, workChan := range workChans {
+		if i == 0 || p.config.StablePartitioning {
+			close(workChan)
+		}
 	}
-	close(workChan)
 
 	// Wait for all workers to complete and close results channel
 	go func() {
@@ -346,17 +656,46 @@ func (p *BrainParser) processGroupsParallel(groups []*LogGroup, allLogs []*LogMe
 		close(resultsChan)
 	}()
 
-	// Collect results
+	// Collect results in group order so the output does not depend on scheduling
+	groupTemplates := make([][]*ParseResult, len(groups))
+	highCardinality := 0
+	for item := range resultsChan {
+		groupTemplates[item.index] = item.templates
+		highCardinality += item.highCardinality
+		report.advance(len(groups[item.index].Logs), PhaseTrees)
+	}
+
 	var allTemplates []*ParseResult
-	for templates := range resultsChan {
+	for _, templates := range groupTemplates {
 		allTemplates = append(allTemplates, templates...)
 	}
 
-	return allTemplates
+	return allTemplates, highCardinality
+}
+
+// stablePartitionWorkers is the number of workers with Config.StablePartitioning,
+// fixed so the assignment of groups to workers is the same on every machine
+const stablePartitionWorkers = 8
+
+// ordered reports whether groups, columns and tree nodes are traversed in a
+// fixed order: with Config.Deterministic and implied by Config.StablePartitioning
+func (p *BrainParser) ordered() bool {
+	return p.config.Deterministic || p.config.StablePartitioning
+}
+
+// partitionWorker maps a group to a worker by a stable hash of its pattern key
+func partitionWorker(group *LogGroup, numWorkers int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(group.Pattern.Key()))
+	return int(h.Sum32() % uint32(numWorkers)) // #nosec G115 -- numWorkers is small and positive
 }
 
 // getOptimalWorkerCount determines the optimal number of workers based on groups and system
 func (p *BrainParser) getOptimalWorkerCount(groups []*LogGroup) int {
+	if p.config.StablePartitioning {
+		return stablePartitionWorkers
+	}
+
 	// Count groups that meet the parallel processing threshold
 	largeGroupCount := 0
 	for _, group := range groups {
@@ -395,8 +734,15 @@ func (p *BrainParser) BuildTreeForGroup(group *LogGroup) *BidirectionalTree {
 
 	var parentCols, childCols []int
 	columnWords := getColumnWords(group.Logs)
-
+	positions := make([]int, 0, len(columnWords))
 	for pos := range columnWords {
+		positions = append(positions, pos)
+	}
+	if p.ordered() {
+		sort.Ints(positions)
+	}
+
+	for _, pos := range positions {
 		if rootPositions[pos] {
 			continue
 		}
@@ -464,17 +810,33 @@ func (p *BrainParser) updateChildDirection(tree *BidirectionalTree, rootNode *No
 		return
 	}
 
-	// Sort columns by number of unique words (as in the paper)
+	// Sort columns by number of unique words (as in the paper). Counting
+	// stops at the high-cardinality limit, so such columns sort last.
+	limit := p.highCardinalityLimit()
+	uniqueCounts := make(map[int]int, len(childCols))
+	for _, pos := range childCols {
+		uniqueCounts[pos] = countUniqueWordsInColumn(currentLogs, pos, limit)
+	}
 	sort.Slice(childCols, func(i, j int) bool {
 		posI, posJ := childCols[i], childCols[j]
-		countI := countUniqueWordsInColumn(currentLogs, posI)
-		countJ := countUniqueWordsInColumn(currentLogs, posJ)
+		countI, countJ := uniqueCounts[posI], uniqueCounts[posJ]
+		if p.ordered() && countI == countJ {
+			return p.tieBreakKey(posI) < p.tieBreakKey(posJ)
+		}
 		return countI < countJ
 	})
 
 	posToProcess := childCols[0]
 	remainingCols := childCols[1:]
 
+	// A high-cardinality column has more branches than any threshold allows,
+	// so it is variable without grouping the logs by its words
+	if uniqueCounts[posToProcess] >= limit {
+		tree.markHighCardinality(posToProcess)
+		p.addVariableChild(tree, rootNode, currentLogs, posToProcess, remainingCols)
+		return
+	}
+
 	wordsInColumn := make(map[string][]*LogMessage)
 	for _, log := range currentLogs {
 		if posToProcess < len(log.Words) {
@@ -489,17 +851,18 @@ func (p *BrainParser) updateChildDirection(tree *BidirectionalTree, rootNode *No
 
 	// If number of branches >= threshold, consider all as variables (Algorithm 3, line 10: num ≥ threshold)
 	if uniqueWordsCount >= threshold {
-		variableNode := GetNode()
-		variableNode.IsVariable = true
-		variableNode.Children = GetStringMap()
-		variableNode.Position = posToProcess
-		variableNode.Logs = currentLogs
-		rootNode.Children["<*>"] = variableNode
-		// Continue recursion for the same group, but with remaining columns
-		p.updateChildDirection(tree, rootNode.Children["<*>"], currentLogs, remainingCols)
+		p.addVariableChild(tree, rootNode, currentLogs, posToProcess, remainingCols)
 	} else {
 		// Otherwise create constant branches and split the group
-		for word, subGroupLogs := range wordsInColumn {
+		words := make([]string, 0, len(wordsInColumn))
+		for word := range wordsInColumn {
+			words = append(words, word)
+		}
+		if p.ordered() {
+			sort.Strings(words) // Same node and pool order in every run
+		}
+		for _, word := range words {
+			subGroupLogs := wordsInColumn[word]
 			newNode := GetNode()
 			newNode.Value = unique.Make(word)
 			newNode.IsVariable = false
@@ -517,6 +880,51 @@ func (p *BrainParser) updateChildDirection(tree *BidirectionalTree, rootNode *No
 	}
 }
 
+// addVariableChild adds a variable node for the column at pos below rootNode
+// and continues the recursion for the same logs with the remaining columns
+func (p *BrainParser) addVariableChild(tree *BidirectionalTree, rootNode *Node, currentLogs []*LogMessage, pos int, remainingCols []int) {
+	variableNode := GetNode()
+	variableNode.IsVariable = true
+	variableNode.Children = GetStringMap()
+	variableNode.Position = pos
+	variableNode.Logs = currentLogs
+	rootNode.Children["<*>"] = variableNode
+	p.updateChildDirection(tree, variableNode, currentLogs, remainingCols)
+}
+
+// markHighCardinality records a child column marked variable by the
+// high-cardinality shortcut
+func (t *BidirectionalTree) markHighCardinality(pos int) {
+	if !slices.Contains(t.HighCardinality, pos) {
+		t.HighCardinality = append(t.HighCardinality, pos)
+	}
+}
+
+// tieBreakKey orders child columns with the same number of unique words in
+// deterministic mode: by position, or with Config.Seed by a permutation of
+// the positions derived from the seed
+func (p *BrainParser) tieBreakKey(pos int) uint64 {
+	if p.config.Seed == 0 {
+		return uint64(pos) // #nosec G115 -- positions are not negative
+	}
+	// SplitMix64 finalizer of the seeded position
+	x := uint64(p.config.Seed) + uint64(pos+1)*0x9e3779b97f4a7c15 // #nosec G115 -- bit pattern only
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// highCardinalityLimit returns the number of distinct words from which a
+// child column is marked variable without splitting. It is raised to the
+// largest possible branch threshold, so the shortcut never changes templates.
+func (p *BrainParser) highCardinalityLimit() int {
+	limit := p.config.HighCardinalityLimit
+	if p.config.UseDynamicThreshold {
+		return max(limit, maxDynamicThreshold)
+	}
+	return max(limit, p.config.ChildBranchThreshold)
+}
+
 // iterativelyUpdateParentNodes recalculates parent nodes for subgroups
 // This is the critical improvement that addresses variable->constant reclassification
 func (p *BrainParser) iterativelyUpdateParentNodes(tree *BidirectionalTree, node *Node, subGroupLogs []*LogMessage) {
@@ -604,6 +1012,16 @@ func (p *BrainParser) setConstantInParentWords(node *Node, parentPos int, consta
 
 // Helper functions for tree building
 
+// sortedKeys returns map keys in ascending order for deterministic iteration
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func getColumnWords(logs []*LogMessage) map[int][]Word {
 	columnWords := make(map[int][]Word)
 	if len(logs) == 0 {
@@ -620,12 +1038,17 @@ func getColumnWords(logs []*LogMessage) map[int][]Word {
 	return columnWords
 }
 
-func countUniqueWordsInColumn(logs []*LogMessage, position int) int {
-	unique := make(map[string]bool)
+// countUniqueWordsInColumn counts the distinct words at position, stopping
+// once limit words were found
+func countUniqueWordsInColumn(logs []*LogMessage, position, limit int) int {
+	seen := make(map[unique.Handle[string]]struct{})
 	for _, log := range logs {
 		if position < len(log.Words) {
-			unique[log.Words[position].Value.Value()] = true
+			seen[log.Words[position].Value] = struct{}{}
+			if len(seen) >= limit {
+				break
+			}
 		}
 	}
-	return len(unique)
+	return len(seen)
 }
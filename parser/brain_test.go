@@ -2,10 +2,14 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"reflect"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unique"
 )
 
@@ -810,3 +814,369 @@ func TestBrain_EnhancedFeaturesTuning(t *testing.T) {
 		}
 	}
 }
+
+// Test result buffer reuse with ParseInto and Release
+func TestBrain_ParseInto(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`})
+
+	logLines := []string{
+		"event A happened",
+		"event B happened",
+		"event C happened",
+		"task X finished",
+		"task Y finished",
+	}
+
+	expected := parser.Parse(logLines)
+
+	var buf Results
+	buf = parser.ParseInto(logLines, buf)
+	if len(buf) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(buf))
+	}
+
+	// Second call must reuse the same result structs
+	first := make(map[*ParseResult]bool, len(buf))
+	for _, res := range buf {
+		first[res] = true
+	}
+	buf = parser.ParseInto(logLines, buf)
+	for _, res := range buf {
+		if !first[res] {
+			t.Errorf("Expected result struct for %q to be reused", res.Template)
+		}
+	}
+
+	got := make(map[string]int)
+	for _, res := range buf {
+		got[res.Template] = res.Count
+		if len(res.LogIDs) != res.Count {
+			t.Errorf("Template %q: expected %d log IDs, got %d", res.Template, res.Count, len(res.LogIDs))
+		}
+	}
+	for _, res := range expected {
+		if got[res.Template] != res.Count {
+			t.Errorf("Template %q: expected count %d, got %d", res.Template, res.Count, got[res.Template])
+		}
+	}
+
+	// Smaller input shrinks the buffer and releases surplus entries
+	buf = parser.ParseInto(logLines[:1], buf)
+	if len(buf) != 1 || buf[0].Count != 1 {
+		t.Fatalf("Expected a single result with count 1, got %d results", len(buf))
+	}
+
+	buf.Release()
+	if buf[0] != nil {
+		t.Error("Expected Release to clear result entries")
+	}
+}
+
+func TestBrain_DeterministicSeed(t *testing.T) {
+	order := func(seed int64) []int {
+		parser := New(Config{Deterministic: true, Seed: seed})
+		positions := []int{0, 1, 2, 3, 4, 5, 6, 7}
+		sort.Slice(positions, func(i, j int) bool {
+			return parser.tieBreakKey(positions[i]) < parser.tieBreakKey(positions[j])
+		})
+		return positions
+	}
+	if got := order(0); !reflect.DeepEqual(got, []int{0, 1, 2, 3, 4, 5, 6, 7}) {
+		t.Errorf("Seed 0 must keep position order, got %v", got)
+	}
+	if !reflect.DeepEqual(order(42), order(42)) {
+		t.Error("Same seed produced different orders")
+	}
+	if reflect.DeepEqual(order(42), order(0)) && reflect.DeepEqual(order(7), order(0)) {
+		t.Error("Seeds do not permute the tie order")
+	}
+
+	var logLines []string
+	for i := range 40 {
+		logLines = append(logLines, fmt.Sprintf("user u%c opened file f%c", 'a'+rune(i%2), 'a'+rune(i%3)))
+	}
+	render := func(results []*ParseResult) string {
+		var sb strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&sb, "%s|%d|%v\n", r.Template, r.Count, r.LogIDs)
+		}
+		return sb.String()
+	}
+	config := Config{Delimiters: `\s+`, Deterministic: true, Seed: 42}
+	expected := render(New(config).Parse(logLines))
+	for range 5 {
+		if got := render(New(config).Parse(logLines)); got != expected {
+			t.Fatalf("Seeded run produced different output:\n%s\nvs\n%s", got, expected)
+		}
+	}
+}
+
+// Test that deterministic mode yields identical results across runs
+func TestBrain_DeterministicMode(t *testing.T) {
+	var logLines []string
+	for i := range 40 {
+		logLines = append(logLines,
+			fmt.Sprintf("user u%d opened file f%d mode %c", i%7, i%5, 'a'+rune(i%3)),
+			fmt.Sprintf("job j%d finished step s%d", i%4, i%6),
+		)
+	}
+
+	config := Config{
+		Delimiters:                  `\s+`,
+		Deterministic:               true,
+		ParallelProcessingThreshold: 10, // Exercise the parallel path as well
+	}
+
+	render := func(results []*ParseResult) string {
+		var sb strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&sb, "%s|%d|%v\n", r.Template, r.Count, r.LogIDs)
+		}
+		return sb.String()
+	}
+
+	expected := render(New(config).Parse(logLines))
+	for range 10 {
+		if got := render(New(config).Parse(logLines)); got != expected {
+			t.Fatalf("Deterministic mode produced different output:\n%s\nvs\n%s", got, expected)
+		}
+	}
+}
+
+// Test self-profiling data in parse reports
+func TestBrain_ParseWithReportProfiling(t *testing.T) {
+	logLines := []string{
+		"event A happened",
+		"event B happened",
+		"task X finished",
+	}
+
+	report := New(Config{Delimiters: `\s+`}).ParseWithReport(logLines)
+	if report.Profile != nil {
+		t.Error("Expected no profile when profiling is disabled")
+	}
+	if len(report.Results) == 0 {
+		t.Fatal("Expected results in report")
+	}
+
+	report = New(Config{Delimiters: `\s+`, EnableProfiling: true}).ParseWithReport(logLines)
+	if report.Profile == nil {
+		t.Fatal("Expected profile when profiling is enabled")
+	}
+
+	expectedPhases := []string{PhasePreprocess, PhaseGrouping, PhaseTrees, PhaseAggregation, PhaseFinalize}
+	if len(report.Profile.Phases) != len(expectedPhases) {
+		t.Fatalf("Expected %d phases, got %d", len(expectedPhases), len(report.Profile.Phases))
+	}
+	var sum time.Duration
+	for i, phase := range report.Profile.Phases {
+		if phase.Name != expectedPhases[i] {
+			t.Errorf("Expected phase %q at index %d, got %q", expectedPhases[i], i, phase.Name)
+		}
+		sum += phase.Duration
+	}
+	if report.Profile.Total < sum {
+		t.Errorf("Total %v is smaller than the sum of phases %v", report.Profile.Total, sum)
+	}
+	if report.Profile.PeakHeap == 0 {
+		t.Error("Expected peak heap to be recorded")
+	}
+}
+
+// Test that aggregation merges are recorded in the audit log
+func TestBrain_MergeAuditAggregation(t *testing.T) {
+	parser := New(Config{})
+	report := &ParseReport{}
+
+	results := parser.aggregateResultsInto([]*ParseResult{
+		{Template: "User <*> logged in", Count: 2, LogIDs: []int{0, 1}, origin: "User logged"},
+		{Template: "System started", Count: 1, LogIDs: []int{2}, origin: "System started"},
+		{Template: "User <*> logged in", Count: 3, LogIDs: []int{3, 4, 5}, origin: "in"},
+	}, nil, report)
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 aggregated results, got %d", len(results))
+	}
+	if len(report.MergeAudit) != 1 {
+		t.Fatalf("Expected 1 audit entry, got %d", len(report.MergeAudit))
+	}
+
+	entry := report.MergeAudit[0]
+	if entry.Reason != MergeReasonAggregation || entry.Result != "User <*> logged in" {
+		t.Errorf("Unexpected audit entry: %+v", entry)
+	}
+	if !reflect.DeepEqual(entry.Counts, []int{2, 3}) {
+		t.Errorf("Expected source counts [2 3], got %v", entry.Counts)
+	}
+	if !reflect.DeepEqual(entry.Sources, []string{"User logged", "in"}) {
+		t.Errorf("Expected the group patterns as sources, got %q", entry.Sources)
+	}
+}
+
+// Test that templates re-parsed with relaxed settings are audited with the
+// low-quality templates their lines came from
+func TestBrain_MergeAuditReparse(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`, UseEnhancedPostProcessing: true, MinContentWordsRatio: 0.9})
+	report := parser.ParseWithReport([]string{
+		"user alice logged in",
+		"user bob logged in",
+		"user carol logged in",
+	})
+
+	var reparse []MergeAuditEntry
+	for _, entry := range report.MergeAudit {
+		if entry.Reason == MergeReasonReparse {
+			reparse = append(reparse, entry)
+		}
+	}
+	if len(reparse) != 1 {
+		t.Fatalf("Expected 1 reparse audit entry, got %+v", report.MergeAudit)
+	}
+	entry := reparse[0]
+	if entry.Result != "user <*> logged in" || !reflect.DeepEqual(entry.Sources, []string{"user <*> logged in"}) ||
+		!reflect.DeepEqual(entry.Counts, []int{3}) {
+		t.Errorf("Unexpected reparse audit entry: %+v", entry)
+	}
+	if ids := report.Results[0].LogIDs; !reflect.DeepEqual(ids, []int{0, 1, 2}) {
+		t.Errorf("Expected re-parsed LogIDs of the input lines, got %v", ids)
+	}
+}
+
+// Test that stable partitioning routes groups consistently and matches
+// the output of deterministic mode
+func TestBrain_StablePartitioning(t *testing.T) {
+	group := &LogGroup{Pattern: LogPattern{
+		Words:     []Word{{Value: unique.Make("job"), Position: 0, Frequency: 4}},
+		Frequency: 4,
+	}}
+	worker := partitionWorker(group, 8)
+	for range 10 {
+		if got := partitionWorker(group, 8); got != worker || got < 0 || got >= 8 {
+			t.Fatalf("partitionWorker returned %d, expected stable %d", got, worker)
+		}
+	}
+
+	var logLines []string
+	for i := range 60 {
+		logLines = append(logLines,
+			fmt.Sprintf("user u%d opened file f%d mode %c", i%7, i%5, 'a'+rune(i%3)),
+			fmt.Sprintf("job j%d finished step s%d", i%4, i%6),
+			fmt.Sprintf("cache %d evicted", i),
+		)
+	}
+
+	render := func(results []*ParseResult) string {
+		var sb strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&sb, "%s|%d|%v\n", r.Template, r.Count, r.LogIDs)
+		}
+		return sb.String()
+	}
+
+	sequential := render(New(Config{Delimiters: `\s+`, Deterministic: true}).Parse(logLines))
+	// Stable partitioning implies the ordered traversal of deterministic
+	// mode and does not depend on the number of usable CPUs
+	config := Config{
+		Delimiters:                  `\s+`,
+		StablePartitioning:          true,
+		ParallelProcessingThreshold: 10,
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	for _, procs := range []int{1, 2, 4, 16} {
+		runtime.GOMAXPROCS(procs)
+		for range 3 {
+			if got := render(New(config).Parse(logLines)); got != sequential {
+				t.Fatalf("Stable partitioning output with GOMAXPROCS=%d differs from sequential:\n%s\nvs\n%s", procs, got, sequential)
+			}
+		}
+	}
+}
+
+func TestBrain_HighCardinalityLimit(t *testing.T) {
+	name := func(i int) string { // Letters only, not replaced as a common variable
+		return string([]byte{byte('a' + i/26%26), byte('a' + i%26)})
+	}
+	var logLines []string
+	for i := range 300 {
+		logLines = append(logLines, fmt.Sprintf("session opened for user %s from host %s", name(i), name(i%3)))
+	}
+
+	render := func(results []*ParseResult) string {
+		var sb strings.Builder
+		for _, r := range results {
+			fmt.Fprintf(&sb, "%s|%d|%v\n", r.Template, r.Count, r.LogIDs)
+		}
+		return sb.String()
+	}
+
+	full := New(Config{Delimiters: `\s+`, Deterministic: true, HighCardinalityLimit: 1 << 30}).ParseWithReport(logLines)
+	if len(full.Warnings) != 0 {
+		t.Fatalf("Expected no warnings without the shortcut, got %v", full.Warnings)
+	}
+
+	report := New(Config{Delimiters: `\s+`, Deterministic: true, HighCardinalityLimit: 20}).ParseWithReport(logLines)
+	if got, want := render(report.Results), render(full.Results); got != want {
+		t.Fatalf("Shortcut changed the templates:\n%s\nvs\n%s", got, want)
+	}
+	if len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "at least 20 distinct words") {
+		t.Fatalf("Expected one high-cardinality warning, got %v", report.Warnings)
+	}
+
+	// The limit is raised to the branch threshold
+	parser := New(Config{ChildBranchThreshold: 50, HighCardinalityLimit: 5})
+	if got := parser.highCardinalityLimit(); got != 50 {
+		t.Errorf("Expected limit raised to the threshold 50, got %d", got)
+	}
+	logs, _ := parser.preprocessor.preprocessWeightedLogs(logLines, nil)
+	if got := countUniqueWordsInColumn(logs, 4, 7); got != 7 {
+		t.Errorf("Expected counting to stop at 7, got %d", got)
+	}
+}
+
+func TestBrain_ConcurrentUse(t *testing.T) {
+	parser := New(Config{
+		Delimiters:                  `\s+`,
+		VariableStatistics:          true,
+		ApproximateMatch:            0.5,
+		ParallelProcessingThreshold: 10,
+	})
+	var logLines []string
+	for i := range 100 {
+		logLines = append(logLines,
+			fmt.Sprintf("user u%d logged in after %d ms", i%7, i),
+			fmt.Sprintf("cache %d evicted", i),
+		)
+	}
+
+	// Run with -race to detect unsynchronized access
+	const goroutines, calls = 8, 5
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var buffer Results
+			for i := range calls {
+				if (g+i)%2 == 0 {
+					parser.Parse(logLines)
+				} else {
+					buffer = parser.ParseInto(logLines, buffer)
+				}
+				parser.Match(logLines[i])
+				_ = parser.SaveState(io.Discard)
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for _, count := range parser.state.counts {
+		total += count
+	}
+	if want := goroutines * calls * len(logLines); total != want {
+		t.Errorf("Expected %d lines recorded, got %d", want, total)
+	}
+	if _, ok := parser.Match("cache 7 evicted"); !ok {
+		t.Error("Expected a learned template to match")
+	}
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"math"
+	"sort"
+)
+
+// LabelAlarm classifies how unusual the label cardinality of a template is.
+type LabelAlarm int
+
+const (
+	LabelAlarmNone   LabelAlarm = iota // Cardinality within the usual range
+	LabelAlarmBroad                    // Emitted by unusually many distinct sources
+	LabelAlarmNarrow                   // Emitted by unusually few distinct sources
+)
+
+// String returns the lower-case alarm name.
+func (a LabelAlarm) String() string {
+	switch a {
+	case LabelAlarmBroad:
+		return "broad"
+	case LabelAlarmNarrow:
+		return "narrow"
+	default:
+		return "none"
+	}
+}
+
+// LabelCardinalityOptions contains options for label cardinality analysis.
+type LabelCardinalityOptions struct {
+	Keys     []string // Label keys to analyze (default: all keys seen in labels)
+	Sigma    float64  // Z-score beyond which a template is flagged (default: 2.0)
+	MinCount int      // Templates with fewer lines are reported but never flagged (default: 2)
+}
+
+// LabelCardinality describes the distinct values of one label key among the
+// lines of one template.
+type LabelCardinality struct {
+	Template string
+	Key      string
+	Count    int        // Lines of the template
+	Distinct int        // Distinct label values among those lines
+	Ratio    float64    // Distinct divided by the distinct values of the key across all lines
+	ZScore   float64    // Deviation of Ratio from the mean over eligible templates, in standard deviations
+	Alarm    LabelAlarm // Classification of the deviation
+}
+
+// AnalyzeLabelCardinality computes per-template label cardinality from labels
+// indexed by log ID (e.g. host or pod extracted alongside each message) and
+// flags templates emitted by an unusually broad or narrow set of sources.
+// Lines without a value for a key are ignored for that key.
+func AnalyzeLabelCardinality(results []*ParseResult, labels []map[string]string, opts LabelCardinalityOptions) []LabelCardinality {
+	if opts.Sigma <= 0 {
+		opts.Sigma = 2.0
+	}
+	if opts.MinCount <= 0 {
+		opts.MinCount = 2
+	}
+
+	keys := opts.Keys
+	if len(keys) == 0 {
+		keys = labelKeys(labels)
+	}
+
+	var report []LabelCardinality
+	for _, key := range keys {
+		global := make(map[string]struct{})
+		for _, lineLabels := range labels {
+			if value, ok := lineLabels[key]; ok {
+				global[value] = struct{}{}
+			}
+		}
+		if len(global) == 0 {
+			continue
+		}
+
+		start := len(report)
+		for _, result := range results {
+			distinct := make(map[string]struct{})
+			for _, id := range result.LogIDs {
+				if id < 0 || id >= len(labels) {
+					continue
+				}
+				if value, ok := labels[id][key]; ok {
+					distinct[value] = struct{}{}
+				}
+			}
+			report = append(report, LabelCardinality{
+				Template: result.Template,
+				Key:      key,
+				Count:    result.Count,
+				Distinct: len(distinct),
+				Ratio:    float64(len(distinct)) / float64(len(global)),
+			})
+		}
+		flagLabelOutliers(report[start:], opts)
+	}
+	return report
+}
+
+// flagLabelOutliers scores the ratios of one key and sets alarms for outliers
+func flagLabelOutliers(entries []LabelCardinality, opts LabelCardinalityOptions) {
+	var sum, sumSq float64
+	n := 0
+	for _, entry := range entries {
+		if entry.Count >= opts.MinCount {
+			sum += entry.Ratio
+			sumSq += entry.Ratio * entry.Ratio
+			n++
+		}
+	}
+	if n < 2 {
+		return
+	}
+
+	mean := sum / float64(n)
+	stddev := math.Sqrt(math.Max(sumSq/float64(n)-mean*mean, 0))
+	if stddev == 0 {
+		return
+	}
+
+	for i := range entries {
+		entry := &entries[i]
+		entry.ZScore = (entry.Ratio - mean) / stddev
+		if entry.Count < opts.MinCount {
+			continue
+		}
+		switch {
+		case entry.ZScore >= opts.Sigma:
+			entry.Alarm = LabelAlarmBroad
+		case entry.ZScore <= -opts.Sigma:
+			entry.Alarm = LabelAlarmNarrow
+		}
+	}
+}
+
+// labelKeys returns all label keys in sorted order
+func labelKeys(labels []map[string]string) []string {
+	seen := make(map[string]struct{})
+	for _, lineLabels := range labels {
+		for key := range lineLabels {
+			seen[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
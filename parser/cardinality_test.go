@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+// buildLabeledResults creates one template per entry of hostsPerTemplate, each
+// with ten lines spread round-robin over that many hosts
+func buildLabeledResults(hostsPerTemplate []int) ([]*ParseResult, []map[string]string) {
+	var results []*ParseResult
+	var labels []map[string]string
+	for i, hosts := range hostsPerTemplate {
+		result := &ParseResult{Template: fmt.Sprintf("template %d <*>", i)}
+		for j := 0; j < 10; j++ {
+			result.LogIDs = append(result.LogIDs, len(labels))
+			labels = append(labels, map[string]string{"host": fmt.Sprintf("host-%d", j%hosts)})
+		}
+		result.Count = len(result.LogIDs)
+		results = append(results, result)
+	}
+	return results, labels
+}
+
+func TestAnalyzeLabelCardinalityBroad(t *testing.T) {
+	results, labels := buildLabeledResults([]int{2, 2, 2, 2, 2, 2, 2, 2, 10})
+	report := AnalyzeLabelCardinality(results, labels, LabelCardinalityOptions{})
+
+	if len(report) != len(results) {
+		t.Fatalf("expected %d entries, got %d", len(results), len(report))
+	}
+	for i, entry := range report {
+		expected := LabelAlarmNone
+		if i == len(report)-1 {
+			expected = LabelAlarmBroad
+		}
+		if entry.Key != "host" || entry.Alarm != expected {
+			t.Errorf("entry %d: key %q alarm %s (z=%.2f), want host/%s", i, entry.Key, entry.Alarm, entry.ZScore, expected)
+		}
+	}
+	if last := report[len(report)-1]; last.Distinct != 10 || last.Ratio != 1.0 {
+		t.Errorf("broad entry: distinct %d ratio %.2f, want 10/1.00", last.Distinct, last.Ratio)
+	}
+}
+
+func TestAnalyzeLabelCardinalityNarrow(t *testing.T) {
+	results, labels := buildLabeledResults([]int{10, 10, 10, 10, 10, 10, 10, 10, 1})
+	report := AnalyzeLabelCardinality(results, labels, LabelCardinalityOptions{Keys: []string{"host", "pod"}})
+
+	if len(report) != len(results) {
+		t.Fatalf("expected %d entries (missing key skipped), got %d", len(results), len(report))
+	}
+	if last := report[len(report)-1]; last.Alarm != LabelAlarmNarrow || last.Distinct != 1 {
+		t.Errorf("expected narrow alarm with 1 distinct host, got %s with %d", last.Alarm, last.Distinct)
+	}
+}
+
+func TestAnalyzeLabelCardinalityMinCount(t *testing.T) {
+	results, labels := buildLabeledResults([]int{2, 2, 2, 2, 2, 2, 2, 2, 10})
+	report := AnalyzeLabelCardinality(results, labels, LabelCardinalityOptions{MinCount: 11})
+	for _, entry := range report {
+		if entry.Alarm != LabelAlarmNone {
+			t.Errorf("template %q flagged despite MinCount", entry.Template)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package parser
+
+import (
+	"context"
+	_ "embed" // Embeds the catalog page
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultCatalogHistory is the number of count samples kept per template
+const defaultCatalogHistory = 60
+
+//go:embed catalog.html
+var catalogPage []byte
+
+// ExampleSource provides example log lines of a template.
+// ResultsSource implements this interface.
+type ExampleSource interface {
+	Examples(template string, n int) []string
+}
+
+// ResultsSource serves fixed parse results, e.g. of a finished Parse call,
+// as SnapshotSource and ExampleSource.
+type ResultsSource struct {
+	Results  []*ParseResult
+	LogLines []string // Lines the LogIDs of Results refer to (optional, enables examples)
+}
+
+// Snapshot returns the fixed results.
+func (rs *ResultsSource) Snapshot() []*ParseResult {
+	return rs.Results
+}
+
+// Examples returns up to n member lines of a template.
+func (rs *ResultsSource) Examples(template string, n int) []string {
+	var examples []string
+	for _, result := range rs.Results {
+		if result.Template != template {
+			continue
+		}
+		for _, id := range result.LogIDs {
+			if len(examples) >= n {
+				break
+			}
+			if id >= 0 && id < len(rs.LogLines) {
+				examples = append(examples, rs.LogLines[id])
+			}
+		}
+		break
+	}
+	return examples
+}
+
+// CatalogServer is an http.Handler serving a template catalog: an embedded
+// web page at "/", its data as JSON at "/api/templates" and the changes since
+// a sampled version at "/api/delta?since=N". Count trends and versions are
+// recorded by Run; example lines are shown if the source implements
+// ExampleSource.
+type CatalogServer struct {
+	source   SnapshotSource
+	interval time.Duration
+	mux      *http.ServeMux
+	deltas   *DeltaTracker
+
+	mu      sync.Mutex
+	history map[string][]int // Count samples per template, oldest first
+}
+
+// CatalogTemplate is one template entry of the catalog API.
+type CatalogTemplate struct {
+	ID       string   `json:"id,omitempty"`
+	Template string   `json:"template"`
+	Count    int      `json:"count"`
+	Severity string   `json:"severity"`
+	Trend    []int    `json:"trend"`
+	Examples []string `json:"examples,omitempty"`
+}
+
+// CatalogResponse is the body of the catalog API.
+type CatalogResponse struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	TotalLogs   int               `json:"total_logs"`
+	Templates   []CatalogTemplate `json:"templates"`
+}
+
+// NewCatalogServer creates a catalog for source sampling count trends every
+// interval (default: 10s).
+func NewCatalogServer(source SnapshotSource, interval time.Duration) *CatalogServer {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	cs := &CatalogServer{
+		source:   source,
+		interval: interval,
+		mux:      http.NewServeMux(),
+		history:  make(map[string][]int),
+		deltas:   NewDeltaTracker(source, defaultCatalogHistory),
+	}
+	cs.mux.HandleFunc("GET /{$}", cs.servePage)
+	cs.mux.HandleFunc("GET /api/templates", cs.serveTemplates)
+	cs.mux.HandleFunc("GET /api/delta", cs.serveDelta)
+	cs.Sample()
+	return cs
+}
+
+// Run samples template counts every interval until ctx is canceled.
+func (cs *CatalogServer) Run(ctx context.Context) {
+	ticker := time.NewTicker(cs.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.Sample()
+		}
+	}
+}
+
+// Sample records the current count of every template for trends and as a
+// new delta version if the state changed.
+func (cs *CatalogServer) Sample() {
+	cs.deltas.Update()
+	results := cs.source.Snapshot()
+
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	for _, result := range results {
+		samples := append(cs.history[result.Template], result.Count)
+		if len(samples) > defaultCatalogHistory {
+			samples = samples[len(samples)-defaultCatalogHistory:]
+		}
+		cs.history[result.Template] = samples
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (cs *CatalogServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cs.mux.ServeHTTP(w, r)
+}
+
+// servePage serves the embedded catalog page
+func (cs *CatalogServer) servePage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(catalogPage)
+}
+
+// serveTemplates serves the current templates with trends and examples
+func (cs *CatalogServer) serveTemplates(w http.ResponseWriter, _ *http.Request) {
+	results := cs.source.Snapshot()
+	examples, _ := cs.source.(ExampleSource)
+
+	response := CatalogResponse{
+		GeneratedAt: time.Now().UTC(),
+		Templates:   make([]CatalogTemplate, 0, len(results)),
+	}
+	cs.mu.Lock()
+	for _, result := range results {
+		response.TotalLogs += result.Count
+		entry := CatalogTemplate{
+			ID:       result.ID,
+			Template: result.Template,
+			Count:    result.Count,
+			Severity: result.Severity.String(),
+			Trend:    append([]int(nil), cs.history[result.Template]...),
+		}
+		if examples != nil {
+			entry.Examples = examples.Examples(result.Template, 3)
+		}
+		response.Templates = append(response.Templates, entry)
+	}
+	cs.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, "failed to encode templates", http.StatusInternalServerError)
+	}
+}
+
+// serveDelta serves the template changes since the sampled version in the
+// "since" query parameter (missing = complete state)
+func (cs *CatalogServer) serveDelta(w http.ResponseWriter, r *http.Request) {
+	var since uint64
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = strconv.ParseUint(value, 10, 64); err != nil {
+			http.Error(w, "invalid since version", http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cs.deltas.Since(since)); err != nil {
+		http.Error(w, "failed to encode delta", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCatalogServer(t *testing.T) {
+	source := &ResultsSource{
+		Results: []*ParseResult{
+			{Template: "User <*> logged in", Count: 2, LogIDs: []int{0, 1}},
+			{Template: "Disk full", Count: 1, LogIDs: []int{2}, Severity: SeverityError},
+		},
+		LogLines: []string{"User alice logged in", "User bob logged in", "Disk full"},
+	}
+	catalog := NewCatalogServer(source, 0)
+	source.Results[0].Count = 5
+	catalog.Sample()
+
+	server := httptest.NewServer(catalog)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("GET / failed: %v", err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "Template catalog") {
+		t.Errorf("Unexpected page response: %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/api/templates")
+	if err != nil {
+		t.Fatalf("GET /api/templates failed: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var catalogResp CatalogResponse
+	if err := json.NewDecoder(resp.Body).Decode(&catalogResp); err != nil {
+		t.Fatalf("Invalid catalog JSON: %v", err)
+	}
+
+	if catalogResp.TotalLogs != 6 || len(catalogResp.Templates) != 2 {
+		t.Fatalf("Expected 6 logs in 2 templates, got %+v", catalogResp)
+	}
+	user := catalogResp.Templates[0]
+	if !reflect.DeepEqual(user.Trend, []int{2, 5}) {
+		t.Errorf("Expected trend [2 5], got %v", user.Trend)
+	}
+	if !reflect.DeepEqual(user.Examples, []string{"User alice logged in", "User bob logged in"}) {
+		t.Errorf("Unexpected examples: %v", user.Examples)
+	}
+	if catalogResp.Templates[1].Severity != "error" {
+		t.Errorf("Expected error severity, got %q", catalogResp.Templates[1].Severity)
+	}
+
+	deltaResp, err := http.Get(server.URL + "/api/delta?since=1")
+	if err != nil {
+		t.Fatalf("GET /api/delta failed: %v", err)
+	}
+	var delta TemplateDelta
+	err = json.NewDecoder(deltaResp.Body).Decode(&delta)
+	_ = deltaResp.Body.Close()
+	if err != nil {
+		t.Fatalf("Invalid delta JSON: %v", err)
+	}
+	if delta.Version != 2 || delta.Full || len(delta.Increments) != 1 || delta.Increments[0].Count != 3 {
+		t.Errorf("Unexpected delta: %+v", delta)
+	}
+
+	badResp, err := http.Get(server.URL + "/api/delta?since=x")
+	if err != nil {
+		t.Fatalf("GET /api/delta failed: %v", err)
+	}
+	_ = badResp.Body.Close()
+	if badResp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid version, got %d", badResp.StatusCode)
+	}
+
+	resp404, err := http.Get(server.URL + "/missing")
+	if err != nil {
+		t.Fatalf("GET /missing failed: %v", err)
+	}
+	_ = resp404.Body.Close()
+	if resp404.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown path, got %d", resp404.StatusCode)
+	}
+}
@@ -0,0 +1,184 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Header field names of CEF and LEEF events in per-line metadata
+var (
+	cefHeaderFields  = []string{"version", "device_vendor", "device_product", "device_version", "event_id", "name", "severity"}
+	leefHeaderFields = []string{"version", "device_vendor", "device_product", "device_version", "event_id"}
+)
+
+// SecurityEvents is the content of a CEF or LEEF export read by
+// ReadSecurityEvents.
+type SecurityEvents struct {
+	Lines    []string            // Extensions (CEF) or attributes (LEEF) in input order
+	Fields   []map[string]string // Header fields per line, aligned with Lines (see ParseCEF and ParseLEEF)
+	Unparsed int                 // Lines that are no CEF or LEEF event, kept whole in Lines
+}
+
+// ReadSecurityEvents reads CEF (ArcSight Common Event Format) and LEEF (IBM
+// QRadar Log Event Extended Format) events, one per line and optionally with
+// a syslog header, as exported by firewalls and SIEMs. Only the extension or
+// attribute part of every event is returned for template mining; the header
+// fields are kept as metadata. Lines that are no CEF or LEEF event are kept
+// whole without fields; events without a message are skipped.
+func ReadSecurityEvents(reader io.Reader) (*SecurityEvents, error) {
+	events := &SecurityEvents{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		message, fields, ok := ParseCEF(line)
+		if !ok {
+			message, fields, ok = ParseLEEF(line)
+		}
+		if !ok {
+			events.Unparsed++
+			message, fields = line, map[string]string{}
+		}
+		if message = strings.TrimSpace(message); message == "" {
+			continue
+		}
+		events.Lines = append(events.Lines, message)
+		events.Fields = append(events.Fields, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading security events: %w", err)
+	}
+	return events, nil
+}
+
+// ParseCEF splits a CEF event ("CEF:Version|Device Vendor|Device Product|
+// Device Version|Device Event Class ID|Name|Severity|Extension") into its
+// extension, or the name if the extension is empty, and the header fields
+// format ("cef"), version, device_vendor, device_product, device_version,
+// event_id, name and severity. A syslog header before the event is parsed
+// with ParseSyslog and its fields are added; CEF fields take precedence. ok
+// is false if the line is no CEF event.
+func ParseCEF(line string) (message string, fields map[string]string, ok bool) {
+	event, fields, ok := cutSecurityEvent(line, "CEF:")
+	if !ok {
+		return "", nil, false
+	}
+	header, extension, ok := splitHeader(event, len(cefHeaderFields))
+	if !ok {
+		return "", nil, false
+	}
+	fields["format"] = "cef"
+	for i, name := range cefHeaderFields {
+		fields[name] = header[i]
+	}
+	if strings.TrimSpace(extension) == "" {
+		return header[5], fields, true
+	}
+	return extension, fields, true
+}
+
+// ParseLEEF splits a LEEF 1.0 ("LEEF:1.0|Vendor|Product|Version|EventID|
+// Attributes") or LEEF 2.0 event (with a delimiter field before the
+// attributes, a character or its hex code like x5E) into its attributes,
+// separated by tabs, and the header fields format ("leef"), version,
+// device_vendor, device_product, device_version and event_id. A syslog header
+// before the event is parsed with ParseSyslog and its fields are added; LEEF
+// fields take precedence. ok is false if the line is no LEEF event.
+func ParseLEEF(line string) (message string, fields map[string]string, ok bool) {
+	event, fields, ok := cutSecurityEvent(line, "LEEF:")
+	if !ok {
+		return "", nil, false
+	}
+	count := len(leefHeaderFields)
+	if !strings.HasPrefix(event, "1.") {
+		count++ // Delimiter field of LEEF 2.0
+	}
+	header, attributes, ok := splitHeader(event, count)
+	if !ok {
+		return "", nil, false
+	}
+	fields["format"] = "leef"
+	for i, name := range leefHeaderFields {
+		fields[name] = header[i]
+	}
+
+	if count > len(leefHeaderFields) {
+		delimiter, ok := leefDelimiter(header[count-1])
+		if !ok {
+			return "", nil, false
+		}
+		if delimiter != "\t" {
+			attributes = strings.ReplaceAll(attributes, delimiter, "\t")
+		}
+	}
+	return attributes, fields, true
+}
+
+// cutSecurityEvent returns the event starting with prefix and the fields of
+// a syslog header before it
+func cutSecurityEvent(line, prefix string) (string, map[string]string, bool) {
+	start := strings.Index(line, prefix)
+	if start < 0 {
+		return "", nil, false
+	}
+	fields := make(map[string]string)
+	if start > 0 {
+		message, syslogFields, ok := ParseSyslog(line)
+		if !ok || !strings.HasPrefix(message, prefix) {
+			return "", nil, false
+		}
+		fields = syslogFields
+		line = message
+		start = 0
+	}
+	return line[start+len(prefix):], fields, true
+}
+
+// splitHeader splits the first count pipe-separated header fields of event,
+// unescaping \| and \\, and returns them with the rest of the event
+func splitHeader(event string, count int) ([]string, string, bool) {
+	header := make([]string, 0, count)
+	var field strings.Builder
+	for i := 0; i < len(event); i++ {
+		switch {
+		case event[i] == '\\' && i+1 < len(event) && (event[i+1] == '|' || event[i+1] == '\\'):
+			i++
+			field.WriteByte(event[i])
+		case event[i] == '|':
+			header = append(header, strings.TrimSpace(field.String()))
+			field.Reset()
+			if len(header) == count {
+				return header, event[i+1:], true
+			}
+		default:
+			field.WriteByte(event[i])
+		}
+	}
+	return nil, "", false
+}
+
+// leefDelimiter decodes the delimiter field of LEEF 2.0 (empty = tab)
+func leefDelimiter(field string) (string, bool) {
+	switch {
+	case field == "":
+		return "\t", true
+	case len(field) == 1:
+		return field, true
+	}
+	lower := strings.ToLower(field)
+	hex, found := strings.CutPrefix(lower, "0x")
+	if !found {
+		hex, found = strings.CutPrefix(lower, "x")
+	}
+	code, err := strconv.ParseUint(hex, 16, 7) // ASCII only
+	if !found || err != nil || code == 0 {
+		return "", false
+	}
+	return string(rune(code)), true
+}
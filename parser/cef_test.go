@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCEF(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+		fields  map[string]string
+	}{
+		{
+			`CEF:0|Security|threatmanager|1.0|100|worm successfully stopped|10|src=10.0.0.1 dst=2.1.2.2 spt=1232`,
+			"src=10.0.0.1 dst=2.1.2.2 spt=1232",
+			map[string]string{"format": "cef", "version": "0", "device_vendor": "Security", "device_product": "threatmanager",
+				"device_version": "1.0", "event_id": "100", "name": "worm successfully stopped", "severity": "10"},
+		},
+		{
+			`CEF:0|Fortinet|FortiGate\|VM|7.0|1|Blocked \\ denied|5|`,
+			"Blocked \\ denied",
+			map[string]string{"format": "cef", "version": "0", "device_vendor": "Fortinet", "device_product": "FortiGate|VM",
+				"device_version": "7.0", "event_id": "1", "name": `Blocked \ denied`, "severity": "5"},
+		},
+		{
+			`Sep 19 08:26:10 fw1 CEF:0|Palo Alto|PAN-OS|10.1|THREAT|url|3|act=block request=http://a|b`,
+			"act=block request=http://a|b",
+			map[string]string{"timestamp": "Sep 19 08:26:10", "hostname": "fw1", "format": "cef", "version": "0",
+				"device_vendor": "Palo Alto", "device_product": "PAN-OS", "device_version": "10.1", "event_id": "THREAT",
+				"name": "url", "severity": "3"},
+		},
+	}
+	for _, tt := range tests {
+		message, fields, ok := ParseCEF(tt.line)
+		if !ok {
+			t.Errorf("ParseCEF(%q) not recognized", tt.line)
+			continue
+		}
+		if message != tt.message {
+			t.Errorf("ParseCEF(%q) message = %q, want %q", tt.line, message, tt.message)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("ParseCEF(%q) fields = %v, want %v", tt.line, fields, tt.fields)
+		}
+	}
+
+	for _, line := range []string{"User alice logged in", "CEF:0|Vendor|Product|1.0", "noise CEF:0|a|b|c|d|e|f|g"} {
+		if _, _, ok := ParseCEF(line); ok {
+			t.Errorf("ParseCEF(%q) recognized, want not", line)
+		}
+	}
+}
+
+func TestParseLEEF(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+		fields  map[string]string
+	}{
+		{
+			"LEEF:1.0|Microsoft|MSExchange|4.0 SP1|15345|src=192.0.2.0\tdst=172.50.123.1\tsev=5",
+			"src=192.0.2.0\tdst=172.50.123.1\tsev=5",
+			map[string]string{"format": "leef", "version": "1.0", "device_vendor": "Microsoft", "device_product": "MSExchange",
+				"device_version": "4.0 SP1", "event_id": "15345"},
+		},
+		{
+			"LEEF:2.0|Lancope|StealthWatch|1.0|41|^|src=10.0.1.8^dst=10.0.0.5^sev=5",
+			"src=10.0.1.8\tdst=10.0.0.5\tsev=5",
+			map[string]string{"format": "leef", "version": "2.0", "device_vendor": "Lancope", "device_product": "StealthWatch",
+				"device_version": "1.0", "event_id": "41"},
+		},
+		{
+			"LEEF:2.0|Vendor|Product|1.0|7|x7C|src=10.0.1.8|dst=10.0.0.5",
+			"src=10.0.1.8\tdst=10.0.0.5",
+			map[string]string{"format": "leef", "version": "2.0", "device_vendor": "Vendor", "device_product": "Product",
+				"device_version": "1.0", "event_id": "7"},
+		},
+	}
+	for _, tt := range tests {
+		message, fields, ok := ParseLEEF(tt.line)
+		if !ok {
+			t.Errorf("ParseLEEF(%q) not recognized", tt.line)
+			continue
+		}
+		if message != tt.message {
+			t.Errorf("ParseLEEF(%q) message = %q, want %q", tt.line, message, tt.message)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("ParseLEEF(%q) fields = %v, want %v", tt.line, fields, tt.fields)
+		}
+	}
+
+	for _, line := range []string{"LEEF:1.0|Vendor|Product", "LEEF:2.0|a|b|c|d|xZZ|src=1"} {
+		if _, _, ok := ParseLEEF(line); ok {
+			t.Errorf("ParseLEEF(%q) recognized, want not", line)
+		}
+	}
+}
+
+func TestReadSecurityEvents(t *testing.T) {
+	data := `CEF:0|Security|threatmanager|1.0|100|worm stopped|10|src=10.0.0.1 dst=2.1.2.2
+LEEF:1.0|Microsoft|MSExchange|4.0|15345|src=192.0.2.0	dst=172.50.123.1
+
+plain line
+CEF:0|Security|threatmanager|1.0|100||10|
+`
+	events, err := ReadSecurityEvents(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadSecurityEvents error: %v", err)
+	}
+	expectedLines := []string{"src=10.0.0.1 dst=2.1.2.2", "src=192.0.2.0\tdst=172.50.123.1", "plain line"}
+	if !reflect.DeepEqual(events.Lines, expectedLines) {
+		t.Errorf("Lines = %q, want %q", events.Lines, expectedLines)
+	}
+	if len(events.Fields) != 3 || events.Fields[0]["format"] != "cef" || events.Fields[1]["format"] != "leef" || len(events.Fields[2]) != 0 {
+		t.Errorf("Unexpected fields %v", events.Fields)
+	}
+	if events.Unparsed != 1 {
+		t.Errorf("Unparsed = %d, want 1", events.Unparsed)
+	}
+}
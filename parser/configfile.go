@@ -0,0 +1,457 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig reads a Config from a JSON, YAML or TOML file chosen by the
+// file extension (.yaml/.yml, .toml, anything else is read as JSON). All
+// formats use the keys of the JSON schema written by MarshalJSON, e.g.
+// child_branch_threshold or common_variables.
+//
+// YAML and TOML support the subset configurations need: comments, strings,
+// numbers, booleans, lists and one level of nested mappings or tables such
+// as common_variables. Quote regexes in YAML with single quotes so
+// backslashes are kept as-is.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var doc map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		doc, err = decodeYAMLConfig(string(data))
+	case ".toml":
+		doc, err = decodeTOMLConfig(string(data))
+	default:
+		var config Config
+		if err := json.Unmarshal(data, &config); err != nil {
+			return Config{}, fmt.Errorf("failed to decode config %s: %w", filepath.Base(path), err)
+		}
+		return config, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to decode config %s: %w", filepath.Base(path), err)
+	}
+
+	// Both formats map onto the JSON schema, which owns versioning and defaults
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to decode config %s: %w", filepath.Base(path), err)
+	}
+	var config Config
+	if err := json.Unmarshal(encoded, &config); err != nil {
+		return Config{}, fmt.Errorf("failed to decode config %s: %w", filepath.Base(path), err)
+	}
+	return config, nil
+}
+
+// configLine is a non-empty line of a YAML or TOML document without comment
+type configLine struct {
+	number int // 1-based line number
+	indent int
+	text   string
+}
+
+// splitConfigLines strips comments and blank lines
+func splitConfigLines(data string) ([]configLine, error) {
+	var lines []configLine
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripComment(raw), " \t")
+		trimmed := strings.TrimLeft(text, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if strings.Contains(text[:len(text)-len(trimmed)], "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		lines = append(lines, configLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	return lines, nil
+}
+
+// stripComment removes a '#' comment outside of quoted strings
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++ // Skip escaped character
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// splitFlow splits the items of a flow list or inline table body at commas
+// outside of quotes and brackets
+func splitFlow(body string) ([]string, error) {
+	var items []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(body[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return nil, fmt.Errorf("unterminated list %q", body)
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" {
+		items = append(items, last)
+	}
+	return items, nil
+}
+
+// unquote decodes a double-quoted string with escapes or a literal
+// single-quoted string, in which singleEscape (in YAML two single quotes)
+// stands for a quote
+func unquote(value string, singleEscape string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		inner := value[1 : len(value)-1]
+		if singleEscape != "" {
+			inner = strings.ReplaceAll(inner, singleEscape, "'")
+		}
+		return inner, nil
+	}
+	return "", fmt.Errorf("invalid quoted string %s", value)
+}
+
+// parseNumber parses an integer or finite float scalar. NaN and infinities
+// have no JSON encoding, so "nan" or "inf" are not numbers.
+func parseNumber(value string) (any, bool) {
+	plain := strings.ReplaceAll(value, "_", "")
+	if n, err := strconv.ParseInt(plain, 10, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(plain, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f, true
+	}
+	return nil, false
+}
+
+// decodeYAMLConfig decodes a block-style YAML mapping
+func decodeYAMLConfig(data string) (map[string]any, error) {
+	lines, err := splitConfigLines(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && lines[0].text == "---" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return map[string]any{}, nil
+	}
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].number)
+	}
+	doc, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("line %d: document is not a mapping", lines[0].number)
+	}
+	return doc, nil
+}
+
+// parseYAMLBlock parses the mapping or sequence starting at lines[i] with the
+// given indentation and returns it with the index of the following line
+func parseYAMLBlock(lines []configLine, i, indent int) (any, int, error) {
+	if isYAMLItem(lines[i].text) {
+		var items []any
+		for i < len(lines) && lines[i].indent == indent && isYAMLItem(lines[i].text) {
+			rest := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+			if rest == "" {
+				return nil, 0, fmt.Errorf("line %d: nested sequence items are not supported", lines[i].number)
+			}
+			item, err := parseYAMLScalar(rest, lines[i].number)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			i++
+		}
+		return items, i, nil
+	}
+
+	mapping := make(map[string]any)
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		key, value, err := splitYAMLKey(line)
+		if err != nil {
+			return nil, 0, err
+		}
+		if _, ok := mapping[key]; ok {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.number, key)
+		}
+		i++
+
+		switch {
+		case value != "":
+			if mapping[key], err = parseYAMLScalar(value, line.number); err != nil {
+				return nil, 0, err
+			}
+		case i < len(lines) && (lines[i].indent > indent || lines[i].indent == indent && isYAMLItem(lines[i].text)):
+			if mapping[key], i, err = parseYAMLBlock(lines, i, lines[i].indent); err != nil {
+				return nil, 0, err
+			}
+		default:
+			mapping[key] = nil
+		}
+	}
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].number)
+	}
+	return mapping, i, nil
+}
+
+// isYAMLItem reports whether a line is a sequence item
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits a "key: value" line
+func splitYAMLKey(line configLine) (string, string, error) {
+	text := line.text
+	if text[0] == '"' || text[0] == '\'' {
+		end := strings.IndexByte(text[1:], text[0])
+		if end < 0 || !strings.HasPrefix(text[end+2:], ":") {
+			return "", "", fmt.Errorf("line %d: invalid key", line.number)
+		}
+		key, err := unquote(text[:end+2], "''")
+		return key, strings.TrimSpace(text[end+3:]), err
+	}
+
+	colon := strings.Index(text, ": ")
+	if colon < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", fmt.Errorf("line %d: expected \"key: value\"", line.number)
+		}
+		colon = len(text) - 1
+	}
+	return strings.TrimSpace(text[:colon]), strings.TrimSpace(text[colon+1:]), nil
+}
+
+// parseYAMLScalar parses a scalar or flow sequence value
+func parseYAMLScalar(value string, number int) (any, error) {
+	switch {
+	case value[0] == '"' || value[0] == '\'':
+		s, err := unquote(value, "''")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		return s, nil
+	case value[0] == '[':
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("line %d: multi-line flow sequences are not supported", number)
+		}
+		parts, err := splitFlow(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		items := make([]any, len(parts))
+		for i, part := range parts {
+			if items[i], err = parseYAMLScalar(part, number); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case value == "{}":
+		return map[string]any{}, nil
+	case value[0] == '{' || value[0] == '|' || value[0] == '>' || value[0] == '&' || value[0] == '*':
+		return nil, fmt.Errorf("line %d: unsupported YAML syntax %q", number, value)
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	case value == "null" || value == "~":
+		return nil, nil
+	}
+	if n, ok := parseNumber(value); ok {
+		return n, nil
+	}
+	return value, nil
+}
+
+// decodeTOMLConfig decodes a TOML document of key/value pairs and tables
+func decodeTOMLConfig(data string) (map[string]any, error) {
+	lines, err := splitConfigLines(data)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(map[string]any)
+	table := doc
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line.text, "[") && !strings.HasPrefix(line.text, "[[") {
+			if !strings.HasSuffix(line.text, "]") {
+				return nil, fmt.Errorf("line %d: invalid table header", line.number)
+			}
+			name, err := parseTOMLKey(strings.TrimSpace(line.text[1:len(line.text)-1]), line.number)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc[name]; ok {
+				return nil, fmt.Errorf("line %d: duplicate table %q", line.number, name)
+			}
+			table = make(map[string]any)
+			doc[name] = table
+			continue
+		}
+
+		key, value, ok := strings.Cut(line.text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", line.number)
+		}
+		name, err := parseTOMLKey(strings.TrimSpace(key), line.number)
+		if err != nil {
+			return nil, err
+		}
+		value = strings.TrimSpace(value)
+
+		// Arrays may span several lines
+		for strings.HasPrefix(value, "[") && !tomlBalanced(value) && i+1 < len(lines) {
+			i++
+			value += " " + lines[i].text
+		}
+
+		if _, ok := table[name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.number, name)
+		}
+		if table[name], err = parseTOMLValue(value, line.number); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// parseTOMLKey parses a bare or quoted key
+func parseTOMLKey(key string, number int) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("line %d: empty key", number)
+	}
+	if key[0] == '"' || key[0] == '\'' {
+		name, err := unquote(key, "")
+		if err != nil {
+			return "", fmt.Errorf("line %d: %w", number, err)
+		}
+		return name, nil
+	}
+	if strings.ContainsAny(key, ". \t") {
+		return "", fmt.Errorf("line %d: dotted keys are not supported: %q", number, key)
+	}
+	return key, nil
+}
+
+// tomlBalanced reports whether all brackets of an array value are closed
+func tomlBalanced(value string) bool {
+	_, err := splitFlow(value)
+	return err == nil
+}
+
+// parseTOMLValue parses a string, number, boolean, array or inline table
+func parseTOMLValue(value string, number int) (any, error) {
+	if value == "" {
+		return nil, fmt.Errorf("line %d: missing value", number)
+	}
+	switch {
+	case strings.HasPrefix(value, `"""`) || strings.HasPrefix(value, "'''"):
+		return nil, fmt.Errorf("line %d: multi-line strings are not supported", number)
+	case value[0] == '"' || value[0] == '\'':
+		s, err := unquote(value, "")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		return s, nil
+	case value[0] == '[' || value[0] == '{':
+		closing := map[byte]byte{'[': ']', '{': '}'}[value[0]]
+		if value[len(value)-1] != closing {
+			return nil, fmt.Errorf("line %d: unterminated value %q", number, value)
+		}
+		parts, err := splitFlow(value[1 : len(value)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		if value[0] == '[' {
+			items := make([]any, len(parts))
+			for i, part := range parts {
+				if items[i], err = parseTOMLValue(part, number); err != nil {
+					return nil, err
+				}
+			}
+			return items, nil
+		}
+		table := make(map[string]any, len(parts))
+		for _, part := range parts {
+			key, item, ok := strings.Cut(part, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected \"key = value\" in inline table", number)
+			}
+			name, err := parseTOMLKey(strings.TrimSpace(key), number)
+			if err != nil {
+				return nil, err
+			}
+			if table[name], err = parseTOMLValue(strings.TrimSpace(item), number); err != nil {
+				return nil, err
+			}
+		}
+		return table, nil
+	case value == "true":
+		return true, nil
+	case value == "false":
+		return false, nil
+	}
+	if n, ok := parseNumber(value); ok {
+		return n, nil
+	}
+	if isNonFinite(value) {
+		return value, nil // Kept as a string, like in YAML
+	}
+	return nil, fmt.Errorf("line %d: invalid value %q", number, value)
+}
+
+// isNonFinite reports whether a TOML scalar is nan or inf with optional sign
+func isNonFinite(value string) bool {
+	switch strings.TrimLeft(value, "+-") {
+	case "nan", "inf":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	expected := Config{
+		Delimiters:           `[\s,:=]+`,
+		ChildBranchThreshold: 4,
+		UseDynamicThreshold:  true,
+		EntropyThreshold:     0.9,
+		IgnorePositions:      []int{0, 1},
+		TemplateDenyPatterns: []string{"^DEBUG", "heartbeat # keep"},
+		CommonVariables: map[string]string{
+			"ticket": `^[A-Z]+-\d+$`,
+			"pod":    `^pod-[a-z0-9]+$`,
+		},
+	}
+
+	files := map[string]string{
+		"config.yaml": `# Brain configuration
+version: 1
+delimiters: '[\s,:=]+'
+child_branch_threshold: 4
+use_dynamic_threshold: true
+entropy_threshold: 0.9   # more conservative
+ignore_positions: [0, 1]
+template_deny_patterns:
+  - "^DEBUG"
+  - 'heartbeat # keep'
+common_variables:
+  ticket: '^[A-Z]+-\d+$'
+  pod: "^pod-[a-z0-9]+$"
+`,
+		"config.toml": `# Brain configuration
+version = 1
+delimiters = '[\s,:=]+'
+child_branch_threshold = 4
+use_dynamic_threshold = true
+entropy_threshold = 0.9 # more conservative
+ignore_positions = [0, 1]
+template_deny_patterns = [
+  "^DEBUG",
+  'heartbeat # keep',
+]
+
+[common_variables]
+ticket = '^[A-Z]+-\d+$'
+"pod" = "^pod-[a-z0-9]+$"
+`,
+		"config.json": `{"version":1,"delimiters":"[\\s,:=]+","child_branch_threshold":4,"use_dynamic_threshold":true,` +
+			`"entropy_threshold":0.9,"ignore_positions":[0,1],"template_deny_patterns":["^DEBUG","heartbeat # keep"],` +
+			`"common_variables":{"ticket":"^[A-Z]+-\\d+$","pod":"^pod-[a-z0-9]+$"}}`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Errorf("%s: LoadConfig failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(config, expected) {
+			t.Errorf("%s: got %+v, want %+v", name, config, expected)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	files := map[string]string{
+		"tabs.yaml":      "common_variables:\n\tpod: x\n",
+		"block.yaml":     "delimiters: |\n  \\s+\n",
+		"type.yaml":      "child_branch_threshold: many\n",
+		"dotted.toml":    "common_variables.pod = 'x'\n",
+		"unclosed.toml":  "ignore_positions = [0, 1\n",
+		"duplicate.toml": "weight = 0.1\nweight = 0.2\n",
+		"version.yaml":   "version: 99\n",
+		"nan.yaml":       "weight: nan\n",
+		"inf.toml":       "weight = -inf\n",
+		"invalid.json":   `{"weight":`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := LoadConfig(path)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		} else if !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected the file name in the error, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadConfigNonFinite(t *testing.T) {
+	// NaN and infinities have no JSON encoding and are kept as strings
+	files := map[string]string{
+		"config.yaml": "template_deny_patterns: [nan, inf, -inf]\n",
+		"config.toml": "template_deny_patterns = [nan, inf, -inf]\n",
+	}
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			t.Errorf("%s: LoadConfig failed: %v", name, err)
+			continue
+		}
+		if expected := []string{"nan", "inf", "-inf"}; !reflect.DeepEqual(config.TemplateDenyPatterns, expected) {
+			t.Errorf("%s: got %q, want %q", name, config.TemplateDenyPatterns, expected)
+		}
+	}
+}
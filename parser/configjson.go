@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ConfigSchemaVersion is the version of the Config JSON schema written by
+// MarshalJSON.
+//
+// Compatibility rules:
+//   - Adding a field does not change the version. Older packages ignore
+//     unknown fields, newer packages leave missing fields at their zero value,
+//     which New replaces with the default.
+//   - Renaming, removing or changing the meaning of a field increments the
+//     version. UnmarshalJSON upgrades documents of every older version and
+//     rejects documents of a newer version with ErrConfigVersion.
+//   - Documents without a version are treated as version 1.
+const ConfigSchemaVersion = 1
+
+// ErrConfigVersion is returned when a Config document was written by a newer,
+// incompatible schema version.
+var ErrConfigVersion = errors.New("unsupported config schema version")
+
+// configJSON is the serialized form of Config
+type configJSON struct {
+	Version                     int                `json:"version"`
+	Delimiters                  string             `json:"delimiters,omitempty"`
+	CommonVariables             *map[string]string `json:"common_variables,omitempty"`
+	Placeholders                *map[string]string `json:"placeholders,omitempty"`
+	ChildBranchThreshold        int                `json:"child_branch_threshold,omitempty"`
+	Weight                      float64            `json:"weight,omitempty"`
+	UseDynamicThreshold         bool               `json:"use_dynamic_threshold,omitempty"`
+	DynamicThresholdFactor      float64            `json:"dynamic_threshold_factor,omitempty"`
+	UseEnhancedPostProcessing   bool               `json:"use_enhanced_post_processing,omitempty"`
+	UseStatisticalThreshold     bool               `json:"use_statistical_threshold,omitempty"`
+	ParallelProcessingThreshold int                `json:"parallel_processing_threshold,omitempty"`
+	LCPTieBreak                 string             `json:"lcp_tie_break,omitempty"`
+	ContiguityBonus             float64            `json:"contiguity_bonus,omitempty"`
+	FrequencyTolerance          float64            `json:"frequency_tolerance,omitempty"`
+	IgnorePositions             *[]int             `json:"ignore_positions,omitempty"`
+	IgnoreTokenPatterns         *[]string          `json:"ignore_token_patterns,omitempty"`
+	Deterministic               bool               `json:"deterministic,omitempty"`
+	Seed                        int64              `json:"seed,omitempty"`
+	EnableProfiling             bool               `json:"enable_profiling,omitempty"`
+	MaxInitialGroups            int                `json:"max_initial_groups,omitempty"`
+	HighCardinalityLimit        int                `json:"high_cardinality_limit,omitempty"`
+	UnicodeDigits               bool               `json:"unicode_digits,omitempty"`
+	FoldUnicode                 bool               `json:"fold_unicode,omitempty"`
+	TemplatePositions           bool               `json:"template_positions,omitempty"`
+	VariableStatistics          bool               `json:"variable_statistics,omitempty"`
+	AnomalyThreshold            float64            `json:"anomaly_threshold,omitempty"`
+	ApproximateMatch            float64            `json:"approximate_match,omitempty"`
+	TemplateAllowPatterns       *[]string          `json:"template_allow_patterns,omitempty"`
+	TemplateDenyPatterns        *[]string          `json:"template_deny_patterns,omitempty"`
+	StablePartitioning          bool               `json:"stable_partitioning,omitempty"`
+	PruneConstantColumns        bool               `json:"prune_constant_columns,omitempty"`
+	MergeSubsumedTemplates      bool               `json:"merge_subsumed_templates,omitempty"`
+	VariableLengthTokens        int                `json:"variable_length_tokens,omitempty"`
+	ExamplesPerTemplate         int                `json:"examples_per_template,omitempty"`
+	QualityPolicy               string             `json:"quality_policy,omitempty"`
+	EntropyThreshold            float64            `json:"entropy_threshold,omitempty"`
+	MinEntropyLength            int                `json:"min_entropy_length,omitempty"`
+	MaxConsecutiveWildcards     int                `json:"max_consecutive_wildcards,omitempty"`
+	MinContentWordsRatio        float64            `json:"min_content_words_ratio,omitempty"`
+	TimestampMinDigits          int                `json:"timestamp_min_digits,omitempty"`
+	TimestampMinSeparators      int                `json:"timestamp_min_separators,omitempty"`
+}
+
+var tieBreakNames = map[TieBreakStrategy]string{
+	TieBreakLength:     "length",
+	TieBreakContiguity: "contiguity",
+	TieBreakFrequency:  "frequency",
+}
+
+// MarshalJSON encodes the configuration together with ConfigSchemaVersion.
+// Zero-valued fields are omitted so they keep meaning "use the default";
+// empty but non-nil maps and slices are kept, e.g. CommonVariables disabling
+// masking.
+func (c Config) MarshalJSON() ([]byte, error) {
+	tieBreak, ok := tieBreakNames[c.LCPTieBreak]
+	if !ok {
+		return nil, fmt.Errorf("unknown tie-break strategy %d", c.LCPTieBreak)
+	}
+	if c.LCPTieBreak == TieBreakLength {
+		tieBreak = ""
+	}
+
+	return json.Marshal(configJSON{
+		Version:                     ConfigSchemaVersion,
+		Delimiters:                  c.Delimiters,
+		CommonVariables:             mapRef(c.CommonVariables),
+		Placeholders:                mapRef(c.Placeholders),
+		ChildBranchThreshold:        c.ChildBranchThreshold,
+		Weight:                      c.Weight,
+		UseDynamicThreshold:         c.UseDynamicThreshold,
+		DynamicThresholdFactor:      c.DynamicThresholdFactor,
+		UseEnhancedPostProcessing:   c.UseEnhancedPostProcessing,
+		UseStatisticalThreshold:     c.UseStatisticalThreshold,
+		ParallelProcessingThreshold: c.ParallelProcessingThreshold,
+		LCPTieBreak:                 tieBreak,
+		ContiguityBonus:             c.ContiguityBonus,
+		FrequencyTolerance:          c.FrequencyTolerance,
+		IgnorePositions:             sliceRef(c.IgnorePositions),
+		IgnoreTokenPatterns:         sliceRef(c.IgnoreTokenPatterns),
+		Deterministic:               c.Deterministic,
+		Seed:                        c.Seed,
+		EnableProfiling:             c.EnableProfiling,
+		MaxInitialGroups:            c.MaxInitialGroups,
+		HighCardinalityLimit:        c.HighCardinalityLimit,
+		UnicodeDigits:               c.UnicodeDigits,
+		FoldUnicode:                 c.FoldUnicode,
+		TemplatePositions:           c.TemplatePositions,
+		VariableStatistics:          c.VariableStatistics,
+		AnomalyThreshold:            c.AnomalyThreshold,
+		ApproximateMatch:            c.ApproximateMatch,
+		TemplateAllowPatterns:       sliceRef(c.TemplateAllowPatterns),
+		TemplateDenyPatterns:        sliceRef(c.TemplateDenyPatterns),
+		StablePartitioning:          c.StablePartitioning,
+		PruneConstantColumns:        c.PruneConstantColumns,
+		MergeSubsumedTemplates:      c.MergeSubsumedTemplates,
+		VariableLengthTokens:        c.VariableLengthTokens,
+		ExamplesPerTemplate:         c.ExamplesPerTemplate,
+		QualityPolicy:               c.QualityPolicy,
+		EntropyThreshold:            c.EntropyThreshold,
+		MinEntropyLength:            c.MinEntropyLength,
+		MaxConsecutiveWildcards:     c.MaxConsecutiveWildcards,
+		MinContentWordsRatio:        c.MinContentWordsRatio,
+		TimestampMinDigits:          c.TimestampMinDigits,
+		TimestampMinSeparators:      c.TimestampMinSeparators,
+	})
+}
+
+// UnmarshalJSON decodes a configuration written by MarshalJSON of this or an
+// older package version. Unknown fields are ignored.
+func (c *Config) UnmarshalJSON(data []byte) error {
+	var doc configJSON
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to decode config: %w", err)
+	}
+	if doc.Version == 0 {
+		doc.Version = 1
+	}
+	if doc.Version > ConfigSchemaVersion {
+		return fmt.Errorf("%w: %d (supported up to %d)", ErrConfigVersion, doc.Version, ConfigSchemaVersion)
+	}
+
+	tieBreak := TieBreakLength
+	if doc.LCPTieBreak != "" {
+		found := false
+		for strategy, name := range tieBreakNames {
+			if name == doc.LCPTieBreak {
+				tieBreak, found = strategy, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown tie-break strategy %q", doc.LCPTieBreak)
+		}
+	}
+
+	*c = Config{
+		Delimiters:                  doc.Delimiters,
+		CommonVariables:             fieldValue(doc.CommonVariables),
+		Placeholders:                fieldValue(doc.Placeholders),
+		ChildBranchThreshold:        doc.ChildBranchThreshold,
+		Weight:                      doc.Weight,
+		UseDynamicThreshold:         doc.UseDynamicThreshold,
+		DynamicThresholdFactor:      doc.DynamicThresholdFactor,
+		UseEnhancedPostProcessing:   doc.UseEnhancedPostProcessing,
+		UseStatisticalThreshold:     doc.UseStatisticalThreshold,
+		ParallelProcessingThreshold: doc.ParallelProcessingThreshold,
+		LCPTieBreak:                 tieBreak,
+		ContiguityBonus:             doc.ContiguityBonus,
+		FrequencyTolerance:          doc.FrequencyTolerance,
+		IgnorePositions:             fieldValue(doc.IgnorePositions),
+		IgnoreTokenPatterns:         fieldValue(doc.IgnoreTokenPatterns),
+		Deterministic:               doc.Deterministic,
+		Seed:                        doc.Seed,
+		EnableProfiling:             doc.EnableProfiling,
+		MaxInitialGroups:            doc.MaxInitialGroups,
+		HighCardinalityLimit:        doc.HighCardinalityLimit,
+		UnicodeDigits:               doc.UnicodeDigits,
+		FoldUnicode:                 doc.FoldUnicode,
+		TemplatePositions:           doc.TemplatePositions,
+		VariableStatistics:          doc.VariableStatistics,
+		AnomalyThreshold:            doc.AnomalyThreshold,
+		ApproximateMatch:            doc.ApproximateMatch,
+		TemplateAllowPatterns:       fieldValue(doc.TemplateAllowPatterns),
+		TemplateDenyPatterns:        fieldValue(doc.TemplateDenyPatterns),
+		StablePartitioning:          doc.StablePartitioning,
+		PruneConstantColumns:        doc.PruneConstantColumns,
+		MergeSubsumedTemplates:      doc.MergeSubsumedTemplates,
+		VariableLengthTokens:        doc.VariableLengthTokens,
+		ExamplesPerTemplate:         doc.ExamplesPerTemplate,
+		QualityPolicy:               doc.QualityPolicy,
+		EntropyThreshold:            doc.EntropyThreshold,
+		MinEntropyLength:            doc.MinEntropyLength,
+		MaxConsecutiveWildcards:     doc.MaxConsecutiveWildcards,
+		MinContentWordsRatio:        doc.MinContentWordsRatio,
+		TimestampMinDigits:          doc.TimestampMinDigits,
+		TimestampMinSeparators:      doc.TimestampMinSeparators,
+	}
+	return nil
+}
+
+// mapRef returns a pointer to a map that is not nil, so an empty map is
+// encoded instead of being omitted like an unset one
+func mapRef[M ~map[K]V, K comparable, V any](m M) *M {
+	if m == nil {
+		return nil
+	}
+	return &m
+}
+
+// sliceRef returns a pointer to a slice that is not nil, like mapRef
+func sliceRef[S ~[]E, E any](s S) *S {
+	if s == nil {
+		return nil
+	}
+	return &s
+}
+
+// fieldValue returns the value of a decoded optional field, nil if it was missing
+func fieldValue[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
+
+// ReadConfig reads a JSON configuration from r. LoadConfig reads a
+// configuration file.
+func ReadConfig(r io.Reader) (Config, error) {
+	var config Config
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	return config, nil
+}
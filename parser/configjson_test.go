@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigJSONRoundTrip(t *testing.T) {
+	config := Config{
+		Delimiters:             `[\s,;]+`,
+		CommonVariables:        map[string]string{"ip": `\d+\.\d+\.\d+\.\d+`},
+		Placeholders:           map[string]string{"ip": "<IP>"},
+		ChildBranchThreshold:   4,
+		UseDynamicThreshold:    true,
+		DynamicThresholdFactor: 1.5,
+		LCPTieBreak:            TieBreakContiguity,
+		IgnorePositions:        []int{0, 2},
+		IgnoreTokenPatterns:    []string{`^tid-`},
+		Deterministic:          true,
+		MaxInitialGroups:       100,
+		EntropyThreshold:       0.7,
+		isReparsing:            true,
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if !strings.Contains(string(data), `"version":1`) || !strings.Contains(string(data), `"lcp_tie_break":"contiguity"`) {
+		t.Errorf("unexpected encoding: %s", data)
+	}
+
+	var decoded Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	config.isReparsing = false // Internal state is not serialized
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", decoded, config)
+	}
+}
+
+func TestConfigJSONCompatibility(t *testing.T) {
+	// Unversioned documents and unknown (newer, additive) fields are accepted
+	config, err := ReadConfig(strings.NewReader(`{"child_branch_threshold":5,"future_option":true}`))
+	if err != nil {
+		t.Fatalf("ReadConfig error: %v", err)
+	}
+	if config.ChildBranchThreshold != 5 || config.LCPTieBreak != TieBreakLength {
+		t.Errorf("unexpected config: %+v", config)
+	}
+
+	// Missing fields are filled with defaults by New
+	if parser := New(config); parser.config.DynamicThresholdFactor != 2.0 {
+		t.Errorf("expected default dynamic factor, got %v", parser.config.DynamicThresholdFactor)
+	}
+
+	_, err = ReadConfig(strings.NewReader(`{"version":99}`))
+	if !errors.Is(err, ErrConfigVersion) {
+		t.Errorf("expected ErrConfigVersion, got %v", err)
+	}
+
+	_, err = ReadConfig(strings.NewReader(`{"lcp_tie_break":"random"}`))
+	if err == nil {
+		t.Error("expected error for unknown tie-break strategy")
+	}
+}
+
+func TestConfigJSONEmptyMaps(t *testing.T) {
+	// An empty CommonVariables map disables masking and must survive a round
+	// trip instead of turning into nil, which New replaces with the defaults
+	config := Config{Delimiters: `\s+`, CommonVariables: map[string]string{}, IgnoreTokenPatterns: []string{}}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	if !strings.Contains(string(data), `"common_variables":{}`) {
+		t.Errorf("Expected an explicit empty common_variables, got %s", data)
+	}
+
+	var decoded Config
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if decoded.CommonVariables == nil || len(decoded.CommonVariables) != 0 || decoded.IgnoreTokenPatterns == nil {
+		t.Errorf("Expected empty non-nil map and slice, got %#v and %#v", decoded.CommonVariables, decoded.IgnoreTokenPatterns)
+	}
+	if decoded.Placeholders != nil {
+		t.Errorf("Unset maps must stay nil, got %#v", decoded.Placeholders)
+	}
+	if parser := New(decoded); len(parser.config.CommonVariables) != 0 {
+		t.Errorf("Expected masking to stay disabled, got %d patterns", len(parser.config.CommonVariables))
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseContext(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 100; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+
+	p := New(Config{Delimiters: `\s+`})
+	results, err := p.ParseContext(context.Background(), logLines)
+	if err != nil || len(results) != 1 || results[0].Count != 100 {
+		t.Fatalf("Unexpected results %v, %v", results, err)
+	}
+
+	for _, parallel := range []int{1000, 10} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p := New(Config{Delimiters: `\s+`, ParallelProcessingThreshold: parallel})
+		results, err := p.ParseContext(ctx, logLines)
+		if !errors.Is(err, context.Canceled) || results != nil {
+			t.Errorf("parallel=%d: expected cancellation, got %v, %v", parallel, results, err)
+		}
+		if _, ok := p.Match("User alice logged in"); ok {
+			t.Errorf("parallel=%d: canceled parse must not record templates", parallel)
+		}
+	}
+
+	// Resumed parsers honor cancellation too
+	p.state.resume = true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.ParseContext(ctx, logLines); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation of resumed parse, got %v", err)
+	}
+}
+
+func TestParseReportContext(t *testing.T) {
+	var logLines []string
+	weights := make([]int, 0, 100)
+	for i := 0; i < 100; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+		weights = append(weights, 2)
+	}
+	parses := map[string]func(p *BrainParser, ctx context.Context) (*ParseReport, error){
+		"report": func(p *BrainParser, ctx context.Context) (*ParseReport, error) {
+			return p.ParseWithReportContext(ctx, logLines)
+		},
+		"weighted": func(p *BrainParser, ctx context.Context) (*ParseReport, error) {
+			return p.ParseWeightedContext(ctx, logLines, weights)
+		},
+		"params": func(p *BrainParser, ctx context.Context) (*ParseReport, error) {
+			return p.ParseWithParamsContext(ctx, logLines)
+		},
+		"two-pass": func(p *BrainParser, ctx context.Context) (*ParseReport, error) {
+			return p.ParseTwoPassContext(ctx, logLines, TwoPassOptions{SampleSize: 30})
+		},
+	}
+
+	for name, parse := range parses {
+		var progressed int
+		p := New(Config{Delimiters: `\s+`, EnableProfiling: true})
+		p.OnProgress(func(processed, total int, _ string) { progressed = processed })
+		report, err := parse(p, context.Background())
+		if err != nil || len(report.Results) != 1 {
+			t.Fatalf("%s: unexpected report %v, %v", name, report, err)
+		}
+		if name != "two-pass" && report.Profile == nil {
+			t.Errorf("%s: expected the profile in the report", name)
+		}
+		if name == "params" && len(report.Results[0].Params) != 100 {
+			t.Errorf("%s: expected params of every line, got %d", name, len(report.Results[0].Params))
+		}
+		if progressed == 0 {
+			t.Errorf("%s: expected progress to be reported", name)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		p = New(Config{Delimiters: `\s+`})
+		if report, err := parse(p, ctx); !errors.Is(err, context.Canceled) || report != nil {
+			t.Errorf("%s: expected cancellation, got %v, %v", name, report, err)
+		}
+		if _, ok := p.Match("User alice logged in"); ok {
+			t.Errorf("%s: canceled parse must not record templates", name)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CoverageThreshold returns the highest count threshold such that templates
+// with Count >= threshold together cover at least the given fraction of all
+// log lines. Fraction must be in (0, 1]; results with no lines yield 1.
+func CoverageThreshold(results []*ParseResult, fraction float64) (int, error) {
+	if fraction <= 0 || fraction > 1 {
+		return 0, fmt.Errorf("coverage fraction must be in (0, 1], got %g", fraction)
+	}
+
+	counts := make([]int, 0, len(results))
+	total := 0
+	for _, result := range results {
+		counts = append(counts, result.Count)
+		total += result.Count
+	}
+	if total == 0 {
+		return 1, nil
+	}
+
+	// Take templates from the most frequent down until the target is reached
+	sort.Sort(sort.Reverse(sort.IntSlice(counts)))
+	target := fraction * float64(total)
+	covered := 0
+	for _, count := range counts {
+		covered += count
+		if float64(covered) >= target {
+			return max(count, 1), nil
+		}
+	}
+	return 1, nil
+}
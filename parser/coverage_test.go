@@ -0,0 +1,47 @@
+package parser
+
+import "testing"
+
+func TestCoverageThreshold(t *testing.T) {
+	results := []*ParseResult{
+		{Template: "a", Count: 60},
+		{Template: "b", Count: 3},
+		{Template: "c", Count: 30},
+		{Template: "d", Count: 5},
+		{Template: "e", Count: 1},
+		{Template: "f", Count: 1},
+	}
+
+	tests := []struct {
+		fraction float64
+		expected int
+	}{
+		{0.5, 60},
+		{0.6, 60},
+		{0.9, 30},
+		{0.95, 5},
+		{0.98, 3},
+		{0.99, 1},
+		{1.0, 1},
+	}
+	for _, tt := range tests {
+		got, err := CoverageThreshold(results, tt.fraction)
+		if err != nil {
+			t.Fatalf("CoverageThreshold(%g) error: %v", tt.fraction, err)
+		}
+		if got != tt.expected {
+			t.Errorf("CoverageThreshold(%g) = %d, want %d", tt.fraction, got, tt.expected)
+		}
+	}
+}
+
+func TestCoverageThresholdInvalid(t *testing.T) {
+	for _, fraction := range []float64{0, -0.5, 1.5} {
+		if _, err := CoverageThreshold(nil, fraction); err == nil {
+			t.Errorf("CoverageThreshold(%g) expected error", fraction)
+		}
+	}
+	if got, err := CoverageThreshold(nil, 0.99); err != nil || got != 1 {
+		t.Errorf("CoverageThreshold(nil) = %d, %v; want 1, nil", got, err)
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"maps"
+	"sort"
+	"sync"
+)
+
+// defaultDeltaVersions is the number of recent versions a DeltaTracker keeps
+const defaultDeltaVersions = 60
+
+// DeltaTracker versions the state of a SnapshotSource so pollers can fetch
+// only the template changes since a version they already have.
+type DeltaTracker struct {
+	source      SnapshotSource
+	maxVersions int
+
+	mu      sync.Mutex
+	version uint64
+	states  []deltaState // Recent versions, oldest first
+}
+
+// deltaState is the template counts of one version
+type deltaState struct {
+	version uint64
+	counts  map[string]int
+}
+
+// TemplateDelta contains the template changes between two versions.
+type TemplateDelta struct {
+	Version    uint64             `json:"version"`              // Current version, pass as since in the next request
+	Since      uint64             `json:"since"`                // Version the delta is relative to
+	Full       bool               `json:"full"`                 // Since is unknown or expired, New holds the complete state
+	New        []SnapshotTemplate `json:"new,omitempty"`        // Templates added since, with their total count
+	Increments []SnapshotTemplate `json:"increments,omitempty"` // Templates whose count changed, with the difference
+	Removed    []string           `json:"removed,omitempty"`    // Templates no longer present (e.g. after re-aggregation)
+}
+
+// NewDeltaTracker creates a tracker keeping the last maxVersions versions
+// (default: 60). Call Update to record the current state of source.
+func NewDeltaTracker(source SnapshotSource, maxVersions int) *DeltaTracker {
+	if maxVersions <= 0 {
+		maxVersions = defaultDeltaVersions
+	}
+	return &DeltaTracker{
+		source:      source,
+		maxVersions: maxVersions,
+	}
+}
+
+// Update records the current state of the source as a new version if it
+// changed and returns the current version. Versions start at 1.
+func (dt *DeltaTracker) Update() uint64 {
+	counts := make(map[string]int)
+	for _, result := range dt.source.Snapshot() {
+		counts[result.Template] += result.Count
+	}
+
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	if n := len(dt.states); n > 0 && maps.Equal(dt.states[n-1].counts, counts) {
+		return dt.version
+	}
+
+	dt.version++
+	dt.states = append(dt.states, deltaState{version: dt.version, counts: counts})
+	if len(dt.states) > dt.maxVersions {
+		dt.states = dt.states[len(dt.states)-dt.maxVersions:]
+	}
+	return dt.version
+}
+
+// Version returns the current version (0 before the first Update).
+func (dt *DeltaTracker) Version() uint64 {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	return dt.version
+}
+
+// Since returns the changes from version since to the current version.
+// If since is 0, expired or unknown, the complete state is returned with
+// Full set. Entries are sorted by template.
+func (dt *DeltaTracker) Since(since uint64) TemplateDelta {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	delta := TemplateDelta{Version: dt.version, Since: since}
+	if len(dt.states) == 0 {
+		delta.Full = true
+		return delta
+	}
+	current := dt.states[len(dt.states)-1].counts
+
+	var base map[string]int
+	for _, state := range dt.states {
+		if state.version == since {
+			base = state.counts
+			break
+		}
+	}
+	if base == nil {
+		delta.Full = true
+	}
+
+	for template, count := range current {
+		previous, ok := base[template]
+		switch {
+		case !ok:
+			delta.New = append(delta.New, SnapshotTemplate{Template: template, Count: count})
+		case count != previous:
+			delta.Increments = append(delta.Increments, SnapshotTemplate{Template: template, Count: count - previous})
+		}
+	}
+	for template := range base {
+		if _, ok := current[template]; !ok {
+			delta.Removed = append(delta.Removed, template)
+		}
+	}
+
+	sortSnapshotTemplates(delta.New)
+	sortSnapshotTemplates(delta.Increments)
+	sort.Strings(delta.Removed)
+	return delta
+}
+
+// sortSnapshotTemplates sorts entries by template
+func sortSnapshotTemplates(entries []SnapshotTemplate) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Template < entries[j].Template
+	})
+}
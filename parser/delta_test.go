@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeltaTracker(t *testing.T) {
+	source := &staticSnapshotSource{results: []*ParseResult{
+		{Template: "User <*> logged in", Count: 3},
+		{Template: "System started", Count: 1},
+	}}
+	tracker := NewDeltaTracker(source, 2)
+
+	if delta := tracker.Since(0); !delta.Full || delta.Version != 0 || len(delta.New) != 0 {
+		t.Errorf("Expected empty full delta before first update, got %+v", delta)
+	}
+
+	v1 := tracker.Update()
+	if v1 != 1 || tracker.Update() != 1 {
+		t.Fatalf("Expected version 1 to be kept while unchanged, got %d", tracker.Version())
+	}
+
+	full := tracker.Since(0)
+	if !full.Full || len(full.New) != 2 || full.New[0].Template != "System started" {
+		t.Errorf("Unexpected full delta: %+v", full)
+	}
+
+	source.results = []*ParseResult{
+		{Template: "User <*> logged in", Count: 5},
+		{Template: "Disk full", Count: 1},
+	}
+	v2 := tracker.Update()
+	delta := tracker.Since(v1)
+	expected := TemplateDelta{
+		Version:    v2,
+		Since:      v1,
+		New:        []SnapshotTemplate{{Template: "Disk full", Count: 1}},
+		Increments: []SnapshotTemplate{{Template: "User <*> logged in", Count: 2}},
+		Removed:    []string{"System started"},
+	}
+	if !reflect.DeepEqual(delta, expected) {
+		t.Errorf("Unexpected delta:\n got %+v\nwant %+v", delta, expected)
+	}
+
+	if delta := tracker.Since(v2); delta.Full || len(delta.New)+len(delta.Increments)+len(delta.Removed) != 0 {
+		t.Errorf("Expected empty delta for current version, got %+v", delta)
+	}
+
+	// Versions beyond the retention fall back to the full state
+	source.results = []*ParseResult{{Template: "Disk full", Count: 2}}
+	tracker.Update()
+	if delta := tracker.Since(v1); !delta.Full || len(delta.New) != 1 || delta.New[0].Count != 2 {
+		t.Errorf("Expected full delta for expired version, got %+v", delta)
+	}
+}
@@ -0,0 +1,312 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// DrainStateVersion is the schema version written by DrainParser.SaveState.
+const DrainStateVersion = 1
+
+// Defaults of DrainConfig, as recommended by the Drain paper
+const (
+	defaultDrainDepth       = 4
+	defaultDrainSimilarity  = 0.4
+	defaultDrainMaxChildren = 100
+)
+
+// DrainConfig configures a DrainParser.
+type DrainConfig struct {
+	Delimiters          string              `json:"delimiters"`           // Regex for splitting tokens (default: [\s,:=] as for Brain)
+	CommonVariables     map[string]string   `json:"common_variables"`     // Patterns of variables masked as <*> before parsing (default: DefaultCommonVariables)
+	Depth               int                 `json:"depth"`                // Depth of the parse tree: root, length layer, Depth-2 token layers (default: 4)
+	SimilarityThreshold float64             `json:"similarity_threshold"` // Minimum share of equal tokens for a line to join a template (default: 0.4)
+	MaxChildren         int                 `json:"max_children"`         // Maximum children of a tree node, further tokens share a <*> child (default: 100)
+	TemplateID          func(string) string `json:"-"`                    // Template identifier (default: HashTemplateID), not serialized
+}
+
+// DrainParser parses logs with Drain (He et al., "Drain: An Online Log
+// Parsing Approach with Fixed Depth Tree", ICWS 2017) behind the same API as
+// BrainParser, so the two algorithms can be compared on the same logs. Lines
+// are tokenized and masked like for Brain, routed through a tree of fixed
+// depth by their length and first tokens and joined to the most similar
+// template of the leaf, whose differing tokens become <*>. Drain learns
+// online: every Parse call continues with the templates of previous calls.
+// It is safe for concurrent use.
+type DrainParser struct {
+	config       DrainConfig
+	preprocessor *Preprocessor
+
+	mu       sync.Mutex
+	root     *drainNode
+	clusters []*tokenCluster // In order of creation
+}
+
+// drainNode is a node of the parse tree, either with children or, in the
+// last layer, with the clusters of its lines
+type drainNode struct {
+	children map[string]*drainNode
+	clusters []*tokenCluster
+}
+
+// drainSavedState is the JSON form of a DrainParser
+type drainSavedState struct {
+	Version   int                  `json:"version"`
+	Algorithm string               `json:"algorithm"`
+	Config    DrainConfig          `json:"config"`
+	Clusters  []savedTokenTemplate `json:"templates"`
+}
+
+// NewDrain creates a DrainParser with the given configuration. It returns an
+// error if a regex of the configuration does not compile or a setting is out
+// of range.
+func NewDrain(config DrainConfig) (*DrainParser, error) {
+	if config.Delimiters == "" {
+		config.Delimiters = `[\s,:=]`
+	}
+	if config.CommonVariables == nil {
+		config.CommonVariables = getDefaultCommonVariables()
+	}
+	if config.Depth == 0 {
+		config.Depth = defaultDrainDepth
+	}
+	if config.SimilarityThreshold == 0 {
+		config.SimilarityThreshold = defaultDrainSimilarity
+	}
+	if config.MaxChildren == 0 {
+		config.MaxChildren = defaultDrainMaxChildren
+	}
+	if config.TemplateID == nil {
+		config.TemplateID = HashTemplateID
+	}
+
+	switch {
+	case config.Depth < 3:
+		return nil, fmt.Errorf("invalid Drain depth %d: must be at least 3", config.Depth)
+	case config.SimilarityThreshold < 0 || config.SimilarityThreshold > 1:
+		return nil, fmt.Errorf("invalid Drain similarity threshold %g: must be in [0, 1]", config.SimilarityThreshold)
+	case config.MaxChildren < 2:
+		return nil, fmt.Errorf("invalid Drain max children %d: must be at least 2", config.MaxChildren)
+	}
+	if err := validateTokenPatterns(config.Delimiters, config.CommonVariables); err != nil {
+		return nil, err
+	}
+
+	return &DrainParser{
+		config:       config,
+		preprocessor: NewPreprocessor(config.Delimiters, config.CommonVariables),
+		root:         &drainNode{children: make(map[string]*drainNode)},
+	}, nil
+}
+
+// Parse adds the lines to the learned templates and returns the templates
+// of the lines, sorted by count in descending order. Counts and LogIDs cover
+// only logLines; templates are as generalized by the whole call.
+func (d *DrainParser) Parse(logLines []string) []*ParseResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	members := make(map[*tokenCluster][]int)
+	var order []*tokenCluster
+	for id, line := range logLines {
+		cluster := d.add(d.preprocessor.maskedTokens(line))
+		if _, ok := members[cluster]; !ok {
+			order = append(order, cluster)
+		}
+		members[cluster] = append(members[cluster], id)
+	}
+
+	return clusterResults(order, members, logLines, d.config.TemplateID)
+}
+
+// Match classifies a line by the learned templates without learning from
+// it. The line matches a template if every token is equal or a <*> of the
+// template. The returned result holds the template, its accumulated count
+// and the severity of the line; LogIDs are not set.
+func (d *DrainParser) Match(line string) (*ParseResult, bool) {
+	tokens := d.preprocessor.maskedTokens(line)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	node := d.root.children[strconv.Itoa(len(tokens))]
+	for i := 0; node != nil && i < d.tokenLayers(len(tokens)); i++ {
+		next, ok := node.children[tokens[i]]
+		if !ok {
+			next = node.children["<*>"]
+		}
+		node = next
+	}
+	if node == nil {
+		return nil, false
+	}
+	for _, cluster := range node.clusters {
+		if similarity, _ := drainSimilarity(cluster.tokens, tokens); similarity == 1 || len(tokens) == 0 {
+			template := strings.Join(cluster.tokens, " ")
+			return &ParseResult{
+				ID:         d.config.TemplateID(template),
+				Template:   template,
+				Count:      cluster.count,
+				Severity:   InferLineSeverity(line),
+				Similarity: 1,
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// Templates returns the learned templates in order of creation.
+func (d *DrainParser) Templates() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return clusterTemplates(d.clusters)
+}
+
+// Snapshot returns all learned templates with their accumulated counts,
+// sorted by count in descending order. Severities are inferred from the
+// template text and LogIDs are not set.
+func (d *DrainParser) Snapshot() []*ParseResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return snapshotClusters(d.clusters, d.config.TemplateID)
+}
+
+// Evaluate measures how well results reproduce a ground truth, see
+// BrainParser.Evaluate.
+func (d *DrainParser) Evaluate(results []*ParseResult, truth *GroundTruth) (*Evaluation, error) {
+	return evaluate(d.preprocessor, results, truth)
+}
+
+// SaveState writes the configuration and the learned templates with their
+// accumulated counts as JSON; LoadDrainState or LoadParser restore them.
+func (d *DrainParser) SaveState(w io.Writer) error {
+	d.mu.Lock()
+	state := drainSavedState{
+		Version:   DrainStateVersion,
+		Algorithm: AlgorithmDrain,
+		Config:    d.config,
+		Clusters:  saveClusters(d.clusters),
+	}
+	d.mu.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// LoadDrainState restores a DrainParser saved with SaveState.
+func LoadDrainState(r io.Reader) (*DrainParser, error) {
+	var state drainSavedState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if state.Algorithm != AlgorithmDrain {
+		return nil, fmt.Errorf("state of algorithm %q is no Drain state", state.Algorithm)
+	}
+	if state.Version > DrainStateVersion {
+		return nil, fmt.Errorf("unsupported state version %d (supported up to %d)", state.Version, DrainStateVersion)
+	}
+	d, err := NewDrain(state.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state config: %w", err)
+	}
+	for _, saved := range state.Clusters {
+		cluster := &tokenCluster{tokens: saved.Tokens, count: saved.Count}
+		leaf := d.leaf(cluster.tokens)
+		leaf.clusters = append(leaf.clusters, cluster)
+		d.clusters = append(d.clusters, cluster)
+	}
+	return d, nil
+}
+
+// add joins tokens to the most similar cluster of their leaf, generalizing
+// its template, or creates a cluster. The caller must hold d.mu.
+func (d *DrainParser) add(tokens []string) *tokenCluster {
+	leaf := d.leaf(tokens)
+	var best *tokenCluster
+	bestSimilarity, bestWildcards := -1.0, -1
+	for _, cluster := range leaf.clusters {
+		similarity, wildcards := drainSimilarity(cluster.tokens, tokens)
+		if similarity > bestSimilarity || (similarity == bestSimilarity && wildcards > bestWildcards) {
+			best, bestSimilarity, bestWildcards = cluster, similarity, wildcards
+		}
+	}
+	if best == nil || bestSimilarity < d.config.SimilarityThreshold {
+		cluster := &tokenCluster{tokens: append([]string(nil), tokens...), count: 1}
+		leaf.clusters = append(leaf.clusters, cluster)
+		d.clusters = append(d.clusters, cluster)
+		return cluster
+	}
+	for i, token := range tokens {
+		if best.tokens[i] != token {
+			best.tokens[i] = "<*>"
+		}
+	}
+	best.count++
+	return best
+}
+
+// leaf returns the leaf of the tree tokens are routed to, creating the path
+// if needed. The caller must hold d.mu.
+func (d *DrainParser) leaf(tokens []string) *drainNode {
+	node := d.root.child(strconv.Itoa(len(tokens)))
+	for i := 0; i < d.tokenLayers(len(tokens)); i++ {
+		token := tokens[i]
+		if _, ok := node.children[token]; ok {
+			node = node.children[token]
+			continue
+		}
+		_, hasWildcard := node.children["<*>"]
+		switch {
+		case strings.IndexFunc(token, unicode.IsDigit) >= 0:
+			token = "<*>" // Numbers are likely variables
+		case hasWildcard && len(node.children) >= d.config.MaxChildren:
+			token = "<*>"
+		case !hasWildcard && len(node.children)+1 >= d.config.MaxChildren:
+			token = "<*>" // Reserve the last child for everything else
+		}
+		node = node.child(token)
+	}
+	return node
+}
+
+// tokenLayers returns the token layers of the tree for lines of n tokens
+func (d *DrainParser) tokenLayers(n int) int {
+	return min(d.config.Depth-2, n)
+}
+
+// child returns the child of a node for token, creating it if needed
+func (n *drainNode) child(token string) *drainNode {
+	child, ok := n.children[token]
+	if !ok {
+		child = &drainNode{children: make(map[string]*drainNode)}
+		n.children[token] = child
+	}
+	return child
+}
+
+// drainSimilarity returns the share of tokens equal to the template, where
+// <*> counts as equal, and the number of <*> of the template. template and
+// tokens have the same length.
+func drainSimilarity(template, tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+	equal, wildcards := 0, 0
+	for i, token := range template {
+		switch {
+		case token == "<*>":
+			wildcards++
+			equal++
+		case token == tokens[i]:
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens)), wildcards
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var drainTestLines = []string{
+	"Receiving block blk_1 src /10.0.0.1",
+	"Receiving block blk_2 src /10.0.0.2",
+	"Receiving block blk_3 src /10.0.0.3",
+	"ERROR Disk sda full",
+	"ERROR Disk sdb full",
+	"Connection closed by peer",
+}
+
+func TestDrainParse(t *testing.T) {
+	d, err := NewDrain(DrainConfig{})
+	if err != nil {
+		t.Fatalf("NewDrain error: %v", err)
+	}
+	results := d.Parse(drainTestLines)
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 templates, got %d: %v", len(results), d.Templates())
+	}
+	if results[0].Template != "Receiving block <*> src <*>" || results[0].Count != 3 || len(results[0].LogIDs) != 3 || results[0].ID == "" {
+		t.Errorf("Unexpected first result %+v", results[0])
+	}
+	if results[1].Template != "ERROR Disk <*> full" || results[1].Severity != SeverityError {
+		t.Errorf("Unexpected second result %+v", results[1])
+	}
+
+	// Later calls continue with the learned templates
+	results = d.Parse([]string{"ERROR Disk sdc full"})
+	if len(results) != 1 || results[0].Count != 1 || results[0].Template != "ERROR Disk <*> full" {
+		t.Errorf("Expected the known template for a new batch, got %+v", results)
+	}
+	if snapshot := d.Snapshot(); snapshot[0].Count != 3 || snapshot[1].Count != 3 {
+		t.Errorf("Expected accumulated counts, got %+v %+v", snapshot[0], snapshot[1])
+	}
+}
+
+func TestDrainMatch(t *testing.T) {
+	d, _ := NewDrain(DrainConfig{})
+	d.Parse(drainTestLines)
+	result, ok := d.Match("Receiving block blk_9 src /10.0.0.9")
+	if !ok || result.Template != "Receiving block <*> src <*>" || result.Count != 3 {
+		t.Errorf("Expected a match, got %+v %v", result, ok)
+	}
+	if _, ok := d.Match("Connection reset by peer"); ok {
+		t.Error("Expected no match for a line differing from a template")
+	}
+	if len(d.Templates()) != 3 {
+		t.Errorf("Expected Match not to learn, got %v", d.Templates())
+	}
+}
+
+func TestDrainConfigValidation(t *testing.T) {
+	for _, config := range []DrainConfig{
+		{Depth: 2},
+		{SimilarityThreshold: 1.5},
+		{MaxChildren: 1},
+		{Delimiters: "["},
+	} {
+		if _, err := NewDrain(config); err == nil {
+			t.Errorf("Expected an error for %+v", config)
+		}
+	}
+}
+
+func TestDrainMaxChildren(t *testing.T) {
+	d, _ := NewDrain(DrainConfig{MaxChildren: 2})
+	d.Parse([]string{"alpha started", "beta started", "gamma started"})
+	if _, ok := d.root.children["2"].children["<*>"]; !ok {
+		t.Errorf("Expected a <*> child once a node is full, got %v", d.root.children["2"].children)
+	}
+}
+
+func TestLoadParser(t *testing.T) {
+	d, _ := NewDrain(DrainConfig{SimilarityThreshold: 0.5})
+	d.Parse(drainTestLines)
+	var buf bytes.Buffer
+	if err := d.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	if _, err := LoadState(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("Expected LoadState to reject a Drain state")
+	}
+	loaded, err := LoadParser(&buf)
+	if err != nil {
+		t.Fatalf("LoadParser error: %v", err)
+	}
+	restored, ok := loaded.(*DrainParser)
+	if !ok {
+		t.Fatalf("Expected a DrainParser, got %T", loaded)
+	}
+	if restored.config.SimilarityThreshold != 0.5 || strings.Join(restored.Templates(), "|") != strings.Join(d.Templates(), "|") {
+		t.Errorf("Unexpected restored parser %+v %v", restored.config, restored.Templates())
+	}
+	if _, ok := restored.Match("ERROR Disk sdz full"); !ok {
+		t.Error("Expected the restored parser to match")
+	}
+
+	brain := New(Config{})
+	brain.Parse(drainTestLines)
+	buf.Reset()
+	if err := brain.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	if loaded, err := LoadParser(&buf); err != nil {
+		t.Errorf("LoadParser error: %v", err)
+	} else if _, ok := loaded.(*BrainParser); !ok {
+		t.Errorf("Expected a BrainParser, got %T", loaded)
+	}
+
+	if loaded, err := LoadParser(strings.NewReader(`{"algorithm":"slct"}`)); err == nil || loaded != nil {
+		t.Errorf("Expected an error for an unknown algorithm, got %v", loaded)
+	}
+}
+
+func TestDrainEvaluate(t *testing.T) {
+	truth, _ := ReadLogHubStructured(strings.NewReader(testStructuredCSV))
+	d, _ := NewDrain(DrainConfig{})
+	evaluation, err := d.Evaluate(d.Parse(truth.Lines), truth)
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	if evaluation.Lines != 5 || evaluation.TruthTemplates != 3 {
+		t.Errorf("Unexpected evaluation %+v", evaluation)
+	}
+}
@@ -0,0 +1,136 @@
+package parser
+
+import (
+	"math"
+	"sort"
+)
+
+// TemplateDrift is a template that differs between two runs.
+type TemplateDrift struct {
+	TemplateID string  `json:"template_id"`
+	Template   string  `json:"template"`
+	OldCount   int     `json:"old_count"`
+	NewCount   int     `json:"new_count"`
+	OldShare   float64 `json:"old_share"`       // OldCount divided by the lines of the old run
+	NewShare   float64 `json:"new_share"`       // NewCount divided by the lines of the new run
+	Ratio      float64 `json:"ratio,omitempty"` // NewShare divided by OldShare (shifted templates only)
+}
+
+// TemplateDiff is the template drift between two runs, see DiffTemplates.
+type TemplateDiff struct {
+	OldLines int             `json:"old_lines"`
+	NewLines int             `json:"new_lines"`
+	Added    []TemplateDrift `json:"added,omitempty"`   // Templates only in the new run, most frequent first
+	Removed  []TemplateDrift `json:"removed,omitempty"` // Templates only in the old run, most frequent first
+	Shifted  []TemplateDrift `json:"shifted,omitempty"` // Templates of both runs with a different share of lines, largest change first
+}
+
+// DiffTemplates compares the templates of two runs, e.g. of the logs before
+// and after a release or of two saved states (see BrainParser.Snapshot).
+// Templates are matched by their text. Counts are compared as shares of the
+// lines of each run, so runs of different size can be compared.
+func DiffTemplates(old, new []*ParseResult) TemplateDiff {
+	oldCounts, oldIDs, oldLines := sumTemplateCounts(old)
+	newCounts, newIDs, newLines := sumTemplateCounts(new)
+	diff := TemplateDiff{OldLines: oldLines, NewLines: newLines}
+
+	for template, newCount := range newCounts {
+		drift := TemplateDrift{
+			TemplateID: newIDs[template],
+			Template:   template,
+			OldCount:   oldCounts[template],
+			NewCount:   newCount,
+			OldShare:   share(oldCounts[template], oldLines),
+			NewShare:   share(newCount, newLines),
+		}
+		if drift.TemplateID == "" {
+			drift.TemplateID = oldIDs[template]
+		}
+		switch _, ok := oldCounts[template]; {
+		case !ok:
+			diff.Added = append(diff.Added, drift)
+		case drift.OldCount*newLines != newCount*oldLines: // Shares differ
+			drift.Ratio = drift.NewShare / drift.OldShare
+			diff.Shifted = append(diff.Shifted, drift)
+		}
+	}
+	for template, oldCount := range oldCounts {
+		if _, ok := newCounts[template]; !ok {
+			diff.Removed = append(diff.Removed, TemplateDrift{
+				TemplateID: oldIDs[template],
+				Template:   template,
+				OldCount:   oldCount,
+				OldShare:   share(oldCount, oldLines),
+			})
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool {
+		return byCount(diff.Added[i].NewCount, diff.Added[j].NewCount, diff.Added[i].Template, diff.Added[j].Template)
+	})
+	sort.Slice(diff.Removed, func(i, j int) bool {
+		return byCount(diff.Removed[i].OldCount, diff.Removed[j].OldCount, diff.Removed[i].Template, diff.Removed[j].Template)
+	})
+	sort.Slice(diff.Shifted, func(i, j int) bool {
+		a, b := math.Abs(math.Log(diff.Shifted[i].Ratio)), math.Abs(math.Log(diff.Shifted[j].Ratio))
+		if a != b {
+			return a > b
+		}
+		return diff.Shifted[i].Template < diff.Shifted[j].Template
+	})
+	return diff
+}
+
+// Significant returns the diff with only the shifted templates whose share
+// changed at least by the factor minRatio in either direction, e.g. 2 keeps
+// templates that doubled or halved.
+func (d TemplateDiff) Significant(minRatio float64) TemplateDiff {
+	shifted := make([]TemplateDrift, 0, len(d.Shifted))
+	for _, drift := range d.Shifted {
+		if drift.Ratio >= minRatio || drift.Ratio*minRatio <= 1 {
+			shifted = append(shifted, drift)
+		}
+	}
+	d.Shifted = shifted
+	return d
+}
+
+// Changed reports whether templates were added, removed or shifted.
+func (d TemplateDiff) Changed() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Shifted) > 0
+}
+
+// sumTemplateCounts returns the count and ID of every template of results
+// and the total count, skipping results without lines
+func sumTemplateCounts(results []*ParseResult) (map[string]int, map[string]string, int) {
+	counts := make(map[string]int, len(results))
+	ids := make(map[string]string, len(results))
+	total := 0
+	for _, result := range results {
+		if result.Count <= 0 {
+			continue
+		}
+		counts[result.Template] += result.Count
+		if result.ID != "" {
+			ids[result.Template] = result.ID
+		}
+		total += result.Count
+	}
+	return counts, ids, total
+}
+
+// share returns count divided by total, or 0 for an empty run
+func share(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// byCount orders by count descending, then by template
+func byCount(countA, countB int, templateA, templateB string) bool {
+	if countA != countB {
+		return countA > countB
+	}
+	return templateA < templateB
+}
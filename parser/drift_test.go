@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiffTemplates(t *testing.T) {
+	old := []*ParseResult{
+		{ID: "a", Template: "User <*> logged in", Count: 80},
+		{ID: "b", Template: "Disk <*> full", Count: 10},
+		{ID: "c", Template: "Cache warmed", Count: 10},
+		{ID: "z", Template: "Unused", Count: 0},
+	}
+	new := []*ParseResult{
+		{ID: "a", Template: "User <*> logged in", Count: 160},
+		{ID: "b", Template: "Disk <*> full", Count: 60},
+		{ID: "d", Template: "Panic in <*>", Count: 5},
+		{ID: "e", Template: "Retry <*>", Count: 15},
+	}
+	diff := DiffTemplates(old, new)
+	if diff.OldLines != 100 || diff.NewLines != 240 {
+		t.Errorf("Unexpected line totals %d and %d", diff.OldLines, diff.NewLines)
+	}
+	if len(diff.Added) != 2 || diff.Added[0].Template != "Retry <*>" || diff.Added[1].TemplateID != "d" {
+		t.Errorf("Unexpected added templates %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].Template != "Cache warmed" || diff.Removed[0].OldShare != 0.1 {
+		t.Errorf("Unexpected removed templates %+v", diff.Removed)
+	}
+	if len(diff.Shifted) != 2 || diff.Shifted[0].Template != "Disk <*> full" || diff.Shifted[0].Ratio != 2.5 {
+		t.Fatalf("Unexpected shifted templates %+v", diff.Shifted)
+	}
+	if user := diff.Shifted[1]; user.OldShare != 0.8 || user.NewShare != 160.0/240 {
+		t.Errorf("Unexpected user template shift %+v", user)
+	}
+
+	significant := diff.Significant(2)
+	if len(significant.Shifted) != 1 || significant.Shifted[0].TemplateID != "b" || len(diff.Shifted) != 2 {
+		t.Errorf("Expected only the disk template to shift by 2x, got %+v", significant.Shifted)
+	}
+	if !significant.Changed() || DiffTemplates(old, old).Changed() {
+		t.Error("Expected only differing runs to be changed")
+	}
+}
+
+func TestBrainParserSnapshot(t *testing.T) {
+	brainParser := New(Config{Delimiters: `\s+`})
+	if snapshot := brainParser.Snapshot(); len(snapshot) != 0 {
+		t.Fatalf("Expected an empty snapshot before parsing, got %+v", snapshot)
+	}
+	brainParser.Parse([]string{"User alice logged in", "User bob logged in", "User carol logged in", "Disk full"})
+
+	var buf bytes.Buffer
+	if err := brainParser.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	loaded, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	snapshot := loaded.Snapshot()
+	if len(snapshot) != 2 || snapshot[0].Template != "User <*> logged in" || snapshot[0].Count != 3 || snapshot[0].ID == "" {
+		t.Errorf("Unexpected snapshot %+v", snapshot)
+	}
+	if DiffTemplates(brainParser.Snapshot(), snapshot).Changed() {
+		t.Error("Expected a loaded state to match the saved one")
+	}
+}
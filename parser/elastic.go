@@ -0,0 +1,277 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Defaults of ElasticSink
+const (
+	defaultElasticTemplatesIndex = "brain-templates"
+	defaultElasticLinesIndex     = "brain-lines"
+	defaultElasticBatchSize      = 1000
+)
+
+// ElasticTemplatesMapping is the mapping CreateIndices creates the templates
+// index with: one document per template, identified by its template ID.
+const ElasticTemplatesMapping = `{
+  "mappings": {
+    "properties": {
+      "@timestamp":  {"type": "date"},
+      "template_id": {"type": "keyword"},
+      "template":    {"type": "text", "fields": {"keyword": {"type": "keyword", "ignore_above": 8191}}},
+      "count":       {"type": "long"},
+      "severity":    {"type": "keyword"}
+    }
+  }
+}`
+
+// ElasticLinesMapping is the mapping CreateIndices creates the lines index
+// with: one document per log line with the template it was assigned to.
+const ElasticLinesMapping = `{
+  "mappings": {
+    "properties": {
+      "@timestamp":  {"type": "date"},
+      "template_id": {"type": "keyword"},
+      "template":    {"type": "keyword", "ignore_above": 8191},
+      "line":        {"type": "long"},
+      "message":     {"type": "text"},
+      "severity":    {"type": "keyword"}
+    }
+  }
+}`
+
+// ElasticTemplate is a document of the templates index.
+type ElasticTemplate struct {
+	Timestamp  time.Time `json:"@timestamp"`
+	TemplateID string    `json:"template_id"`
+	Template   string    `json:"template"`
+	Count      int       `json:"count"`
+	Severity   string    `json:"severity"`
+}
+
+// ElasticLine is a document of the lines index.
+type ElasticLine struct {
+	Timestamp  time.Time `json:"@timestamp"`
+	TemplateID string    `json:"template_id"`
+	Template   string    `json:"template"`
+	Line       int       `json:"line"` // Index of the line in the parsed input
+	Message    string    `json:"message"`
+	Severity   string    `json:"severity"`
+}
+
+// ElasticSink bulk-indexes templates and per-line template assignments into
+// Elasticsearch or OpenSearch for dashboards in Kibana or OpenSearch
+// Dashboards. It uses only the _bulk and index APIs both share.
+type ElasticSink struct {
+	URL            string           // Base URL of the cluster, e.g. http://localhost:9200; credentials in the URL are sent as basic auth
+	TemplatesIndex string           // Index of the templates (default: "brain-templates")
+	LinesIndex     string           // Index of the line assignments (default: "brain-lines")
+	APIKey         string           // Elasticsearch API key sent as "Authorization: ApiKey" (optional)
+	BatchSize      int              // Documents per bulk request (default: 1000)
+	Client         *http.Client     // Optional, defaults to http.DefaultClient
+	Now            func() time.Time // @timestamp of the documents (default: time.Now)
+}
+
+// CreateIndices creates the templates and lines indices with
+// ElasticTemplatesMapping and ElasticLinesMapping. Existing indices are kept.
+func (es *ElasticSink) CreateIndices(ctx context.Context) error {
+	for index, mapping := range map[string]string{
+		es.templatesIndex(): ElasticTemplatesMapping,
+		es.linesIndex():     ElasticLinesMapping,
+	} {
+		status, body, err := es.do(ctx, http.MethodPut, "/"+index, "application/json", []byte(mapping))
+		if err != nil {
+			return err
+		}
+		if status >= 300 && !bytes.Contains(body, []byte("resource_already_exists_exception")) {
+			return fmt.Errorf("failed to create index %s: status %d: %s", index, status, bytes.TrimSpace(body))
+		}
+	}
+	return nil
+}
+
+// IndexTemplates indexes every result as an ElasticTemplate with its
+// template ID as document ID, so indexing again updates the counts.
+func (es *ElasticSink) IndexTemplates(ctx context.Context, results []*ParseResult) error {
+	now := es.now()
+	actions := make([]elasticAction, len(results))
+	for i, result := range results {
+		actions[i] = elasticAction{
+			id: result.ID,
+			doc: ElasticTemplate{
+				Timestamp:  now,
+				TemplateID: result.ID,
+				Template:   result.Template,
+				Count:      result.Count,
+				Severity:   result.Severity.String(),
+			},
+		}
+	}
+	return es.bulk(ctx, es.templatesIndex(), actions)
+}
+
+// IndexLines indexes every line of lines that a result covers as an
+// ElasticLine with the template it was assigned to. LogIDs of results must
+// index into lines, as returned by Parse.
+func (es *ElasticSink) IndexLines(ctx context.Context, results []*ParseResult, lines []string) error {
+	now := es.now()
+	var actions []elasticAction
+	for _, result := range results {
+		for _, id := range result.LogIDs {
+			if id < 0 || id >= len(lines) {
+				continue
+			}
+			actions = append(actions, elasticAction{doc: ElasticLine{
+				Timestamp:  now,
+				TemplateID: result.ID,
+				Template:   result.Template,
+				Line:       id,
+				Message:    lines[id],
+				Severity:   InferLineSeverity(lines[id]).String(),
+			}})
+		}
+	}
+	return es.bulk(ctx, es.linesIndex(), actions)
+}
+
+// elasticAction is a document of a bulk request, with an ID or without to
+// let the cluster assign one
+type elasticAction struct {
+	id  string
+	doc any
+}
+
+// elasticBulkResponse is the part of a _bulk response needed to find
+// failed documents
+type elasticBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// bulk indexes actions into index in batches of BatchSize
+func (es *ElasticSink) bulk(ctx context.Context, index string, actions []elasticAction) error {
+	batchSize := es.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultElasticBatchSize
+	}
+	for start := 0; start < len(actions); start += batchSize {
+		var body bytes.Buffer
+		encoder := json.NewEncoder(&body)
+		encoder.SetEscapeHTML(false) // Keep <*> readable
+		for _, action := range actions[start:min(start+batchSize, len(actions))] {
+			meta := map[string]map[string]string{"index": {"_index": index}}
+			if action.id != "" {
+				meta["index"]["_id"] = action.id
+			}
+			if err := encoder.Encode(meta); err != nil {
+				return fmt.Errorf("failed to encode bulk request: %w", err)
+			}
+			if err := encoder.Encode(action.doc); err != nil {
+				return fmt.Errorf("failed to encode bulk request: %w", err)
+			}
+		}
+
+		status, data, err := es.do(ctx, http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes())
+		if err != nil {
+			return err
+		}
+		if status >= 300 {
+			return fmt.Errorf("bulk request to %s failed: status %d: %s", index, status, bytes.TrimSpace(data))
+		}
+		var response elasticBulkResponse
+		if err := json.Unmarshal(data, &response); err != nil {
+			return fmt.Errorf("invalid bulk response: %w", err)
+		}
+		if response.Errors {
+			return bulkItemsError(index, response)
+		}
+	}
+	return nil
+}
+
+// bulkItemsError summarizes the failed documents of a bulk response
+func bulkItemsError(index string, response elasticBulkResponse) error {
+	failed := 0
+	var first string
+	for _, item := range response.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			failed++
+			if first == "" {
+				first = result.Error.Type + ": " + result.Error.Reason
+			}
+		}
+	}
+	return fmt.Errorf("failed to index %d documents into %s, first: %s", failed, index, first)
+}
+
+// do sends a request to the cluster and returns the status and body of the
+// response
+func (es *ElasticSink) do(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+	if es.URL == "" {
+		return 0, nil, errors.New("elasticsearch URL is required")
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(es.URL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create elasticsearch request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if es.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+es.APIKey)
+	} else if user := req.URL.User; user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+
+	client := es.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read elasticsearch response: %w", err)
+	}
+	return resp.StatusCode, data, nil
+}
+
+func (es *ElasticSink) templatesIndex() string {
+	if es.TemplatesIndex == "" {
+		return defaultElasticTemplatesIndex
+	}
+	return es.TemplatesIndex
+}
+
+func (es *ElasticSink) linesIndex() string {
+	if es.LinesIndex == "" {
+		return defaultElasticLinesIndex
+	}
+	return es.LinesIndex
+}
+
+func (es *ElasticSink) now() time.Time {
+	if es.Now == nil {
+		return time.Now()
+	}
+	return es.Now()
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestElasticSink(t *testing.T) {
+	var mu sync.Mutex
+	var bulks [][]map[string]any
+	var created []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		auth = r.Header.Get("Authorization")
+		if r.Method == http.MethodPut {
+			created = append(created, r.URL.Path)
+			body, _ := io.ReadAll(r.Body)
+			if !json.Valid(body) {
+				t.Errorf("Invalid mapping for %s", r.URL.Path)
+			}
+			_, _ = io.WriteString(w, `{"acknowledged":true}`)
+			return
+		}
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var lines []map[string]any
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var line map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Errorf("Invalid bulk line %q: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		bulks = append(bulks, lines)
+		_, _ = io.WriteString(w, `{"errors":false,"items":[]}`)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sink := &ElasticSink{URL: server.URL + "/", APIKey: "secret", BatchSize: 2, Now: func() time.Time { return now }}
+	ctx := context.Background()
+	if err := sink.CreateIndices(ctx); err != nil {
+		t.Fatalf("CreateIndices error: %v", err)
+	}
+	if len(created) != 2 || auth != "ApiKey secret" {
+		t.Errorf("Unexpected index creation %v with auth %q", created, auth)
+	}
+
+	lines := []string{"User alice logged in", "User bob logged in", "ERROR disk full"}
+	results := []*ParseResult{
+		{ID: "a", Template: "User <*> logged in", Count: 2, LogIDs: []int{0, 1}},
+		{ID: "b", Template: "ERROR disk full", Count: 1, LogIDs: []int{2}, Severity: SeverityError},
+	}
+	if err := sink.IndexTemplates(ctx, results); err != nil {
+		t.Fatalf("IndexTemplates error: %v", err)
+	}
+	if len(bulks) != 1 || len(bulks[0]) != 4 {
+		t.Fatalf("Expected one bulk request with 2 templates, got %v", bulks)
+	}
+	meta := bulks[0][0]["index"].(map[string]any)
+	if meta["_index"] != "brain-templates" || meta["_id"] != "a" {
+		t.Errorf("Unexpected action %v", meta)
+	}
+	if doc := bulks[0][3]; doc["template"] != "ERROR disk full" || doc["severity"] != "error" || doc["@timestamp"] != "2024-05-01T12:00:00Z" {
+		t.Errorf("Unexpected template document %v", doc)
+	}
+
+	bulks = nil
+	if err := sink.IndexLines(ctx, results, lines); err != nil {
+		t.Fatalf("IndexLines error: %v", err)
+	}
+	if len(bulks) != 2 {
+		t.Fatalf("Expected 3 lines in 2 batches, got %d", len(bulks))
+	}
+	meta = bulks[0][0]["index"].(map[string]any)
+	if meta["_index"] != "brain-lines" || meta["_id"] != nil {
+		t.Errorf("Unexpected action %v", meta)
+	}
+	if doc := bulks[0][3]; doc["message"] != "User bob logged in" || doc["template_id"] != "a" || doc["line"] != 1.0 {
+		t.Errorf("Unexpected line document %v", doc)
+	}
+}
+
+func TestElasticSinkErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"errors":true,"items":[{"index":{"status":201}},`+
+			`{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"failed to parse"}}}]}`)
+	}))
+	defer server.Close()
+
+	sink := &ElasticSink{URL: server.URL}
+	results := []*ParseResult{{ID: "a", Template: "x"}, {ID: "b", Template: "y"}}
+	err := sink.IndexTemplates(context.Background(), results)
+	if err == nil || !strings.Contains(err.Error(), "1 documents") || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Expected bulk item error, got %v", err)
+	}
+
+	if err := (&ElasticSink{}).IndexTemplates(context.Background(), results); err == nil {
+		t.Error("Expected error without URL")
+	}
+}
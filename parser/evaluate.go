@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GroundTruth is the labeled lines of a LogHub-style structured CSV file,
+// e.g. HDFS_2k.log_structured.csv.
+type GroundTruth struct {
+	Lines     []string // Content column
+	Templates []string // EventTemplate column, aligned with Lines
+	EventIDs  []string // EventId column, aligned with Lines (the templates if the file has none)
+}
+
+// Evaluation is the accuracy of parse results against a GroundTruth.
+type Evaluation struct {
+	Lines            int     `json:"lines"`
+	Templates        int     `json:"templates"`         // Groups found by the parser
+	TruthTemplates   int     `json:"truth_templates"`   // Groups of the ground truth
+	GroupingAccuracy float64 `json:"grouping_accuracy"` // Share of lines whose group has exactly the lines of their true group
+	ParsingAccuracy  float64 `json:"parsing_accuracy"`  // Share of lines whose template equals their true template
+	Precision        float64 `json:"precision"`         // Share of line pairs grouped together that belong together
+	Recall           float64 `json:"recall"`            // Share of line pairs belonging together that are grouped together
+	F1               float64 `json:"f1"`                // Harmonic mean of Precision and Recall
+}
+
+// ReadLogHubStructured reads the ground truth of a LogHub structured CSV
+// file with the columns Content and EventTemplate and optionally EventId;
+// other columns are ignored. Rows with empty content are skipped.
+func ReadLogHubStructured(reader io.Reader) (*GroundTruth, error) {
+	decoded, err := decodeTabular(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding ground truth: %w", err)
+	}
+	csvReader := csv.NewReader(decoded)
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading ground truth header: %w", err)
+	}
+	content, template, eventID := -1, -1, -1
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "content":
+			content = i
+		case "eventtemplate":
+			template = i
+		case "eventid":
+			eventID = i
+		}
+	}
+	if content < 0 || template < 0 {
+		return nil, fmt.Errorf("ground truth needs the columns Content and EventTemplate, got %v", header)
+	}
+
+	truth := &GroundTruth{}
+	for {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading ground truth: %w", err)
+		}
+		line := strings.TrimSpace(record[content])
+		if line == "" {
+			continue
+		}
+		truth.Lines = append(truth.Lines, line)
+		truth.Templates = append(truth.Templates, record[template])
+		if eventID >= 0 {
+			truth.EventIDs = append(truth.EventIDs, record[eventID])
+		} else {
+			truth.EventIDs = append(truth.EventIDs, record[template])
+		}
+	}
+	return truth, nil
+}
+
+// Evaluate measures how well results parsed from truth.Lines reproduce the
+// ground truth with the metrics of the LogHub benchmark: grouping accuracy,
+// parsing accuracy and the pairwise precision, recall and F1 of the
+// grouping. Templates are compared as words split by Config.Delimiters with
+// runs of <*> counted as one, so the delimiters the parser drops do not
+// count as errors. Lines no result covers count as wrongly parsed
+// singletons.
+func (p *BrainParser) Evaluate(results []*ParseResult, truth *GroundTruth) (*Evaluation, error) {
+	return evaluate(p.preprocessor, results, truth)
+}
+
+// evaluate measures results against truth, splitting templates with the
+// delimiters of preprocessor
+func evaluate(preprocessor *Preprocessor, results []*ParseResult, truth *GroundTruth) (*Evaluation, error) {
+	n := len(truth.Lines)
+	if len(truth.Templates) != n || len(truth.EventIDs) != n {
+		return nil, errors.New("ground truth columns are not aligned")
+	}
+	if n == 0 {
+		return nil, errors.New("ground truth has no lines")
+	}
+
+	// Group of every line, results first, uncovered lines as singletons
+	groups := make([]int, n)
+	for i := range groups {
+		groups[i] = -1
+	}
+	for group, result := range results {
+		for _, id := range result.LogIDs {
+			if id >= 0 && id < n {
+				groups[id] = group
+			}
+		}
+	}
+	next := len(results)
+	for i := range groups {
+		if groups[i] < 0 {
+			groups[i] = next
+			next++
+		}
+	}
+
+	type cell struct {
+		group int
+		truth string
+	}
+	groupSizes := make(map[int]int)
+	truthSizes := make(map[string]int)
+	cells := make(map[cell]int)
+	for i, group := range groups {
+		groupSizes[group]++
+		truthSizes[truth.EventIDs[i]]++
+		cells[cell{group, truth.EventIDs[i]}]++
+	}
+
+	evaluation := &Evaluation{Lines: n, Templates: len(groupSizes), TruthTemplates: len(truthSizes)}
+	correctlyGrouped, samePairs := 0, 0
+	for c, count := range cells {
+		if count == groupSizes[c.group] && count == truthSizes[c.truth] {
+			correctlyGrouped += count
+		}
+		samePairs += pairs(count)
+	}
+	groupPairs, truthPairs := 0, 0
+	for _, size := range groupSizes {
+		groupPairs += pairs(size)
+	}
+	for _, size := range truthSizes {
+		truthPairs += pairs(size)
+	}
+	evaluation.GroupingAccuracy = float64(correctlyGrouped) / float64(n)
+	evaluation.Precision = pairRatio(samePairs, groupPairs)
+	evaluation.Recall = pairRatio(samePairs, truthPairs)
+	if sum := evaluation.Precision + evaluation.Recall; sum > 0 {
+		evaluation.F1 = 2 * evaluation.Precision * evaluation.Recall / sum
+	}
+
+	parsed := 0
+	normalized := make(map[string]string)
+	normalize := func(template string) string {
+		if words, ok := normalized[template]; ok {
+			return words
+		}
+		words := preprocessor.templateWords(template)
+		normalized[template] = words
+		return words
+	}
+	for i, group := range groups {
+		if group < len(results) && normalize(results[group].Template) == normalize(truth.Templates[i]) {
+			parsed++
+		}
+	}
+	evaluation.ParsingAccuracy = float64(parsed) / float64(n)
+	return evaluation, nil
+}
+
+// templateWords returns the words of a template split by the delimiters,
+// with runs of <*> merged, joined by single spaces
+func (p *Preprocessor) templateWords(template string) string {
+	words := p.splitWithoutFiltering(template)
+	merged := words[:0]
+	for _, word := range words {
+		if p.slots.isSlot(word) {
+			word = "<*>" // Ground truths know only <*>
+		}
+		if word == "<*>" && len(merged) > 0 && merged[len(merged)-1] == "<*>" {
+			continue
+		}
+		merged = append(merged, word)
+	}
+	return strings.Join(merged, " ")
+}
+
+// pairs returns the number of unordered pairs of n items
+func pairs(n int) int {
+	return n * (n - 1) / 2
+}
+
+// pairRatio returns matched divided by total pairs, or 1 if there are no
+// pairs to match
+func pairRatio(matched, total int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(matched) / float64(total)
+}
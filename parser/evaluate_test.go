@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testStructuredCSV = `LineId,Date,Content,EventId,EventTemplate
+1,081109,"Receiving block blk_1 src: /10.0.0.1:50010",E1,Receiving block <*> src: <*>
+2,081109,"Receiving block blk_2 src: /10.0.0.2:50010",E1,Receiving block <*> src: <*>
+3,081109,"Verification succeeded for blk_1",E2,Verification succeeded for <*>
+4,081109,"Verification succeeded for blk_2",E2,Verification succeeded for <*>
+5,081109,"Deleting block blk_1 file /data/blk_1",E3,Deleting block <*> file <*>
+6,081109,,E3,Deleting block <*> file <*>
+`
+
+func TestReadLogHubStructured(t *testing.T) {
+	truth, err := ReadLogHubStructured(strings.NewReader("\xEF\xBB\xBF" + testStructuredCSV))
+	if err != nil {
+		t.Fatalf("ReadLogHubStructured error: %v", err)
+	}
+	if len(truth.Lines) != 5 || truth.Lines[2] != "Verification succeeded for blk_1" || truth.EventIDs[4] != "E3" || truth.Templates[0] != "Receiving block <*> src: <*>" {
+		t.Errorf("Unexpected ground truth %+v", truth)
+	}
+
+	truth, err = ReadLogHubStructured(strings.NewReader("Content,EventTemplate\nDisk full,Disk full\n"))
+	if err != nil || truth.EventIDs[0] != "Disk full" {
+		t.Errorf("Expected templates as event IDs without EventId, got %+v (%v)", truth, err)
+	}
+	if _, err := ReadLogHubStructured(strings.NewReader("LineId,Content\n1,x\n")); err == nil {
+		t.Error("Expected an error without EventTemplate")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	truth, err := ReadLogHubStructured(strings.NewReader(testStructuredCSV))
+	if err != nil {
+		t.Fatalf("ReadLogHubStructured error: %v", err)
+	}
+	brainParser := New(Config{})
+	results := []*ParseResult{
+		{Template: "Receiving block <*> src <*> <*>", LogIDs: []int{0, 1}},   // Correct, delimiters dropped
+		{Template: "Verification succeeded for <*>", LogIDs: []int{2, 3, 4}}, // Merged with E3
+	}
+	evaluation, err := brainParser.Evaluate(results, truth)
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	if evaluation.Lines != 5 || evaluation.Templates != 2 || evaluation.TruthTemplates != 3 {
+		t.Errorf("Unexpected counts %+v", evaluation)
+	}
+	if evaluation.GroupingAccuracy != 0.4 || evaluation.ParsingAccuracy != 0.8 {
+		t.Errorf("Expected GA 0.4 and PA 0.8, got %+v", evaluation)
+	}
+	// 2 of 4 grouped pairs are right, the 2 true pairs are both found
+	if evaluation.Precision != 0.5 || evaluation.Recall != 1 || math.Abs(evaluation.F1-2.0/3) > 1e-9 {
+		t.Errorf("Unexpected pairwise metrics %+v", evaluation)
+	}
+
+	parsed := brainParser.Parse(truth.Lines)
+	if evaluation, err = brainParser.Evaluate(parsed, truth); err != nil || evaluation.GroupingAccuracy != 1 {
+		t.Errorf("Expected Brain to group the sample perfectly, got %+v (%v)", evaluation, err)
+	}
+}
@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+)
+
+// setExamples fills the Examples of every result with up to
+// Config.ExamplesPerTemplate member lines, reservoir-sampled from its LogIDs
+// and kept in input order. Sampling is seeded by the template, so repeated
+// parses of the same input pick the same lines.
+func (p *BrainParser) setExamples(results []*ParseResult, logLines []string) {
+	limit := p.config.ExamplesPerTemplate
+	for _, result := range results {
+		rng := exampleRand(result.Template)
+		sample := make([]int, 0, min(limit, len(result.LogIDs)))
+		for i, id := range result.LogIDs {
+			if len(sample) < limit {
+				sample = append(sample, id)
+			} else if j := rng.IntN(i + 1); j < limit {
+				sample[j] = id
+			}
+		}
+		sort.Ints(sample)
+		result.Examples = nil
+		for _, id := range sample {
+			if id >= 0 && id < len(logLines) {
+				result.Examples = append(result.Examples, logLines[id])
+			}
+		}
+	}
+}
+
+// mergeExamples combines the examples of target and a result merged into
+// it, before their counts are added. If there are more than limit, each
+// example is drawn from the side whose remaining examples stand for more
+// lines, so the merged sample stays proportional to the counts.
+func mergeExamples(target, source *ParseResult, limit int) {
+	if len(source.Examples) == 0 {
+		return
+	}
+	a, b := target.Examples, source.Examples
+	if len(a)+len(b) <= limit {
+		target.Examples = append(append(make([]string, 0, len(a)+len(b)), a...), b...)
+		return
+	}
+
+	rng := exampleRand(target.Template)
+	perA, perB := 0.0, 0.0
+	if len(a) > 0 {
+		perA = float64(target.Count) / float64(len(a))
+	}
+	perB = float64(source.Count) / float64(len(b))
+	merged := make([]string, 0, limit)
+	i, j := 0, 0
+	for len(merged) < limit {
+		weightA := perA * float64(len(a)-i)
+		weightB := perB * float64(len(b)-j)
+		if j == len(b) || i < len(a) && rng.Float64()*(weightA+weightB) < weightA {
+			merged = append(merged, a[i])
+			i++
+		} else {
+			merged = append(merged, b[j])
+			j++
+		}
+	}
+	target.Examples = merged
+}
+
+// exampleRand returns a random source seeded by template
+func exampleRand(template string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(template))
+	return rand.New(rand.NewPCG(h.Sum64(), 0))
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBrain_ExamplesPerTemplate(t *testing.T) {
+	var lines []string
+	for i := range 50 {
+		lines = append(lines, fmt.Sprintf("User user%d logged in", i))
+	}
+	lines = append(lines, "Disk full")
+
+	config := Config{Delimiters: `\s+`, ExamplesPerTemplate: 3}
+	results := New(config).Parse(lines)
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 templates, got %+v", results)
+	}
+	for _, result := range results {
+		if len(result.Examples) != min(3, result.Count) {
+			t.Errorf("Expected %d examples for %q, got %v", min(3, result.Count), result.Template, result.Examples)
+		}
+		for _, example := range result.Examples {
+			if !strings.HasPrefix(example, strings.Fields(result.Template)[0]) {
+				t.Errorf("Example %q is not a member line of %q", example, result.Template)
+			}
+		}
+	}
+
+	// Sampling is reproducible
+	again := New(config).Parse(lines)
+	if !reflect.DeepEqual(results[0].Examples, again[0].Examples) {
+		t.Errorf("Expected the same examples, got %v and %v", results[0].Examples, again[0].Examples)
+	}
+
+	if results := New(Config{Delimiters: `\s+`}).Parse(lines); results[0].Examples != nil {
+		t.Errorf("Expected no examples by default, got %v", results[0].Examples)
+	}
+}
+
+func TestMergeExamples(t *testing.T) {
+	target := &ParseResult{Template: "a <*>", Count: 1000, Examples: []string{"a 1", "a 2"}}
+	mergeExamples(target, &ParseResult{Count: 1, Examples: []string{"a 3"}}, 5)
+	if !reflect.DeepEqual(target.Examples, []string{"a 1", "a 2", "a 3"}) {
+		t.Errorf("Expected all examples below the limit, got %v", target.Examples)
+	}
+
+	mergeExamples(target, &ParseResult{Count: 1, Examples: []string{"a 4", "a 5", "a 6"}}, 3)
+	if len(target.Examples) != 3 {
+		t.Fatalf("Expected 3 examples, got %v", target.Examples)
+	}
+	// The source stands for one line against a thousand
+	if !reflect.DeepEqual(target.Examples, []string{"a 1", "a 2", "a 3"}) {
+		t.Errorf("Expected the examples of the larger side, got %v", target.Examples)
+	}
+}
+
+func TestStreamingProcessorExamples(t *testing.T) {
+	var lines []string
+	for i := range 200 {
+		lines = append(lines, fmt.Sprintf("Request %d served", i))
+	}
+	sp := NewStreamingProcessor(Config{Delimiters: `\s+`, ExamplesPerTemplate: 4}, StreamingConfig{BatchSize: 50, MaxWorkers: 4})
+	results, err := sp.ProcessLargeSlice(context.Background(), lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Count != 200 || len(results[0].Examples) != 4 {
+		t.Fatalf("Expected one template with 4 examples merged from the batches, got %+v", results)
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// foldTable maps look-alike characters to their ASCII form: the NFKC
+// compatibility mappings common in logs copied from web UIs (spaces,
+// ligatures, ellipsis) plus typographic quotes and dashes, which NFKC keeps.
+// An empty string removes the character.
+var foldTable = map[rune]string{
+	// Spaces
+	'\u00A0': " ", '\u1680': " ", '\u202F': " ", '\u205F': " ", '\u3000': " ",
+	// Invisible characters
+	'\u200B': "", '\u200C': "", '\u200D': "", '\u2060': "", '\uFEFF': "",
+	// Quotes and primes
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`,
+	// Dashes and minus
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	// Punctuation and ligatures
+	'․': ".", '‥': "..", '…': "...",
+	'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl",
+}
+
+// foldUnicode folds non-breaking and other Unicode spaces, full-width ASCII,
+// smart quotes, dashes and similar look-alikes in line to ASCII. It reports
+// whether the line was changed.
+func foldUnicode(line string) (string, bool) {
+	i := 0
+	for i < len(line) && line[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(line) {
+		return line, false // ASCII fast path
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(line))
+	sb.WriteString(line[:i])
+	changed := false
+	for _, r := range line[i:] {
+		switch {
+		case r >= '\uFF01' && r <= '\uFF5E': // Full-width ASCII
+			sb.WriteRune(r - 0xFEE0)
+			changed = true
+		case r >= '\u2000' && r <= '\u200A': // En quad ... hair space
+			sb.WriteByte(' ')
+			changed = true
+		default:
+			if folded, ok := foldTable[r]; ok {
+				sb.WriteString(folded)
+				changed = true
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	if !changed {
+		return line, false
+	}
+	return sb.String(), true
+}
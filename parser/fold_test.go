@@ -0,0 +1,47 @@
+package parser
+
+import "testing"
+
+func TestFoldUnicode(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected string
+		changed  bool
+	}{
+		{"plain ascii", "plain ascii", false},
+		{"ошибка диска", "ошибка диска", false}, // Letters are kept
+		{"user\u00A0alice", "user alice", true},
+		{"“quoted” and ‘single’", `"quoted" and 'single'`, true},
+		{"ＥＲＲＯＲ：\u3000ｆｕｌｌ", "ERROR: full", true},
+		{"retry 1–2…", "retry 1-2...", true},
+		{"\uFEFFstart\u200B", "start", true},
+	}
+	for _, tt := range tests {
+		got, changed := foldUnicode(tt.line)
+		if got != tt.expected || changed != tt.changed {
+			t.Errorf("foldUnicode(%q) = %q, %v; want %q, %v", tt.line, got, changed, tt.expected, tt.changed)
+		}
+	}
+}
+
+func TestBrain_FoldUnicode(t *testing.T) {
+	logLines := []string{
+		"Connection\u00A0refused by server1",
+		"Connection refused by server2",
+		"Connection refused by server3",
+		"Connection refused by server4",
+	}
+
+	report := New(Config{Delimiters: `\s+`}).ParseWithReport(logLines)
+	if report.FoldedLines != 0 {
+		t.Errorf("Expected no folded lines without FoldUnicode, got %d", report.FoldedLines)
+	}
+
+	report = New(Config{Delimiters: `\s+`, FoldUnicode: true}).ParseWithReport(logLines)
+	if report.FoldedLines != 1 {
+		t.Errorf("Expected 1 folded line, got %d", report.FoldedLines)
+	}
+	if len(report.Results) != 1 || report.Results[0].Count != 4 {
+		t.Errorf("Expected folded lines to share one template, got %d templates", len(report.Results))
+	}
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+)
+
+// FrequencySchemaVersion is the version of the document written by
+// FrequencyTable.Save.
+const FrequencySchemaVersion = 1
+
+// minDecayedFrequency is the weight below which Decay drops a word
+const minDecayedFrequency = 0.5
+
+// FrequencyTable accumulates word frequencies across Parse calls. Shared via
+// Config.Frequencies, the Brain algorithm sees the frequencies of all lines
+// learned so far instead of those of the current batch only, so templates of
+// an OnlineParser, a StreamingProcessor and plain parsers stay consistent
+// between batches. Decay ages the vocabulary. All methods are safe for
+// concurrent use.
+type FrequencyTable struct {
+	mu     sync.RWMutex
+	counts map[string]float64
+}
+
+// savedFrequencies is the serialized form of a FrequencyTable
+type savedFrequencies struct {
+	Version int                `json:"version"`
+	Words   map[string]float64 `json:"words"`
+}
+
+// NewFrequencyTable creates an empty frequency table.
+func NewFrequencyTable() *FrequencyTable {
+	return &FrequencyTable{counts: make(map[string]float64)}
+}
+
+// Add counts every word weight times.
+func (t *FrequencyTable) Add(words []string, weight int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, word := range words {
+		t.counts[word] += float64(weight)
+	}
+}
+
+// Count returns the frequency of word, rounded after decay.
+func (t *FrequencyTable) Count(word string) int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return int(math.Round(t.counts[word]))
+}
+
+// Len returns the number of words in the table.
+func (t *FrequencyTable) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.counts)
+}
+
+// Decay multiplies all frequencies by factor (between 0 and 1), so words not
+// seen recently lose weight against current ones. Words whose frequency drops
+// below 0.5 are removed.
+func (t *FrequencyTable) Decay(factor float64) {
+	factor = max(0, min(factor, 1))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for word, count := range t.counts {
+		if count *= factor; count < minDecayedFrequency {
+			delete(t.counts, word)
+		} else {
+			t.counts[word] = count
+		}
+	}
+}
+
+// Snapshot returns an independent copy of the table.
+func (t *FrequencyTable) Snapshot() *FrequencyTable {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	snapshot := &FrequencyTable{counts: make(map[string]float64, len(t.counts))}
+	for word, count := range t.counts {
+		snapshot.counts[word] = count
+	}
+	return snapshot
+}
+
+// Restore replaces the contents of the table with those of snapshot.
+func (t *FrequencyTable) Restore(snapshot *FrequencyTable) {
+	restored := snapshot.Snapshot().counts
+	t.mu.Lock()
+	t.counts = restored
+	t.mu.Unlock()
+}
+
+// Save writes the table as JSON.
+func (t *FrequencyTable) Save(w io.Writer) error {
+	t.mu.RLock()
+	doc := savedFrequencies{Version: FrequencySchemaVersion, Words: t.counts}
+	err := json.NewEncoder(w).Encode(doc)
+	t.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to write frequencies: %w", err)
+	}
+	return nil
+}
+
+// LoadFrequencyTable reads a table written by FrequencyTable.Save.
+func LoadFrequencyTable(r io.Reader) (*FrequencyTable, error) {
+	var doc savedFrequencies
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read frequencies: %w", err)
+	}
+	if doc.Version > FrequencySchemaVersion {
+		return nil, fmt.Errorf("unsupported frequencies version %d (supported up to %d)", doc.Version, FrequencySchemaVersion)
+	}
+	t := NewFrequencyTable()
+	for word, count := range doc.Words {
+		if count > 0 {
+			t.counts[word] = count
+		}
+	}
+	return t, nil
+}
+
+// addBatch adds the word counts of a batch and returns the accumulated
+// frequency of every word of the batch, in one step so concurrent batches
+// see consistent totals
+func (t *FrequencyTable) addBatch(batch map[string]int) map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	totals := make(map[string]int, len(batch))
+	for word, count := range batch {
+		t.counts[word] += float64(count)
+		totals[word] = int(math.Round(t.counts[word]))
+	}
+	return totals
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFrequencyTable(t *testing.T) {
+	table := NewFrequencyTable()
+	table.Add([]string{"User", "alice", "User"}, 1)
+	table.Add([]string{"User"}, 3)
+	if got := table.Count("User"); got != 5 {
+		t.Errorf("Count(User) = %d, want 5", got)
+	}
+	if got := table.Len(); got != 2 {
+		t.Errorf("Len() = %d, want 2", got)
+	}
+
+	snapshot := table.Snapshot()
+	table.Decay(0.4)
+	if got := table.Count("User"); got != 2 {
+		t.Errorf("Count(User) after decay = %d, want 2", got)
+	}
+	if got := table.Count("alice"); got != 0 || table.Len() != 1 {
+		t.Errorf("Expected alice to decay away, got count %d and %d words", got, table.Len())
+	}
+	if got := snapshot.Count("alice"); got != 1 {
+		t.Errorf("Expected snapshot to be unaffected by decay, got %d", got)
+	}
+
+	table.Restore(snapshot)
+	if table.Count("User") != 5 || table.Count("alice") != 1 {
+		t.Errorf("Unexpected restored counts %d, %d", table.Count("User"), table.Count("alice"))
+	}
+
+	var buf bytes.Buffer
+	if err := table.Save(&buf); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	loaded, err := LoadFrequencyTable(&buf)
+	if err != nil {
+		t.Fatalf("LoadFrequencyTable error: %v", err)
+	}
+	if loaded.Count("User") != 5 || loaded.Len() != 2 {
+		t.Errorf("Unexpected loaded table with %d words", loaded.Len())
+	}
+	if _, err := LoadFrequencyTable(strings.NewReader(`{"version":99}`)); err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+}
+
+func TestFrequencyTableConcurrent(t *testing.T) {
+	table := NewFrequencyTable()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				table.Add([]string{"a", "b"}, 1)
+				table.addBatch(map[string]int{"a": 1})
+				_ = table.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := table.Count("a"); got != 1600 {
+		t.Errorf("Count(a) = %d, want 1600", got)
+	}
+	if got := table.Count("b"); got != 800 {
+		t.Errorf("Count(b) = %d, want 800", got)
+	}
+}
+
+func TestSharedFrequencies(t *testing.T) {
+	table := NewFrequencyTable()
+	p := New(Config{Delimiters: `\s+`, Frequencies: table})
+	p.Parse([]string{
+		"User alice logged in",
+		"User carol logged in",
+		"User dave logged in",
+	})
+	if got := table.Count("logged"); got != 3 {
+		t.Fatalf("Expected parse to add to the table, got count %d", got)
+	}
+
+	// A single line learns against the frequencies of earlier batches
+	online := NewOnlineParser(Config{Delimiters: `\s+`, Frequencies: table})
+	results := online.Add([]string{"Session bob opened by eve"})
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 template, got %+v", results)
+	}
+	if got := table.Count("Session"); got != 1 {
+		t.Errorf("Expected online parser to share the table, got count %d", got)
+	}
+
+	// Internal reparsing never adds to the table
+	before := table.Count("User")
+	New(Config{Delimiters: `\s+`, Frequencies: table, isReparsing: true}).Parse([]string{"User x logged in"})
+	if got := table.Count("User"); got != before {
+		t.Errorf("Expected reparsing to keep the table, got %d, want %d", got, before)
+	}
+}
+
+func TestSharedFrequenciesStreaming(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 400; i++ {
+		logLines = append(logLines, fmt.Sprintf("Request %d served in %dms", i, i%17))
+	}
+
+	table := NewFrequencyTable()
+	sp := NewStreamingProcessor(Config{Delimiters: `\s+`, Frequencies: table}, StreamingConfig{BatchSize: 50, MaxWorkers: 4})
+	if _, err := sp.ProcessLargeSlice(context.Background(), logLines); err != nil {
+		t.Fatalf("ProcessLargeSlice error: %v", err)
+	}
+	if got := table.Count("served"); got != len(logLines) {
+		t.Errorf("Expected all batches in the shared table, got %d, want %d", got, len(logLines))
+	}
+}
@@ -0,0 +1,94 @@
+package parser
+
+import "sync/atomic"
+
+// FrozenMatcher is an immutable snapshot of the templates a parser learned.
+// It classifies lines like BrainParser.Match but never takes a lock, so it
+// can be shared by any number of goroutines. Create it with Freeze and
+// publish updates through an AtomicMatcher.
+type FrozenMatcher struct {
+	matcher *TemplateMatcher // nil if no templates were learned
+	entries []frozenTemplate // Aligned with the matcher templates
+	words   func(line string) []string
+}
+
+// frozenTemplate is the frozen metadata of one template
+type frozenTemplate struct {
+	id    string
+	count int
+	keep  bool // Passes the allow/deny lists
+}
+
+// Freeze returns an immutable snapshot of the templates learned by previous
+// Parse calls (or loaded with LoadState) with their current counts and IDs.
+// Later Parse calls do not affect the snapshot.
+func (p *BrainParser) Freeze() *FrozenMatcher {
+	frozen := &FrozenMatcher{}
+	if p.state == nil {
+		return frozen
+	}
+	templates, matcher := p.state.knownMatcher()
+	if matcher == nil {
+		return frozen
+	}
+
+	frozen.matcher = matcher
+	frozen.words = p.lineWords
+	frozen.entries = make([]frozenTemplate, len(templates))
+	p.state.mu.Lock()
+	for i, template := range templates {
+		frozen.entries[i].count = p.state.counts[template]
+	}
+	p.state.mu.Unlock()
+	for i, template := range templates {
+		frozen.entries[i].keep = p.templateFilter == nil || p.templateFilter.keep(template)
+		frozen.entries[i].id = p.config.TemplateID(template)
+	}
+	return frozen
+}
+
+// Len returns the number of templates in the snapshot.
+func (fm *FrozenMatcher) Len() int {
+	return len(fm.entries)
+}
+
+// Match classifies a line against the frozen templates. The returned result
+// holds the template, its ID, its count at freeze time and the severity of
+// the line; LogIDs are not set.
+func (fm *FrozenMatcher) Match(line string) (*ParseResult, bool) {
+	if fm == nil || fm.matcher == nil {
+		return nil, false
+	}
+	i := fm.matcher.matchWords(fm.words(line))
+	if i < 0 || !fm.entries[i].keep {
+		return nil, false
+	}
+	return &ParseResult{
+		ID:       fm.entries[i].id,
+		Template: fm.matcher.templates[i],
+		Count:    fm.entries[i].count,
+		Severity: InferLineSeverity(line),
+	}, true
+}
+
+// AtomicMatcher holds the current FrozenMatcher of a service. Readers match
+// against whatever snapshot is current while the learner publishes new ones
+// with Store; neither side blocks the other. The zero value matches nothing.
+type AtomicMatcher struct {
+	current atomic.Pointer[FrozenMatcher]
+}
+
+// Load returns the current snapshot, or nil if none was stored.
+func (am *AtomicMatcher) Load() *FrozenMatcher {
+	return am.current.Load()
+}
+
+// Store publishes a new snapshot for subsequent matches.
+func (am *AtomicMatcher) Store(fm *FrozenMatcher) {
+	am.current.Store(fm)
+}
+
+// Match classifies a line against the current snapshot.
+func (am *AtomicMatcher) Match(line string) (*ParseResult, bool) {
+	return am.current.Load().Match(line)
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`, TemplateID: SequentialTemplateIDs("T")})
+	if _, ok := p.Freeze().Match("User alice logged in"); ok {
+		t.Error("Expected no match before any Parse call")
+	}
+
+	var logLines []string
+	for i := 0; i < 10; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+	p.Parse(logLines)
+	frozen := p.Freeze()
+	if frozen.Len() != 1 {
+		t.Fatalf("Expected 1 frozen template, got %d", frozen.Len())
+	}
+
+	// Later parses do not change the snapshot
+	p.Parse(logLines)
+	result, ok := frozen.Match("User dave logged in")
+	if !ok || result.Template != "User <*> logged in" || result.Count != 10 || result.ID != "T1" {
+		t.Errorf("Unexpected frozen match %+v, %v", result, ok)
+	}
+	if live, _ := p.Match("User dave logged in"); live.Count != 20 {
+		t.Errorf("Expected live count 20, got %d", live.Count)
+	}
+	if _, ok := frozen.Match("Disk full"); ok {
+		t.Error("Expected no match for unknown line")
+	}
+}
+
+func TestAtomicMatcher(t *testing.T) {
+	var am AtomicMatcher
+	if _, ok := am.Match("User alice logged in"); ok {
+		t.Error("Expected zero AtomicMatcher to match nothing")
+	}
+
+	p := New(Config{Delimiters: `\s+`})
+	var logLines []string
+	for i := 0; i < 10; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+	p.Parse(logLines)
+	am.Store(p.Freeze())
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				if _, ok := am.Match("User bob logged in"); !ok {
+					t.Error("Expected concurrent match")
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		p.Parse(logLines)
+		am.Store(p.Freeze())
+	}
+	wg.Wait()
+
+	if result, _ := am.Match("User bob logged in"); result.Count != 60 {
+		t.Errorf("Expected count of latest snapshot 60, got %d", result.Count)
+	}
+}
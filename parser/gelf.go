@@ -0,0 +1,229 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GELF chunking limits (GELF 1.1 specification)
+const (
+	gelfMaxChunks    = 128
+	gelfChunkTimeout = 5 * time.Second
+	gelfChunkHeader  = 12 // Magic, message ID, sequence number and count
+)
+
+// gelfMaxMessageSize limits decompressed GELF messages
+const gelfMaxMessageSize = defaultMaxLineSize
+
+// gelfChunkMagic starts every chunk of a chunked GELF message
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFLogs is the content of a GELF file read by ReadGELF.
+type GELFLogs struct {
+	Lines   []string            // short_message of every message in input order
+	Fields  []map[string]string // Other fields per line, aligned with Lines (see ParseGELF)
+	Skipped int                 // Non-empty lines that are no GELF message
+}
+
+// ReadGELF reads newline-delimited GELF (Graylog Extended Log Format) JSON
+// messages and returns their short_message for template mining with the
+// other fields of every message as metadata.
+func ReadGELF(reader io.Reader) (*GELFLogs, error) {
+	logs := &GELFLogs{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		message, fields, err := ParseGELF(line)
+		if err != nil {
+			logs.Skipped++
+			continue
+		}
+		logs.Lines = append(logs.Lines, message)
+		logs.Fields = append(logs.Fields, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading GELF messages: %w", err)
+	}
+	return logs, nil
+}
+
+// ParseGELF decodes an uncompressed GELF JSON message and returns its
+// short_message and the fields host, timestamp, level and facility as
+// present, plus every additional field without its leading underscore.
+// version and full_message are dropped; additional fields do not overwrite
+// standard ones.
+func ParseGELF(data []byte) (message string, fields map[string]string, err error) {
+	var object map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // Keep timestamps and levels as written
+	if err := decoder.Decode(&object); err != nil || object == nil {
+		return "", nil, errors.New("invalid GELF message: no JSON object")
+	}
+	message, _ = jsonString(object["short_message"])
+	if message = strings.TrimSpace(message); message == "" {
+		return "", nil, errors.New("invalid GELF message: no short_message")
+	}
+
+	fields = make(map[string]string)
+	for _, key := range []string{"host", "timestamp", "level", "facility"} {
+		if value, ok := jsonString(object[key]); ok {
+			fields[key] = value
+		}
+	}
+	for key, value := range object {
+		name, additional := strings.CutPrefix(key, "_")
+		if !additional || name == "" {
+			continue
+		}
+		if _, standard := fields[name]; standard {
+			continue
+		}
+		if value, ok := jsonString(value); ok {
+			fields[name] = value
+		}
+	}
+	return message, fields, nil
+}
+
+// GELFReceiver turns UDP datagrams into GELF JSON messages. It reassembles
+// chunked messages, discarding incomplete ones after 5 seconds, and
+// decompresses gzip and zlib payloads. It is safe for concurrent use.
+type GELFReceiver struct {
+	mu      sync.Mutex
+	pending map[uint64]*gelfChunks
+	now     func() time.Time
+}
+
+// gelfChunks collects the chunks of one message
+type gelfChunks struct {
+	started  time.Time
+	chunks   [][]byte
+	received int
+}
+
+// NewGELFReceiver creates a receiver without pending chunks.
+func NewGELFReceiver() *GELFReceiver {
+	return &GELFReceiver{pending: make(map[uint64]*gelfChunks), now: time.Now}
+}
+
+// Receive processes one datagram and returns the complete decompressed
+// message, or nil if the datagram is a chunk of a message still incomplete.
+func (gr *GELFReceiver) Receive(datagram []byte) ([]byte, error) {
+	if !bytes.HasPrefix(datagram, gelfChunkMagic) {
+		return decompressGELF(datagram)
+	}
+	if len(datagram) < gelfChunkHeader {
+		return nil, errors.New("invalid GELF chunk: short header")
+	}
+	id := binary.BigEndian.Uint64(datagram[2:10])
+	sequence, count := int(datagram[10]), int(datagram[11])
+	if count == 0 || count > gelfMaxChunks || sequence >= count {
+		return nil, fmt.Errorf("invalid GELF chunk: sequence %d of %d", sequence, count)
+	}
+
+	gr.mu.Lock()
+	defer gr.mu.Unlock()
+	now := gr.now()
+	for pendingID, message := range gr.pending {
+		if now.Sub(message.started) > gelfChunkTimeout {
+			delete(gr.pending, pendingID)
+		}
+	}
+	message := gr.pending[id]
+	if message == nil {
+		message = &gelfChunks{started: now, chunks: make([][]byte, count)}
+		gr.pending[id] = message
+	}
+	if len(message.chunks) != count {
+		delete(gr.pending, id)
+		return nil, errors.New("invalid GELF chunk: inconsistent sequence count")
+	}
+	if message.chunks[sequence] == nil {
+		message.chunks[sequence] = append([]byte(nil), datagram[gelfChunkHeader:]...)
+		message.received++
+	}
+	if message.received < count {
+		return nil, nil
+	}
+	delete(gr.pending, id)
+	return decompressGELF(bytes.Join(message.chunks, nil))
+}
+
+// decompressGELF detects gzip and zlib compressed payloads by their magic
+// bytes and returns them decompressed; other payloads are returned as-is
+func decompressGELF(payload []byte) ([]byte, error) {
+	var reader io.ReadCloser
+	var err error
+	switch {
+	case len(payload) >= 2 && payload[0] == 0x1f && payload[1] == 0x8b:
+		reader, err = gzip.NewReader(bytes.NewReader(payload))
+	case len(payload) >= 2 && payload[0] == 0x78 && binary.BigEndian.Uint16(payload)%31 == 0:
+		reader, err = zlib.NewReader(bytes.NewReader(payload))
+	default:
+		return payload, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed GELF message: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+	data, err := io.ReadAll(io.LimitReader(reader, gelfMaxMessageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("invalid compressed GELF message: %w", err)
+	}
+	if len(data) > gelfMaxMessageSize {
+		return nil, errors.New("GELF message too large")
+	}
+	return data, nil
+}
+
+// ListenGELF receives GELF messages from conn, e.g. a UDP listener from
+// net.ListenPacket("udp", ":12201"), and calls handle with the short_message
+// and fields of every message (see ParseGELF) until ctx is canceled or conn
+// fails. Datagrams that are no valid GELF message are passed to onError if
+// set and dropped. conn is closed when ListenGELF returns.
+func ListenGELF(ctx context.Context, conn net.PacketConn, handle func(message string, fields map[string]string), onError func(error)) error {
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer func() {
+		stop()
+		_ = conn.Close()
+	}()
+
+	receiver := NewGELFReceiver()
+	buffer := make([]byte, 64*1024) // Largest UDP datagram
+	for {
+		n, _, err := conn.ReadFrom(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("error receiving GELF messages: %w", err)
+		}
+		data, err := receiver.Receive(buffer[:n])
+		if err == nil && data != nil {
+			var message string
+			var fields map[string]string
+			if message, fields, err = ParseGELF(data); err == nil {
+				handle(message, fields)
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGELF(t *testing.T) {
+	data := `{"version":"1.1","host":"web1","short_message":"User alice logged in","full_message":"trace",` +
+		`"timestamp":1705312800.5,"level":6,"_user":"alice","_host":"spoofed","_":"x"}`
+	message, fields, err := ParseGELF([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseGELF error: %v", err)
+	}
+	if message != "User alice logged in" {
+		t.Errorf("message = %q", message)
+	}
+	expected := map[string]string{"host": "web1", "timestamp": "1705312800.5", "level": "6", "user": "alice"}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("fields = %v, want %v", fields, expected)
+	}
+
+	for _, invalid := range []string{`not json`, `{"host":"web1"}`, `{"short_message":"  "}`, `null`} {
+		if _, _, err := ParseGELF([]byte(invalid)); err == nil {
+			t.Errorf("ParseGELF(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestReadGELF(t *testing.T) {
+	data := `{"version":"1.1","host":"web1","short_message":"Disk full on sda","level":3}
+
+{"version":"1.1","host":"web2"}
+{"version":"1.1","host":"web2","short_message":"Disk full on sdb","_device":"sdb"}
+`
+	logs, err := ReadGELF(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadGELF error: %v", err)
+	}
+	if !reflect.DeepEqual(logs.Lines, []string{"Disk full on sda", "Disk full on sdb"}) {
+		t.Errorf("Unexpected lines %q", logs.Lines)
+	}
+	if logs.Fields[0]["level"] != "3" || logs.Fields[1]["device"] != "sdb" {
+		t.Errorf("Unexpected fields %v", logs.Fields)
+	}
+	if logs.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", logs.Skipped)
+	}
+}
+
+// gelfChunk builds a chunk datagram
+func gelfChunk(id byte, sequence, count int, payload []byte) []byte {
+	chunk := []byte{0x1e, 0x0f, 0, 0, 0, 0, 0, 0, 0, id, byte(sequence), byte(count)}
+	return append(chunk, payload...)
+}
+
+func TestGELFReceiver(t *testing.T) {
+	message := []byte(`{"short_message":"Connection reset by peer"}`)
+
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, _ = gz.Write(message)
+	_ = gz.Close()
+	var zlibbed bytes.Buffer
+	zw := zlib.NewWriter(&zlibbed)
+	_, _ = zw.Write(message)
+	_ = zw.Close()
+
+	receiver := NewGELFReceiver()
+	for name, datagram := range map[string][]byte{"plain": message, "gzip": gzipped.Bytes(), "zlib": zlibbed.Bytes()} {
+		data, err := receiver.Receive(datagram)
+		if err != nil || !bytes.Equal(data, message) {
+			t.Errorf("%s: Receive = %q, %v", name, data, err)
+		}
+	}
+
+	// Chunks of a gzipped message arriving out of order
+	payload := gzipped.Bytes()
+	half := len(payload) / 2
+	if data, err := receiver.Receive(gelfChunk(1, 1, 2, payload[half:])); data != nil || err != nil {
+		t.Fatalf("First chunk returned %q, %v", data, err)
+	}
+	if data, err := receiver.Receive(gelfChunk(1, 0, 2, payload[:half])); err != nil || !bytes.Equal(data, message) {
+		t.Errorf("Reassembled message = %q, %v", data, err)
+	}
+
+	// Incomplete messages expire
+	now := time.Now()
+	receiver.now = func() time.Time { return now }
+	_, _ = receiver.Receive(gelfChunk(2, 0, 2, message[:10]))
+	now = now.Add(gelfChunkTimeout + time.Second)
+	_, _ = receiver.Receive(gelfChunk(3, 0, 2, message[:10]))
+	if _, ok := receiver.pending[2]; ok {
+		t.Error("Expected the incomplete message to expire")
+	}
+
+	for _, invalid := range [][]byte{{0x1e, 0x0f, 1}, gelfChunk(4, 2, 2, nil), gelfChunk(4, 0, 0, nil)} {
+		if _, err := receiver.Receive(invalid); err == nil {
+			t.Errorf("Receive(%v) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestListenGELF(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("UDP not available: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan string, 2)
+	errs := make(chan error, 2)
+	done := make(chan error, 1)
+	go func() {
+		done <- ListenGELF(ctx, conn, func(message string, fields map[string]string) {
+			received <- message + " from " + fields["host"]
+		}, func(err error) { errs <- err })
+	}()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Dial error: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	_, _ = client.Write([]byte(`no gelf`))
+	_, _ = client.Write([]byte(`{"host":"web1","short_message":"User alice logged in"}`))
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("Expected an error for the invalid datagram")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the invalid datagram")
+	}
+	select {
+	case message := <-received:
+		if message != "User alice logged in from web1" {
+			t.Errorf("Received %q", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the message")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("ListenGELF returned %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// defaultGrafanaTag marks every annotation exported for Grafana
+const defaultGrafanaTag = "go-brain"
+
+// GrafanaOptions contains options for exporting templates for Grafana.
+type GrafanaOptions struct {
+	Tags       []string // Tags of every annotation (default: "go-brain")
+	TotalLines int      // Line count the ratio column refers to (default: sum of the template counts)
+}
+
+// GrafanaExport is the document written by ExportGrafana: the templates as a
+// table in the format of the JSON/Infinity datasources and new-template
+// events as annotations in the format of the Grafana annotations HTTP API.
+type GrafanaExport struct {
+	Table       GrafanaTable        `json:"table"`
+	Annotations []GrafanaAnnotation `json:"annotations"`
+}
+
+// GrafanaTable is a table response of the Grafana JSON datasource format.
+type GrafanaTable struct {
+	Type    string          `json:"type"` // Always "table"
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+// GrafanaColumn is a column of a GrafanaTable.
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"` // string or number
+}
+
+// GrafanaAnnotation is an annotation as accepted by POST /api/annotations.
+type GrafanaAnnotation struct {
+	Time int64    `json:"time"` // Unix milliseconds
+	Tags []string `json:"tags"`
+	Text string   `json:"text"`
+}
+
+// NewGrafanaTable returns the templates as a table with the columns
+// template_id, template, count, ratio and severity, in the order of results.
+// Ratio is the share of totalLines (default: the sum of the counts).
+func NewGrafanaTable(results []*ParseResult, totalLines int) GrafanaTable {
+	if totalLines <= 0 {
+		for _, result := range results {
+			totalLines += result.Count
+		}
+	}
+	table := GrafanaTable{
+		Type: "table",
+		Columns: []GrafanaColumn{
+			{Text: "template_id", Type: "string"},
+			{Text: "template", Type: "string"},
+			{Text: "count", Type: "number"},
+			{Text: "ratio", Type: "number"},
+			{Text: "severity", Type: "string"},
+		},
+		Rows: make([][]any, 0, len(results)),
+	}
+	for _, result := range results {
+		ratio := 0.0
+		if totalLines > 0 {
+			ratio = float64(result.Count) / float64(totalLines)
+		}
+		table.Rows = append(table.Rows, []any{result.ID, result.Template, result.Count, ratio, result.Severity.String()})
+	}
+	return table
+}
+
+// NewTemplateAnnotations returns an annotation for every AuditCreated event,
+// tagged with tags (default: "go-brain"), "new-template" and the severity
+// inferred from the template. Other events are ignored.
+func NewTemplateAnnotations(events []AuditEvent, tags []string) []GrafanaAnnotation {
+	if tags == nil {
+		tags = []string{defaultGrafanaTag}
+	}
+	annotations := make([]GrafanaAnnotation, 0, len(events))
+	for _, event := range events {
+		if event.Event != AuditCreated {
+			continue
+		}
+		eventTags := append(append([]string(nil), tags...), "new-template", InferLineSeverity(event.Template).String())
+		if event.TemplateID != "" {
+			eventTags = append(eventTags, "template:"+event.TemplateID)
+		}
+		annotations = append(annotations, GrafanaAnnotation{
+			Time: event.Time.UnixMilli(),
+			Tags: eventTags,
+			Text: fmt.Sprintf("New template (%d lines): %s", event.Count, event.Template),
+		})
+	}
+	return annotations
+}
+
+// ExportGrafana writes the templates as a Grafana table and the AuditCreated
+// events as annotations in one JSON document (see GrafanaExport).
+func ExportGrafana(w io.Writer, results []*ParseResult, events []AuditEvent, opts GrafanaOptions) error {
+	export := GrafanaExport{
+		Table:       NewGrafanaTable(results, opts.TotalLines),
+		Annotations: NewTemplateAnnotations(events, opts.Tags),
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return fmt.Errorf("failed to write Grafana export: %w", err)
+	}
+	return nil
+}
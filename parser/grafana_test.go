@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewGrafanaTable(t *testing.T) {
+	results := []*ParseResult{
+		{ID: "a1", Template: "User <*> logged in", Count: 3, Severity: SeverityInfo},
+		{ID: "b2", Template: "Disk full", Count: 1, Severity: SeverityError},
+	}
+	table := NewGrafanaTable(results, 0)
+	if table.Type != "table" || len(table.Columns) != 5 {
+		t.Fatalf("Unexpected table header %+v", table)
+	}
+	expected := [][]any{
+		{"a1", "User <*> logged in", 3, 0.75, "info"},
+		{"b2", "Disk full", 1, 0.25, "error"},
+	}
+	if !reflect.DeepEqual(table.Rows, expected) {
+		t.Errorf("Rows = %v, want %v", table.Rows, expected)
+	}
+
+	if table := NewGrafanaTable(results, 8); table.Rows[0][3] != 0.375 {
+		t.Errorf("Expected ratio of totalLines, got %v", table.Rows[0][3])
+	}
+}
+
+func TestNewTemplateAnnotations(t *testing.T) {
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []AuditEvent{
+		{Time: at, Event: AuditCreated, Template: "Connection failed to <*>", TemplateID: "c3", Count: 2},
+		{Time: at, Event: AuditCountUpdated, Template: "User <*> logged in", Count: 9, Delta: 1},
+	}
+	annotations := NewTemplateAnnotations(events, nil)
+	if len(annotations) != 1 {
+		t.Fatalf("Expected 1 annotation, got %+v", annotations)
+	}
+	expected := GrafanaAnnotation{
+		Time: at.UnixMilli(),
+		Tags: []string{"go-brain", "new-template", "error", "template:c3"},
+		Text: "New template (2 lines): Connection failed to <*>",
+	}
+	if !reflect.DeepEqual(annotations[0], expected) {
+		t.Errorf("Annotation = %+v, want %+v", annotations[0], expected)
+	}
+
+	if annotations := NewTemplateAnnotations(events[:1], []string{"prod"}); annotations[0].Tags[0] != "prod" {
+		t.Errorf("Expected custom tags, got %v", annotations[0].Tags)
+	}
+}
+
+func TestExportGrafana(t *testing.T) {
+	var buf bytes.Buffer
+	results := []*ParseResult{{ID: "a1", Template: "User <*> logged in", Count: 1}}
+	if err := ExportGrafana(&buf, results, nil, GrafanaOptions{}); err != nil {
+		t.Fatalf("ExportGrafana error: %v", err)
+	}
+	var doc struct {
+		Table       GrafanaTable        `json:"table"`
+		Annotations []GrafanaAnnotation `json:"annotations"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if len(doc.Table.Rows) != 1 || doc.Annotations == nil || len(doc.Annotations) != 0 {
+		t.Errorf("Unexpected export %s", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("<*>")) {
+		t.Error("Expected unescaped wildcards")
+	}
+}
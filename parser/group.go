@@ -3,60 +3,132 @@ package parser
 import (
 	"regexp"
 	"sort"
+	"strings"
 )
 
 // CreateInitialGroups creates initial groups of logs.
 func CreateInitialGroups(logs []*LogMessage, config *Config) map[string]*LogGroup {
-	// 1. Group logs by length
-	logsByLength := make(map[int][]*LogMessage)
+	groups, _ := createInitialGroups(logs, config)
+	return groups
+}
+
+// createInitialGroups creates initial groups of logs and enforces the soft cap
+// on group count, returning audit entries for groups merged due to overflow.
+func createInitialGroups(logs []*LogMessage, config *Config) (map[string]*LogGroup, []MergeAuditEntry) {
+	finalGroups := make(map[string]*LogGroup)
+	overflow := newOverflowBuckets(config.MaxInitialGroups)
+
+	// Group logs by length and Longest Common Pattern, in input order so the
+	// cap keeps the same groups on every run
 	for _, log := range logs {
 		length := len(log.Words)
-		logsByLength[length] = append(logsByLength[length], log)
+		lcp := findLongestWordCombination(log, config)
+
+		// Always include length in the key to prevent collisions between groups of different lengths
+		sb := GetStringBuilder()
+		sb.WriteString(lcp.Key())
+		sb.WriteString("-len:")
+		writeInt(sb, length)
+		uniqueKey := sb.String()
+		PutStringBuilder(sb)
+
+		if group, exists := finalGroups[uniqueKey]; exists {
+			group.Logs = append(group.Logs, log)
+			continue
+		}
+		if overflow.full(len(finalGroups)) {
+			overflow.add(finalGroups, uniqueKey, LogPattern{Words: lcp.Words, Frequency: lcp.Frequency}, log)
+			continue
+		}
+		finalGroups[uniqueKey] = &LogGroup{
+			Pattern: LogPattern{Words: lcp.Words, Frequency: lcp.Frequency},
+			Logs:    []*LogMessage{log},
+		}
 	}
 
-	finalGroups := make(map[string]*LogGroup)
+	return finalGroups, overflow.audit
+}
 
-	// 2. For each group with the same length, group by Longest Common Pattern
-	for length, group := range logsByLength {
-		logsByPattern := make(map[string][]*LogMessage)
-		patterns := make(map[string]LogPattern)
+// overflowBuckets routes logs of new patterns into coarse buckets by log
+// length once maxGroups pattern groups exist, so the number of groups stays
+// at most maxGroups plus one bucket per distinct overflowing log length. The
+// buckets have an empty pattern, so their templates are formed by the parent
+// direction alone. Only the key and log count of each overflowing pattern are
+// kept for the audit.
+type overflowBuckets struct {
+	maxGroups int
+	buckets   int            // Buckets among the groups
+	patterns  map[string]int // Overflowing pattern key -> index into its audit entry
+	entries   map[string]int // Bucket key -> index into audit
+	audit     []MergeAuditEntry
+}
 
-		for _, log := range group {
-			lcp := findLongestWordCombination(log, config)
-			key := lcp.Key()
-			logsByPattern[key] = append(logsByPattern[key], log)
-			if _, exists := patterns[key]; !exists {
-				patterns[key] = LogPattern{Words: lcp.Words, Frequency: lcp.Frequency}
-			}
-		}
+// newOverflowBuckets creates the overflow state for a cap of maxGroups
+// pattern groups, 0 for no limit
+func newOverflowBuckets(maxGroups int) *overflowBuckets {
+	return &overflowBuckets{maxGroups: maxGroups}
+}
 
-		// Form final groups with length-aware keys to prevent collisions
-		for key, logList := range logsByPattern {
-			// Always include length in the key to prevent collisions between groups of different lengths
-			sb := GetStringBuilder()
-			sb.WriteString(key)
-			sb.WriteString("-len:")
-			writeInt(sb, length)
-			uniqueKey := sb.String()
-			PutStringBuilder(sb)
+// full reports whether a new pattern group would exceed the cap
+func (o *overflowBuckets) full(groups int) bool {
+	return o.maxGroups > 0 && groups-o.buckets >= o.maxGroups
+}
 
-			finalGroups[uniqueKey] = &LogGroup{
-				Pattern: patterns[key],
-				Logs:    logList,
-			}
+// add puts log, whose pattern has no group of its own, into the bucket of its
+// length and records the pattern in the audit entry of the bucket
+func (o *overflowBuckets) add(groups map[string]*LogGroup, key string, pattern LogPattern, log *LogMessage) {
+	sb := GetStringBuilder()
+	sb.WriteString("overflow-len:")
+	writeInt(sb, len(log.Words))
+	bucketKey := sb.String()
+	PutStringBuilder(sb)
+
+	bucket, exists := groups[bucketKey]
+	if !exists {
+		if o.entries == nil {
+			o.entries = make(map[string]int)
+			o.patterns = make(map[string]int)
 		}
+		bucket = &LogGroup{}
+		groups[bucketKey] = bucket
+		o.buckets++
+		o.entries[bucketKey] = len(o.audit)
+		o.audit = append(o.audit, MergeAuditEntry{Reason: MergeReasonOverflow, Result: bucketKey})
 	}
+	bucket.Logs = append(bucket.Logs, log)
 
-	return finalGroups
+	entry := &o.audit[o.entries[bucketKey]]
+	i, seen := o.patterns[key]
+	if !seen {
+		i = len(entry.Sources)
+		o.patterns[key] = i
+		entry.Sources = append(entry.Sources, patternText(pattern))
+		entry.Counts = append(entry.Counts, 0)
+	}
+	entry.Counts[i]++
+}
+
+// patternText renders a group pattern as its words in position order
+func patternText(pattern LogPattern) string {
+	words := make([]string, len(pattern.Words))
+	for i, word := range pattern.Words {
+		words[i] = word.Value.Value()
+	}
+	return strings.Join(words, " ")
 }
 
 // findLongestWordCombination finds the longest combination of words with the same frequency.
 // Implements frequency threshold according to the paper: threshold = highest_frequency * weight.
 // Also handles two-frequency logs as mentioned in the paper.
 func findLongestWordCombination(log *LogMessage, config *Config) WordCombination {
+	buckets := bucketFrequencies(log.Words, config.FrequencyTolerance)
 	combosByFreq := make(map[int][]Word)
 	for _, word := range log.Words {
-		combosByFreq[word.Frequency] = append(combosByFreq[word.Frequency], word)
+		if word.Frequency == 0 {
+			continue // Ignored token
+		}
+		freq := buckets[word.Frequency]
+		combosByFreq[freq] = append(combosByFreq[freq], word)
 	}
 
 	if len(combosByFreq) == 0 {
@@ -84,7 +156,7 @@ func findLongestWordCombination(log *LogMessage, config *Config) WordCombination
 
 	var longestCombo WordCombination
 	maxLen := 0
-	maxTokenLength := 0 // Secondary metric for tie-breaking
+	maxScore := 0.0
 
 	// Sort frequencies to ensure deterministic result
 	freqs := make([]int, 0, len(combosByFreq))
@@ -100,12 +172,13 @@ func findLongestWordCombination(log *LogMessage, config *Config) WordCombination
 		}
 
 		words := combosByFreq[freq]
-		tokenLength := calculateTotalTokenLength(words)
 
-		// Primary criterion: number of words, secondary: total token length
-		if len(words) > maxLen || (len(words) == maxLen && tokenLength > maxTokenLength) {
+		// Primary criterion: number of words plus optional contiguity bonus,
+		// secondary: configured tie-breaking strategy
+		score := float64(len(words)) + config.ContiguityBonus*float64(calculateContiguity(words))
+		if score > maxScore || (score == maxScore && winsTieBreak(words, freq, longestCombo, config.LCPTieBreak)) {
+			maxScore = score
 			maxLen = len(words)
-			maxTokenLength = tokenLength
 			longestCombo = WordCombination{Frequency: freq, Words: words}
 		}
 	}
@@ -120,6 +193,34 @@ func findLongestWordCombination(log *LogMessage, config *Config) WordCombination
 	return longestCombo
 }
 
+// bucketFrequencies maps every word frequency to the frequency of its bucket.
+// Frequencies within the relative tolerance of a higher bucket frequency
+// (e.g. 999 and 1000 with tolerance 0.01) share that bucket.
+func bucketFrequencies(words []Word, tolerance float64) map[int]int {
+	buckets := make(map[int]int, len(words))
+	for _, word := range words {
+		buckets[word.Frequency] = word.Frequency
+	}
+	if tolerance <= 0 || len(buckets) < 2 {
+		return buckets
+	}
+
+	freqs := make([]int, 0, len(buckets))
+	for f := range buckets {
+		freqs = append(freqs, f)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(freqs)))
+
+	bucketFreq := freqs[0]
+	for _, f := range freqs {
+		if float64(bucketFreq-f) > float64(bucketFreq)*tolerance {
+			bucketFreq = f // Start a new bucket
+		}
+		buckets[f] = bucketFreq
+	}
+	return buckets
+}
+
 // isTwoFrequencyVariableLog checks if a two-frequency log likely contains variable parts
 // by looking for numeric patterns, IP addresses, or other variable-like patterns
 func isTwoFrequencyVariableLog(combosByFreq map[int][]Word) bool {
@@ -187,6 +288,36 @@ func selectConstantCombinationFromTwoFrequency(combosByFreq map[int][]Word) Word
 	return WordCombination{Frequency: freq, Words: combosByFreq[freq]}
 }
 
+// winsTieBreak reports whether a combination should replace the current best one
+// of the same length according to the given tie-breaking strategy
+func winsTieBreak(words []Word, freq int, best WordCombination, strategy TieBreakStrategy) bool {
+	switch strategy {
+	case TieBreakContiguity:
+		contiguity, bestContiguity := calculateContiguity(words), calculateContiguity(best.Words)
+		if contiguity != bestContiguity {
+			return contiguity > bestContiguity
+		}
+	case TieBreakFrequency:
+		if freq != best.Frequency {
+			return freq < best.Frequency
+		}
+	case TieBreakLength:
+	}
+	return calculateTotalTokenLength(words) > calculateTotalTokenLength(best.Words)
+}
+
+// calculateContiguity counts adjacent position pairs in a word combination,
+// so combinations forming a real phrase score higher than scattered words
+func calculateContiguity(words []Word) int {
+	contiguity := 0
+	for i := 1; i < len(words); i++ {
+		if words[i].Position == words[i-1].Position+1 {
+			contiguity++
+		}
+	}
+	return contiguity
+}
+
 // calculateTotalTokenLength calculates the sum of token lengths for tie-breaking
 func calculateTotalTokenLength(words []Word) int {
 	total := 0
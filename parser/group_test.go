@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 	"unique"
 )
@@ -66,3 +67,182 @@ func TestCreateInitialGroups(t *testing.T) {
 		t.Errorf("Failed to find all expected groups. A: %v, B: %v, C: %v", groupAFound, groupBFound, groupCFound)
 	}
 }
+
+func TestFindLongestWordCombination_TieBreak(t *testing.T) {
+	// Three combinations of two words each:
+	// freq 5 - scattered with the longest tokens
+	// freq 4 - contiguous with short tokens
+	// freq 2 - scattered with short tokens and the lowest frequency
+	log := &LogMessage{
+		Words: []Word{
+			{Value: unique.Make("longalpha"), Position: 0, Frequency: 5},
+			{Value: unique.Make("p"), Position: 1, Frequency: 4},
+			{Value: unique.Make("q"), Position: 2, Frequency: 4},
+			{Value: unique.Make("r"), Position: 3, Frequency: 2},
+			{Value: unique.Make("longbeta"), Position: 4, Frequency: 5},
+			{Value: unique.Make("s"), Position: 5, Frequency: 2},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		strategy TieBreakStrategy
+		wantFreq int
+	}{
+		{"length", TieBreakLength, 5},
+		{"contiguity", TieBreakContiguity, 4},
+		{"frequency", TieBreakFrequency, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			combo := findLongestWordCombination(log, &Config{LCPTieBreak: tt.strategy})
+			if combo.Frequency != tt.wantFreq {
+				t.Errorf("Expected combination with frequency %d, got %d", tt.wantFreq, combo.Frequency)
+			}
+			if len(combo.Words) != 2 {
+				t.Errorf("Expected 2 words in combination, got %d", len(combo.Words))
+			}
+		})
+	}
+}
+
+func TestFindLongestWordCombination_ContiguityBonus(t *testing.T) {
+	// freq 7 has three scattered words, freq 3 has a two-word phrase
+	log := &LogMessage{
+		Words: []Word{
+			{Value: unique.Make("a"), Position: 0, Frequency: 7},
+			{Value: unique.Make("connection"), Position: 1, Frequency: 3},
+			{Value: unique.Make("refused"), Position: 2, Frequency: 3},
+			{Value: unique.Make("b"), Position: 3, Frequency: 7},
+			{Value: unique.Make("x"), Position: 4, Frequency: 1},
+			{Value: unique.Make("c"), Position: 5, Frequency: 7},
+		},
+	}
+
+	combo := findLongestWordCombination(log, &Config{})
+	if combo.Frequency != 7 {
+		t.Errorf("Without bonus expected the longest combination (freq 7), got freq %d", combo.Frequency)
+	}
+
+	combo = findLongestWordCombination(log, &Config{ContiguityBonus: 1.5})
+	if combo.Frequency != 3 {
+		t.Errorf("With bonus expected the contiguous phrase (freq 3), got freq %d", combo.Frequency)
+	}
+}
+
+func TestFindLongestWordCombination_FrequencyTolerance(t *testing.T) {
+	log := &LogMessage{
+		Words: []Word{
+			{Value: unique.Make("request"), Position: 0, Frequency: 1000},
+			{Value: unique.Make("served"), Position: 1, Frequency: 999},
+			{Value: unique.Make("in"), Position: 2, Frequency: 1000},
+			{Value: unique.Make("value"), Position: 3, Frequency: 10},
+		},
+	}
+
+	combo := findLongestWordCombination(log, &Config{})
+	if len(combo.Words) != 2 {
+		t.Errorf("Without tolerance expected 2 words, got %d", len(combo.Words))
+	}
+
+	combo = findLongestWordCombination(log, &Config{FrequencyTolerance: 0.01})
+	if len(combo.Words) != 3 || combo.Frequency != 1000 {
+		t.Fatalf("With tolerance expected 3 words at freq 1000, got %d words at freq %d", len(combo.Words), combo.Frequency)
+	}
+	for i, word := range combo.Words {
+		if word.Position != i {
+			t.Errorf("Expected words in position order, got position %d at index %d", word.Position, i)
+		}
+	}
+}
+
+func TestCreateInitialGroups_OverflowMerging(t *testing.T) {
+	preprocessor := NewPreprocessor(`\s+`, map[string]string{})
+	logs := preprocessor.PreprocessLogs([]string{
+		"alpha beta gamma",
+		"alpha beta gamma",
+		"alpha beta gamma",
+		"one two three",
+		"four five six",
+		"seven eight",
+		"nine ten",
+	})
+
+	config := &Config{MaxInitialGroups: 1}
+	groups, audit := createInitialGroups(logs, config)
+
+	// The first group is kept, the rest is merged into one bucket per length
+	if len(groups) != 3 {
+		t.Fatalf("Expected 3 groups (1 kept + 2 length buckets), got %d", len(groups))
+	}
+	if len(audit) != 2 {
+		t.Fatalf("Expected 2 audit entries (one per length bucket), got %d", len(audit))
+	}
+	overflow := 0
+	for _, entry := range audit {
+		if entry.Reason != MergeReasonOverflow {
+			t.Errorf("Expected overflow reason, got %q", entry.Reason)
+		}
+		if len(entry.Sources) != len(entry.Counts) {
+			t.Errorf("Expected a count per source, got %d sources and %d counts", len(entry.Sources), len(entry.Counts))
+		}
+		overflow += len(entry.Sources)
+	}
+	if overflow != 4 {
+		t.Errorf("Expected 4 overflow groups, got %d", overflow)
+	}
+	total := 0
+	for _, group := range groups {
+		total += len(group.Logs)
+	}
+	if total != len(logs) {
+		t.Errorf("Expected all %d logs to be kept, got %d", len(logs), total)
+	}
+}
+
+func TestCreateInitialGroups_OverflowBound(t *testing.T) {
+	var lines []string
+	for i := 0; i < 200; i++ {
+		word := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		words := []string{"w" + word, "x" + word, "y" + word}
+		lines = append(lines, strings.Join(words[:2+i%2], " "))
+	}
+	logs := NewPreprocessor(`\s+`, map[string]string{}).PreprocessLogs(lines)
+
+	groups, audit := createInitialGroups(logs, &Config{MaxInitialGroups: 10})
+	// 10 pattern groups plus a bucket for each of the 2 line lengths
+	if len(groups) != 12 {
+		t.Errorf("Expected 12 groups, got %d", len(groups))
+	}
+	overflow := 0
+	for _, entry := range audit {
+		for _, count := range entry.Counts {
+			overflow += count
+		}
+	}
+	if overflow != 190 {
+		t.Errorf("Expected 190 overflow lines in the audit, got %d", overflow)
+	}
+}
+
+func TestBrain_MaxInitialGroupsWarning(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`, MaxInitialGroups: 1})
+	report := parser.ParseWithReport([]string{
+		"alpha beta gamma",
+		"alpha beta gamma",
+		"one two three",
+		"four five six",
+	})
+
+	if len(report.Warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %v", report.Warnings)
+	}
+	total := 0
+	for _, result := range report.Results {
+		total += result.Count
+	}
+	if total != 4 {
+		t.Errorf("Expected all 4 logs in results, got %d", total)
+	}
+}
@@ -0,0 +1,77 @@
+package parser
+
+import "slices"
+
+// Hooks are user callbacks run at fixed points of the parse pipeline to
+// inspect or change intermediate data, e.g. for custom enrichment or
+// filtering, set with Config.Hooks. They run for the lines Brain learns on
+// (with LoadState or an OnlineParser only the lines matching no known
+// template), not for the internal reparsing of low-quality templates. Hooks
+// are called sequentially from the goroutine running Parse; concurrent Parse
+// calls of one parser call them concurrently.
+type Hooks struct {
+	// AfterPreprocess receives the tokenized logs before they are grouped
+	// and returns the logs to group; words may be changed and logs dropped.
+	// The LogMessage.ID of a log is its index in the parsed lines.
+	AfterPreprocess func(logs []*LogMessage) []*LogMessage
+
+	// AfterGrouping receives the initial groups by longest common pattern
+	// before their trees are built and returns the groups to process.
+	AfterGrouping func(groups []*LogGroup) []*LogGroup
+
+	// BeforeTemplateEmit is called for every final result, with severity
+	// and ID set, before it is returned; returning false drops the result.
+	// Dropped results and changes to the template text are not reflected in
+	// the learned state.
+	BeforeTemplateEmit func(result *ParseResult) bool
+}
+
+// PreTokenizeHook rewrites a log line before it is split into words, e.g.
+// to strip ANSI color codes or rewrite hostnames. It also runs for lines
+// passed to Match and for the extraction of slot values, so templates match
+// the lines as rewritten; the original line stays the content of the log.
+type PreTokenizeHook func(line string) string
+
+// PostTokenizeHook rewrites the words of a log line after splitting, before
+// ignore rules and common variable masking; words may be changed, added or
+// dropped. Match compares the text of lines with the templates and does not
+// see these rewrites.
+type PostTokenizeHook func(words []string) []string
+
+// afterPreprocess runs the AfterPreprocess hook on a copy of logs, keeping
+// logs intact for lookups by ID
+func (p *BrainParser) afterPreprocess(logs []*LogMessage) []*LogMessage {
+	if p.config.Hooks == nil || p.config.Hooks.AfterPreprocess == nil || p.config.isReparsing {
+		return logs
+	}
+	return p.config.Hooks.AfterPreprocess(slices.Clone(logs))
+}
+
+// afterGrouping runs the AfterGrouping hook
+func (p *BrainParser) afterGrouping(groups []*LogGroup) []*LogGroup {
+	if p.config.Hooks == nil || p.config.Hooks.AfterGrouping == nil || p.config.isReparsing {
+		return groups
+	}
+	return p.config.Hooks.AfterGrouping(groups)
+}
+
+// beforeTemplateEmit runs the BeforeTemplateEmit hook on final results and
+// releases the dropped ones. Parsers without state only learn for another
+// parser, which emits the results.
+func (p *BrainParser) beforeTemplateEmit(results Results) Results {
+	if p.config.Hooks == nil || p.config.Hooks.BeforeTemplateEmit == nil || p.state == nil {
+		return results
+	}
+	kept := results[:0]
+	for _, result := range results {
+		if p.config.Hooks.BeforeTemplateEmit(result) {
+			kept = append(kept, result)
+			continue
+		}
+		PutIntSlice(result.LogIDs)
+		result.LogIDs = nil
+		PutParseResult(result)
+	}
+	clear(results[len(kept):])
+	return kept
+}
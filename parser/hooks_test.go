@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	lines := []string{
+		"User alice logged in", "User bob logged in", "User carol logged in",
+		"GET /healthz 200", "GET /healthz 200",
+		"Disk sda full", "Disk sdb full", "Disk sdc full",
+	}
+	var preprocessed, grouped, emitted int
+	hooks := &Hooks{
+		AfterPreprocess: func(logs []*LogMessage) []*LogMessage {
+			preprocessed = len(logs)
+			kept := logs[:0]
+			for _, log := range logs {
+				if !strings.Contains(log.Content.Value(), "/healthz") {
+					kept = append(kept, log)
+				}
+			}
+			return kept
+		},
+		AfterGrouping: func(groups []*LogGroup) []*LogGroup {
+			grouped = len(groups)
+			return groups
+		},
+		BeforeTemplateEmit: func(result *ParseResult) bool {
+			emitted++
+			if strings.HasPrefix(result.Template, "Disk") {
+				result.Severity = SeverityError
+			}
+			return !strings.HasPrefix(result.Template, "User")
+		},
+	}
+	p := New(Config{Delimiters: `\s+`, ChildBranchThreshold: 3, Hooks: hooks})
+	results := p.Parse(lines)
+
+	if preprocessed != len(lines) || grouped == 0 {
+		t.Errorf("Hooks saw %d logs and %d groups", preprocessed, grouped)
+	}
+	if emitted != 2 {
+		t.Errorf("BeforeTemplateEmit called %d times, want 2", emitted)
+	}
+	if len(results) != 1 || results[0].Template != "Disk <*> full" || results[0].Severity != SeverityError ||
+		len(results[0].LogIDs) != 3 || results[0].LogIDs[0] != 5 {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	// Resumed parsers emit every result once
+	var buf bytes.Buffer
+	if err := p.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	resumed, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	emitted = 0
+	resumed.config.Hooks = hooks
+	resumed.Parse([]string{"Disk sdd full", "Fan 1 failed", "Fan 2 failed", "Fan 3 failed"})
+	if emitted != 2 {
+		t.Errorf("BeforeTemplateEmit called %d times after resume, want 2", emitted)
+	}
+}
+
+func TestTokenizeHooks(t *testing.T) {
+	ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	lines := []string{
+		"\x1b[31mERROR\x1b[0m connection to db-1.prod.internal lost",
+		"\x1b[31mERROR\x1b[0m connection to db-2.prod.internal lost",
+		"\x1b[31mERROR\x1b[0m connection to db-3.prod.internal lost",
+	}
+	var hosts int
+	config := Config{
+		Delimiters: `\s+`,
+		PreTokenizeHooks: []PreTokenizeHook{
+			func(line string) string { return ansi.ReplaceAllString(line, "") },
+		},
+		PostTokenizeHooks: []PostTokenizeHook{
+			func(words []string) []string {
+				for i, word := range words {
+					if strings.HasSuffix(word, ".prod.internal") {
+						words[i] = "HOST"
+						hosts++
+					}
+				}
+				return words
+			},
+		},
+	}
+	p := New(config)
+	report := p.ParseWithParams(lines)
+
+	if len(report.Results) != 1 || report.Results[0].Template != "ERROR connection to HOST lost" {
+		t.Fatalf("Expected one rewritten template, got %v", report.Results)
+	}
+	if hosts < len(lines) {
+		t.Errorf("Expected the post-tokenize hook to run for every line, got %d calls", hosts)
+	}
+	if content := p.preprocessor.PreprocessLogs(lines[:1])[0].Content.Value(); content != lines[0] {
+		t.Errorf("Expected the original content, got %q", content)
+	}
+
+	// Match rewrites the line like Parse
+	if result, ok := p.Match("\x1b[31mERROR\x1b[0m connection to HOST lost"); !ok || result.Template != report.Results[0].Template {
+		t.Errorf("Expected the colored line to match, got %v, %v", result, ok)
+	}
+}
@@ -0,0 +1,23 @@
+package parser
+
+import "iter"
+
+// ParseSeq parses logLines like Parse when the iteration starts and yields
+// the results one by one. Every result is returned to the pools once the
+// loop body has handled it, so it must not be retained (copy what is needed);
+// results left over by breaking the loop are released too. Consumers writing
+// results out, e.g. as NDJSON, this way recycle the results and their LogIDs
+// for the next parse instead of leaving the whole slice to the garbage
+// collector.
+func (p *BrainParser) ParseSeq(logLines []string) iter.Seq[*ParseResult] {
+	return func(yield func(*ParseResult) bool) {
+		results := Results(p.Parse(logLines))
+		defer results.Release()
+		for i, result := range results {
+			if !yield(result) {
+				return
+			}
+			results[i : i+1].Release()
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseSeq(t *testing.T) {
+	var lines []string
+	for i := 0; i < 5; i++ {
+		lines = append(lines, fmt.Sprintf("User user%d logged in", i), fmt.Sprintf("Disk sd%c full", 'a'+i))
+	}
+	config := Config{Delimiters: `\s+`, ChildBranchThreshold: 3, Deterministic: true}
+
+	expected := make(map[string]int)
+	for _, result := range New(config).Parse(lines) {
+		expected[result.Template] = result.Count
+	}
+	got := make(map[string]int)
+	for result := range New(config).ParseSeq(lines) {
+		got[result.Template] = result.Count
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("ParseSeq yielded %v, want %v", got, expected)
+	}
+
+	yielded := 0
+	for range New(config).ParseSeq(lines) {
+		yielded++
+		break
+	}
+	if yielded != 1 {
+		t.Errorf("Expected the loop to stop after 1 result, got %d", yielded)
+	}
+}
+
+func TestOnlineParserOnStable(t *testing.T) {
+	op := NewOnlineParser(Config{Delimiters: `\s+`, TemplateDenyPatterns: []string{"^Debug"}})
+	var stable []*ParseResult
+	op.OnStable(2, func(result *ParseResult) {
+		stable = append(stable, result)
+		_ = op.Lines() // Handlers may use the parser
+	})
+
+	op.Add([]string{"User alice logged in", "User bob logged in", "User carol logged in", "Debug tick 1", "Debug tick 2", "Debug tick 3"})
+	if len(stable) != 0 {
+		t.Fatalf("No template is stable after one batch, got %+v", stable)
+	}
+	op.Add([]string{"User dave logged in", "Debug tick 4", "Disk sda full", "Disk sdb full", "Disk sdc full"})
+	if len(stable) != 1 || stable[0].Template != "User <*> logged in" || stable[0].Count != 4 || stable[0].ID == "" {
+		t.Fatalf("Expected the user template to become stable, got %+v", stable)
+	}
+	op.Add([]string{"User erin logged in", "Disk sdd full"})
+	if len(stable) != 2 || stable[1].Template != "Disk <*> full" {
+		t.Errorf("Expected each template to be pushed once, got %+v", stable)
+	}
+}
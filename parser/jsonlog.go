@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultJSONMessageField is the message field of JSON logs if none is set
+const defaultJSONMessageField = "message"
+
+// JSONLogOptions selects the fields ReadJSONLogs takes from every log object.
+// Paths are dot-separated, e.g. "log.message" finds the message in
+// {"log": {"message": "..."}} as well as in {"log.message": "..."}.
+type JSONLogOptions struct {
+	MessageField string   // Path of the log message (default: "message")
+	Fields       []string // Paths of fields carried through per line, e.g. "timestamp", "level"
+}
+
+// JSONLogs is the content of a JSON log read by ReadJSONLogs.
+type JSONLogs struct {
+	Lines   []string            // Messages in input order
+	Fields  []map[string]string // Carried fields per line by path, missing fields omitted (nil without JSONLogOptions.Fields)
+	Skipped int                 // Non-empty lines that are no JSON object or have no message
+}
+
+// ReadJSONLogs reads JSON logs with one object per line (NDJSON) and returns
+// the messages and requested fields. Numbers and booleans are returned as
+// written; nested objects and arrays as compact JSON.
+func ReadJSONLogs(reader io.Reader, opts JSONLogOptions) (*JSONLogs, error) {
+	messageField := opts.MessageField
+	if messageField == "" {
+		messageField = defaultJSONMessageField
+	}
+
+	logs := &JSONLogs{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var object map[string]any
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber() // Keep numbers as written
+		if err := decoder.Decode(&object); err != nil || object == nil {
+			logs.Skipped++
+			continue
+		}
+		message, ok := jsonPath(object, messageField)
+		if message = strings.TrimSpace(message); !ok || message == "" {
+			logs.Skipped++
+			continue
+		}
+
+		logs.Lines = append(logs.Lines, message)
+		if len(opts.Fields) > 0 {
+			fields := make(map[string]string, len(opts.Fields))
+			for _, path := range opts.Fields {
+				if value, ok := jsonPath(object, path); ok {
+					fields[path] = value
+				}
+			}
+			logs.Fields = append(logs.Fields, fields)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading JSON logs: %w", err)
+	}
+	return logs, nil
+}
+
+// jsonPath returns the value at a dot-separated path of object as a string.
+// Keys containing dots are matched before nested objects. Null is missing.
+func jsonPath(object map[string]any, path string) (string, bool) {
+	if value, ok := object[path]; ok {
+		return jsonString(value)
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '.' {
+			continue
+		}
+		if nested, ok := object[path[:i]].(map[string]any); ok {
+			if value, ok := jsonPath(nested, path[i+1:]); ok {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonString formats a decoded JSON value
+func jsonString(value any) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		if v {
+			return "true", true
+		}
+		return "false", true
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "", false
+		}
+		return string(data), true
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadJSONLogs(t *testing.T) {
+	data := `{"ts":"2024-01-15T10:00:00Z","level":"info","log":{"message":"User alice logged in"}}
+{"ts":"2024-01-15T10:00:01Z","log.message":"Disk full","code":507,"retry":false,"tags":["disk"]}
+
+not json
+{"ts":"2024-01-15T10:00:02Z","log":{"message":"  "}}
+{"log":{"message":"Request served","level":null}}
+`
+	logs, err := ReadJSONLogs(strings.NewReader(data), JSONLogOptions{
+		MessageField: "log.message",
+		Fields:       []string{"ts", "code", "retry", "tags", "log.level"},
+	})
+	if err != nil {
+		t.Fatalf("ReadJSONLogs error: %v", err)
+	}
+
+	expectedLines := []string{"User alice logged in", "Disk full", "Request served"}
+	if !reflect.DeepEqual(logs.Lines, expectedLines) {
+		t.Errorf("Lines = %q, want %q", logs.Lines, expectedLines)
+	}
+	expectedFields := []map[string]string{
+		{"ts": "2024-01-15T10:00:00Z"},
+		{"ts": "2024-01-15T10:00:01Z", "code": "507", "retry": "false", "tags": `["disk"]`},
+		{},
+	}
+	if !reflect.DeepEqual(logs.Fields, expectedFields) {
+		t.Errorf("Fields = %v, want %v", logs.Fields, expectedFields)
+	}
+	if logs.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", logs.Skipped)
+	}
+}
+
+func TestReadJSONLogsDefaults(t *testing.T) {
+	logs, err := ReadJSONLogs(strings.NewReader(`{"message":"System started","level":"info"}`+"\n"), JSONLogOptions{})
+	if err != nil {
+		t.Fatalf("ReadJSONLogs error: %v", err)
+	}
+	if !reflect.DeepEqual(logs.Lines, []string{"System started"}) || logs.Fields != nil {
+		t.Errorf("Unexpected logs %+v", logs)
+	}
+}
+
+func TestJSONPath(t *testing.T) {
+	object := map[string]any{
+		"a":   map[string]any{"b": map[string]any{"c": "nested"}},
+		"a.b": map[string]any{"d": "dotted"},
+		"x.y": "flat",
+	}
+	tests := []struct {
+		path     string
+		expected string
+		ok       bool
+	}{
+		{"a.b.c", "nested", true},
+		{"a.b.d", "dotted", true},
+		{"x.y", "flat", true},
+		{"a.c", "", false},
+		{"missing", "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := jsonPath(object, tt.path); got != tt.expected || ok != tt.ok {
+			t.Errorf("jsonPath(%q) = %q, %v, want %q, %v", tt.path, got, ok, tt.expected, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Defaults of StreamingConfig.LowLatency
+const (
+	defaultLiveBatchSize     = 64
+	defaultLiveLearnInterval = 200 * time.Millisecond
+)
+
+// LiveAssignment is the template ProcessLive assigned to one input line.
+type LiveAssignment struct {
+	Line    int           // 1-based line number in the input
+	Text    string        // Line as read
+	Result  *ParseResult  // Template, ID, accumulated count and line severity (nil if the template is dropped by the allow/deny lists)
+	Learned bool          // The line matched no known template and was assigned after background learning
+	Latency time.Duration // Time from reading the line to its assignment
+}
+
+// liveLine is a line waiting for background learning
+type liveLine struct {
+	number  int
+	text    string
+	read    time.Time
+	matched bool // Already assigned on arrival
+}
+
+// ProcessLive assigns every line of reader to a template with low latency for
+// live tailing. Each line is matched on arrival against a lock-free snapshot
+// of the model learned so far and reported at once if it matches. All lines
+// are also queued for a background learner that adds them to the model in
+// small batches, at the latest every StreamingConfig.LearnInterval, publishes
+// a new snapshot and reports the lines that did not match. assign is called
+// sequentially, but learned lines are reported after later matched lines.
+// Snapshot returns the templates of the model while and after it runs.
+// Empty lines are skipped; lines are checked like in ProcessReader.
+func (sp *StreamingProcessor) ProcessLive(ctx context.Context, reader io.Reader, assign func(LiveAssignment)) error {
+	model := NewOnlineParser(sp.parser.config)
+	var matcher AtomicMatcher
+	matcher.Store(model.parser.Freeze())
+	sp.partialMu.Lock()
+	sp.partial, sp.live = nil, model
+	sp.partialMu.Unlock()
+
+	var assignMu sync.Mutex
+	report := func(assignment LiveAssignment) {
+		assignMu.Lock()
+		defer assignMu.Unlock()
+		assign(assignment)
+	}
+
+	queue := make(chan liveLine, sp.batchSize)
+	learned := make(chan error, 1)
+	go func() {
+		learned <- sp.learnLive(ctx, model, &matcher, queue, report)
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	splitter := &lineSplitter{maxLineSize: sp.maxLineSize, truncate: sp.truncate, onError: sp.onLineError}
+	scanner.Split(splitter.split)
+	scanner.Buffer(make([]byte, 4096), sp.maxLineSize)
+
+	var readErr error
+	for scanner.Scan() {
+		if readErr = ctx.Err(); readErr != nil {
+			break
+		}
+		text := scanner.Text()
+		if !utf8.ValidString(text) {
+			splitter.report(LineError{Reason: LineErrorInvalidUTF8, Offset: splitter.start, Line: splitter.line, Length: len(text)})
+			text = strings.ToValidUTF8(text, "\uFFFD")
+		}
+		if text == "" {
+			continue
+		}
+
+		line := liveLine{number: splitter.line, text: text, read: time.Now()}
+		if result, ok := matcher.Match(text); ok {
+			line.matched = true
+			report(LiveAssignment{Line: line.number, Text: text, Result: result, Latency: time.Since(line.read)})
+		}
+		queue <- line
+	}
+	close(queue)
+	learnErr := <-learned
+
+	if readErr == nil {
+		if err := scanner.Err(); err != nil {
+			readErr = fmt.Errorf("scanner error during live processing: %w", err)
+		}
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return learnErr
+}
+
+// learnLive adds queued lines to the model in batches of up to the batch
+// size, at least every learn interval, until the queue is closed
+func (sp *StreamingProcessor) learnLive(ctx context.Context, model *OnlineParser, matcher *AtomicMatcher, queue <-chan liveLine, report func(LiveAssignment)) error {
+	ticker := time.NewTicker(sp.learnInterval)
+	defer ticker.Stop()
+
+	var batch []liveLine
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		texts := make([]string, len(batch))
+		for i, line := range batch {
+			texts[i] = line.text
+		}
+		results, err := model.AddContext(ctx, texts)
+		if err != nil {
+			return err
+		}
+
+		frozen := model.parser.Freeze()
+		matcher.Store(frozen)
+		byID := make(map[int]*ParseResult, len(batch))
+		for _, result := range results {
+			for _, id := range result.LogIDs {
+				byID[id] = result
+			}
+		}
+		for id, line := range batch {
+			if line.matched {
+				continue
+			}
+			assignment := LiveAssignment{Line: line.number, Text: line.text, Learned: true}
+			if result, ok := frozen.Match(line.text); ok {
+				assignment.Result = result
+			} else if result := byID[id]; result != nil {
+				assignment.Result = &ParseResult{ID: result.ID, Template: result.Template, Count: result.Count, Severity: InferLineSeverity(line.text)}
+			}
+			assignment.Latency = time.Since(line.read)
+			report(assignment)
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		select {
+		case line, ok := <-queue:
+			if !ok {
+				return flush()
+			}
+			batch = append(batch, line)
+			if len(batch) < sp.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+		if err := flush(); err != nil {
+			for range queue {
+				// Drain so the reader can stop
+			}
+			return err
+		}
+	}
+}
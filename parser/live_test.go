@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProcessLive(t *testing.T) {
+	sp := NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{LowLatency: true, LearnInterval: 10 * time.Millisecond})
+	reader, writer := io.Pipe()
+
+	var mu sync.Mutex
+	assignments := make(map[int]LiveAssignment)
+	learnedAll := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- sp.ProcessLive(context.Background(), reader, func(a LiveAssignment) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := assignments[a.Line]; ok {
+				t.Errorf("Line %d assigned twice", a.Line)
+			}
+			assignments[a.Line] = a
+			if len(assignments) == 5 {
+				close(learnedAll)
+			}
+		})
+	}()
+
+	// Unknown lines are learned in the background
+	for i := 0; i < 5; i++ {
+		fmt.Fprintf(writer, "User user%d logged in\n", i)
+	}
+	select {
+	case <-learnedAll:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for background learning")
+	}
+
+	// Known lines are assigned on arrival
+	fmt.Fprintln(writer, "")
+	fmt.Fprintln(writer, "User dave logged in")
+	writer.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("ProcessLive error: %v", err)
+	}
+
+	if len(assignments) != 6 {
+		t.Fatalf("Expected 6 assignments, got %d", len(assignments))
+	}
+	for line := 1; line <= 5; line++ {
+		a := assignments[line]
+		if !a.Learned || a.Result == nil || a.Result.Template != "User <*> logged in" {
+			t.Errorf("Unexpected assignment of line %d: %+v", line, a)
+		}
+	}
+	matched := assignments[7] // Line 6 is empty
+	if matched.Learned || matched.Result == nil || matched.Result.Template != "User <*> logged in" || matched.Result.ID == "" {
+		t.Errorf("Expected line 7 to match on arrival, got %+v", matched)
+	}
+
+	snapshot := sp.Snapshot()
+	if len(snapshot) != 1 || snapshot[0].Count != 6 {
+		t.Errorf("Expected snapshot with 6 lines in one template, got %+v", snapshot)
+	}
+}
+
+func TestProcessLiveCanceled(t *testing.T) {
+	sp := NewStreamingProcessor(Config{}, StreamingConfig{LowLatency: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := sp.ProcessLive(ctx, strings.NewReader("a b c\nd e f\n"), func(LiveAssignment) {})
+	if err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
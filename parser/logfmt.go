@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultLogfmtMessageKey is the message key of logfmt logs if none is set
+const defaultLogfmtMessageKey = "msg"
+
+// LogfmtOptions configures ReadLogfmt.
+type LogfmtOptions struct {
+	MessageKey string // Key of the log message (default: "msg")
+}
+
+// LogfmtLogs is the content of a logfmt log read by ReadLogfmt.
+type LogfmtLogs struct {
+	Lines   []string            // Messages in input order
+	Fields  []map[string]string // All other key/value pairs per line, aligned with Lines
+	Skipped int                 // Non-empty lines without a message
+}
+
+// ReadLogfmt reads logfmt logs (key=value pairs as written by many Go
+// services, e.g. level=info msg="User alice logged in" user=alice) and
+// returns the messages with the remaining pairs of every line as metadata.
+func ReadLogfmt(reader io.Reader, opts LogfmtOptions) (*LogfmtLogs, error) {
+	messageKey := opts.MessageKey
+	if messageKey == "" {
+		messageKey = defaultLogfmtMessageKey
+	}
+
+	logs := &LogfmtLogs{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := ParseLogfmt(line)
+		message := strings.TrimSpace(fields[messageKey])
+		if message == "" {
+			logs.Skipped++
+			continue
+		}
+		delete(fields, messageKey)
+		logs.Lines = append(logs.Lines, message)
+		logs.Fields = append(logs.Fields, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading logfmt logs: %w", err)
+	}
+	return logs, nil
+}
+
+// ParseLogfmt splits a logfmt line into its key/value pairs. Values may be
+// bare or double-quoted with Go escapes; a key without "=" has an empty
+// value. Of repeated keys the last one wins.
+func ParseLogfmt(line string) map[string]string {
+	fields := make(map[string]string)
+	for i := 0; i < len(line); {
+		// Skip spaces between pairs
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '\t' {
+			i++
+		}
+		key := line[start:i]
+		if i >= len(line) || line[i] != '=' {
+			if key != "" {
+				fields[key] = ""
+			}
+			continue
+		}
+		i++ // Skip '='
+
+		var value string
+		if i < len(line) && line[i] == '"' {
+			value, i = logfmtQuoted(line, i)
+		} else {
+			start = i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			value = line[start:i]
+		}
+		if key != "" {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+// logfmtQuoted decodes the quoted value starting at line[start] and returns
+// it with the index after the closing quote. An unterminated value runs to
+// the end of the line; invalid escapes are kept as written.
+func logfmtQuoted(line string, start int) (string, int) {
+	i := start + 1
+	for i < len(line) && line[i] != '"' {
+		if line[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	end := min(i+1, len(line))
+	if value, err := strconv.Unquote(line[start:end]); err == nil {
+		return value, end
+	}
+	return strings.Trim(line[start:end], `"`), end
+}
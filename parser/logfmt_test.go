@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLogfmt(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected map[string]string
+	}{
+		{
+			`level=info msg="User alice logged in" user=alice`,
+			map[string]string{"level": "info", "msg": "User alice logged in", "user": "alice"},
+		},
+		{
+			`msg="say \"hi\"\tnow" path=/a=b debug`,
+			map[string]string{"msg": "say \"hi\"\tnow", "path": "/a=b", "debug": ""},
+		},
+		{
+			`empty= msg="unterminated value`,
+			map[string]string{"empty": "", "msg": "unterminated value"},
+		},
+		{
+			`msg=first msg=second`,
+			map[string]string{"msg": "second"},
+		},
+	}
+	for _, tt := range tests {
+		if got := ParseLogfmt(tt.line); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("ParseLogfmt(%q) = %q, want %q", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestReadLogfmt(t *testing.T) {
+	data := `ts=2024-01-15T10:00:00Z level=info msg="User alice logged in"
+ts=2024-01-15T10:00:01Z level=warn msg="Disk almost full" pct=91
+
+ts=2024-01-15T10:00:02Z level=debug
+`
+	logs, err := ReadLogfmt(strings.NewReader(data), LogfmtOptions{})
+	if err != nil {
+		t.Fatalf("ReadLogfmt error: %v", err)
+	}
+	if !reflect.DeepEqual(logs.Lines, []string{"User alice logged in", "Disk almost full"}) {
+		t.Errorf("Unexpected lines %q", logs.Lines)
+	}
+	expectedFields := []map[string]string{
+		{"ts": "2024-01-15T10:00:00Z", "level": "info"},
+		{"ts": "2024-01-15T10:00:01Z", "level": "warn", "pct": "91"},
+	}
+	if !reflect.DeepEqual(logs.Fields, expectedFields) {
+		t.Errorf("Fields = %v, want %v", logs.Fields, expectedFields)
+	}
+	if logs.Skipped != 1 {
+		t.Errorf("Skipped = %d, want 1", logs.Skipped)
+	}
+
+	logs, err = ReadLogfmt(strings.NewReader(`level=info message=started`), LogfmtOptions{MessageKey: "message"})
+	if err != nil || !reflect.DeepEqual(logs.Lines, []string{"started"}) {
+		t.Errorf("Expected custom message key to be read, got %+v, %v", logs, err)
+	}
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Algorithms implementing LogParser
+const (
+	AlgorithmBrain = "brain" // BrainParser
+	AlgorithmDrain = "drain" // DrainParser
+	AlgorithmSpell = "spell" // SpellParser
+)
+
+// LogParser is the API shared by the parsing algorithms, so that they can be
+// swapped and compared on the same logs.
+type LogParser interface {
+	// Parse groups lines into templates, sorted by count in descending order.
+	Parse(logLines []string) []*ParseResult
+	// Match classifies a line by the learned templates without learning from it.
+	Match(line string) (*ParseResult, bool)
+	// SaveState writes the configuration and the learned templates as JSON.
+	SaveState(w io.Writer) error
+}
+
+var (
+	_ LogParser = (*BrainParser)(nil)
+	_ LogParser = (*DrainParser)(nil)
+	_ LogParser = (*SpellParser)(nil)
+)
+
+// LoadParser restores a parser of either algorithm from a state written by
+// its SaveState. States without an algorithm are Brain states.
+func LoadParser(r io.Reader) (LogParser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	var header struct {
+		Algorithm string `json:"algorithm"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	// Return nil interfaces on errors, not typed nil pointers
+	switch header.Algorithm {
+	case "", AlgorithmBrain:
+		p, err := LoadState(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case AlgorithmDrain:
+		d, err := LoadDrainState(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case AlgorithmSpell:
+		s, err := LoadSpellState(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", header.Algorithm)
+	}
+}
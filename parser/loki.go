@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lokiWildcard is the unnamed capture of Loki pattern expressions
+const lokiWildcard = "<_>"
+
+// lokiCapture matches text Loki would parse as a named capture
+var lokiCapture = regexp.MustCompile(`<[A-Za-z_][A-Za-z0-9_]*>`)
+
+// LokiPattern converts a template to a Grafana Loki pattern expression as
+// used by the pattern parser and the |> line filter: every <*> becomes <_>.
+// Loki requires a literal between captures, so adjacent wildcards are merged,
+// and literal text Loki would parse as a named capture, like <html>, is
+// replaced by <_> as pattern expressions cannot escape it. Templates keep
+// the words of a line separated by single spaces, so patterns of templates
+// learned with delimiters other than whitespace only match lines using
+// spaces there too.
+func LokiPattern(template string) string {
+	pattern := strings.ReplaceAll(template, "<*>", lokiWildcard)
+	pattern = lokiCapture.ReplaceAllLiteralString(pattern, lokiWildcard)
+	for strings.Contains(pattern, lokiWildcard+lokiWildcard) {
+		pattern = strings.ReplaceAll(pattern, lokiWildcard+lokiWildcard, lokiWildcard)
+	}
+	return pattern
+}
+
+// LokiLineFilter returns the LogQL line filter selecting the lines of a
+// template: a pattern match filter (|> "User <_> logged in"), or a contains
+// filter (|= "Backup finished") for templates without variables.
+func LokiLineFilter(template string) string {
+	pattern := LokiPattern(template)
+	if !strings.Contains(pattern, lokiWildcard) {
+		return "|= " + strconv.Quote(pattern)
+	}
+	return "|> " + strconv.Quote(pattern)
+}
+
+// ExportLokiPatterns writes the LogQL line filter of every template (see
+// LokiLineFilter), one per line, ready to append to a stream selector.
+func ExportLokiPatterns(w io.Writer, results []*ParseResult) error {
+	for _, result := range results {
+		if _, err := fmt.Fprintln(w, LokiLineFilter(result.Template)); err != nil {
+			return fmt.Errorf("failed to write Loki patterns: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLokiPattern(t *testing.T) {
+	tests := []struct {
+		template string
+		pattern  string
+		filter   string
+	}{
+		{"User <*> logged in", "User <_> logged in", `|> "User <_> logged in"`},
+		{"<*> <*> request id=<*><*>", "<_> <_> request id=<_>", `|> "<_> <_> request id=<_>"`},
+		{"Rendered <html> page <*>", "Rendered <_> page <_>", `|> "Rendered <_> page <_>"`},
+		{`Backup "daily" finished`, `Backup "daily" finished`, `|= "Backup \"daily\" finished"`},
+	}
+	for _, test := range tests {
+		if pattern := LokiPattern(test.template); pattern != test.pattern {
+			t.Errorf("LokiPattern(%q) = %q, expected %q", test.template, pattern, test.pattern)
+		}
+		if filter := LokiLineFilter(test.template); filter != test.filter {
+			t.Errorf("LokiLineFilter(%q) = %s, expected %s", test.template, filter, test.filter)
+		}
+	}
+
+	var buf bytes.Buffer
+	results := []*ParseResult{{Template: "User <*> logged in"}, {Template: "Disk full"}}
+	if err := ExportLokiPatterns(&buf, results); err != nil {
+		t.Fatalf("ExportLokiPatterns error: %v", err)
+	}
+	if expected := "|> \"User <_> logged in\"\n|= \"Disk full\"\n"; buf.String() != expected {
+		t.Errorf("Unexpected export:\n%s", buf.String())
+	}
+}
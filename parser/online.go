@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OnlineParser learns templates incrementally from batches of lines, e.g. the
+// new lines of a followed log file. Lines matching a template learned from an
+// earlier batch are counted directly; only the remaining lines run the Brain
+// algorithm. It is safe for concurrent use and implements SnapshotSource.
+type OnlineParser struct {
+	mu       sync.Mutex // Serializes batches
+	parser   *BrainParser
+	lines    int                  // Lines added so far
+	batches  int                  // Batches added so far
+	lastSeen map[string]time.Time // Time of the last batch containing each template
+	changes  []AuditEvent         // State changes of the running batch
+	audit    *json.Encoder        // Audit log (nil = disabled)
+	now      func() time.Time     // Config.Clock
+	latest   time.Time            // Latest event time of all batches (Config.Timestamps)
+
+	stableAfter int                // Batches a template must be part of to be stable (see OnStable)
+	onStable    func(*ParseResult) // Receives templates once they are stable (nil = disabled)
+	seenBatches map[string]int     // Batches each template was part of, up to stableAfter
+
+	rarity    RarityOptions         // Which lines are anomalies (see OnAnomaly)
+	onAnomaly func(TemplateAnomaly) // Receives new and rare lines (nil = disabled)
+}
+
+// NewOnlineParser creates an online parser with the given configuration.
+func NewOnlineParser(config Config) *OnlineParser {
+	op := &OnlineParser{
+		parser:   New(config),
+		lastSeen: make(map[string]time.Time),
+	}
+	op.now = op.parser.config.Clock
+	op.parser.state.resume = true
+	op.parser.state.observe = op.observe
+	return op
+}
+
+// Add parses a batch of lines and adds its templates to the learned state.
+// The returned results cover only the batch; their LogIDs index into lines.
+func (op *OnlineParser) Add(lines []string) []*ParseResult {
+	results, _ := op.AddContext(context.Background(), lines)
+	return results
+}
+
+// AddContext behaves like Add but stops early when ctx is canceled, returning
+// ctx.Err(). A canceled batch does not change the learned state. If writing
+// the audit log fails, the results are returned together with the error.
+func (op *OnlineParser) AddContext(ctx context.Context, lines []string) ([]*ParseResult, error) {
+	op.mu.Lock()
+	results, stable, anomalies, err := op.add(ctx, lines)
+	onStable, onAnomaly := op.onStable, op.onAnomaly
+	op.mu.Unlock()
+
+	// Called without the lock, so handlers may use the parser
+	for _, result := range stable {
+		onStable(result)
+	}
+	for _, anomaly := range anomalies {
+		onAnomaly(anomaly)
+	}
+	return results, err
+}
+
+// add parses a batch and returns its results, the templates that became
+// stable with it and its anomalies. The caller must hold op.mu.
+func (op *OnlineParser) add(ctx context.Context, lines []string) ([]*ParseResult, []*ParseResult, []TemplateAnomaly, error) {
+	op.changes = op.changes[:0]
+	report, err := op.parser.parseReport(ctx, lines, nil, &ParseReport{})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	op.lines += len(lines)
+	op.batches++
+	if latest, ok := op.parser.latestTimestamp(lines); ok && latest.After(op.latest) {
+		op.latest = latest
+	}
+
+	now := op.currentTime()
+	events := make([]AuditEvent, 0, len(op.changes)+len(report.MergeAudit))
+	for _, entry := range report.MergeAudit {
+		events = append(events, AuditEvent{
+			Event:        AuditMerged,
+			Template:     entry.Result,
+			Reason:       entry.Reason,
+			Sources:      entry.Sources,
+			SourceCounts: entry.Counts,
+		})
+	}
+	for _, change := range op.changes {
+		op.lastSeen[change.Template] = now
+		events = append(events, change)
+	}
+	for _, violation := range report.Violations {
+		events = append(events, violation.auditEvent())
+	}
+	return report.Results, op.stabilized(), op.anomalies(report.Results, lines, now), op.writeAudit(now, events)
+}
+
+// currentTime returns the latest event time of all batches with
+// Config.Timestamps, so replayed logs expire by log time, or the clock. The
+// caller must hold op.mu.
+func (op *OnlineParser) currentTime() time.Time {
+	if !op.latest.IsZero() {
+		return op.latest
+	}
+	return op.now()
+}
+
+// Approve marks templates as approved, see BrainParser.Approve.
+func (op *OnlineParser) Approve(templates ...string) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.parser.Approve(templates...)
+}
+
+// Violations returns the automatic changes approved templates were protected
+// from, see BrainParser.Violations.
+func (op *OnlineParser) Violations() []TemplateViolation {
+	return op.parser.Violations()
+}
+
+// Expire removes templates that were not part of any batch for longer than
+// idle and returns them sorted. With Config.Timestamps, time is the latest
+// event time of all batches instead of Config.Clock. Expired templates are learned again if they
+// reappear. Approved templates are kept and reported as violations.
+func (op *OnlineParser) Expire(idle time.Duration) ([]string, error) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	now := op.currentTime()
+	var expired []string
+	var protected []AuditEvent
+	state := op.parser.state
+	state.mu.Lock()
+	for template, seen := range op.lastSeen {
+		if now.Sub(seen) <= idle {
+			continue
+		}
+		if !state.approved[template] {
+			expired = append(expired, template)
+			continue
+		}
+		violation := TemplateViolation{Action: ViolationExpire, Template: template}
+		if state.violate(violation) {
+			protected = append(protected, violation.auditEvent())
+		}
+	}
+	state.mu.Unlock()
+	if len(expired) == 0 {
+		return nil, op.writeAudit(now, protected)
+	}
+	sort.Strings(expired)
+
+	counts := op.parser.state.remove(expired)
+	events := make([]AuditEvent, len(expired))
+	for i, template := range expired {
+		delete(op.lastSeen, template)
+		delete(op.seenBatches, template)
+		events[i] = AuditEvent{Event: AuditExpired, Template: template, Count: counts[i]}
+	}
+	return expired, op.writeAudit(now, append(events, protected...))
+}
+
+// Match classifies a line by the learned templates without learning from it,
+// see BrainParser.Match.
+func (op *OnlineParser) Match(line string) (*ParseResult, bool) {
+	return op.parser.Match(line)
+}
+
+// LastSeen returns the time of the last batch containing template. ok is
+// false if the template is unknown or was only loaded or approved.
+func (op *OnlineParser) LastSeen(template string) (seen time.Time, ok bool) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	seen, ok = op.lastSeen[template]
+	return seen, ok
+}
+
+// Lines returns the number of lines added so far.
+func (op *OnlineParser) Lines() int {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.lines
+}
+
+// Snapshot returns all learned templates with their accumulated counts,
+// sorted by count in descending order. Severities are inferred from the
+// template text and LogIDs are not set.
+func (op *OnlineParser) Snapshot() []*ParseResult {
+	return op.parser.Snapshot()
+}
+
+// SaveState writes the learned templates and configuration as JSON, see
+// BrainParser.SaveState.
+func (op *OnlineParser) SaveState(w io.Writer) error {
+	return op.parser.SaveState(w)
+}
+
+// OnStable makes the parser push every template to handle once it was part
+// of minBatches batches (at least 1), i.e. once it has proven to be more than
+// a one-off, e.g. to publish templates to alerting while learning goes on.
+// handle is called after the batch completing the template was added, from
+// the goroutine adding it, with the accumulated count; expired templates are
+// pushed again once they are stable again. A nil handle disables pushing.
+func (op *OnlineParser) OnStable(minBatches int, handle func(result *ParseResult)) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.stableAfter = max(minBatches, 1)
+	op.onStable = handle
+	if op.seenBatches == nil {
+		op.seenBatches = make(map[string]int)
+	}
+}
+
+// stabilized counts the batch for every template it changed and returns the
+// templates that became stable with it. The caller must hold op.mu.
+func (op *OnlineParser) stabilized() []*ParseResult {
+	if op.onStable == nil {
+		return nil
+	}
+	var stable []*ParseResult
+	for _, change := range op.changes {
+		seen := op.seenBatches[change.Template]
+		if seen >= op.stableAfter {
+			continue
+		}
+		op.seenBatches[change.Template] = seen + 1
+		if seen+1 < op.stableAfter || (op.parser.templateFilter != nil && !op.parser.templateFilter.keep(change.Template)) {
+			continue
+		}
+		stable = append(stable, &ParseResult{
+			Template: change.Template,
+			Count:    change.Count,
+			Severity: InferLineSeverity(change.Template),
+		})
+	}
+	op.parser.assignTemplateIDs(stable)
+	return stable
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOnlineParser(t *testing.T) {
+	op := NewOnlineParser(Config{Delimiters: `\s+`})
+
+	var first []string
+	for i := 0; i < 5; i++ {
+		first = append(first, fmt.Sprintf("User user%d logged in", i))
+	}
+	if results := op.Add(first); len(results) != 1 || results[0].Template != "User <*> logged in" {
+		t.Fatalf("Unexpected results of first batch: %+v", results)
+	}
+
+	// A single line of a known template is matched instead of learned as-is
+	results := op.Add([]string{"User late logged in", "ERROR disk sda full", "ERROR disk sdb full", "ERROR disk sdc full"})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 templates in second batch, got %+v", results)
+	}
+
+	snapshot := op.Snapshot()
+	if len(snapshot) != 2 || op.Lines() != 9 {
+		t.Fatalf("Expected 2 templates from 9 lines, got %d from %d", len(snapshot), op.Lines())
+	}
+	if snapshot[0].Template != "User <*> logged in" || snapshot[0].Count != 6 || snapshot[0].ID == "" {
+		t.Errorf("Unexpected first template %+v", snapshot[0])
+	}
+	if snapshot[1].Template != "ERROR disk <*> full" || snapshot[1].Count != 3 || snapshot[1].Severity != SeverityError {
+		t.Errorf("Unexpected second template %+v", snapshot[1])
+	}
+
+	var buf bytes.Buffer
+	if err := op.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+	restored, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if result, ok := restored.Match("User bob logged in"); !ok || result.Count != 6 {
+		t.Errorf("Expected restored template with count 6, got %+v", result)
+	}
+}
+
+func TestOnlineParserMatch(t *testing.T) {
+	op := NewOnlineParser(Config{Delimiters: `\s+`})
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	op.now = func() time.Time { return now }
+	op.Add([]string{"User alice logged in", "User bob logged in", "User carol logged in"})
+
+	result, ok := op.Match("User dave logged in")
+	if !ok || result.Template != "User <*> logged in" || result.Count != 3 {
+		t.Fatalf("Expected match of learned template with count 3, got %+v", result)
+	}
+	if op.Lines() != 3 {
+		t.Errorf("Match must not learn, got %d lines", op.Lines())
+	}
+	if seen, ok := op.LastSeen(result.Template); !ok || !seen.Equal(now) {
+		t.Errorf("Expected last seen %v, got %v %v", now, seen, ok)
+	}
+	if _, ok := op.LastSeen("unknown"); ok {
+		t.Error("Expected unknown template not to be seen")
+	}
+}
@@ -60,8 +60,8 @@ func TestOptimizedFeatures(t *testing.T) {
 // TestSIMDCapabilities tests SIMD capability detection
 func TestSIMDCapabilities(t *testing.T) {
 	caps := DetectSIMDCapabilities()
-	t.Logf("SIMD Capabilities: Platform=%s, AVX2=%v, SSE42=%v, NEON=%v",
-		caps.Platform, caps.HasAVX2, caps.HasSSE42, caps.HasNEON)
+	t.Logf("SIMD Capabilities: Platform=%s, AVX2=%v, SSE42=%v",
+		caps.Platform, caps.HasAVX2, caps.HasSSE42)
 
 	// Test word counting
 	counter := NewSIMDWordCounter()
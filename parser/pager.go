@@ -0,0 +1,123 @@
+package parser
+
+import "encoding/binary"
+
+// defaultPageSize is used when a pager is created without a page size
+const defaultPageSize = 100
+
+// CompactLogIDs stores log IDs as delta-encoded varints. Consecutive IDs of
+// a template are usually close to each other, so most deltas fit in one byte.
+type CompactLogIDs struct {
+	data  []byte
+	count int
+}
+
+// NewCompactLogIDs encodes ids compactly, preserving their order.
+func NewCompactLogIDs(ids []int) CompactLogIDs {
+	data := make([]byte, 0, len(ids)+binary.MaxVarintLen64)
+	prev := 0
+	for _, id := range ids {
+		data = binary.AppendVarint(data, int64(id-prev))
+		prev = id
+	}
+	return CompactLogIDs{data: data, count: len(ids)}
+}
+
+// Len returns the number of stored IDs.
+func (c CompactLogIDs) Len() int {
+	return c.count
+}
+
+// Expand decodes the IDs into a new slice.
+func (c CompactLogIDs) Expand() []int {
+	if c.count == 0 {
+		return nil
+	}
+	ids := make([]int, 0, c.count)
+	prev := 0
+	for data := c.data; len(data) > 0; {
+		delta, n := binary.Varint(data)
+		if n <= 0 {
+			break
+		}
+		data = data[n:]
+		prev += int(delta)
+		ids = append(ids, prev)
+	}
+	return ids
+}
+
+// pagedResult is a result with compactly stored log IDs
+type pagedResult struct {
+	result ParseResult // LogIDs left nil
+	logIDs CompactLogIDs
+}
+
+// ResultPager provides paginated access to large result sets. Log IDs are
+// kept compactly encoded and expanded only when requested with LogIDs.
+type ResultPager struct {
+	results  []pagedResult
+	pageSize int
+}
+
+// NewResultPager builds a pager over copies of results (default page size:
+// 100) with their LogIDs compacted. The results stay owned by the caller,
+// who may release them afterwards; other slices such as Params and Examples
+// are shared with the copies.
+func NewResultPager(results []*ParseResult, pageSize int) *ResultPager {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+
+	pager := &ResultPager{
+		results:  make([]pagedResult, len(results)),
+		pageSize: pageSize,
+	}
+	for i, result := range results {
+		pager.results[i] = pagedResult{result: *result, logIDs: NewCompactLogIDs(result.LogIDs)}
+		pager.results[i].result.LogIDs = nil
+	}
+	return pager
+}
+
+// Len returns the total number of results.
+func (p *ResultPager) Len() int {
+	return len(p.results)
+}
+
+// PageSize returns the number of results per page.
+func (p *ResultPager) PageSize() int {
+	return p.pageSize
+}
+
+// PageCount returns the number of pages.
+func (p *ResultPager) PageCount() int {
+	return (len(p.results) + p.pageSize - 1) / p.pageSize
+}
+
+// Page returns the results of a zero-based page with LogIDs left nil.
+// Use LogIDs with the result index to materialize them on demand.
+// Out of range pages return nil.
+func (p *ResultPager) Page(page int) []*ParseResult {
+	start := page * p.pageSize
+	if page < 0 || start >= len(p.results) {
+		return nil
+	}
+	end := min(start+p.pageSize, len(p.results))
+
+	results := make([]*ParseResult, 0, end-start)
+	for _, paged := range p.results[start:end] {
+		result := paged.result
+		results = append(results, &result)
+	}
+	return results
+}
+
+// LogIDs expands the log IDs of the result at the given overall index
+// (page*PageSize() + position within the page).
+func (p *ResultPager) LogIDs(index int) []int {
+	if index < 0 || index >= len(p.results) {
+		return nil
+	}
+	return p.results[index].logIDs.Expand()
+}
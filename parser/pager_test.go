@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompactLogIDs(t *testing.T) {
+	ids := []int{5, 6, 7, 1000, 3, 70000}
+	compact := NewCompactLogIDs(ids)
+
+	if compact.Len() != len(ids) {
+		t.Errorf("Expected length %d, got %d", len(ids), compact.Len())
+	}
+	if got := compact.Expand(); !reflect.DeepEqual(got, ids) {
+		t.Errorf("Expected %v, got %v", ids, got)
+	}
+	if got := NewCompactLogIDs(nil).Expand(); got != nil {
+		t.Errorf("Expected nil for empty IDs, got %v", got)
+	}
+}
+
+func TestResultPager(t *testing.T) {
+	results := []*ParseResult{
+		{Template: "a", Count: 3, LogIDs: []int{0, 1, 2}},
+		{Template: "b", Count: 2, LogIDs: []int{3, 4}},
+		{Template: "c", Count: 1, LogIDs: []int{5}, Examples: []string{"c"}, Params: [][]string{{"x"}}},
+	}
+	pager := NewResultPager(results, 2)
+	if !reflect.DeepEqual(results[1].LogIDs, []int{3, 4}) {
+		t.Errorf("The caller's LogIDs must be left alone, got %v", results[1].LogIDs)
+	}
+
+	if pager.Len() != 3 || pager.PageCount() != 2 {
+		t.Fatalf("Expected 3 results in 2 pages, got %d in %d", pager.Len(), pager.PageCount())
+	}
+
+	first := pager.Page(0)
+	if len(first) != 2 || first[0].Template != "a" || first[1].Template != "b" {
+		t.Errorf("Unexpected first page: %+v", first)
+	}
+	if first[0].LogIDs != nil {
+		t.Error("Page results must not materialize LogIDs")
+	}
+
+	last := pager.Page(1)
+	if len(last) != 1 || last[0].Template != "c" || last[0].Count != 1 {
+		t.Errorf("Unexpected last page: %+v", last)
+	}
+	if len(last) == 1 && (!reflect.DeepEqual(last[0].Examples, []string{"c"}) || len(last[0].Params) != 1) {
+		t.Errorf("Page results must keep all fields, got %+v", last[0])
+	}
+	if pager.Page(2) != nil || pager.Page(-1) != nil {
+		t.Error("Out of range pages must be nil")
+	}
+
+	if got := pager.LogIDs(1); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("Expected LogIDs [3 4], got %v", got)
+	}
+	if pager.LogIDs(3) != nil {
+		t.Error("Out of range index must return nil")
+	}
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// ParseWithParams behaves like ParseWithReport and additionally fills
+// ParseResult.Params with the values of the <*> slots of every member line.
+func (p *BrainParser) ParseWithParams(logLines []string) *ParseReport {
+	report := p.ParseWithReport(logLines)
+	p.fillParams(report.Results, logLines)
+	return report
+}
+
+// ParseWithParamsContext behaves like ParseWithParams but stops early when
+// ctx is canceled or its deadline expires, returning ctx.Err() and no report.
+func (p *BrainParser) ParseWithParamsContext(ctx context.Context, logLines []string) (*ParseReport, error) {
+	report, err := p.ParseWithReportContext(ctx, logLines)
+	if err != nil {
+		return nil, err
+	}
+	p.fillParams(report.Results, logLines)
+	return report, nil
+}
+
+// fillParams sets the Params of results to the slot values of their lines
+func (p *BrainParser) fillParams(results []*ParseResult, logLines []string) {
+	for _, result := range results {
+		extractor := newParamExtractor(p.preprocessor, result.Template)
+		result.Params = make([][]string, len(result.LogIDs))
+		for i, id := range result.LogIDs {
+			if id >= 0 && id < len(logLines) {
+				result.Params[i], _ = extractor.extract(logLines[id])
+			}
+		}
+	}
+}
+
+// ParamSlot summarizes the values of one <*> slot of a template.
+type ParamSlot struct {
+	Column   int      `json:"column"`             // Index of the slot token in the template
+	Type     string   `json:"type,omitempty"`     // Inferred variable type (see TokenInfo.Type)
+	Examples []string `json:"examples,omitempty"` // Distinct values in order of appearance
+}
+
+// ParamSlots summarizes result.Params, as filled by ParseWithParams, per <*>
+// slot of the template: its column, the variable type inferred like
+// TokenInfo.Type and up to maxExamples distinct example values. It returns
+// nil if the template has no slots.
+func (p *BrainParser) ParamSlots(result *ParseResult, maxExamples int) []ParamSlot {
+	var slots []ParamSlot
+	for i, token := range strings.Fields(result.Template) {
+		if p.preprocessor.slots.isSlot(token) {
+			slots = append(slots, ParamSlot{Column: i})
+		}
+	}
+	seen := make([]map[string]bool, len(slots))
+	samples := make([][]string, len(slots))
+	sampled := 0
+	for _, values := range result.Params {
+		if len(values) != len(slots) {
+			continue
+		}
+		for j, value := range values {
+			if sampled < maxTypeSamples {
+				samples[j] = append(samples[j], value)
+			}
+			if len(slots[j].Examples) < maxExamples && !seen[j][value] {
+				if seen[j] == nil {
+					seen[j] = make(map[string]bool)
+				}
+				seen[j][value] = true
+				slots[j].Examples = append(slots[j].Examples, value)
+			}
+		}
+		sampled++
+	}
+	for j := range slots {
+		slots[j].Type = p.preprocessor.variableType(samples[j])
+	}
+	return slots
+}
+
+// ExtractParams returns the values filling the <*> slots of template in line,
+// in slot order. It reports false if the line does not match the template.
+func (p *BrainParser) ExtractParams(template, line string) ([]string, bool) {
+	return newParamExtractor(p.preprocessor, template).extract(line)
+}
+
+// paramExtractor extracts the <*> values of one template from log lines
+type paramExtractor struct {
+	preprocessor *Preprocessor
+	tokens       []string       // Template tokens
+	slots        int            // Number of <*> tokens
+	regex        *regexp.Regexp // Fallback matcher, compiled on first use
+	regexErr     bool
+}
+
+// newParamExtractor creates an extractor for template
+func newParamExtractor(preprocessor *Preprocessor, template string) *paramExtractor {
+	tokens := strings.Fields(template)
+	slots := 0
+	for _, token := range tokens {
+		if preprocessor.slots.isSlot(token) {
+			slots++
+		}
+	}
+	return &paramExtractor{preprocessor: preprocessor, tokens: tokens, slots: slots}
+}
+
+// extract returns the slot values of line. Lines are first aligned with the
+// template token by token as they were split for parsing; if post-processing
+// changed the token count, a regex built from the template is used instead.
+func (pe *paramExtractor) extract(line string) ([]string, bool) {
+	normalized, _ := pe.preprocessor.normalizeLine(line)
+	words := pe.preprocessor.tokenize(normalized)
+	if params, ok := pe.align(words); ok {
+		return params, true
+	}
+	return pe.match(pe.preprocessor.runPreTokenize(line))
+}
+
+// align extracts slot values from words split like the template tokens
+func (pe *paramExtractor) align(words []string) ([]string, bool) {
+	if len(words) != len(pe.tokens) {
+		return nil, false
+	}
+	params := make([]string, 0, pe.slots)
+	slots := pe.preprocessor.slots
+	for i, token := range pe.tokens {
+		switch {
+		case !slots.matches(token, words[i]):
+			return nil, false
+		case slots.isSlot(token):
+			params = append(params, words[i])
+		}
+	}
+	return params, true
+}
+
+// match extracts slot values with a regex capturing each <*> slot
+func (pe *paramExtractor) match(line string) ([]string, bool) {
+	if pe.regex == nil && !pe.regexErr {
+		regex, err := regexp.Compile(templateParamRegex(pe.tokens, pe.preprocessor.slots))
+		pe.regex, pe.regexErr = regex, err != nil
+	}
+	if pe.regex == nil {
+		return nil, false
+	}
+	match := pe.regex.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	return match[1:], true
+}
+
+// templateParamRegex builds an anchored regex with a capture group per <*>
+// token and named placeholder of slots
+func templateParamRegex(tokens []string, slots placeholderSlots) string {
+	sb := GetStringBuilder()
+	defer PutStringBuilder(sb)
+
+	sb.WriteString(`^\W*`)
+	for i, token := range tokens {
+		if token == VariadicPlaceholder {
+			// Optional trailing tokens capture an empty value if absent
+			sb.WriteString(`(?:\W+(.+?))?`)
+			continue
+		}
+		if i > 0 {
+			sb.WriteString(`\W+`)
+		}
+		if slots.isSlot(token) {
+			sb.WriteString(`(.+?)`)
+		} else {
+			sb.WriteString(regexp.QuoteMeta(token))
+		}
+	}
+	sb.WriteString(`\W*$`)
+	return sb.String()
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseWithParams(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 20; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in from 10.0.0.%d", i, i))
+	}
+
+	report := New(Config{Delimiters: `\s+`}).ParseWithParams(logLines)
+	if len(report.Results) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(report.Results))
+	}
+	result := report.Results[0]
+	if result.Template != "User <*> logged in from <*>" {
+		t.Fatalf("Unexpected template %q", result.Template)
+	}
+	if len(result.Params) != len(result.LogIDs) {
+		t.Fatalf("Expected params for %d logs, got %d", len(result.LogIDs), len(result.Params))
+	}
+	for i, id := range result.LogIDs {
+		expected := []string{fmt.Sprintf("user%d", id), fmt.Sprintf("10.0.0.%d", id)}
+		if !reflect.DeepEqual(result.Params[i], expected) {
+			t.Errorf("Params of log %d = %q, want %q", id, result.Params[i], expected)
+		}
+	}
+}
+
+func TestExtractParams(t *testing.T) {
+	p := New(Config{Delimiters: `[\s,:=]+`})
+
+	tests := []struct {
+		template string
+		line     string
+		expected []string
+		ok       bool
+	}{
+		{"Connection to <*> <*> failed", "Connection to db:5432 failed", []string{"db", "5432"}, true},
+		{"Request <*> done", "Request id=42 done", []string{"id=42"}, true}, // Token count differs, regex fallback
+		{"Request <*> done", "Request 42 done", []string{"42"}, true},
+		{"Disk <*> full", "Disk is almost full", []string{"is almost"}, true}, // Regex fallback spans tokens
+		{"Disk <*> full", "Memory low", nil, false},
+	}
+	for _, tt := range tests {
+		params, ok := p.ExtractParams(tt.template, tt.line)
+		if ok != tt.ok || (ok && !reflect.DeepEqual(params, tt.expected)) {
+			t.Errorf("ExtractParams(%q, %q) = %q, %v; want %q, %v", tt.template, tt.line, params, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestParamSlots(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`})
+	result := &ParseResult{
+		Template: "User <*> logged in from <*>",
+		Params: [][]string{
+			{"alice", "10.0.0.1"},
+			{"bob", "10.0.0.2"},
+			{"alice", "10.0.0.1"},
+			{"carol", "10.0.0.3"},
+			{"dave"}, // Misaligned values are ignored
+		},
+	}
+
+	slots := parser.ParamSlots(result, 2)
+	expected := []ParamSlot{
+		{Column: 1, Type: TokenTypeString, Examples: []string{"alice", "bob"}},
+		{Column: 5, Type: "ipv4_address", Examples: []string{"10.0.0.1", "10.0.0.2"}},
+	}
+	if !reflect.DeepEqual(slots, expected) {
+		t.Errorf("ParamSlots = %+v, want %+v", slots, expected)
+	}
+	if slots := parser.ParamSlots(&ParseResult{Template: "no slots"}, 2); slots != nil {
+		t.Errorf("Expected no slots, got %+v", slots)
+	}
+}
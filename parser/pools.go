@@ -29,9 +29,11 @@ type MemoryPools struct {
 	LogSlices   sync.Pool // *PooledLogSlice - wrapper for []*LogMessage
 	IntSlices   sync.Pool // *PooledIntSlice - wrapper for []int
 	WordSlices  sync.Pool // *PooledWordSlice - wrapper for []Word (patterns)
+	Results     sync.Pool // *ParseResult - already pointer, perfect
 }
 
-// globalPools is the singleton pool instance
+// globalPools is the singleton pool instance, shared by all parsers (sync.Pool
+// is safe for concurrent use)
 var globalPools = &MemoryPools{}
 
 // initPools initializes all memory pools with proper factory functions
@@ -73,6 +75,10 @@ func init() { //nolint:gochecknoinits // Required for pool initialization
 			Data: make([]Word, 0, 8),
 		}
 	}
+
+	globalPools.Results.New = func() any {
+		return &ParseResult{}
+	}
 }
 
 // GetLogMessage gets a LogMessage from the pool
@@ -225,6 +231,42 @@ func PutWordSliceForPattern(slice []Word) {
 	}
 }
 
+// GetParseResult gets a ParseResult from the pool
+func GetParseResult() *ParseResult {
+	res, ok := globalPools.Results.Get().(*ParseResult)
+	if !ok {
+		res = &ParseResult{}
+	}
+	// Reset all fields, keeping LogIDs capacity
+	*res = ParseResult{LogIDs: res.LogIDs[:0]}
+	return res
+}
+
+// PutParseResult returns a ParseResult to the pool
+func PutParseResult(res *ParseResult) {
+	if res != nil {
+		globalPools.Results.Put(res)
+	}
+}
+
+// Results is a slice of parse results that can be recycled with Release
+// and reused as destination buffer by ParseInto.
+type Results []*ParseResult
+
+// Release returns the LogIDs slices and result structs back to their pools.
+// The results must not be used after calling Release.
+func (r Results) Release() {
+	for i, res := range r {
+		if res == nil {
+			continue
+		}
+		PutIntSlice(res.LogIDs)
+		res.LogIDs = nil
+		PutParseResult(res)
+		r[i] = nil
+	}
+}
+
 // PooledLogMessage is a wrapper that automatically returns LogMessage to pool when done
 type PooledLogMessage struct {
 	*LogMessage
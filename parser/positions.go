@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxTypeSamples is the number of member lines used to infer variable types
+const maxTypeSamples = 10
+
+// Variable types inferred when no common variable pattern matches all values
+const (
+	TokenTypeNumber = "number"
+	TokenTypeString = "string"
+)
+
+// TokenInfo describes one token of a template.
+type TokenInfo struct {
+	Text       string `json:"text"`           // Token text, "<*>" for variables
+	IsVariable bool   `json:"is_variable"`    // Token is a <*> slot or a named placeholder
+	Type       string `json:"type,omitempty"` // Inferred variable type: common variable pattern name (e.g. "ipv4_address"), TokenTypeNumber or TokenTypeString
+	Column     int    `json:"column"`         // Index of the token in the template
+}
+
+// setTemplatePositions fills the Positions of every result
+func (p *BrainParser) setTemplatePositions(results []*ParseResult, logLines []string) {
+	for _, result := range results {
+		result.Positions = p.templatePositions(result, logLines)
+	}
+}
+
+// templatePositions splits the template of result into tokens and infers the
+// type of every variable from up to maxTypeSamples member lines
+func (p *BrainParser) templatePositions(result *ParseResult, logLines []string) []TokenInfo {
+	tokens := strings.Fields(result.Template)
+	positions := make([]TokenInfo, len(tokens))
+	var slots []int // Indexes of variable tokens
+	for i, token := range tokens {
+		positions[i] = TokenInfo{Text: token, IsVariable: p.preprocessor.slots.isSlot(token), Column: i}
+		if positions[i].IsVariable {
+			slots = append(slots, i)
+		}
+	}
+	if len(slots) == 0 {
+		return positions
+	}
+
+	extractor := newParamExtractor(p.preprocessor, result.Template)
+	samples := make([][]string, len(slots))
+	sampled := 0
+	for _, id := range result.LogIDs {
+		if sampled >= maxTypeSamples {
+			break
+		}
+		if id < 0 || id >= len(logLines) {
+			continue
+		}
+		params, ok := extractor.extract(logLines[id])
+		if !ok || len(params) != len(slots) {
+			continue
+		}
+		for j, value := range params {
+			samples[j] = append(samples[j], value)
+		}
+		sampled++
+	}
+	for j, i := range slots {
+		positions[i].Type = p.preprocessor.variableType(samples[j])
+	}
+	return positions
+}
+
+// variableType returns the name of the most specific common variable pattern
+// matching all values, TokenTypeNumber if all values are numbers, or
+// TokenTypeString. It returns an empty string if there are no values.
+func (p *Preprocessor) variableType(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	var best string
+	var bestRegex *regexp.Regexp
+	for _, name := range sortedKeys(p.commonVariables) {
+		regex := p.commonVariables[name]
+		if !matchesAll(regex, values) {
+			continue
+		}
+		if bestRegex == nil || isBetterMatch(regex, bestRegex, values[0]) {
+			best, bestRegex = name, regex
+		}
+	}
+	if best != "" {
+		return best
+	}
+
+	for _, value := range values {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return TokenTypeString
+		}
+	}
+	return TokenTypeNumber
+}
+
+// matchesAll checks whether regex matches every value
+func matchesAll(regex *regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if !regex.MatchString(value) {
+			return false
+		}
+	}
+	return true
+}
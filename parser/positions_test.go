@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestTemplatePositions(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 20; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in from 10.0.0.%d", i, i))
+	}
+
+	results := New(Config{Delimiters: `\s+`, TemplatePositions: true}).Parse(logLines)
+	if len(results) != 1 || results[0].Template != "User <*> logged in from <*>" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+	expected := []TokenInfo{
+		{Text: "User", Column: 0},
+		{Text: "<*>", IsVariable: true, Type: TokenTypeString, Column: 1},
+		{Text: "logged", Column: 2},
+		{Text: "in", Column: 3},
+		{Text: "from", Column: 4},
+		{Text: "<*>", IsVariable: true, Type: "ipv4_address", Column: 5},
+	}
+	if !reflect.DeepEqual(results[0].Positions, expected) {
+		t.Errorf("Positions = %+v, want %+v", results[0].Positions, expected)
+	}
+
+	if results := New(Config{Delimiters: `\s+`}).Parse(logLines); results[0].Positions != nil {
+		t.Errorf("Expected no positions without TemplatePositions, got %+v", results[0].Positions)
+	}
+}
+
+func TestVariableType(t *testing.T) {
+	p := New(Config{})
+
+	tests := []struct {
+		values   []string
+		expected string
+	}{
+		{nil, ""},
+		{[]string{"42", "7"}, "pure_numbers"},
+		{[]string{"-1.5", "3"}, TokenTypeNumber},
+		{[]string{"10.0.0.1", "192.168.1.10"}, "ipv4_address"},
+		{[]string{"10.0.0.1", "alice"}, TokenTypeString},
+		{[]string{"0x1F", "0xff"}, "hex_numbers"},
+	}
+	for _, tt := range tests {
+		if got := p.preprocessor.variableType(tt.values); got != tt.expected {
+			t.Errorf("variableType(%q) = %q, want %q", tt.values, got, tt.expected)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package parser
 
 import (
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 	"unique"
 )
 
@@ -60,10 +63,25 @@ var dateTimePatterns = []struct {
 	{regexp.MustCompile(`\d{2}\.\d{2}\.\d{4} \d{2}:\d{2}:\d{2}`), "dotted_datetime"},
 }
 
-// Preprocessor contains logic for log preprocessing.
+// numberUnitPattern finds numbers followed by a size or percent unit, optionally
+// with thousand separators or a space before the unit (like 1,024KB, 3.5 GiB, 12 %)
+var numberUnitPattern = regexp.MustCompile(`\b(\d{1,3}(,\d{3})+|\d+)(\.\d+)?( ?([KMGTPE]i?)?B\b| ?%)`)
+
+// Preprocessor contains logic for log preprocessing. It is not changed by
+// preprocessing and is safe for concurrent use.
 type Preprocessor struct {
 	delimiters      *regexp.Regexp
+	delimiterSet    *delimiterSet             // Byte table of simple delimiters (nil = split with the regex)
 	commonVariables map[string]*regexp.Regexp // Compiled regex for common variables
+	ignorePositions map[int]bool              // Token positions kept as <*> without frequency
+	ignorePatterns  []*regexp.Regexp          // Tokens kept as <*> without frequency
+	unicodeDigits   bool                      // Use Unicode-aware numeric detection
+	foldUnicode     bool                      // Fold look-alike characters to ASCII
+	frequencies     *FrequencyTable           // Shared frequencies accumulated across calls (nil = per call)
+	preTokenize     []PreTokenizeHook         // Config.PreTokenizeHooks
+	postTokenize    []PostTokenizeHook        // Config.PostTokenizeHooks
+	placeholders    map[string]string         // Config.Placeholders
+	slots           placeholderSlots          // Named placeholders of placeholders
 }
 
 // NewPreprocessor creates a new preprocessor.
@@ -75,28 +93,78 @@ func NewPreprocessor(delimiters string, commonVariables map[string]string) *Prep
 
 	return &Preprocessor{
 		delimiters:      regexp.MustCompile(delimiters),
+		delimiterSet:    compileDelimiterSet(delimiters),
 		commonVariables: compiledVariables,
 	}
 }
 
+// setIgnoreRules configures token positions and patterns that are excluded
+// from frequency computation and grouping and kept as <*> in templates.
+func (p *Preprocessor) setIgnoreRules(positions []int, patterns []string) {
+	p.ignorePositions = nil
+	p.ignorePatterns = nil
+	if len(positions) > 0 {
+		p.ignorePositions = make(map[int]bool, len(positions))
+		for _, pos := range positions {
+			p.ignorePositions[pos] = true
+		}
+	}
+	for _, pattern := range patterns {
+		p.ignorePatterns = append(p.ignorePatterns, regexp.MustCompile(pattern))
+	}
+}
+
+// isIgnored reports whether the word at position pos of a split line is
+// excluded by the ignore rules
+func (p *Preprocessor) isIgnored(pos int, word string) bool {
+	if p.ignorePositions[pos] {
+		return true
+	}
+	for _, pattern := range p.ignorePatterns {
+		if pattern.MatchString(word) {
+			return true
+		}
+	}
+	return false
+}
+
 // PreprocessLogs performs full preprocessing of a set of log lines.
 func (p *Preprocessor) PreprocessLogs(logLines []string) []*LogMessage {
-	// 1. Preprocess datetime patterns to protect spaces within them
+	logs, _ := p.preprocessWeightedLogs(logLines, nil)
+	return logs
+}
+
+// preprocessWeightedLogs preprocesses log lines counting every line weight
+// times in word frequencies (nil weights = 1 per line). It also returns the
+// number of lines changed by Unicode folding.
+func (p *Preprocessor) preprocessWeightedLogs(logLines []string, weights []int) ([]*LogMessage, int) {
+	// 1. Fold look-alike characters and protect spaces within datetimes
 	preprocessedLines := make([]string, len(logLines))
+	folded := 0
 	for i, line := range logLines {
-		preprocessedLines[i] = preprocessDateTimePatterns(line)
+		var changed bool
+		preprocessedLines[i], changed = p.normalizeLine(line)
+		if changed {
+			folded++
+		}
 	}
 
 	// 2. Split logs without filtering to get original words
 	wordFrequencies := make(map[string]int)
 	var rawSplitLogs [][]string
-	for _, line := range preprocessedLines {
-		words := p.splitWithoutFiltering(line)
+	for i, line := range preprocessedLines {
+		words := p.tokenize(line)
 		rawSplitLogs = append(rawSplitLogs, words)
-		for _, word := range words {
-			wordFrequencies[word]++
+		weight := lineWeight(weights, i)
+		for j, word := range words {
+			if !p.isIgnored(j, word) {
+				wordFrequencies[word] += weight
+			}
 		}
 	}
+	if p.frequencies != nil {
+		wordFrequencies = p.frequencies.addBatch(wordFrequencies)
+	}
 
 	// 3. Create LogMessage structures, applying filtering while preserving original frequencies
 	processedLogs := make([]*LogMessage, len(logLines))
@@ -104,6 +172,7 @@ func (p *Preprocessor) PreprocessLogs(logLines []string) []*LogMessage {
 		// Use pooled LogMessage
 		logMessage := GetLogMessage()
 		logMessage.ID = i
+		logMessage.Weight = lineWeight(weights, i)
 		logMessage.Content = unique.Make(logLines[i]) // Intern the content string
 
 		// Use pooled word slice if available, otherwise allocate
@@ -122,6 +191,12 @@ func (p *Preprocessor) PreprocessLogs(logLines []string) []*LogMessage {
 		}
 
 		for j, rawWord := range rawWords {
+			if p.isIgnored(j, rawWord) {
+				// Ignored tokens stay in the template as slots, without a
+				// frequency they are never part of the LCP
+				logMessage.Words[j] = Word{Value: unique.Make("<*>"), Position: j}
+				continue
+			}
 			// Apply common variable filtering to the word value
 			filteredWord := p.filterCommonVariables(rawWord)
 			logMessage.Words[j] = Word{
@@ -133,17 +208,36 @@ func (p *Preprocessor) PreprocessLogs(logLines []string) []*LogMessage {
 		processedLogs[i] = logMessage
 	}
 
-	return processedLogs
+	return processedLogs, folded
+}
+
+// tokenize splits a normalized line into words, runs the post-tokenize hooks
+// and drops ignored tokens
+func (p *Preprocessor) tokenize(line string) []string {
+	words := p.splitWithoutFiltering(line)
+	for _, hook := range p.postTokenize {
+		words = hook(words)
+	}
+	return words
 }
 
 // splitWithoutFiltering divides a string into words using given delimiters without applying variable filtering.
 func (p *Preprocessor) splitWithoutFiltering(line string) []string {
-	// Replace all delimiters with one (space) and then split
-	normalized := p.delimiters.ReplaceAllString(line, " ")
-	words := strings.Fields(normalized)
+	var words []string
+	if p.delimiterSet != nil {
+		// Scan simple delimiters without intermediate strings
+		words = p.delimiterSet.split(line)
+	} else {
+		// Replace all delimiters with one (space) and then split
+		normalized := p.delimiters.ReplaceAllString(line, " ")
+		words = strings.Fields(normalized)
+	}
 
 	// Restore datetime delimiters that were protected during preprocessing
 	for i, word := range words {
+		if !strings.Contains(word, "_DT") {
+			continue
+		}
 		restored := word
 		restored = strings.ReplaceAll(restored, dtSpacePlaceholder, " ")
 		restored = strings.ReplaceAll(restored, dtColonPlaceholder, ":")
@@ -155,6 +249,88 @@ func (p *Preprocessor) splitWithoutFiltering(line string) []string {
 	return words
 }
 
+// isPlaceholder reports whether a template token is an unnamed variable slot:
+// <*> or VariadicPlaceholder. Named placeholders are slots only where they are
+// configured, see placeholderSlots.
+func isPlaceholder(token string) bool {
+	return token == "<*>" || token == VariadicPlaceholder
+}
+
+// isPlaceholderName reports whether token has the form of a named
+// placeholder: an upper-case name in angle brackets like <ORDER>
+func isPlaceholderName(token string) bool {
+	if len(token) < 3 || token[0] != '<' || token[len(token)-1] != '>' {
+		return false
+	}
+	for i := 1; i < len(token)-1; i++ {
+		c := token[i]
+		if !('A' <= c && c <= 'Z' || c == '_' || i > 1 && '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// placeholderSlots maps the named placeholders of Config.Placeholders to the
+// pattern of their common variable, nil if a slot takes any word. Other
+// tokens in angle brackets like <EOF> are constants.
+type placeholderSlots map[string]*regexp.Regexp
+
+// newPlaceholderSlots returns named placeholder tokens that take any word
+func newPlaceholderSlots(tokens []string) placeholderSlots {
+	if len(tokens) == 0 {
+		return nil
+	}
+	slots := make(placeholderSlots, len(tokens))
+	for _, token := range tokens {
+		slots[token] = nil
+	}
+	return slots
+}
+
+// isSlot reports whether a template token is <*>, VariadicPlaceholder or a
+// named placeholder of s
+func (s placeholderSlots) isSlot(token string) bool {
+	if isPlaceholder(token) {
+		return true
+	}
+	_, ok := s[token]
+	return ok
+}
+
+// matches reports whether word fills template token: a constant equals its
+// word, <*> takes any word and a named placeholder the words of its variable
+func (s placeholderSlots) matches(token, word string) bool {
+	if token == word || isPlaceholder(token) {
+		return true
+	}
+	pattern, ok := s[token]
+	return ok && (pattern == nil || pattern.MatchString(word))
+}
+
+// tokens returns the named placeholders of s in sorted order
+func (s placeholderSlots) tokens() []string {
+	tokens := make([]string, 0, len(s))
+	for token := range s {
+		tokens = append(tokens, token)
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// setPlaceholders configures the named placeholders replacing common
+// variables, each matching the words of its variable
+func (p *Preprocessor) setPlaceholders(placeholders map[string]string) {
+	p.placeholders = placeholders
+	p.slots = nil
+	for name, token := range placeholders {
+		if p.slots == nil {
+			p.slots = make(placeholderSlots, len(placeholders))
+		}
+		p.slots[token] = p.commonVariables[name]
+	}
+}
+
 // filterCommonVariables replaces common variables with wildcards according to configuration.
 func (p *Preprocessor) filterCommonVariables(word string) string {
 	// Find all matching patterns and select the most specific one
@@ -177,11 +353,14 @@ func (p *Preprocessor) filterCommonVariables(word string) string {
 	}
 
 	if bestMatch.matched {
+		if placeholder, ok := p.placeholders[bestMatch.name]; ok {
+			return placeholder
+		}
 		return "<*>"
 	}
 
 	// Check if word is numeric-heavy (30% or more digits)
-	if isNumericVariable(word) {
+	if isNumericVariable(word) || (p.unicodeDigits && isNumericVariableUnicode(word)) {
 		return "<*>"
 	}
 
@@ -255,7 +434,8 @@ func countSpecificChars(pattern string) int {
 	return count
 }
 
-// isNumericVariable checks if a token contains 30% or more digits, making it likely a variable
+// isNumericVariable checks if a token contains 30% or more digits, making it likely a variable.
+// The share is measured in characters, so letters of other scripts count once.
 func isNumericVariable(word string) bool {
 	if len(word) == 0 {
 		return false
@@ -269,7 +449,88 @@ func isNumericVariable(word string) bool {
 	}
 
 	// If 30% or more of the characters are digits, consider it a variable
-	return float64(digitCount)/float64(len(word)) >= 0.3
+	return float64(digitCount)/float64(utf8.RuneCountInString(word)) >= 0.3
+}
+
+// isNumericVariableUnicode is the Unicode-aware variant of isNumericVariable.
+// It counts digits of any script (Arabic-Indic, full-width, ...) and treats
+// digit group separators and decimal marks between digits as numeric, so
+// values like "١٢٣٤", "１２３" or "1'234.5" are detected.
+func isNumericVariableUnicode(word string) bool {
+	runes := []rune(word)
+	if len(runes) == 0 {
+		return false
+	}
+
+	numericCount := 0
+	for i, ch := range runes {
+		switch {
+		case unicode.IsDigit(ch):
+			numericCount++
+		case isDigitSeparator(ch) && i > 0 && i < len(runes)-1 &&
+			unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]):
+			numericCount++
+		}
+	}
+
+	// Same 30% rule as isNumericVariable
+	return float64(numericCount)/float64(len(runes)) >= 0.3
+}
+
+// isDigitSeparator reports whether ch is used to group digits or as a decimal mark
+func isDigitSeparator(ch rune) bool {
+	switch ch {
+	case ',', '.', '\'', '_', '\u00A0', '\u202F', '\u066B', '\u066C':
+		return true
+	}
+	return false
+}
+
+// normalizeLine runs the pre-tokenize hooks, folds look-alike characters if
+// enabled, reporting whether folding changed the line, and protects
+// delimiters within multi-word tokens (datetimes and numbers with units) so
+// they are not split
+func (p *Preprocessor) normalizeLine(line string) (string, bool) {
+	line = p.runPreTokenize(line)
+	changed := false
+	if p.foldUnicode {
+		line, changed = foldUnicode(line)
+	}
+	return p.protectNumberUnits(preprocessDateTimePatterns(line)), changed
+}
+
+// runPreTokenize returns line changed by the pre-tokenize hooks in order
+func (p *Preprocessor) runPreTokenize(line string) string {
+	for _, hook := range p.preTokenize {
+		line = hook(line)
+	}
+	return line
+}
+
+// protectNumberUnits protects separators and spaces within numbers with units
+// that a common variable pattern matches as a whole, so removing a pattern
+// (e.g. "file_sizes_spaced") also keeps such numbers split
+func (p *Preprocessor) protectNumberUnits(line string) string {
+	if !strings.ContainsAny(line, ",% ") {
+		return line // Nothing to join
+	}
+	return numberUnitPattern.ReplaceAllStringFunc(line, func(match string) string {
+		if !strings.ContainsAny(match, ", ") || !p.isCommonVariable(match) {
+			return match
+		}
+		protected := strings.ReplaceAll(match, " ", dtSpacePlaceholder)
+		return strings.ReplaceAll(protected, ",", dtCommaPlaceholder)
+	})
+}
+
+// isCommonVariable checks whether any common variable pattern matches word
+func (p *Preprocessor) isCommonVariable(word string) bool {
+	for _, regex := range p.commonVariables {
+		if regex.MatchString(word) {
+			return true
+		}
+	}
+	return false
 }
 
 // preprocessDateTimePatterns finds datetime patterns in log lines and protects spaces within them
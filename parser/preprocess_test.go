@@ -56,6 +56,7 @@ func TestIsNumericVariable(t *testing.T) {
 		{"abc123def456", true, "mixed with 6/12 digits (50%)"},
 		{"12345", true, "pure numbers (100%)"},
 		{"0xFF123", true, "hex with 5/7 digits (71%)"},
+		{"файл12", true, "Cyrillic with 2/6 characters digits (33%)"},
 
 		// Should NOT be detected as variables (< 30% digits)
 		{"username", false, "pure text (0%)"},
@@ -361,3 +362,213 @@ func TestPreprocessor_MixedPatterns(t *testing.T) {
 		}
 	}
 }
+
+func TestPreprocessor_IgnoreRules(t *testing.T) {
+	preprocessor := NewPreprocessor(`\s+`, map[string]string{})
+	preprocessor.setIgnoreRules([]int{0}, []string{`^tid-\w+$`})
+
+	logs := preprocessor.PreprocessLogs([]string{
+		"host1 tid-a1 request served",
+		"host2 tid-b2 request served",
+	})
+
+	for _, log := range logs {
+		if len(log.Words) != 4 {
+			t.Fatalf("Expected ignored words to be kept, got %d words", len(log.Words))
+		}
+		for i, word := range log.Words {
+			if word.Position != i {
+				t.Errorf("Expected positions of the line, got %d at index %d", word.Position, i)
+			}
+			expected := 2
+			if i < 2 {
+				expected = 0 // Ignored tokens are not counted
+			}
+			if word.Frequency != expected {
+				t.Errorf("Expected frequency %d for %q, got %d", expected, word.Value.Value(), word.Frequency)
+			}
+		}
+		if log.Words[0].Value.Value() != "<*>" || log.Words[1].Value.Value() != "<*>" {
+			t.Errorf("Expected ignored words as <*>, got %q %q", log.Words[0].Value.Value(), log.Words[1].Value.Value())
+		}
+	}
+}
+
+func TestBrain_IgnoreRulesGrouping(t *testing.T) {
+	logLines := []string{
+		"web-1 worker-17 cache flushed",
+		"web-2 worker-3 cache flushed",
+		"db-1 worker-8 cache flushed",
+	}
+
+	parser := New(Config{
+		Delimiters:          `\s+`,
+		IgnorePositions:     []int{0},
+		IgnoreTokenPatterns: []string{`^worker-\d+$`},
+	})
+	results := parser.Parse(logLines)
+
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(results))
+	}
+	if results[0].Template != "<*> <*> cache flushed" || results[0].Count != 3 {
+		t.Errorf("Unexpected result: %q (count %d)", results[0].Template, results[0].Count)
+	}
+	if _, ok := parser.Match("web-9 worker-2 cache flushed"); !ok {
+		t.Error("Expected a line with other ignored tokens to match")
+	}
+}
+
+func TestIsNumericVariableUnicode(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected bool
+	}{
+		{"١٢٣٤", true},       // Arabic-Indic digits
+		{"１２３", true},        // Full-width digits
+		{"१२३ms", true},      // Devanagari digits with unit
+		{"1'234'567", true},  // Swiss thousands separators
+		{"1\u00A0234", true}, // No-break space separator
+		{"12.345,67", true},  // Decimal comma
+		{"user", false},      // No digits
+		{"ошибка", false},    // Cyrillic letters only
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := isNumericVariableUnicode(tt.word); got != tt.expected {
+			t.Errorf("isNumericVariableUnicode(%q) = %v, want %v", tt.word, got, tt.expected)
+		}
+	}
+
+	// Strict ASCII detection does not see non-ASCII digits
+	if isNumericVariable("١٢٣٤") {
+		t.Error("Expected strict detection to ignore Arabic-Indic digits")
+	}
+}
+
+func TestBrain_UnicodeDigits(t *testing.T) {
+	logLines := []string{
+		"order ١٢٣ shipped",
+		"order ٤٥٦ shipped",
+	}
+
+	strict := New(Config{Delimiters: `\s+`, CommonVariables: map[string]string{}, ChildBranchThreshold: 5})
+	if results := strict.Parse(logLines); len(results) != 2 {
+		t.Errorf("Expected 2 templates in strict mode, got %d", len(results))
+	}
+
+	unicodeParser := New(Config{Delimiters: `\s+`, CommonVariables: map[string]string{}, ChildBranchThreshold: 5, UnicodeDigits: true})
+	results := unicodeParser.Parse(logLines)
+	if len(results) != 1 || results[0].Template != "order <*> shipped" {
+		t.Errorf("Expected single template 'order <*> shipped', got %d results", len(results))
+	}
+}
+
+func TestHeuristics_Unicode(t *testing.T) {
+	p := New(Config{})
+	tests := []struct {
+		name     string
+		detect   func(string) bool
+		word     string
+		expected bool
+	}{
+		{"containsMixedPatterns", containsMixedPatterns, "сессия_42", true},
+		{"containsMixedPatterns", containsMixedPatterns, "HTTP2", false},
+		{"hasComplexPattern", hasComplexPattern, "ключ1-знач2", true},
+		{"looksLikeHash", looksLikeHash, "ß0123456789abcdef", true},
+		{"looksLikeEncoded", looksLikeEncoded, "用户登录失败请检查密码是否正确并重试", false},
+		{"hasHighEntropy", p.hasHighEntropy, "数据库连接超时请稍后重试", false},
+		{"hasHighEntropy", p.hasHighEntropy, "🔥😀🚀🎉✨💡🌍🎯🔒📦", true},
+	}
+	for _, test := range tests {
+		if got := test.detect(test.word); got != test.expected {
+			t.Errorf("%s(%q) = %v, want %v", test.name, test.word, got, test.expected)
+		}
+	}
+}
+
+func TestPreprocessor_NumberUnitPatterns(t *testing.T) {
+	preprocessor := NewPreprocessor(`[\s,:=]+`, getDefaultCommonVariables())
+
+	tests := []struct {
+		line     string
+		expected []string
+	}{
+		{"read 1,024KB from disk", []string{"read", "<*>", "from", "disk"}},
+		{"heap 3.5 GiB used", []string{"heap", "<*>", "used"}},
+		{"cpu at 12 % load", []string{"cpu", "at", "<*>", "load"}},
+		{"cpu at 99.9% load", []string{"cpu", "at", "<*>", "load"}},
+		{"got 4 Bytes", []string{"got", "<*>", "Bytes"}}, // Unit must be a whole word
+		{"items 1,2,3", []string{"items", "<*>", "<*>", "<*>"}},
+	}
+	for _, tt := range tests {
+		var words []string
+		for _, word := range preprocessor.PreprocessLogs([]string{tt.line})[0].Words {
+			words = append(words, word.Value.Value())
+		}
+		if !reflect.DeepEqual(words, tt.expected) {
+			t.Errorf("PreprocessLogs(%q) = %q, want %q", tt.line, words, tt.expected)
+		}
+	}
+
+	// Removing a pattern keeps the matching numbers split
+	variables := DefaultCommonVariables()
+	delete(variables, "file_sizes_spaced")
+	preprocessor = NewPreprocessor(`[\s,:=]+`, variables)
+	if words := preprocessor.PreprocessLogs([]string{"heap 3.5 GiB used"})[0].Words; len(words) != 4 {
+		t.Errorf("Expected spaced size to stay split without its pattern, got %d words", len(words))
+	}
+}
+
+func TestBrain_NamedPlaceholders(t *testing.T) {
+	variables := DefaultCommonVariables()
+	variables["order_id"] = `^ORD-\d+$`
+	config := Config{
+		Delimiters:      `\s+`,
+		CommonVariables: variables,
+		Placeholders:    map[string]string{"order_id": "<ORDER>"},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	logLines := []string{
+		"Order ORD-1001 shipped to 10.0.0.1",
+		"Order ORD-1002 shipped to 10.0.0.2",
+		"Order ORD-1003 shipped to 10.0.0.3",
+	}
+	p := New(config)
+	report := p.ParseWithParams(logLines)
+
+	if len(report.Results) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(report.Results))
+	}
+	result := report.Results[0]
+	if result.Template != "Order <ORDER> shipped to <*>" {
+		t.Errorf("Unexpected template %q", result.Template)
+	}
+	if !reflect.DeepEqual(result.Params[0], []string{"ORD-1001", "10.0.0.1"}) {
+		t.Errorf("Expected values of both slots, got %v", result.Params[0])
+	}
+	if match, ok := p.Match("Order ORD-2000 shipped to 10.1.1.1"); !ok || match.Template != result.Template {
+		t.Errorf("Expected a new order to match, got %v, %v", match, ok)
+	}
+	// A named placeholder takes only words of its variable
+	if match, ok := p.Match("Order xyz shipped to 10.1.1.1"); ok {
+		t.Errorf("Expected a word other than an order not to match, got %v", match)
+	}
+	if placeholders := p.Placeholders(); !reflect.DeepEqual(placeholders, []string{"<ORDER>"}) {
+		t.Errorf("Unexpected placeholders %v", placeholders)
+	}
+
+	for _, placeholders := range []map[string]string{
+		{"order_id": "ORDER"},
+		{"order_id": "<*>"},
+		{"unknown": "<ORDER>"},
+	} {
+		config.Placeholders = placeholders
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected an error for %v", placeholders)
+		}
+	}
+}
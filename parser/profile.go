@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"runtime"
+	"time"
+)
+
+// Parse phase names recorded by the self-profiler
+const (
+	PhasePreprocess  = "preprocess"
+	PhaseGrouping    = "grouping"
+	PhaseTrees       = "trees"
+	PhaseAggregation = "aggregation"
+	PhaseFinalize    = "finalize"
+)
+
+// PhaseProfile contains resource usage of one parse phase.
+type PhaseProfile struct {
+	Name       string        // Phase name (see Phase* constants)
+	Duration   time.Duration // Wall time spent in the phase
+	Allocs     uint64        // Number of heap allocations during the phase
+	AllocBytes uint64        // Bytes allocated during the phase
+	HeapAlloc  uint64        // Live heap bytes at the end of the phase
+}
+
+// ParseProfile contains self-profiling data of one Parse call.
+type ParseProfile struct {
+	Phases   []PhaseProfile
+	Total    time.Duration // Total wall time
+	PeakHeap uint64        // Highest live heap observed at phase boundaries
+}
+
+// phaseProfiler samples runtime.MemStats at phase boundaries.
+// A nil profiler is valid and records nothing.
+type phaseProfiler struct {
+	profile   *ParseProfile
+	start     time.Time
+	lastTime  time.Time
+	lastStats runtime.MemStats
+}
+
+// newPhaseProfiler starts profiling into the given profile
+func newPhaseProfiler(profile *ParseProfile) *phaseProfiler {
+	pp := &phaseProfiler{profile: profile}
+	runtime.ReadMemStats(&pp.lastStats)
+	pp.start = time.Now()
+	pp.lastTime = pp.start
+	profile.PeakHeap = pp.lastStats.HeapAlloc
+	return pp
+}
+
+// endPhase records the phase that ended now and starts the next one
+func (pp *phaseProfiler) endPhase(name string) {
+	if pp == nil {
+		return
+	}
+
+	now := time.Now()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	pp.profile.Phases = append(pp.profile.Phases, PhaseProfile{
+		Name:       name,
+		Duration:   now.Sub(pp.lastTime),
+		Allocs:     stats.Mallocs - pp.lastStats.Mallocs,
+		AllocBytes: stats.TotalAlloc - pp.lastStats.TotalAlloc,
+		HeapAlloc:  stats.HeapAlloc,
+	})
+	pp.profile.PeakHeap = max(pp.profile.PeakHeap, stats.HeapAlloc)
+	pp.profile.Total = now.Sub(pp.start)
+
+	// Exclude the cost of reading stats from the next phase
+	pp.lastStats = stats
+	pp.lastTime = time.Now()
+}
+
+// endPhase records a phase boundary if the report is being profiled
+func (r *ParseReport) endPhase(name string) {
+	if r != nil {
+		r.profiler.endPhase(name)
+	}
+}
@@ -0,0 +1,79 @@
+package parser
+
+import "context"
+
+// PhaseStreaming is the phase reported by StreamingProcessor progress
+const PhaseStreaming = "streaming"
+
+// PhaseMatching is the phase reported for lines assigned to known templates
+// by ParseTwoPass and by parses resuming from a saved state
+const PhaseMatching = "matching"
+
+// ProgressFunc receives the progress of a long-running operation: processed
+// of total units, where total is 0 if it is not known yet, and the phase
+// that advanced (see Phase* constants).
+type ProgressFunc func(processed, total int, phase string)
+
+// progressTracker reports parse progress in line units over the
+// preprocessing, grouping and template generation phases.
+// A nil tracker is valid and reports nothing.
+type progressTracker struct {
+	callback ProgressFunc
+	done     int
+	total    int
+}
+
+// newProgressTracker returns a tracker of three units per line for callback,
+// or nil if callback is nil
+func newProgressTracker(callback ProgressFunc, lines int) *progressTracker {
+	if callback == nil {
+		return nil
+	}
+	return &progressTracker{callback: callback, total: 3 * lines}
+}
+
+// advance adds n processed units of phase and reports the new state
+func (pt *progressTracker) advance(n int, phase string) {
+	if pt == nil || n <= 0 {
+		return
+	}
+	pt.done = min(pt.done+n, pt.total)
+	pt.callback(pt.done, pt.total, phase)
+}
+
+// finish reports completion if it was not reported yet
+func (pt *progressTracker) finish() {
+	if pt == nil || pt.done == pt.total {
+		return
+	}
+	pt.done = pt.total
+	pt.callback(pt.done, pt.total, PhaseFinalize)
+}
+
+// advance reports progress of phase if the report tracks it
+func (r *ParseReport) advance(n int, phase string) {
+	if r != nil {
+		r.progress.advance(n, phase)
+	}
+}
+
+// OnProgress sets the Config.Progress callback of top-level parses, e.g. of a
+// parser restored with LoadState. A nil progress disables reporting.
+func (p *BrainParser) OnProgress(progress ProgressFunc) {
+	p.config.Progress = progress
+}
+
+// ParseWithProgress behaves like ParseWithReport and reports progress to the
+// callback as the phases advance. Total is three units per line, one each for
+// preprocessing, grouping and template generation; the last call has
+// done == total. The callback is called from the calling goroutine.
+func (p *BrainParser) ParseWithProgress(logLines []string, progress func(done, total int)) *ParseReport {
+	report := &ParseReport{}
+	if progress != nil {
+		report.progress = newProgressTracker(func(done, total int, _ string) {
+			progress(done, total)
+		}, len(logLines))
+	}
+	report, _ = p.parseReport(context.Background(), logLines, nil, report)
+	return report
+}
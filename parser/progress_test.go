@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseWithProgress(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 200; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+		logLines = append(logLines, fmt.Sprintf("Connection to host%d failed after %d ms", i, i*3))
+	}
+
+	for _, parallel := range []int{0, 10} {
+		p := New(Config{Delimiters: `\s+`, ParallelProcessingThreshold: parallel})
+		var calls [][2]int
+		report := p.ParseWithProgress(logLines, func(done, total int) {
+			calls = append(calls, [2]int{done, total})
+		})
+
+		if len(calls) < 3 {
+			t.Fatalf("parallel=%d: expected a call per phase, got %v", parallel, calls)
+		}
+		total := 3 * len(logLines)
+		for i, call := range calls {
+			if call[1] != total {
+				t.Errorf("parallel=%d: call %d has total %d, want %d", parallel, i, call[1], total)
+			}
+			if i > 0 && call[0] < calls[i-1][0] {
+				t.Errorf("parallel=%d: progress went backwards: %v", parallel, calls)
+			}
+		}
+		if last := calls[len(calls)-1]; last[0] != total {
+			t.Errorf("parallel=%d: last call %v is not complete", parallel, last)
+		}
+
+		expected := New(Config{Delimiters: `\s+`, ParallelProcessingThreshold: parallel}).Parse(logLines)
+		if len(report.Results) != len(expected) {
+			t.Errorf("parallel=%d: got %d templates, want %d", parallel, len(report.Results), len(expected))
+		}
+	}
+
+	// A nil callback behaves like ParseWithReport
+	if report := New(Config{}).ParseWithProgress(logLines, nil); len(report.Results) == 0 {
+		t.Error("Expected results with nil callback")
+	}
+}
+
+func TestConfigProgress(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 100; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+
+	var phases []string
+	last := 0
+	p := New(Config{Delimiters: `\s+`, Progress: func(processed, total int, phase string) {
+		if total != 3*len(logLines) {
+			t.Errorf("Expected total %d, got %d", 3*len(logLines), total)
+		}
+		if processed < last {
+			t.Errorf("Progress went backwards: %d after %d", processed, last)
+		}
+		last = processed
+		if len(phases) == 0 || phases[len(phases)-1] != phase {
+			phases = append(phases, phase)
+		}
+	}})
+	p.Parse(logLines)
+
+	if last != 3*len(logLines) {
+		t.Errorf("Expected complete progress, got %d", last)
+	}
+	expected := []string{PhasePreprocess, PhaseGrouping, PhaseTrees}
+	if strings.Join(phases, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected phases %v, got %v", expected, phases)
+	}
+}
+
+func TestStreamingProgress(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 95; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+
+	for _, reader := range []bool{false, true} {
+		var calls [][2]int
+		var lastPhase string
+		config := Config{Delimiters: `\s+`, Progress: func(processed, total int, phase string) {
+			calls = append(calls, [2]int{processed, total})
+			lastPhase = phase
+		}}
+		processor := NewStreamingProcessor(config, StreamingConfig{BatchSize: 10, MaxWorkers: 2})
+		var err error
+		if reader {
+			_, err = processor.ProcessReader(context.Background(), strings.NewReader(strings.Join(logLines, "\n")))
+		} else {
+			_, err = processor.ProcessLargeSlice(context.Background(), logLines)
+		}
+		if err != nil {
+			t.Fatalf("reader=%v: %v", reader, err)
+		}
+
+		// One call per batch and one after aggregation
+		if len(calls) != 11 {
+			t.Fatalf("reader=%v: expected 11 calls, got %v", reader, calls)
+		}
+		for i, call := range calls[:10] {
+			if i > 0 && call[0] <= calls[i-1][0] {
+				t.Errorf("reader=%v: progress did not advance: %v", reader, calls)
+			}
+			if want := len(logLines); !reader && call[1] != want || reader && call[1] != 0 {
+				t.Errorf("reader=%v: call %d has total %d", reader, i, call[1])
+			}
+		}
+		if last := calls[10]; last != [2]int{len(logLines), len(logLines)} || lastPhase != PhaseAggregation {
+			t.Errorf("reader=%v: last call %v in phase %q is not complete", reader, last, lastPhase)
+		}
+	}
+}
+
+func TestMatchingProgress(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 300; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in", i))
+	}
+	logLines = append(logLines, "Disk quota exceeded on volume data") // Residual line
+
+	// Every call of one parse must have the same total, never go backwards
+	// and end complete
+	var calls [][2]int
+	var phases []string
+	progress := func(processed, total int, phase string) {
+		calls = append(calls, [2]int{processed, total})
+		if len(phases) == 0 || phases[len(phases)-1] != phase {
+			phases = append(phases, phase)
+		}
+	}
+	check := func(name string) {
+		t.Helper()
+		total := 3 * len(logLines)
+		for i, call := range calls {
+			if call[1] != total {
+				t.Errorf("%s: call %d has total %d, want %d", name, i, call[1], total)
+			}
+			if i > 0 && call[0] < calls[i-1][0] {
+				t.Errorf("%s: progress went backwards: %v", name, calls)
+			}
+		}
+		if len(calls) == 0 || calls[len(calls)-1][0] != total {
+			t.Errorf("%s: progress is not complete: %v", name, calls)
+		}
+		if len(phases) == 0 || phases[0] != PhaseMatching {
+			t.Errorf("%s: expected the matching pass to be reported first, got phases %v", name, phases)
+		}
+		calls, phases = nil, nil
+	}
+
+	parser := New(Config{Delimiters: `\s+`, ExamplesPerTemplate: 2, Progress: progress})
+	report := parser.ParseTwoPass(logLines, TwoPassOptions{SampleSize: 50})
+	check("two-pass")
+	for _, result := range report.Results {
+		if len(result.Examples) == 0 || result.ID == "" {
+			t.Errorf("two-pass: template %q was not finalized: %+v", result.Template, result)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := parser.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	resumed, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	resumed.OnProgress(progress)
+	resumed.Parse(logLines)
+	check("resumed")
+}
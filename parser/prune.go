@@ -0,0 +1,54 @@
+package parser
+
+import "strings"
+
+// pruneConstantColumns removes the leading columns holding the same word in
+// every log, e.g. an application name or environment header, and returns
+// their template tokens joined by spaces. At least one word of every log is
+// kept. Word positions are renumbered to start at 0.
+func (p *BrainParser) pruneConstantColumns(logs []*LogMessage) string {
+	if len(logs) == 0 {
+		return ""
+	}
+	minLen := len(logs[0].Words)
+	for _, log := range logs {
+		minLen = min(minLen, len(log.Words))
+	}
+
+	pruned := 0
+	for pruned < minLen-1 && isConstantColumn(logs, pruned) {
+		pruned++
+	}
+	if pruned == 0 {
+		return ""
+	}
+
+	header := make([]string, pruned)
+	for i, word := range logs[0].Words[:pruned] {
+		// Post-process like buildCompleteTemplate does for constant words
+		header[i] = word.Value.Value()
+		if !p.preprocessor.slots.isSlot(header[i]) && p.shouldBeVariableWithConfig(header[i]) {
+			header[i] = "<*>"
+		}
+	}
+
+	for _, log := range logs {
+		n := copy(log.Words, log.Words[pruned:])
+		log.Words = log.Words[:n]
+		for i := range log.Words {
+			log.Words[i].Position = i
+		}
+	}
+	return strings.Join(header, " ")
+}
+
+// isConstantColumn checks whether all logs have the same word at position
+func isConstantColumn(logs []*LogMessage, position int) bool {
+	value := logs[0].Words[position].Value
+	for _, log := range logs[1:] {
+		if log.Words[position].Value != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestPruneConstantColumns(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 20; i++ {
+		logLines = append(logLines, fmt.Sprintf("myapp prod User user%d logged in", i))
+		logLines = append(logLines, fmt.Sprintf("myapp prod Connection to host%d failed", i))
+	}
+
+	templates := func(config Config) []string {
+		var templates []string
+		for _, result := range New(config).Parse(logLines) {
+			templates = append(templates, fmt.Sprintf("%s=%d", result.Template, result.Count))
+		}
+		sort.Strings(templates)
+		return templates
+	}
+
+	pruned := templates(Config{Delimiters: `\s+`, PruneConstantColumns: true})
+	expected := []string{
+		"myapp prod Connection to <*> failed=20",
+		"myapp prod User <*> logged in=20",
+	}
+	if fmt.Sprint(pruned) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected pruned templates:\n got %v\nwant %v", pruned, expected)
+	}
+}
+
+func TestPruneConstantColumnsKeepsOneWord(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`})
+	logs := p.preprocessor.PreprocessLogs([]string{"myapp prod started", "myapp prod started now"})
+	header := p.pruneConstantColumns(logs)
+	if header != "myapp prod" {
+		t.Errorf("Expected header %q, got %q", "myapp prod", header)
+	}
+	if len(logs[0].Words) != 1 || logs[0].Words[0].Value.Value() != "started" || logs[0].Words[0].Position != 0 {
+		t.Errorf("Unexpected words after pruning: %+v", logs[0].Words)
+	}
+	if len(logs[1].Words) != 2 || logs[1].Words[1].Position != 1 {
+		t.Errorf("Unexpected words after pruning: %+v", logs[1].Words)
+	}
+
+	// Identical lines keep their last word
+	logs = p.preprocessor.PreprocessLogs([]string{"same line", "same line"})
+	if header := p.pruneConstantColumns(logs); header != "same" || len(logs[0].Words) != 1 {
+		t.Errorf("Expected one word to be kept, got header %q and %d words", header, len(logs[0].Words))
+	}
+}
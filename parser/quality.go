@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Names of the QualityPolicy presets for Config.QualityPolicy
+const (
+	QualityStrict   = "strict"
+	QualityBalanced = "balanced"
+	QualityLenient  = "lenient"
+)
+
+// QualityPolicy bundles the thresholds a template must pass to be reported:
+// its shape, checked by enhanced post-processing, and its support in the
+// parsed lines.
+type QualityPolicy struct {
+	MaxConsecutiveWildcards int     // Maximum consecutive <*> tokens (Config.MaxConsecutiveWildcards)
+	MinContentWordsRatio    float64 // Minimum ratio of non-<*> words (Config.MinContentWordsRatio)
+	MinSupport              int     // Minimum line count of a template
+	Coverage                float64 // Keep the most frequent templates covering this fraction of lines (0 = all)
+}
+
+// qualityPolicies are the presets by name
+var qualityPolicies = map[string]QualityPolicy{
+	QualityStrict:   {MaxConsecutiveWildcards: 2, MinContentWordsRatio: 0.5, MinSupport: 5, Coverage: 0.99},
+	QualityBalanced: {MaxConsecutiveWildcards: 4, MinContentWordsRatio: 0.3, MinSupport: 2},
+	QualityLenient:  {MaxConsecutiveWildcards: 8, MinContentWordsRatio: 0.1, MinSupport: 1},
+}
+
+// LookupQualityPolicy returns the preset with the given name: strict keeps
+// only well-supported templates with few variables, lenient keeps nearly
+// everything, balanced lies in between.
+func LookupQualityPolicy(name string) (QualityPolicy, bool) {
+	policy, ok := qualityPolicies[name]
+	return policy, ok
+}
+
+// QualityPolicyNames returns the names of all presets, sorted.
+func QualityPolicyNames() []string {
+	names := make([]string, 0, len(qualityPolicies))
+	for name := range qualityPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Select returns the results with at least MinSupport lines that are among
+// the most frequent templates covering the Coverage fraction of all lines,
+// in their original order.
+func (q QualityPolicy) Select(results []*ParseResult) ([]*ParseResult, error) {
+	minCount, err := q.minCount(results)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]*ParseResult, 0, len(results))
+	for _, result := range results {
+		if result.Count >= minCount {
+			selected = append(selected, result)
+		}
+	}
+	return selected, nil
+}
+
+// minCount returns the line count a result needs to be selected
+func (q QualityPolicy) minCount(results []*ParseResult) (int, error) {
+	minCount := q.MinSupport
+	if q.Coverage > 0 {
+		threshold, err := CoverageThreshold(results, q.Coverage)
+		if err != nil {
+			return 0, fmt.Errorf("invalid quality policy: %w", err)
+		}
+		minCount = max(minCount, threshold)
+	}
+	return minCount, nil
+}
+
+// applyQualityPolicy fills the shape thresholds of the named policy into
+// config where they are unset and enables the enhanced post-processing that
+// checks them. It returns the policy, or nil if none is selected.
+func applyQualityPolicy(config *Config) *QualityPolicy {
+	policy, ok := LookupQualityPolicy(config.QualityPolicy)
+	if !ok {
+		return nil
+	}
+	if config.MaxConsecutiveWildcards == 0 {
+		config.MaxConsecutiveWildcards = policy.MaxConsecutiveWildcards
+	}
+	if config.MinContentWordsRatio == 0 {
+		config.MinContentWordsRatio = policy.MinContentWordsRatio
+	}
+	config.UseEnhancedPostProcessing = true
+	return &policy
+}
+
+// gateQuality drops the results the quality policy does not select and
+// releases them
+func (p *BrainParser) gateQuality(results Results) Results {
+	if p.quality == nil || p.state == nil {
+		return results // Internal learners are gated by the top-level parse
+	}
+	minCount, err := p.quality.minCount(results)
+	if err != nil {
+		return results
+	}
+	kept := results[:0]
+	for _, result := range results {
+		if result.Count >= minCount {
+			kept = append(kept, result)
+			continue
+		}
+		PutIntSlice(result.LogIDs)
+		result.LogIDs = nil
+		PutParseResult(result)
+	}
+	clear(results[len(kept):])
+	return kept
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQualityPolicy(t *testing.T) {
+	var lines []string
+	for i := 0; i < 40; i++ {
+		lines = append(lines, fmt.Sprintf("User user%c logged in from web", 'a'+i%26))
+	}
+	for i := 0; i < 3; i++ {
+		lines = append(lines, fmt.Sprintf("Backup of volume data finished in %d seconds", 10+i))
+	}
+	lines = append(lines, "Disk sda is full")
+
+	lenient := New(Config{Delimiters: `\s+`, Deterministic: true, QualityPolicy: QualityLenient}).Parse(lines)
+	strict := New(Config{Delimiters: `\s+`, Deterministic: true, QualityPolicy: QualityStrict}).Parse(lines)
+	if len(lenient) != 3 {
+		t.Fatalf("Expected 3 templates with the lenient policy, got %d", len(lenient))
+	}
+	// Strict requires 5 lines and the templates covering 99% of the lines
+	if len(strict) != 1 || strict[0].Count != 40 {
+		t.Errorf("Expected only the frequent template with the strict policy, got %d", len(strict))
+	}
+
+	// Explicit thresholds take precedence over the policy
+	parser := New(Config{QualityPolicy: QualityStrict, MaxConsecutiveWildcards: 7})
+	if parser.config.MaxConsecutiveWildcards != 7 || parser.config.MinContentWordsRatio != 0.5 || !parser.config.UseEnhancedPostProcessing {
+		t.Errorf("Unexpected config with strict policy: %+v", parser.config)
+	}
+
+	policy, ok := LookupQualityPolicy(QualityBalanced)
+	if !ok {
+		t.Fatal("Expected balanced policy")
+	}
+	selected, err := policy.Select([]*ParseResult{{Template: "a", Count: 1}, {Template: "b", Count: 2}})
+	if err != nil || len(selected) != 1 || selected[0].Template != "b" {
+		t.Errorf("Unexpected selection %v, %v", selected, err)
+	}
+	if _, err := (QualityPolicy{Coverage: 2}).Select(selected); err == nil {
+		t.Error("Expected error for coverage above 1")
+	}
+
+	err = Config{QualityPolicy: "paranoid"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "balanced, lenient, strict") {
+		t.Errorf("Expected unknown policy error, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"sort"
+	"time"
+)
+
+// Kinds of TemplateAnomaly
+const (
+	AnomalyNew  = "new"  // The template of the line was learned from the batch of the line
+	AnomalyRare = "rare" // The template of the line is below RarityOptions.RareFrequency
+)
+
+// RarityOptions controls which lines an OnlineParser reports as anomalies.
+type RarityOptions struct {
+	RareFrequency float64 // Share of all lines added so far below which a known template is rare, e.g. 0.001 (0 = report new templates only)
+	MinLines      int     // Lines to add before anything is reported, so the first batches can establish the normal templates (0 = report from the start)
+}
+
+// TemplateAnomaly is a line of a batch whose template is new or rare.
+type TemplateAnomaly struct {
+	Kind       string    `json:"kind"`  // AnomalyNew or AnomalyRare
+	Line       string    `json:"line"`  // The line
+	Index      int       `json:"index"` // Index of the line in its batch
+	Template   string    `json:"template"`
+	TemplateID string    `json:"template_id"`
+	Count      int       `json:"count"`     // Accumulated count of the template including the batch
+	Frequency  float64   `json:"frequency"` // Count divided by the lines added so far
+	Time       time.Time `json:"time"`      // Time of the batch (see Config.Timestamps)
+}
+
+// OnAnomaly makes the parser report every line of a batch whose template is
+// new or rare by options to handle, in the order of the lines. New templates
+// take precedence over rare ones. handle is called after the batch was
+// added, from the goroutine adding it, and may use the parser. A nil handle
+// disables reporting.
+func (op *OnlineParser) OnAnomaly(options RarityOptions, handle func(anomaly TemplateAnomaly)) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.rarity = options
+	op.onAnomaly = handle
+}
+
+// Anomalies returns a channel receiving the anomalies OnAnomaly would report,
+// with room for buffer of them. Anomalies are dropped while the channel is
+// full so that a slow consumer never blocks adding batches. The channel is
+// never closed; calling Anomalies or OnAnomaly again stops sending to it.
+func (op *OnlineParser) Anomalies(options RarityOptions, buffer int) <-chan TemplateAnomaly {
+	anomalies := make(chan TemplateAnomaly, max(buffer, 1))
+	op.OnAnomaly(options, func(anomaly TemplateAnomaly) {
+		select {
+		case anomalies <- anomaly:
+		default:
+		}
+	})
+	return anomalies
+}
+
+// anomalies returns the lines of a batch whose templates are new or rare.
+// The caller must hold op.mu.
+func (op *OnlineParser) anomalies(results []*ParseResult, lines []string, now time.Time) []TemplateAnomaly {
+	if op.onAnomaly == nil || op.lines-len(lines) < op.rarity.MinLines {
+		return nil // Warming up
+	}
+	changes := make(map[string]AuditEvent, len(op.changes))
+	for _, change := range op.changes {
+		changes[change.Template] = change
+	}
+
+	var anomalies []TemplateAnomaly
+	for _, result := range results {
+		change, ok := changes[result.Template]
+		if !ok {
+			continue
+		}
+		frequency := float64(change.Count) / float64(op.lines)
+		kind := AnomalyRare
+		if change.Event == AuditCreated {
+			kind = AnomalyNew
+		} else if frequency >= op.rarity.RareFrequency {
+			continue
+		}
+		id := result.ID
+		if id == "" {
+			id = op.parser.config.TemplateID(result.Template)
+		}
+		for _, index := range result.LogIDs {
+			if index < 0 || index >= len(lines) {
+				continue
+			}
+			anomalies = append(anomalies, TemplateAnomaly{
+				Kind:       kind,
+				Line:       lines[index],
+				Index:      index,
+				Template:   result.Template,
+				TemplateID: id,
+				Count:      change.Count,
+				Frequency:  frequency,
+				Time:       now,
+			})
+		}
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].Index < anomalies[j].Index
+	})
+	return anomalies
+}
@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnlineParserOnAnomaly(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	op := NewOnlineParser(Config{Delimiters: `\s+`, Clock: func() time.Time { return now }})
+	var anomalies []TemplateAnomaly
+	op.OnAnomaly(RarityOptions{RareFrequency: 0.35, MinLines: 10}, func(anomaly TemplateAnomaly) {
+		anomalies = append(anomalies, anomaly)
+		_ = op.Lines() // Handlers may use the parser
+	})
+
+	var warmup []string
+	for _, user := range []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace"} {
+		warmup = append(warmup, "User "+user+" logged in")
+	}
+	op.Add(append(warmup, "Disk sda full", "Disk sdb full", "Disk sdc full"))
+	if len(anomalies) != 0 {
+		t.Fatalf("Expected no anomalies during warmup, got %+v", anomalies)
+	}
+
+	op.Add([]string{"User judy logged in", "Kernel panic on cpu 3", "Disk sdd full"})
+	if len(anomalies) != 2 {
+		t.Fatalf("Expected a new and a rare line, got %+v", anomalies)
+	}
+	if a := anomalies[0]; a.Kind != AnomalyNew || a.Index != 1 || a.Line != "Kernel panic on cpu 3" || a.Count != 1 || a.TemplateID == "" || !a.Time.Equal(now) {
+		t.Errorf("Unexpected new anomaly %+v", a)
+	}
+	if a := anomalies[1]; a.Kind != AnomalyRare || a.Line != "Disk sdd full" || a.Template != "Disk <*> full" || a.Count != 4 || a.Frequency != 4.0/13 {
+		t.Errorf("Unexpected rare anomaly %+v", a)
+	}
+}
+
+func TestOnlineParserAnomaliesChannel(t *testing.T) {
+	op := NewOnlineParser(Config{Delimiters: `\s+`})
+	anomalies := op.Anomalies(RarityOptions{}, 1)
+	op.Add([]string{"Disk sda full", "Kernel panic on cpu 3"})
+
+	anomaly := <-anomalies
+	if anomaly.Kind != AnomalyNew || anomaly.Index != 0 {
+		t.Errorf("Expected the first new line, got %+v", anomaly)
+	}
+	select {
+	case extra := <-anomalies:
+		t.Errorf("Expected anomalies beyond the buffer to be dropped, got %+v", extra)
+	default:
+	}
+
+	op.Add([]string{"Disk sda full"})
+	select {
+	case extra := <-anomalies:
+		t.Errorf("Expected no rare anomalies without RareFrequency, got %+v", extra)
+	default:
+	}
+}
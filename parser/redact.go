@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Placeholder types of the built-in redaction rules, written as <TYPE>
+const (
+	RedactEmail      = "EMAIL"
+	RedactIP         = "IP"
+	RedactCreditCard = "CARD"
+)
+
+// redactionRule replaces matches of pattern accepted by valid (nil: all)
+// with placeholder
+type redactionRule struct {
+	placeholder string
+	pattern     *regexp.Regexp
+	valid       func(string) bool
+}
+
+// builtinRedactionRules are applied before user patterns, emails first as
+// they may contain IPs and numbers
+var builtinRedactionRules = []redactionRule{
+	{
+		placeholder: "<" + RedactEmail + ">",
+		pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`),
+	},
+	{
+		// 13 to 19 digits, optionally grouped by spaces or dashes, starting
+		// like the major card networks and passing the Luhn check
+		placeholder: "<" + RedactCreditCard + ">",
+		pattern:     regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`),
+		valid:       luhnValid,
+	},
+	{
+		// IPv6 candidates are whole words with a colon, so only complete
+		// addresses like ::ffff:10.0.0.1 pass parsing, not parts of
+		// std::vector; a trailing dot ends the word
+		placeholder: "<" + RedactIP + ">",
+		pattern:     regexp.MustCompile(`(?:\w|\.\w)*:(?:[\w:]|\.\w)*`),
+		valid:       validIPv6,
+	},
+	{
+		placeholder: "<" + RedactIP + ">",
+		pattern:     regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}\b`),
+		valid:       validIPv4,
+	},
+}
+
+// Redactor replaces personal data in templates and log lines with typed
+// placeholders, so mined templates can be shared outside the security
+// boundary: emails become <EMAIL>, IPv4 and IPv6 addresses <IP> and
+// credit-card-like numbers <CARD>, followed by user patterns. It is safe for
+// concurrent use.
+type Redactor struct {
+	rules []redactionRule
+}
+
+// NewRedactor creates a Redactor with the built-in rules and the given
+// patterns, "name" -> "regex", whose matches are replaced with <name>. User
+// patterns are applied in order of name after the built-in rules. It returns
+// an error if a pattern does not compile.
+func NewRedactor(patterns map[string]string) (*Redactor, error) {
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rules := append([]redactionRule(nil), builtinRedactionRules...)
+	for _, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("redaction pattern %q has no name", patterns[name])
+		}
+		pattern, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", name, err)
+		}
+		rules = append(rules, redactionRule{placeholder: "<" + name + ">", pattern: pattern})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact returns text with all matches of the rules replaced.
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.rules {
+		if rule.valid == nil {
+			text = rule.pattern.ReplaceAllLiteralString(text, rule.placeholder)
+			continue
+		}
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.valid(match) {
+				return rule.placeholder
+			}
+			return match
+		})
+	}
+	return text
+}
+
+// RedactLines returns a copy of lines with every line redacted.
+func (r *Redactor) RedactLines(lines []string) []string {
+	redacted := make([]string, len(lines))
+	for i, line := range lines {
+		redacted[i] = r.Redact(line)
+	}
+	return redacted
+}
+
+// RedactResults redacts the templates, slot values, token texts and examples
+// of results in place. IDs are kept, so redacted results can still be joined
+// with unredacted ones.
+func (r *Redactor) RedactResults(results []*ParseResult) {
+	for _, result := range results {
+		result.Template = r.Redact(result.Template)
+		for _, params := range result.Params {
+			for i, value := range params {
+				params[i] = r.Redact(value)
+			}
+		}
+		for i := range result.Positions {
+			result.Positions[i].Text = r.Redact(result.Positions[i].Text)
+		}
+		if result.Examples != nil {
+			result.Examples = r.RedactLines(result.Examples)
+		}
+	}
+}
+
+// luhnValid reports whether the digits of number pass the Luhn checksum,
+// skipping separators
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validIPv4 reports whether s is a dotted IPv4 address
+func validIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+
+// validIPv6 reports whether s is an IPv6 address
+func validIPv6(s string) bool {
+	// Hex words like add::face need a digit or all eight groups
+	if !strings.ContainsAny(s, "0123456789") && strings.Count(s, ":") != 7 {
+		return false
+	}
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6()
+}
@@ -0,0 +1,77 @@
+package parser
+
+import "testing"
+
+func TestRedactor(t *testing.T) {
+	redactor, err := NewRedactor(map[string]string{"TOKEN": `tok_[a-z0-9]+`})
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Mail sent to john.doe@example.com", "Mail sent to <EMAIL>"},
+		{"Connection from 192.168.1.10 port 22", "Connection from <IP> port 22"},
+		{"Version 1.2.3.4567 released", "Version 1.2.3.4567 released"},
+		{"Listening on fe80::1ff:fe23:4567:890a and ::1", "Listening on <IP> and <IP>"},
+		{"Mapped ::ffff:10.0.0.1", "Mapped <IP>"},
+		{"Bound [::1]:8080 and fe80::1.", "Bound [<IP>]:8080 and <IP>."},
+		{"std::vector failed", "std::vector failed"},
+		{"call Foo::Bar done", "call Foo::Bar done"},
+		{"in boost::asio::ip::tcp::socket", "in boost::asio::ip::tcp::socket"},
+		{"Opcode add::face and a::b", "Opcode add::face and a::b"}, // Hex words without a digit
+		{"Peer dead:beef:cafe:f00d:abcd:ef01:2345:6789", "Peer <IP>"},
+		{"MAC 00:1a:2b:3c:4d:5e", "MAC 00:1a:2b:3c:4d:5e"},
+		{"Started at 10:30:15", "Started at 10:30:15"},
+		{"Charged card 4111 1111 1111 1111", "Charged card <CARD>"},
+		{"Charged card 4111-1111-1111-1112", "Charged card 4111-1111-1111-1112"}, // Fails the Luhn check
+		{"Timestamp 1705312215123", "Timestamp 1705312215123"},
+		{"Auth with tok_abc123 from <*>", "Auth with <TOKEN> from <*>"},
+	}
+	for _, tt := range tests {
+		if got := redactor.Redact(tt.input); got != tt.expected {
+			t.Errorf("Redact(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+
+	if _, err := NewRedactor(map[string]string{"BAD": `(`}); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+}
+
+func TestRedactResults(t *testing.T) {
+	logLines := []string{
+		"Password reset for admin@example.com from 10.0.0.1",
+		"Password reset for admin@example.com from 10.0.0.2",
+	}
+	// Without common variables the constant email stays in the template
+	p := New(Config{Delimiters: `\s+`, CommonVariables: map[string]string{}})
+	report := p.ParseWithParams(logLines)
+	redactor, err := NewRedactor(nil)
+	if err != nil {
+		t.Fatalf("NewRedactor failed: %v", err)
+	}
+	redactor.RedactResults(report.Results)
+
+	if len(report.Results) != 1 {
+		t.Fatalf("Expected 1 template, got %d", len(report.Results))
+	}
+	result := report.Results[0]
+	if result.Template != "Password reset for <EMAIL> from <*>" {
+		t.Errorf("Unexpected template %q", result.Template)
+	}
+	for _, params := range result.Params {
+		for _, value := range params {
+			if value != "<IP>" {
+				t.Errorf("Expected redacted slot value, got %q", value)
+			}
+		}
+	}
+
+	redacted := redactor.RedactLines(logLines)
+	if redacted[0] != "Password reset for <EMAIL> from <IP>" || logLines[0] == redacted[0] {
+		t.Errorf("Unexpected redacted lines %q (input %q)", redacted, logLines)
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// defaultRegexExamples is the number of member lines checked per template
+const defaultRegexExamples = 10
+
+// RegexIssue describes an example line that is not matched by its own
+// template regex (miss) or is also matched by other templates (collision).
+type RegexIssue struct {
+	LogID    int      // Index of the example line
+	Line     string   // Example line
+	Template string   // Template the line belongs to
+	Matches  []string // Other templates whose regex matches the line (collisions only)
+}
+
+// RegexValidationReport contains the outcome of ValidateTemplateRegexes.
+type RegexValidationReport struct {
+	Templates  int          // Number of validated templates
+	Examples   int          // Number of example lines checked
+	Misses     []RegexIssue // Lines not matched by their own template regex
+	Collisions []RegexIssue // Lines matched by more than one template regex
+}
+
+// OK reports whether every example matched exactly its own template.
+func (r *RegexValidationReport) OK() bool {
+	return len(r.Misses) == 0 && len(r.Collisions) == 0
+}
+
+// ValidateTemplateRegexes re-matches example lines of every template against
+// the regexes produced by TemplateToRegex with the given named placeholders
+// for all given templates. Up to maxExamples member lines are checked per
+// template (default: 10). Regexes are applied unanchored, the way Sigma |re
+// detections match.
+func ValidateTemplateRegexes(results []*ParseResult, logLines []string, maxExamples int, placeholders ...string) (*RegexValidationReport, error) {
+	if maxExamples <= 0 {
+		maxExamples = defaultRegexExamples
+	}
+
+	regexes := make([]*regexp.Regexp, len(results))
+	for i, result := range results {
+		re, err := regexp.Compile(TemplateToRegex(result.Template, placeholders...))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex for template %q: %w", result.Template, err)
+		}
+		regexes[i] = re
+	}
+
+	report := &RegexValidationReport{Templates: len(results)}
+	for i, result := range results {
+		checked := 0
+		for _, logID := range result.LogIDs {
+			if checked >= maxExamples {
+				break
+			}
+			if logID < 0 || logID >= len(logLines) {
+				continue
+			}
+			checked++
+
+			line := logLines[logID]
+			if !regexes[i].MatchString(line) {
+				report.Misses = append(report.Misses, RegexIssue{
+					LogID:    logID,
+					Line:     line,
+					Template: result.Template,
+				})
+			}
+
+			var matches []string
+			for j, re := range regexes {
+				if j != i && re.MatchString(line) {
+					matches = append(matches, results[j].Template)
+				}
+			}
+			if len(matches) > 0 {
+				report.Collisions = append(report.Collisions, RegexIssue{
+					LogID:    logID,
+					Line:     line,
+					Template: result.Template,
+					Matches:  matches,
+				})
+			}
+		}
+		report.Examples += checked
+	}
+
+	return report, nil
+}
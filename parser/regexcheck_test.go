@@ -0,0 +1,55 @@
+package parser
+
+import "testing"
+
+func TestValidateTemplateRegexes(t *testing.T) {
+	logLines := []string{
+		"User alice logged in",
+		"User bob logged in",
+		"Connection closed",
+		"Connection closed by peer",
+	}
+	results := []*ParseResult{
+		{Template: "User <*> logged in", Count: 2, LogIDs: []int{0, 1}},
+		{Template: "Connection closed", Count: 1, LogIDs: []int{2}},
+		{Template: "Connection closed by <*>", Count: 1, LogIDs: []int{3}},
+	}
+
+	report, err := ValidateTemplateRegexes(results, logLines, 0)
+	if err != nil {
+		t.Fatalf("ValidateTemplateRegexes failed: %v", err)
+	}
+	if report.Templates != 3 || report.Examples != 4 {
+		t.Errorf("Expected 3 templates and 4 examples, got %d and %d", report.Templates, report.Examples)
+	}
+	if len(report.Misses) != 0 {
+		t.Errorf("Expected no misses, got %+v", report.Misses)
+	}
+
+	// "Connection closed" is a prefix of the longer template and matches its lines
+	if len(report.Collisions) != 1 {
+		t.Fatalf("Expected 1 collision, got %+v", report.Collisions)
+	}
+	collision := report.Collisions[0]
+	if collision.LogID != 3 || len(collision.Matches) != 1 || collision.Matches[0] != "Connection closed" {
+		t.Errorf("Unexpected collision: %+v", collision)
+	}
+	if report.OK() {
+		t.Error("Report with collisions must not be OK")
+	}
+}
+
+func TestValidateTemplateRegexesMiss(t *testing.T) {
+	logLines := []string{"disk full on sda"}
+	results := []*ParseResult{
+		{Template: "disk full on <*> retrying", Count: 1, LogIDs: []int{0}},
+	}
+
+	report, err := ValidateTemplateRegexes(results, logLines, 1)
+	if err != nil {
+		t.Fatalf("ValidateTemplateRegexes failed: %v", err)
+	}
+	if len(report.Misses) != 1 || report.Misses[0].LogID != 0 {
+		t.Errorf("Expected a miss for line 0, got %+v", report.Misses)
+	}
+}
@@ -0,0 +1,264 @@
+package parser
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Opener opens a named input for reading, e.g. a remote object streamed
+// without a local copy.
+type Opener interface {
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// OpenerFunc adapts a function to the Opener interface.
+type OpenerFunc func(ctx context.Context, name string) (io.ReadCloser, error)
+
+// Open calls f.
+func (f OpenerFunc) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return f(ctx, name)
+}
+
+// RemoteOpener opens URLs with the Opener registered for their scheme, e.g.
+// "s3" for s3://bucket/key.
+type RemoteOpener map[string]Opener
+
+// Handles reports whether name is a URL with a scheme registered in r.
+func (r RemoteOpener) Handles(name string) bool {
+	scheme, _, ok := strings.Cut(name, "://")
+	return ok && r[strings.ToLower(scheme)] != nil
+}
+
+// Open opens name with the Opener of its scheme.
+func (r RemoteOpener) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	scheme, _, _ := strings.Cut(name, "://")
+	opener := r[strings.ToLower(scheme)]
+	if opener == nil {
+		return nil, fmt.Errorf("unsupported input URL scheme: %q", scheme)
+	}
+	return opener.Open(ctx, name)
+}
+
+// HTTPOpener opens http:// and https:// URLs with a GET request, e.g.
+// pre-signed object store URLs.
+type HTTPOpener struct {
+	Client *http.Client // Optional, defaults to http.DefaultClient
+	Header http.Header  // Extra request headers, e.g. Authorization
+}
+
+// Open requests name and returns the response body.
+func (h HTTPOpener) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, name, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input request: %w", err)
+	}
+	for key, values := range h.Header {
+		req.Header[key] = values
+	}
+	return fetchBody(h.Client, req)
+}
+
+// S3Opener opens s3://bucket/key URLs from Amazon S3 or an S3-compatible
+// store. Requests are signed with AWS Signature Version 4 if AccessKeyID is
+// set, anonymous otherwise.
+type S3Opener struct {
+	Client          *http.Client // Optional, defaults to http.DefaultClient
+	Region          string       // Bucket region (default: us-east-1)
+	Endpoint        string       // Base URL of an S3-compatible store, addressed path-style (default: virtual-hosted AWS)
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string           // Temporary credentials only
+	Now             func() time.Time // Signing time (default: time.Now)
+}
+
+// Open fetches the object of an s3:// URL.
+func (s S3Opener) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	bucket, key, err := splitObjectURL(name, "s3")
+	if err != nil {
+		return nil, err
+	}
+	region := s.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	target := "https://" + bucket + ".s3." + region + ".amazonaws.com/" + escapeObjectKey(key)
+	if s.Endpoint != "" {
+		target = strings.TrimSuffix(s.Endpoint, "/") + "/" + bucket + "/" + escapeObjectKey(key)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input request: %w", err)
+	}
+	if s.AccessKeyID != "" {
+		now := time.Now
+		if s.Now != nil {
+			now = s.Now
+		}
+		s.sign(req, region, now().UTC())
+	}
+	return fetchBody(s.Client, req)
+}
+
+// emptyPayloadHash is the hex SHA-256 of an empty request body
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// sign adds AWS Signature Version 4 headers for the s3 service to a GET
+// request. The host, a Range header and all x-amz-* headers are signed.
+func (s S3Opener) sign(req *http.Request, region string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		lower := strings.ToLower(key)
+		if lower == "range" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// GCSOpener opens gs://bucket/object URLs from Google Cloud Storage through
+// its XML API, authorized with an OAuth 2.0 access token if Token is set.
+type GCSOpener struct {
+	Client   *http.Client // Optional, defaults to http.DefaultClient
+	Token    string       // OAuth 2.0 access token, e.g. from gcloud auth print-access-token
+	Endpoint string       // Base URL (default: https://storage.googleapis.com)
+}
+
+// Open fetches the object of a gs:// URL.
+func (g GCSOpener) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	bucket, object, err := splitObjectURL(name, "gs")
+	if err != nil {
+		return nil, err
+	}
+	endpoint := g.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	target := strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + escapeObjectKey(object)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input request: %w", err)
+	}
+	if g.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.Token)
+	}
+	return fetchBody(g.Client, req)
+}
+
+// splitObjectURL splits scheme://bucket/key into bucket and key
+func splitObjectURL(name, scheme string) (bucket, key string, err error) {
+	rest, ok := strings.CutPrefix(name, scheme+"://")
+	if !ok {
+		return "", "", fmt.Errorf("not a %s:// URL: %q", scheme, name)
+	}
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("%s URL %q needs a bucket and an object key", scheme, name)
+	}
+	return bucket, key, nil
+}
+
+// fetchBody sends req and returns the body of a successful response
+func fetchBody(client *http.Client, req *http.Request) (io.ReadCloser, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch input: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		_ = resp.Body.Close()
+		return nil, errors.New("fetching input failed with status " + resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// escapeObjectKey percent-encodes every byte of an object key but unreserved
+// characters and slashes, as AWS Signature Version 4 requires
+func escapeObjectKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' || c == '/' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+// canonicalQuery encodes query parameters sorted by name for signing
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, escapeObjectKey(name)+"="+strings.ReplaceAll(escapeObjectKey(value), "/", "%2F"))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"sort"
+	"time"
+)
+
+// RetiredTemplate is a known template no line of a dataset matches, reported
+// by RetirementReport.
+type RetiredTemplate struct {
+	ID       string     `json:"id"`
+	Template string     `json:"template"`
+	Count    int        `json:"count"`               // Accumulated count of the known template
+	LastSeen *time.Time `json:"last_seen,omitempty"` // Last Parse call the template occurred in, nil if unknown
+	Approved bool       `json:"approved,omitempty"`
+}
+
+// RetirementReport returns the templates learned by previous Parse calls (or
+// loaded with LoadState) that no line of logLines matches, e.g. candidates
+// for deleting alerting rules and dashboards after a release. LastSeen is
+// kept by SaveState, so the report of a loaded state tells how long ago each
+// template last occurred. Templates are ordered by last occurrence, oldest
+// and unknown first. The state of the parser is not changed.
+func (p *BrainParser) RetirementReport(logLines []string) []RetiredTemplate {
+	if p.state == nil {
+		return nil
+	}
+	templates, matcher := p.state.knownMatcher()
+	if matcher == nil {
+		return nil
+	}
+	matched := make([]bool, len(templates))
+	remaining := len(templates)
+	for _, line := range logLines {
+		if i := matcher.matchWords(p.lineWords(line)); i >= 0 && !matched[i] {
+			matched[i] = true
+			if remaining--; remaining == 0 {
+				return nil
+			}
+		}
+	}
+
+	var retired []RetiredTemplate
+	p.state.mu.Lock()
+	for i, template := range templates {
+		if matched[i] {
+			continue
+		}
+		entry := RetiredTemplate{
+			ID:       p.config.TemplateID(template),
+			Template: template,
+			Count:    p.state.counts[template],
+			Approved: p.state.approved[template],
+		}
+		if seen, ok := p.state.seen[template]; ok {
+			entry.LastSeen = &seen
+		}
+		retired = append(retired, entry)
+	}
+	p.state.mu.Unlock()
+
+	sort.SliceStable(retired, func(i, j int) bool {
+		a, b := retired[i].LastSeen, retired[j].LastSeen
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return retired
+}
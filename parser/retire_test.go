@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetirementReport(t *testing.T) {
+	state := `{
+		"version": 1,
+		"config": {"delimiters": "\\s+", "deterministic": true},
+		"templates": [
+			{"template": "User <*> logged in", "count": 10},
+			{"template": "Cache flushed in <*> ms", "count": 4},
+			{"template": "Legacy sync started", "count": 2},
+			{"template": "Queue <*> drained", "count": 1}
+		],
+		"approved": ["Legacy sync started"],
+		"last_seen": {
+			"User <*> logged in": "2026-03-01T10:00:00Z",
+			"Cache flushed in <*> ms": "2026-02-01T10:00:00Z",
+			"Legacy sync started": "2026-01-01T10:00:00Z"
+		}
+	}`
+	parser, err := LoadState(strings.NewReader(state))
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+
+	retired := parser.RetirementReport([]string{"User alice logged in", "User bob logged in"})
+	if len(retired) != 3 {
+		t.Fatalf("Expected 3 retired templates, got %+v", retired)
+	}
+	// Unknown last occurrence first, then oldest first
+	want := []string{"Queue <*> drained", "Legacy sync started", "Cache flushed in <*> ms"}
+	for i, template := range want {
+		if retired[i].Template != template {
+			t.Errorf("Retired %d: expected %q, got %q", i, template, retired[i].Template)
+		}
+	}
+	if retired[0].LastSeen != nil {
+		t.Errorf("Expected no last occurrence for %q, got %v", retired[0].Template, retired[0].LastSeen)
+	}
+	if retired[1].LastSeen == nil || !retired[1].LastSeen.Equal(time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected last occurrence %v", retired[1].LastSeen)
+	}
+	if !retired[1].Approved || retired[1].Count != 2 || retired[1].ID == "" {
+		t.Errorf("Unexpected retired template %+v", retired[1])
+	}
+
+	// The report does not change the state
+	if results := parser.Templates(); len(results) != 4 {
+		t.Errorf("Expected 4 known templates, got %v", results)
+	}
+	if retired := parser.RetirementReport([]string{
+		"User carol logged in", "Cache flushed in 3 ms", "Legacy sync started", "Queue jobs drained",
+	}); len(retired) != 0 {
+		t.Errorf("Expected no retired templates, got %+v", retired)
+	}
+}
+
+func TestStateLastSeen(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`, Deterministic: true})
+	before := time.Now()
+	parser.Parse([]string{"Job alpha done", "Job beta done", "Job gamma done"})
+
+	var buf bytes.Buffer
+	if err := parser.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	restored, err := LoadState(&buf)
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	retired := restored.RetirementReport([]string{"Disk full"})
+	if len(retired) == 0 {
+		t.Fatal("Expected retired templates")
+	}
+	for _, template := range retired {
+		if template.LastSeen == nil || template.LastSeen.Before(before.Truncate(time.Second)) {
+			t.Errorf("Expected last occurrence after %v, got %+v", before, template)
+		}
+	}
+}
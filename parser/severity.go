@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity is the inferred severity class of a template.
+type Severity int
+
+// Severity levels ordered from least to most severe
+const (
+	SeverityUnknown Severity = iota
+	SeverityDebug
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// maxSeveritySamples limits how many member lines are inspected per template
+const maxSeveritySamples = 100
+
+var severityNames = map[Severity]string{
+	SeverityUnknown:  "unknown",
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+// Level tokens as written by common logging frameworks (upper-cased)
+var severityLevelTokens = map[string]Severity{
+	"TRACE":     SeverityDebug,
+	"DEBUG":     SeverityDebug,
+	"DBG":       SeverityDebug,
+	"INFO":      SeverityInfo,
+	"INF":       SeverityInfo,
+	"NOTICE":    SeverityInfo,
+	"WARN":      SeverityWarning,
+	"WARNING":   SeverityWarning,
+	"WRN":       SeverityWarning,
+	"ERROR":     SeverityError,
+	"ERR":       SeverityError,
+	"SEVERE":    SeverityError,
+	"FATAL":     SeverityCritical,
+	"CRITICAL":  SeverityCritical,
+	"CRIT":      SeverityCritical,
+	"PANIC":     SeverityCritical,
+	"EMERG":     SeverityCritical,
+	"EMERGENCY": SeverityCritical,
+	"ALERT":     SeverityCritical,
+}
+
+// Keywords hinting at a severity when no level token is present (lower-cased)
+var severityKeywords = map[string]Severity{
+	"panic":        SeverityCritical,
+	"fatal":        SeverityCritical,
+	"crash":        SeverityCritical,
+	"crashed":      SeverityCritical,
+	"error":        SeverityError,
+	"errors":       SeverityError,
+	"exception":    SeverityError,
+	"fail":         SeverityError,
+	"failed":       SeverityError,
+	"failure":      SeverityError,
+	"timeout":      SeverityError,
+	"refused":      SeverityError,
+	"denied":       SeverityError,
+	"unauthorized": SeverityError,
+	"unreachable":  SeverityError,
+	"warning":      SeverityWarning,
+	"deprecated":   SeverityWarning,
+	"retry":        SeverityWarning,
+	"retrying":     SeverityWarning,
+	"slow":         SeverityWarning,
+}
+
+// String returns the lower-case name of the severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return severityNames[SeverityUnknown]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *Severity) UnmarshalText(text []byte) error {
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// ParseSeverity parses a severity name such as "warning" or "ERROR".
+func ParseSeverity(name string) (Severity, error) {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	for severity, severityName := range severityNames {
+		if lower == severityName {
+			return severity, nil
+		}
+	}
+	if severity, ok := severityLevelTokens[strings.ToUpper(lower)]; ok {
+		return severity, nil
+	}
+	return SeverityUnknown, fmt.Errorf("unknown severity: %q", name)
+}
+
+// InferLineSeverity infers the severity of a single log line. Explicit level
+// tokens (INFO, ERROR, ...) take precedence over keywords (failed, timeout, ...).
+func InferLineSeverity(line string) Severity {
+	levelSeverity := SeverityUnknown
+	keywordSeverity := SeverityUnknown
+
+	for _, token := range strings.FieldsFunc(line, isSeverityDelimiter) {
+		if severity, ok := severityLevelTokens[token]; ok && severity > levelSeverity {
+			levelSeverity = severity
+		}
+		if severity, ok := severityKeywords[strings.ToLower(token)]; ok && severity > keywordSeverity {
+			keywordSeverity = severity
+		}
+	}
+
+	if levelSeverity != SeverityUnknown {
+		return levelSeverity
+	}
+	return keywordSeverity
+}
+
+// isSeverityDelimiter splits lines into plain words for severity inference
+func isSeverityDelimiter(ch rune) bool {
+	return (ch < 'a' || ch > 'z') && (ch < 'A' || ch > 'Z')
+}
+
+// inferSeverities sets the severity of each result to the highest severity
+// observed among a sample of its member lines.
+func inferSeverities(results []*ParseResult, logLines []string) {
+	for _, result := range results {
+		result.Severity = SeverityUnknown
+		for i, logID := range result.LogIDs {
+			if i >= maxSeveritySamples {
+				break
+			}
+			if logID < 0 || logID >= len(logLines) {
+				continue
+			}
+			if severity := InferLineSeverity(logLines[logID]); severity > result.Severity {
+				result.Severity = severity
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package parser
+
+import "testing"
+
+func TestInferLineSeverity(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Severity
+	}{
+		{"2024-01-15 10:30:22 INFO User login successful", SeverityInfo},
+		{"[WARN] Cache miss for key user:123", SeverityWarning},
+		{"level=ERROR msg=\"query failed\"", SeverityError},
+		{"FATAL out of memory", SeverityCritical},
+		{"INFO retry scheduled after failure", SeverityInfo}, // Level token wins over keywords
+		{"Database connection failed: timeout after 30s", SeverityError},
+		{"goroutine panic: nil map", SeverityCritical},
+		{"Using deprecated option", SeverityWarning},
+		{"User login successful", SeverityUnknown},
+		{"Information about errand", SeverityUnknown}, // Substrings are not keywords
+	}
+
+	for _, tt := range tests {
+		if got := InferLineSeverity(tt.line); got != tt.expected {
+			t.Errorf("InferLineSeverity(%q) = %s, want %s", tt.line, got, tt.expected)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for name, expected := range map[string]Severity{
+		"warning": SeverityWarning,
+		"WARN":    SeverityWarning,
+		"error":   SeverityError,
+		"Crit":    SeverityCritical,
+	} {
+		got, err := ParseSeverity(name)
+		if err != nil {
+			t.Errorf("ParseSeverity(%q) failed: %v", name, err)
+		}
+		if got != expected {
+			t.Errorf("ParseSeverity(%q) = %s, want %s", name, got, expected)
+		}
+	}
+
+	if _, err := ParseSeverity("loud"); err == nil {
+		t.Error("Expected error for unknown severity")
+	}
+}
+
+func TestBrain_SeverityInference(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`})
+	results := parser.Parse([]string{
+		"ERROR Database connection failed",
+		"ERROR Database connection failed",
+		"INFO User logged in",
+		"INFO User logged in",
+	})
+
+	severities := make(map[string]Severity)
+	for _, result := range results {
+		severities[result.Template] = result.Severity
+	}
+	if severities["ERROR Database connection failed"] != SeverityError {
+		t.Errorf("Expected error severity, got %s", severities["ERROR Database connection failed"])
+	}
+	if severities["INFO User logged in"] != SeverityInfo {
+		t.Errorf("Expected info severity, got %s", severities["INFO User logged in"])
+	}
+}
@@ -0,0 +1,192 @@
+package parser
+
+import (
+	"crypto/sha1" // #nosec G505 -- used for deterministic rule IDs, not security
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SigmaOptions contains options for exporting templates as Sigma rule skeletons.
+type SigmaOptions struct {
+	TitlePrefix string    // Prefix for generated rule titles (default: "Mined log template")
+	Product     string    // Sigma logsource product (default: "generic")
+	Service     string    // Sigma logsource service (optional)
+	Field       string    // Field matched by regex detections (default: "message")
+	Level       string    // Sigma rule level (default: "medium")
+	MaxCount    int       // Templates with count <= MaxCount are considered rare (default: 0 = rare selection disabled)
+	Keywords    []string  // Keywords selecting error-class templates (default: common error keywords)
+	UseRegex    bool      // Detect with a field regex instead of keyword lists
+	Date        time.Time // Rule date (default: current date)
+
+	// Placeholders are the named placeholders of Config.Placeholders, as
+	// returned by BrainParser.Placeholders, exported like <*>; other tokens
+	// in angle brackets are constants
+	Placeholders []string
+}
+
+// defaultSigmaKeywords select error-class templates for export
+var defaultSigmaKeywords = []string{
+	"error", "fail", "failed", "failure", "exception", "denied",
+	"refused", "timeout", "panic", "fatal", "critical", "unauthorized",
+}
+
+// SelectSigmaTemplates returns templates that are rare or contain error keywords.
+func SelectSigmaTemplates(results []*ParseResult, opts SigmaOptions) []*ParseResult {
+	keywords := opts.Keywords
+	if keywords == nil {
+		keywords = defaultSigmaKeywords
+	}
+
+	slots := newPlaceholderSlots(opts.Placeholders)
+	var selected []*ParseResult
+	for _, result := range results {
+		if opts.MaxCount > 0 && result.Count <= opts.MaxCount {
+			selected = append(selected, result)
+			continue
+		}
+		if templateHasKeyword(result.Template, keywords, slots) {
+			selected = append(selected, result)
+		}
+	}
+	return selected
+}
+
+// ExportSigmaRules writes a Sigma rule skeleton for each given template as a
+// multi-document YAML stream.
+func ExportSigmaRules(w io.Writer, results []*ParseResult, opts SigmaOptions) error {
+	if opts.TitlePrefix == "" {
+		opts.TitlePrefix = "Mined log template"
+	}
+	if opts.Product == "" {
+		opts.Product = "generic"
+	}
+	if opts.Field == "" {
+		opts.Field = "message"
+	}
+	if opts.Level == "" {
+		opts.Level = "medium"
+	}
+	if opts.Date.IsZero() {
+		opts.Date = time.Now()
+	}
+
+	for i, result := range results {
+		sb := GetStringBuilder()
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		writeSigmaRule(sb, result, opts)
+		_, err := io.WriteString(w, sb.String())
+		PutStringBuilder(sb)
+		if err != nil {
+			return fmt.Errorf("failed to write sigma rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeSigmaRule renders one Sigma rule skeleton
+func writeSigmaRule(sb *strings.Builder, result *ParseResult, opts SigmaOptions) {
+	fmt.Fprintf(sb, "title: %s\n", yamlQuote(opts.TitlePrefix+": "+result.Template))
+	fmt.Fprintf(sb, "id: %s\n", templateRuleID(result.Template))
+	sb.WriteString("status: experimental\n")
+	fmt.Fprintf(sb, "description: %s\n", yamlQuote(fmt.Sprintf("Matches log template seen %d times: %s", result.Count, result.Template)))
+	fmt.Fprintf(sb, "date: %s\n", opts.Date.Format("2006/01/02"))
+	sb.WriteString("logsource:\n")
+	fmt.Fprintf(sb, "    product: %s\n", yamlQuote(opts.Product))
+	if opts.Service != "" {
+		fmt.Fprintf(sb, "    service: %s\n", yamlQuote(opts.Service))
+	}
+	sb.WriteString("detection:\n")
+	if opts.UseRegex {
+		sb.WriteString("    selection:\n")
+		fmt.Fprintf(sb, "        %s|re: %s\n", opts.Field, yamlQuote(TemplateToRegex(result.Template, opts.Placeholders...)))
+		sb.WriteString("    condition: selection\n")
+	} else {
+		sb.WriteString("    keywords:\n")
+		sb.WriteString("        '|all':\n")
+		slots := newPlaceholderSlots(opts.Placeholders)
+		for _, token := range strings.Fields(result.Template) {
+			if !slots.isSlot(token) {
+				fmt.Fprintf(sb, "            - %s\n", yamlQuote(token))
+			}
+		}
+		sb.WriteString("    condition: keywords\n")
+	}
+	sb.WriteString("falsepositives:\n    - Unknown\n")
+	fmt.Fprintf(sb, "level: %s\n", opts.Level)
+}
+
+// TemplateToRegex converts a template into a regular expression matching its
+// log lines: constant tokens are quoted, while delimiters and runs of <*> or
+// the given named placeholders between them match any non-empty text. A
+// trailing VariadicPlaceholder also matches no text.
+func TemplateToRegex(template string, placeholders ...string) string {
+	sb := GetStringBuilder()
+	defer PutStringBuilder(sb)
+
+	slots := newPlaceholderSlots(placeholders)
+	pendingGap, variadic := false, false
+	for _, token := range strings.Fields(template) {
+		if token == VariadicPlaceholder {
+			variadic = true
+			continue
+		}
+		if slots.isSlot(token) {
+			pendingGap = true
+			continue
+		}
+		if pendingGap || sb.Len() > 0 {
+			sb.WriteString(`.+?`)
+		}
+		sb.WriteString(regexp.QuoteMeta(token))
+		pendingGap = false
+	}
+	switch {
+	case pendingGap:
+		sb.WriteString(`.+?`)
+	case variadic:
+		sb.WriteString(`.*?`)
+	}
+	return sb.String()
+}
+
+// templateRuleID derives a deterministic UUID (version 5 layout) from a template
+func templateRuleID(template string) string {
+	sum := sha1.Sum([]byte(template)) // #nosec G401 -- not used for security
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// templateHasKeyword checks whether a word of a constant template token
+// equals one of the keywords, ignoring case. Tokens are split into words at
+// characters other than letters and digits, so "error:" and "read_failed"
+// match but "terror" and placeholders of slots do not.
+func templateHasKeyword(template string, keywords []string, slots placeholderSlots) bool {
+	for _, token := range strings.Fields(template) {
+		if slots.isSlot(token) {
+			continue
+		}
+		words := strings.FieldsFunc(token, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			for _, keyword := range keywords {
+				if strings.EqualFold(word, keyword) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelectSigmaTemplates(t *testing.T) {
+	results := []*ParseResult{
+		{Template: "User <*> logged in", Count: 100},
+		{Template: "Database connection failed after <*>", Count: 50},
+		{Template: "Cache warmed up", Count: 2},
+	}
+
+	selected := SelectSigmaTemplates(results, SigmaOptions{MaxCount: 5})
+	if len(selected) != 2 {
+		t.Fatalf("Expected 2 selected templates, got %d", len(selected))
+	}
+	if selected[0].Template != results[1].Template || selected[1].Template != results[2].Template {
+		t.Errorf("Unexpected selection: %q, %q", selected[0].Template, selected[1].Template)
+	}
+}
+
+func TestSelectSigmaTemplates_WholeWords(t *testing.T) {
+	results := []*ParseResult{
+		{Template: "Reading <*> ERROR: disk", Count: 100},
+		{Template: "Job read_failed for <*>", Count: 100},
+		{Template: "Film terror night on <*>", Count: 100},
+		{Template: "Value <*> stored", Count: 100}, // <*> may stand for "error"
+	}
+
+	selected := SelectSigmaTemplates(results, SigmaOptions{})
+	if len(selected) != 2 || selected[0] != results[0] || selected[1] != results[1] {
+		var templates []string
+		for _, result := range selected {
+			templates = append(templates, result.Template)
+		}
+		t.Errorf("Expected only the ERROR and read_failed templates, got %q", templates)
+	}
+}
+
+func TestExportSigmaRules(t *testing.T) {
+	results := []*ParseResult{
+		{Template: "Connection refused by <*> port <*>", Count: 3},
+		{Template: "User's session expired", Count: 1},
+	}
+	opts := SigmaOptions{
+		Service: "app",
+		Date:    time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+	}
+
+	var sb strings.Builder
+	if err := ExportSigmaRules(&sb, results, opts); err != nil {
+		t.Fatalf("ExportSigmaRules failed: %v", err)
+	}
+	out := sb.String()
+
+	for _, want := range []string{
+		"title: 'Mined log template: Connection refused by <*> port <*>'",
+		"date: 2024/01/15",
+		"    service: 'app'",
+		"            - 'refused'",
+		"            - 'User''s'",
+		"    condition: keywords",
+		"---\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "- '<*>'") {
+		t.Error("Wildcards must not be exported as keywords")
+	}
+
+	// Rule IDs are deterministic
+	var again strings.Builder
+	if err := ExportSigmaRules(&again, results, opts); err != nil {
+		t.Fatalf("ExportSigmaRules failed: %v", err)
+	}
+	if again.String() != out {
+		t.Error("Expected identical output for identical input")
+	}
+}
+
+func TestTemplateToRegex(t *testing.T) {
+	re := regexp.MustCompile(TemplateToRegex("Connection refused by <*> port <*>"))
+	if !re.MatchString("Connection refused by 10.0.0.1 port 443") {
+		t.Error("Expected regex to match original log line")
+	}
+	if re.MatchString("Connection accepted by 10.0.0.1 port 443") {
+		t.Error("Expected regex not to match different log line")
+	}
+
+	if got := TemplateToRegex("Unexpected <EOF> in <ORDER>", "<ORDER>"); got != `Unexpected.+?<EOF>.+?in.+?` {
+		t.Errorf("Expected only the named placeholder as a slot, got %q", got)
+	}
+}
@@ -5,52 +5,36 @@ import (
 	"strings"
 )
 
-// SIMDCapabilities detects available SIMD capabilities on the current platform
+// SIMDCapabilities lists the SIMD features the kernels of the current
+// platform can use. Only amd64 has kernels, so other platforms report none.
 type SIMDCapabilities struct {
 	HasAVX2  bool // Intel/AMD AVX2
 	HasSSE42 bool // Intel/AMD SSE4.2
-	HasNEON  bool // ARM NEON
-	HasSVE   bool // ARM SVE
 	Platform string
 }
 
-// DetectSIMDCapabilities detects available SIMD features on the current platform
+// DetectSIMDCapabilities detects available SIMD features on the current
+// platform with CPUID and the OS-enabled register state on amd64.
 func DetectSIMDCapabilities() SIMDCapabilities {
 	caps := SIMDCapabilities{
 		Platform: runtime.GOARCH,
 	}
-
-	// Note: In a real implementation, we would use platform-specific detection
-	// For now, we'll use conservative detection based on GOARCH
-	switch runtime.GOARCH {
-	case "amd64":
-		// Most modern x86-64 processors support SSE4.2
-		caps.HasSSE42 = true
-		// AVX2 requires more careful detection, assume false for safety
-		caps.HasAVX2 = false
-	case "arm64":
-		// Most ARM64 processors support NEON
-		caps.HasNEON = true
-		// SVE is newer, assume false for safety
-		caps.HasSVE = false
-	}
-
+	detectCPU(&caps)
 	return caps
 }
 
-// SIMDPatternMatcher provides cross-platform SIMD-optimized pattern matching
+// SIMDPatternMatcher matches patterns and searches strings with portable Go
+// code; there are no SIMD kernels for string search
 type SIMDPatternMatcher struct {
-	capabilities SIMDCapabilities
-	patterns     []string
-	fallback     *StandardPatternMatcher
+	patterns []string
+	fallback *StandardPatternMatcher
 }
 
-// NewSIMDPatternMatcher creates a new SIMD-optimized pattern matcher
+// NewSIMDPatternMatcher creates a new pattern matcher
 func NewSIMDPatternMatcher(patterns []string) *SIMDPatternMatcher {
 	return &SIMDPatternMatcher{
-		capabilities: DetectSIMDCapabilities(),
-		patterns:     patterns,
-		fallback:     NewStandardPatternMatcher(patterns),
+		patterns: patterns,
+		fallback: NewStandardPatternMatcher(patterns),
 	}
 }
 
@@ -69,34 +53,6 @@ func (spm *SIMDPatternMatcher) FastStringSearch(haystack, needle string) int {
 	if len(haystack) == 0 {
 		return -1
 	}
-
-	// Use platform-optimized search where available
-	switch {
-	case spm.capabilities.HasAVX2:
-		return spm.searchAVX2(haystack, needle)
-	case spm.capabilities.HasSSE42:
-		return spm.searchSSE42(haystack, needle)
-	case spm.capabilities.HasNEON:
-		return spm.searchNEON(haystack, needle)
-	default:
-		return strings.Index(haystack, needle)
-	}
-}
-
-// Platform-specific implementations (placeholders for now)
-func (spm *SIMDPatternMatcher) searchAVX2(haystack, needle string) int {
-	// Real implementation would use AVX2 instructions
-	// For now, use optimized Go fallback
-	return spm.optimizedSearch(haystack, needle)
-}
-
-func (spm *SIMDPatternMatcher) searchSSE42(haystack, needle string) int {
-	// Real implementation would use SSE4.2 instructions
-	return spm.optimizedSearch(haystack, needle)
-}
-
-func (spm *SIMDPatternMatcher) searchNEON(haystack, needle string) int {
-	// Real implementation would use ARM NEON instructions
 	return spm.optimizedSearch(haystack, needle)
 }
 
@@ -177,7 +133,9 @@ func (spm *StandardPatternMatcher) MatchPatterns(text string) []int {
 	return matches
 }
 
-// SIMDWordCounter provides SIMD-optimized word counting
+// SIMDWordCounter provides SIMD-optimized word counting. Assembly kernels
+// exist for AVX2 and SSE4.2 on amd64 only; other platforms, including arm64,
+// use the portable Go implementation.
 type SIMDWordCounter struct {
 	capabilities SIMDCapabilities
 }
@@ -201,27 +159,59 @@ func (swc *SIMDWordCounter) CountWords(text string) int {
 		return swc.countWordsAVX2(text)
 	case swc.capabilities.HasSSE42:
 		return swc.countWordsSSE42(text)
-	case swc.capabilities.HasNEON:
-		return swc.countWordsNEON(text)
 	default:
 		return swc.countWordsStandard(text)
 	}
 }
 
-// Platform-specific word counting implementations
-func (swc *SIMDWordCounter) countWordsAVX2(text string) int {
-	// Real implementation would use AVX2 for parallel character processing
-	return swc.countWordsOptimized(text)
+// hasKernels reports whether CountWords and IndexDelimiter run assembly
+// kernels rather than Go loops
+func (swc *SIMDWordCounter) hasKernels() bool {
+	return swc.capabilities.HasAVX2 || swc.capabilities.HasSSE42
+}
+
+// IndexDelimiter returns the index of the first space, tab, newline or
+// carriage return in text, the delimiters of CountWords, or -1 if there is
+// none.
+func (swc *SIMDWordCounter) IndexDelimiter(text string) int {
+	switch {
+	case swc.capabilities.HasAVX2:
+		return swc.indexDelimiterAVX2(text)
+	case swc.capabilities.HasSSE42:
+		return swc.indexDelimiterSSE42(text)
+	default:
+		return indexDelimiterStandard(text, 0)
+	}
+}
+
+// indexDelimiterStandard returns the index of the first delimiter of text
+// at or after from, or -1
+func indexDelimiterStandard(text string, from int) int {
+	for i := from; i < len(text); i++ {
+		if isWordDelimiter(text[i]) {
+			return i
+		}
+	}
+	return -1
 }
 
-func (swc *SIMDWordCounter) countWordsSSE42(text string) int {
-	// Real implementation would use SSE4.2
-	return swc.countWordsOptimized(text)
+// isWordDelimiter reports whether c separates words for CountWords
+func isWordDelimiter(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
 }
 
-func (swc *SIMDWordCounter) countWordsNEON(text string) int {
-	// Real implementation would use ARM NEON
-	return swc.countWordsOptimized(text)
+// countWordsTail counts the words of text[from:] like countWordsOptimized,
+// continuing a word if the byte before from was no delimiter
+func countWordsTail(text string, from int, prevSpace bool) int {
+	count := 0
+	for i := from; i < len(text); i++ {
+		isSpace := isWordDelimiter(text[i])
+		if !isSpace && prevSpace {
+			count++
+		}
+		prevSpace = isSpace
+	}
+	return count
 }
 
 // countWordsOptimized provides an optimized Go implementation
@@ -357,9 +347,19 @@ func (sb *SIMDBenchmark) GetOptimizationInfo() map[string]any {
 		"platform":     sb.capabilities.Platform,
 		"has_avx2":     sb.capabilities.HasAVX2,
 		"has_sse42":    sb.capabilities.HasSSE42,
-		"has_neon":     sb.capabilities.HasNEON,
-		"has_sve":      sb.capabilities.HasSVE,
 		"num_cpu":      runtime.NumCPU(),
-		"optimization": "fallback_optimized", // Would be "simd" if real SIMD was available
+		"optimization": sb.optimization(),
+	}
+}
+
+// optimization names the implementation CountWords and IndexDelimiter use
+func (sb *SIMDBenchmark) optimization() string {
+	switch {
+	case sb.capabilities.HasAVX2:
+		return "simd_avx2"
+	case sb.capabilities.HasSSE42:
+		return "simd_sse42"
+	default:
+		return "fallback_optimized"
 	}
 }
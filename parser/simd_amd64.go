@@ -0,0 +1,76 @@
+package parser
+
+// Assembly kernels of simd_amd64.s. The word counting kernels process
+// len(s) rounded down to their vector width and report whether the last
+// processed byte was a delimiter; the index kernels return -1 if the
+// processed bytes contain no delimiter.
+
+//go:noescape
+func countWordStartsAVX2(s string, prevSpace bool) (count int, lastSpace bool)
+
+//go:noescape
+func countWordStartsSSE42(s string, prevSpace bool) (count int, lastSpace bool)
+
+//go:noescape
+func indexDelimiterAVX2(s string) int
+
+//go:noescape
+func indexDelimiterSSE42(s string) int
+
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+func xgetbv() (eax, edx uint32)
+
+// detectCPU sets the SIMD features of the CPU that the OS supports. Both
+// kernels need POPCNT, AVX2 also needs the OS to save the YMM registers.
+func detectCPU(caps *SIMDCapabilities) {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 1 {
+		return
+	}
+	_, _, ecx1, _ := cpuid(1, 0)
+	popcnt := ecx1&(1<<23) != 0
+	caps.HasSSE42 = ecx1&(1<<20) != 0 && popcnt
+
+	osxsave, avx := ecx1&(1<<27) != 0, ecx1&(1<<28) != 0
+	if maxID < 7 || !osxsave || !avx || !popcnt {
+		return
+	}
+	if xcr0, _ := xgetbv(); xcr0&0x6 != 0x6 { // XMM and YMM state
+		return
+	}
+	_, ebx7, _, _ := cpuid(7, 0)
+	caps.HasAVX2 = ebx7&(1<<5) != 0
+}
+
+// countWordsAVX2 counts words 32 bytes at a time
+func (swc *SIMDWordCounter) countWordsAVX2(text string) int {
+	n := len(text) &^ 31
+	count, lastSpace := countWordStartsAVX2(text[:n], true)
+	return count + countWordsTail(text, n, lastSpace)
+}
+
+// countWordsSSE42 counts words 16 bytes at a time
+func (swc *SIMDWordCounter) countWordsSSE42(text string) int {
+	n := len(text) &^ 15
+	count, lastSpace := countWordStartsSSE42(text[:n], true)
+	return count + countWordsTail(text, n, lastSpace)
+}
+
+// indexDelimiterAVX2 scans for a delimiter 32 bytes at a time
+func (swc *SIMDWordCounter) indexDelimiterAVX2(text string) int {
+	n := len(text) &^ 31
+	if i := indexDelimiterAVX2(text[:n]); i >= 0 {
+		return i
+	}
+	return indexDelimiterStandard(text, n)
+}
+
+// indexDelimiterSSE42 scans for a delimiter 16 bytes at a time
+func (swc *SIMDWordCounter) indexDelimiterSSE42(text string) int {
+	n := len(text) &^ 15
+	if i := indexDelimiterSSE42(text[:n]); i >= 0 {
+		return i
+	}
+	return indexDelimiterStandard(text, n)
+}
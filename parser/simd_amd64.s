@@ -0,0 +1,218 @@
+#include "textflag.h"
+
+// Delimiters of CountWords: space, tab, newline and carriage return
+#define DELIMITERS $0x0d0a0920
+
+// func countWordStartsAVX2(s string, prevSpace bool) (count int, lastSpace bool)
+TEXT ·countWordStartsAVX2(SB), NOSPLIT, $0-33
+	MOVQ    s_base+0(FP), SI
+	MOVQ    s_len+8(FP), CX
+	MOVBQZX prevSpace+16(FP), DX // 1 if the byte before the chunk is a delimiter
+	XORQ    AX, AX
+
+	MOVQ         $0x20, R8
+	MOVQ         R8, X1
+	VPBROADCASTB X1, Y1
+	MOVQ         $0x09, R8
+	MOVQ         R8, X2
+	VPBROADCASTB X2, Y2
+	MOVQ         $0x0a, R8
+	MOVQ         R8, X3
+	VPBROADCASTB X3, Y3
+	MOVQ         $0x0d, R8
+	MOVQ         R8, X4
+	VPBROADCASTB X4, Y4
+
+avx2Loop:
+	CMPQ      CX, $32
+	JB        avx2Done
+	VMOVDQU   (SI), Y0
+	VPCMPEQB  Y0, Y1, Y5
+	VPCMPEQB  Y0, Y2, Y6
+	VPOR      Y5, Y6, Y5
+	VPCMPEQB  Y0, Y3, Y6
+	VPOR      Y5, Y6, Y5
+	VPCMPEQB  Y0, Y4, Y6
+	VPOR      Y5, Y6, Y5
+	VPMOVMSKB Y5, R9 // Delimiter bytes
+
+	// Word starts are no delimiter after a delimiter
+	MOVQ    R9, R10
+	SHLQ    $1, R10
+	ORQ     DX, R10
+	MOVL    R9, R11
+	NOTL    R11
+	ANDQ    R10, R11
+	POPCNTQ R11, R11
+	ADDQ    R11, AX
+
+	MOVL R9, DX
+	SHRL $31, DX
+	ADDQ $32, SI
+	SUBQ $32, CX
+	JMP  avx2Loop
+
+avx2Done:
+	VZEROUPPER
+	MOVQ AX, count+24(FP)
+	MOVB DX, lastSpace+32(FP)
+	RET
+
+// func countWordStartsSSE42(s string, prevSpace bool) (count int, lastSpace bool)
+TEXT ·countWordStartsSSE42(SB), NOSPLIT, $0-33
+	MOVQ    s_base+0(FP), SI
+	MOVQ    s_len+8(FP), CX
+	MOVBQZX prevSpace+16(FP), DX // 1 if the byte before the chunk is a delimiter
+	XORQ    AX, AX
+
+	PXOR   X7, X7
+	MOVQ   $0x20, R8
+	MOVQ   R8, X1
+	PSHUFB X7, X1
+	MOVQ   $0x09, R8
+	MOVQ   R8, X2
+	PSHUFB X7, X2
+	MOVQ   $0x0a, R8
+	MOVQ   R8, X3
+	PSHUFB X7, X3
+	MOVQ   $0x0d, R8
+	MOVQ   R8, X4
+	PSHUFB X7, X4
+
+sseLoop:
+	CMPQ     CX, $16
+	JB       sseDone
+	MOVOU    (SI), X0
+	MOVOU    X0, X5
+	PCMPEQB  X1, X5
+	MOVOU    X0, X6
+	PCMPEQB  X2, X6
+	POR      X6, X5
+	MOVOU    X0, X6
+	PCMPEQB  X3, X6
+	POR      X6, X5
+	MOVOU    X0, X6
+	PCMPEQB  X4, X6
+	POR      X6, X5
+	PMOVMSKB X5, R9 // Delimiter bytes
+
+	// Word starts are no delimiter after a delimiter
+	MOVQ    R9, R10
+	SHLQ    $1, R10
+	ORQ     DX, R10
+	MOVL    R9, R11
+	XORL    $0xffff, R11
+	ANDQ    R10, R11
+	POPCNTQ R11, R11
+	ADDQ    R11, AX
+
+	MOVL R9, DX
+	SHRL $15, DX
+	ADDQ $16, SI
+	SUBQ $16, CX
+	JMP  sseLoop
+
+sseDone:
+	MOVQ AX, count+24(FP)
+	MOVB DX, lastSpace+32(FP)
+	RET
+
+// func indexDelimiterAVX2(s string) int
+TEXT ·indexDelimiterAVX2(SB), NOSPLIT, $0-24
+	MOVQ s_base+0(FP), SI
+	MOVQ s_len+8(FP), BX
+	XORQ DI, DI
+
+	MOVQ         $0x20, R8
+	MOVQ         R8, X1
+	VPBROADCASTB X1, Y1
+	MOVQ         $0x09, R8
+	MOVQ         R8, X2
+	VPBROADCASTB X2, Y2
+	MOVQ         $0x0a, R8
+	MOVQ         R8, X3
+	VPBROADCASTB X3, Y3
+	MOVQ         $0x0d, R8
+	MOVQ         R8, X4
+	VPBROADCASTB X4, Y4
+
+avx2IndexLoop:
+	LEAQ      32(DI), R8
+	CMPQ      R8, BX
+	JA        avx2NotFound
+	VMOVDQU   (SI)(DI*1), Y0
+	VPCMPEQB  Y0, Y1, Y5
+	VPCMPEQB  Y0, Y2, Y6
+	VPOR      Y5, Y6, Y5
+	VPCMPEQB  Y0, Y3, Y6
+	VPOR      Y5, Y6, Y5
+	VPCMPEQB  Y0, Y4, Y6
+	VPOR      Y5, Y6, Y5
+	VPMOVMSKB Y5, R9
+	TESTL     R9, R9
+	JNZ       avx2Found
+	MOVQ      R8, DI
+	JMP       avx2IndexLoop
+
+avx2Found:
+	VZEROUPPER
+	BSFL R9, R9
+	ADDQ R9, DI
+	MOVQ DI, ret+16(FP)
+	RET
+
+avx2NotFound:
+	VZEROUPPER
+	MOVQ $-1, ret+16(FP)
+	RET
+
+// func indexDelimiterSSE42(s string) int
+TEXT ·indexDelimiterSSE42(SB), NOSPLIT, $0-24
+	MOVQ s_base+0(FP), SI
+	MOVQ s_len+8(FP), BX
+	XORQ DI, DI
+	MOVQ DELIMITERS, R8
+	MOVQ R8, X1
+
+sseIndexLoop:
+	LEAQ  16(DI), R8
+	CMPQ  R8, BX
+	JA    sseNotFound
+	MOVOU (SI)(DI*1), X0
+
+	// Unsigned bytes, equal any of the 4 delimiters in 16 explicit bytes,
+	// least significant index
+	MOVL      $4, AX
+	MOVL      $16, DX
+	PCMPESTRI $0x00, X0, X1
+	JCS       sseFound
+	MOVQ      R8, DI
+	JMP       sseIndexLoop
+
+sseFound:
+	ADDQ CX, DI
+	MOVQ DI, ret+16(FP)
+	RET
+
+sseNotFound:
+	MOVQ $-1, ret+16(FP)
+	RET
+
+// func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+TEXT ·cpuid(SB), NOSPLIT, $0-24
+	MOVL eaxArg+0(FP), AX
+	MOVL ecxArg+4(FP), CX
+	CPUID
+	MOVL AX, eax+8(FP)
+	MOVL BX, ebx+12(FP)
+	MOVL CX, ecx+16(FP)
+	MOVL DX, edx+20(FP)
+	RET
+
+// func xgetbv() (eax, edx uint32)
+TEXT ·xgetbv(SB), NOSPLIT, $0-8
+	MOVL $0, CX
+	XGETBV
+	MOVL AX, eax+0(FP)
+	MOVL DX, edx+4(FP)
+	RET
@@ -0,0 +1,26 @@
+//go:build !amd64
+
+package parser
+
+// detectCPU reports no features, as there are kernels for amd64 only
+func detectCPU(caps *SIMDCapabilities) {}
+
+// countWordsAVX2 is never selected without the amd64 kernels
+func (swc *SIMDWordCounter) countWordsAVX2(text string) int {
+	return swc.countWordsOptimized(text)
+}
+
+// countWordsSSE42 is never selected without the amd64 kernels
+func (swc *SIMDWordCounter) countWordsSSE42(text string) int {
+	return swc.countWordsOptimized(text)
+}
+
+// indexDelimiterAVX2 is never selected without the amd64 kernels
+func (swc *SIMDWordCounter) indexDelimiterAVX2(text string) int {
+	return indexDelimiterStandard(text, 0)
+}
+
+// indexDelimiterSSE42 is never selected without the amd64 kernels
+func (swc *SIMDWordCounter) indexDelimiterSSE42(text string) int {
+	return indexDelimiterStandard(text, 0)
+}
@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// simdTestTexts returns random texts of all lengths up to 300 bytes, dense in
+// delimiters and other bytes, plus edge cases
+func simdTestTexts() []string {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []byte(" \t\n\r\x00\x0bab:=\xff")
+	texts := []string{"", " ", "a", strings.Repeat(" ", 64), strings.Repeat("x", 64), strings.Repeat("ab ", 40)}
+	for n := 0; n <= 300; n++ {
+		for k := 0; k < 3; k++ {
+			text := make([]byte, n)
+			for i := range text {
+				text[i] = alphabet[rng.Intn(len(alphabet))]
+			}
+			texts = append(texts, string(text))
+		}
+	}
+	return texts
+}
+
+func TestSIMDCountWordsMatchesFallback(t *testing.T) {
+	caps := DetectSIMDCapabilities()
+	counter := &SIMDWordCounter{capabilities: caps}
+	for _, path := range []struct {
+		name      string
+		supported bool
+		count     func(string) int
+	}{
+		{"avx2", caps.HasAVX2, counter.countWordsAVX2},
+		{"sse42", caps.HasSSE42, counter.countWordsSSE42},
+	} {
+		t.Run(path.name, func(t *testing.T) {
+			if !path.supported {
+				t.Skipf("CPU without %s", path.name)
+			}
+			for _, text := range simdTestTexts() {
+				if got, want := path.count(text), counter.countWordsOptimized(text); got != want {
+					t.Fatalf("count of %q = %d, want %d", text, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSIMDIndexDelimiterMatchesFallback(t *testing.T) {
+	caps := DetectSIMDCapabilities()
+	counter := &SIMDWordCounter{capabilities: caps}
+	for _, path := range []struct {
+		name      string
+		supported bool
+		index     func(string) int
+	}{
+		{"avx2", caps.HasAVX2, counter.indexDelimiterAVX2},
+		{"sse42", caps.HasSSE42, counter.indexDelimiterSSE42},
+	} {
+		t.Run(path.name, func(t *testing.T) {
+			if !path.supported {
+				t.Skipf("CPU without %s", path.name)
+			}
+			for _, text := range simdTestTexts() {
+				if got, want := path.index(text), indexDelimiterStandard(text, 0); got != want {
+					t.Fatalf("index in %q = %d, want %d", text, got, want)
+				}
+			}
+			// A delimiter only after the last full vector
+			text := strings.Repeat("x", 70) + "\r"
+			if got := path.index(text); got != 70 {
+				t.Errorf("Expected index 70, got %d", got)
+			}
+		})
+	}
+}
+
+func TestSIMDIndexDelimiter(t *testing.T) {
+	counter := NewSIMDWordCounter()
+	for text, want := range map[string]int{
+		"":                            -1,
+		"no-delimiters-here":          -1,
+		"key=value\tnext":             9,
+		strings.Repeat("a", 40) + " ": 40,
+	} {
+		if got := counter.IndexDelimiter(text); got != want {
+			t.Errorf("IndexDelimiter(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func BenchmarkSIMDCountWords(b *testing.B) {
+	text := strings.Repeat("2024-01-15 10:30:45 INFO User alice logged in from 192.168.1.10\n", 16)
+	counter := NewSIMDWordCounter()
+	b.SetBytes(int64(len(text)))
+	for i := 0; i < b.N; i++ {
+		counter.CountWords(text)
+	}
+}
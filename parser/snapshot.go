@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SnapshotSource provides the current aggregated template state.
+// StreamingProcessor implements this interface.
+type SnapshotSource interface {
+	Snapshot() []*ParseResult
+}
+
+// SnapshotTarget stores a serialized snapshot, replacing the previous one atomically.
+type SnapshotTarget interface {
+	WriteSnapshot(ctx context.Context, data []byte) error
+}
+
+// Snapshot is the serialized form of the aggregated template state.
+type Snapshot struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	TotalLogs   int                `json:"total_logs"`
+	Templates   []SnapshotTemplate `json:"templates"`
+}
+
+// SnapshotTemplate is one template entry of a Snapshot.
+type SnapshotTemplate struct {
+	ID       string `json:"id,omitempty"`
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+}
+
+// SnapshotWriter periodically writes the state of a SnapshotSource to a
+// SnapshotTarget. Writes are throttled to the configured interval and skipped
+// when the state did not change since the last successful write.
+type SnapshotWriter struct {
+	source   SnapshotSource
+	target   SnapshotTarget
+	interval time.Duration
+
+	mu        sync.Mutex
+	lastTotal int
+	lastCount int
+	written   bool
+}
+
+// NewSnapshotWriter creates a snapshot writer (default interval: 30s).
+func NewSnapshotWriter(source SnapshotSource, target SnapshotTarget, interval time.Duration) *SnapshotWriter {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &SnapshotWriter{
+		source:   source,
+		target:   target,
+		interval: interval,
+	}
+}
+
+// Run writes snapshots every interval until ctx is canceled, then writes a
+// final snapshot so the target reflects the latest state.
+func (sw *SnapshotWriter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(sw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Final write must not be canceled together with the run context
+			return sw.WriteNow(context.WithoutCancel(ctx))
+		case <-ticker.C:
+			if err := sw.WriteNow(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// WriteNow writes the current state immediately if it changed since the last write.
+func (sw *SnapshotWriter) WriteNow(ctx context.Context) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	results := sw.source.Snapshot()
+	snapshot := Snapshot{
+		GeneratedAt: time.Now().UTC(),
+		Templates:   make([]SnapshotTemplate, 0, len(results)),
+	}
+	for _, result := range results {
+		snapshot.TotalLogs += result.Count
+		snapshot.Templates = append(snapshot.Templates, SnapshotTemplate{
+			ID:       result.ID,
+			Template: result.Template,
+			Count:    result.Count,
+		})
+	}
+
+	// Throttle: nothing new to publish
+	if sw.written && snapshot.TotalLogs == sw.lastTotal && len(snapshot.Templates) == sw.lastCount {
+		return nil
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := sw.target.WriteSnapshot(ctx, data); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	sw.written = true
+	sw.lastTotal = snapshot.TotalLogs
+	sw.lastCount = len(snapshot.Templates)
+	return nil
+}
+
+// FileSnapshotTarget writes snapshots to a local file using write-then-rename
+// so readers never observe a partially written snapshot.
+type FileSnapshotTarget struct {
+	Path string
+}
+
+// WriteSnapshot atomically replaces the target file with data.
+func (ft FileSnapshotTarget) WriteSnapshot(_ context.Context, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(ft.Path), "."+filepath.Base(ft.Path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary snapshot file: %w", err)
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to write temporary snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to close temporary snapshot file: %w", err)
+	}
+	if err := os.Rename(tmpName, ft.Path); err != nil {
+		_ = os.Remove(tmpName)
+		return fmt.Errorf("failed to replace snapshot file: %w", err)
+	}
+	return nil
+}
+
+// HTTPSnapshotTarget uploads snapshots with an HTTP PUT request. Object stores
+// such as S3 or GCS can be targeted with a pre-signed URL, where a PUT replaces
+// the object atomically.
+type HTTPSnapshotTarget struct {
+	URL    string
+	Client *http.Client // Optional, defaults to http.DefaultClient
+}
+
+// WriteSnapshot uploads data to the target URL.
+func (ht HTTPSnapshotTarget) WriteSnapshot(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, ht.URL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := ht.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New("snapshot upload failed with status " + resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type staticSnapshotSource struct {
+	results []*ParseResult
+}
+
+func (s *staticSnapshotSource) Snapshot() []*ParseResult {
+	return s.results
+}
+
+func TestSnapshotWriter_FileTarget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	source := &staticSnapshotSource{results: []*ParseResult{
+		{Template: "User <*> logged in", Count: 3},
+		{Template: "System started", Count: 1},
+	}}
+
+	writer := NewSnapshotWriter(source, FileSnapshotTarget{Path: path}, 0)
+	ctx := context.Background()
+	if err := writer.WriteNow(ctx); err != nil {
+		t.Fatalf("WriteNow failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304
+	if err != nil {
+		t.Fatalf("Failed to read snapshot: %v", err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("Invalid snapshot JSON: %v", err)
+	}
+	if snapshot.TotalLogs != 4 || len(snapshot.Templates) != 2 {
+		t.Errorf("Expected 4 logs in 2 templates, got %d logs in %d templates", snapshot.TotalLogs, len(snapshot.Templates))
+	}
+
+	// Unchanged state is not rewritten
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove snapshot: %v", err)
+	}
+	if err := writer.WriteNow(ctx); err != nil {
+		t.Fatalf("WriteNow failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected unchanged snapshot to be skipped")
+	}
+
+	// No temporary files are left behind
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Failed to read dir: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no leftover files, got %d", len(entries))
+	}
+}
+
+func TestSnapshotWriter_HTTPTarget(t *testing.T) {
+	var received Snapshot
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("Expected PUT request, got %s", r.Method)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &received); err != nil {
+			t.Errorf("Invalid snapshot JSON: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	source := &staticSnapshotSource{results: []*ParseResult{{Template: "a <*>", Count: 2}}}
+	writer := NewSnapshotWriter(source, HTTPSnapshotTarget{URL: server.URL}, 0)
+	if err := writer.WriteNow(context.Background()); err != nil {
+		t.Fatalf("WriteNow failed: %v", err)
+	}
+	if received.TotalLogs != 2 {
+		t.Errorf("Expected 2 logs in uploaded snapshot, got %d", received.TotalLogs)
+	}
+}
+
+func TestStreamingProcessor_Snapshot(t *testing.T) {
+	processor := NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{BatchSize: 2, MaxWorkers: 2})
+
+	logs := []string{
+		"User alice logged in",
+		"User bob logged in",
+		"User carol logged in",
+		"System started",
+	}
+	if _, err := processor.ProcessLargeSlice(context.Background(), logs); err != nil {
+		t.Fatalf("ProcessLargeSlice failed: %v", err)
+	}
+
+	total := 0
+	for _, result := range processor.Snapshot() {
+		total += result.Count
+	}
+	if total != len(logs) {
+		t.Errorf("Expected snapshot to cover %d logs, got %d", len(logs), total)
+	}
+}
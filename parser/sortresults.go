@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Result orders of SortResults
+const (
+	SortByCount     = "count"      // Most lines first
+	SortByTemplate  = "template"   // Template text in lexical order
+	SortByFirstSeen = "first-seen" // Earliest member line (lowest LogID) first
+	SortByCoverage  = "coverage"   // Most tokens covered (lines × template tokens) first
+)
+
+// SortResults sorts results in place by one of the SortBy* orders. Ties are
+// broken by template, so the order is the same for equal results of
+// different runs, which keeps outputs diffable. Results without LogIDs sort
+// last by first-seen. It returns an error for an unknown order.
+func SortResults(results []*ParseResult, by string) error {
+	var compare func(a, b *ParseResult) int
+	switch by {
+	case SortByCount:
+		compare = func(a, b *ParseResult) int { return b.Count - a.Count }
+	case SortByTemplate:
+		compare = func(_, _ *ParseResult) int { return 0 }
+	case SortByFirstSeen:
+		first := make(map[*ParseResult]int, len(results))
+		for _, result := range results {
+			first[result] = -1
+			if len(result.LogIDs) > 0 {
+				first[result] = slices.Min(result.LogIDs)
+			}
+		}
+		compare = func(a, b *ParseResult) int {
+			switch {
+			case first[a] == first[b]:
+				return 0
+			case first[a] < 0:
+				return 1
+			case first[b] < 0:
+				return -1
+			}
+			return first[a] - first[b]
+		}
+	case SortByCoverage:
+		tokens := make(map[*ParseResult]int, len(results))
+		for _, result := range results {
+			tokens[result] = result.Count * len(strings.Fields(result.Template))
+		}
+		compare = func(a, b *ParseResult) int { return tokens[b] - tokens[a] }
+	default:
+		return fmt.Errorf("unknown result order %q: must be %s, %s, %s or %s",
+			by, SortByCount, SortByTemplate, SortByFirstSeen, SortByCoverage)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if c := compare(results[i], results[j]); c != 0 {
+			return c < 0
+		}
+		return results[i].Template < results[j].Template
+	})
+	return nil
+}
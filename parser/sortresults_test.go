@@ -0,0 +1,47 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSortResults(t *testing.T) {
+	newResults := func() []*ParseResult {
+		return []*ParseResult{
+			{Template: "b <*>", Count: 5, LogIDs: []int{3, 1}},
+			{Template: "a", Count: 5, LogIDs: []int{4}},
+			{Template: "c <*> <*> <*>", Count: 2, LogIDs: []int{0}},
+			{Template: "d", Count: 1},
+		}
+	}
+	templates := func(results []*ParseResult) []string {
+		var out []string
+		for _, result := range results {
+			out = append(out, result.Template)
+		}
+		return out
+	}
+
+	tests := []struct {
+		by       string
+		expected []string
+	}{
+		{SortByCount, []string{"a", "b <*>", "c <*> <*> <*>", "d"}},
+		{SortByTemplate, []string{"a", "b <*>", "c <*> <*> <*>", "d"}},
+		{SortByFirstSeen, []string{"c <*> <*> <*>", "b <*>", "a", "d"}},
+		{SortByCoverage, []string{"b <*>", "c <*> <*> <*>", "a", "d"}},
+	}
+	for _, test := range tests {
+		results := newResults()
+		if err := SortResults(results, test.by); err != nil {
+			t.Fatal(err)
+		}
+		if got := templates(results); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.by, test.expected, got)
+		}
+	}
+
+	if err := SortResults(newResults(), "size"); err == nil {
+		t.Error("Expected an error for an unknown order")
+	}
+}
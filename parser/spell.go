@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// SpellStateVersion is the schema version written by SpellParser.SaveState.
+const SpellStateVersion = 1
+
+// defaultSpellSimilarity is the default of SpellConfig.SimilarityThreshold,
+// half of the tokens as recommended by the Spell paper
+const defaultSpellSimilarity = 0.5
+
+// SpellConfig configures a SpellParser.
+type SpellConfig struct {
+	Delimiters          string              `json:"delimiters"`           // Regex for splitting tokens (default: [\s,:=] as for Brain)
+	CommonVariables     map[string]string   `json:"common_variables"`     // Patterns of variables masked as <*> before parsing (default: DefaultCommonVariables)
+	SimilarityThreshold float64             `json:"similarity_threshold"` // Minimum length of the common subsequence as a share of the line's tokens to join a template (default: 0.5)
+	TemplateID          func(string) string `json:"-"`                    // Template identifier (default: HashTemplateID), not serialized
+}
+
+// SpellParser parses logs with Spell (Du and Li, "Spell: Streaming Parsing
+// of System Event Logs", ICDM 2016) behind the same API as BrainParser. A
+// line joins the template sharing the longest common subsequence (LCS) of
+// tokens with it, if that covers SimilarityThreshold of the line; the tokens
+// outside the subsequence become <*>. As templates are not bound to a line
+// length, a <*> stands for any number of tokens, which suits logs with
+// variable-length messages. Spell learns online: every Parse call continues
+// with the templates of previous calls. It is safe for concurrent use.
+type SpellParser struct {
+	config       SpellConfig
+	preprocessor *Preprocessor
+
+	mu       sync.Mutex
+	clusters []*tokenCluster // In order of creation
+}
+
+// spellSavedState is the JSON form of a SpellParser
+type spellSavedState struct {
+	Version   int                  `json:"version"`
+	Algorithm string               `json:"algorithm"`
+	Config    SpellConfig          `json:"config"`
+	Clusters  []savedTokenTemplate `json:"templates"`
+}
+
+// NewSpell creates a SpellParser with the given configuration. It returns an
+// error if a regex of the configuration does not compile or the similarity
+// threshold is out of range.
+func NewSpell(config SpellConfig) (*SpellParser, error) {
+	if config.Delimiters == "" {
+		config.Delimiters = `[\s,:=]`
+	}
+	if config.CommonVariables == nil {
+		config.CommonVariables = getDefaultCommonVariables()
+	}
+	if config.SimilarityThreshold == 0 {
+		config.SimilarityThreshold = defaultSpellSimilarity
+	}
+	if config.TemplateID == nil {
+		config.TemplateID = HashTemplateID
+	}
+
+	if config.SimilarityThreshold < 0 || config.SimilarityThreshold > 1 {
+		return nil, fmt.Errorf("invalid Spell similarity threshold %g: must be in [0, 1]", config.SimilarityThreshold)
+	}
+	if err := validateTokenPatterns(config.Delimiters, config.CommonVariables); err != nil {
+		return nil, err
+	}
+
+	return &SpellParser{
+		config:       config,
+		preprocessor: NewPreprocessor(config.Delimiters, config.CommonVariables),
+	}, nil
+}
+
+// Parse adds the lines to the learned templates and returns the templates
+// of the lines, sorted by count in descending order. Counts and LogIDs cover
+// only logLines; templates are as generalized by the whole call.
+func (s *SpellParser) Parse(logLines []string) []*ParseResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := make(map[*tokenCluster][]int)
+	var order []*tokenCluster
+	for id, line := range logLines {
+		cluster := s.add(s.preprocessor.maskedTokens(line))
+		if _, ok := members[cluster]; !ok {
+			order = append(order, cluster)
+		}
+		members[cluster] = append(members[cluster], id)
+	}
+	return clusterResults(order, members, logLines, s.config.TemplateID)
+}
+
+// Match classifies a line by the learned templates without learning from
+// it. The line matches a template if it has the tokens of the template in
+// order, with any number of tokens at every <*> and nothing else. Of several
+// matching templates the one with most tokens wins. The returned result
+// holds the template, its accumulated count and the severity of the line;
+// LogIDs are not set.
+func (s *SpellParser) Match(line string) (*ParseResult, bool) {
+	tokens := s.preprocessor.maskedTokens(line)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var best *tokenCluster
+	for _, cluster := range s.clusters {
+		if (best == nil || len(cluster.tokens) > len(best.tokens)) && matchTokenGlob(cluster.tokens, tokens) {
+			best = cluster
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	template := strings.Join(best.tokens, " ")
+	return &ParseResult{
+		ID:         s.config.TemplateID(template),
+		Template:   template,
+		Count:      best.count,
+		Severity:   InferLineSeverity(line),
+		Similarity: 1,
+	}, true
+}
+
+// Templates returns the learned templates in order of creation.
+func (s *SpellParser) Templates() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clusterTemplates(s.clusters)
+}
+
+// Snapshot returns all learned templates with their accumulated counts,
+// sorted by count in descending order. Severities are inferred from the
+// template text and LogIDs are not set.
+func (s *SpellParser) Snapshot() []*ParseResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return snapshotClusters(s.clusters, s.config.TemplateID)
+}
+
+// Evaluate measures how well results reproduce a ground truth, see
+// BrainParser.Evaluate.
+func (s *SpellParser) Evaluate(results []*ParseResult, truth *GroundTruth) (*Evaluation, error) {
+	return evaluate(s.preprocessor, results, truth)
+}
+
+// SaveState writes the configuration and the learned templates with their
+// accumulated counts as JSON; LoadSpellState or LoadParser restore them.
+func (s *SpellParser) SaveState(w io.Writer) error {
+	s.mu.Lock()
+	state := spellSavedState{
+		Version:   SpellStateVersion,
+		Algorithm: AlgorithmSpell,
+		Config:    s.config,
+		Clusters:  saveClusters(s.clusters),
+	}
+	s.mu.Unlock()
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// LoadSpellState restores a SpellParser saved with SaveState.
+func LoadSpellState(r io.Reader) (*SpellParser, error) {
+	var state spellSavedState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if state.Algorithm != AlgorithmSpell {
+		return nil, fmt.Errorf("state of algorithm %q is no Spell state", state.Algorithm)
+	}
+	if state.Version > SpellStateVersion {
+		return nil, fmt.Errorf("unsupported state version %d (supported up to %d)", state.Version, SpellStateVersion)
+	}
+	s, err := NewSpell(state.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state config: %w", err)
+	}
+	for _, saved := range state.Clusters {
+		s.clusters = append(s.clusters, &tokenCluster{tokens: saved.Tokens, count: saved.Count})
+	}
+	return s, nil
+}
+
+// add joins tokens to the template with the longest common subsequence,
+// generalizing it, or creates a template. Ties prefer the shorter template.
+// The caller must hold s.mu.
+func (s *SpellParser) add(tokens []string) *tokenCluster {
+	var best *tokenCluster
+	var bestPairs [][2]int
+	required := s.config.SimilarityThreshold * float64(len(tokens))
+	for _, cluster := range s.clusters {
+		// The subsequence is at most as long as the shorter sequence
+		bound := min(len(cluster.tokens), len(tokens))
+		if float64(bound) < required || bound < len(bestPairs) ||
+			best != nil && bound == len(bestPairs) && len(cluster.tokens) >= len(best.tokens) {
+			continue
+		}
+		pairs := commonSubsequence(cluster.tokens, tokens)
+		if len(pairs) > len(bestPairs) || best == nil ||
+			len(pairs) == len(bestPairs) && len(cluster.tokens) < len(best.tokens) {
+			best, bestPairs = cluster, pairs
+		}
+	}
+	if best == nil || float64(len(bestPairs)) < required ||
+		len(bestPairs) == 0 && len(tokens)+len(best.tokens) > 0 { // Nothing in common with threshold 0
+		cluster := &tokenCluster{tokens: append([]string(nil), tokens...), count: 1}
+		s.clusters = append(s.clusters, cluster)
+		return cluster
+	}
+	best.tokens = mergeSubsequence(best.tokens, tokens, bestPairs)
+	best.count++
+	return best
+}
+
+// commonSubsequence returns the index pairs of a longest common subsequence
+// of a and b in ascending order
+func commonSubsequence(a, b []string) [][2]int {
+	// lengths[i][j] is the LCS length of a[i:] and b[j:]
+	lengths := make([][]int, len(a)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+	pairs := make([][2]int, 0, lengths[0][0])
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// mergeSubsequence returns the template of the common subsequence pairs of
+// template and tokens, with one <*> wherever either has tokens outside it
+func mergeSubsequence(template, tokens []string, pairs [][2]int) []string {
+	merged := make([]string, 0, len(pairs)*2+1)
+	wildcard := func() {
+		if len(merged) == 0 || merged[len(merged)-1] != "<*>" {
+			merged = append(merged, "<*>")
+		}
+	}
+	lastI, lastJ := -1, -1
+	for _, pair := range pairs {
+		if pair[0] > lastI+1 || pair[1] > lastJ+1 {
+			wildcard()
+		}
+		if template[pair[0]] == "<*>" {
+			wildcard()
+		} else {
+			merged = append(merged, template[pair[0]])
+		}
+		lastI, lastJ = pair[0], pair[1]
+	}
+	if lastI < len(template)-1 || lastJ < len(tokens)-1 {
+		wildcard()
+	}
+	return merged
+}
+
+// matchTokenGlob reports whether tokens have the tokens of template in order
+// with any number of tokens at every <*> of template
+func matchTokenGlob(template, tokens []string) bool {
+	i, j := 0, 0
+	star, resume := -1, 0 // Last <*> of template and the token it resumes from
+	for j < len(tokens) {
+		switch {
+		case i < len(template) && template[i] == "<*>":
+			star, resume = i, j
+			i++
+		case i < len(template) && template[i] == tokens[j]:
+			i++
+			j++
+		case star >= 0:
+			resume++
+			i, j = star+1, resume
+		default:
+			return false
+		}
+	}
+	for i < len(template) && template[i] == "<*>" {
+		i++
+	}
+	return i == len(template)
+}
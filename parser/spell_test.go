@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpellParse(t *testing.T) {
+	s, err := NewSpell(SpellConfig{})
+	if err != nil {
+		t.Fatalf("NewSpell error: %v", err)
+	}
+	results := s.Parse([]string{
+		"Starting job backup for user alice",
+		"Starting job backup for user bob",
+		"Starting job cleanup of snapshots for user carol",
+		"ERROR Disk sda full",
+		"ERROR Disk sdb full",
+	})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 templates, got %d: %v", len(results), s.Templates())
+	}
+	// Lines of different length share a template
+	if results[0].Template != "Starting job <*> for user <*>" || results[0].Count != 3 || !reflect.DeepEqual(results[0].LogIDs, []int{0, 1, 2}) {
+		t.Errorf("Unexpected first result %+v", results[0])
+	}
+	if results[1].Template != "ERROR Disk <*> full" || results[1].Severity != SeverityError || results[1].ID == "" {
+		t.Errorf("Unexpected second result %+v", results[1])
+	}
+
+	results = s.Parse([]string{"ERROR Disk sdc full"})
+	if len(results) != 1 || results[0].Count != 1 || results[0].Template != "ERROR Disk <*> full" {
+		t.Errorf("Expected the known template for a new batch, got %+v", results)
+	}
+	if snapshot := s.Snapshot(); snapshot[0].Count != 3 || snapshot[1].Count != 3 {
+		t.Errorf("Expected accumulated counts, got %+v %+v", snapshot[0], snapshot[1])
+	}
+}
+
+func TestSpellMatch(t *testing.T) {
+	s, _ := NewSpell(SpellConfig{})
+	s.Parse([]string{"Starting job backup for user alice", "Starting job cleanup for user bob"})
+	result, ok := s.Match("Starting job restore of volume data for user dave")
+	if !ok || result.Template != "Starting job <*> for user <*>" || result.Count != 2 {
+		t.Errorf("Expected a match of any number of tokens at <*>, got %+v %v", result, ok)
+	}
+	if _, ok := s.Match("Stopping job backup for user alice"); ok {
+		t.Error("Expected no match for a line with a different constant token")
+	}
+	if len(s.Templates()) != 1 {
+		t.Errorf("Expected Match not to learn, got %v", s.Templates())
+	}
+}
+
+func TestCommonSubsequence(t *testing.T) {
+	a := strings.Fields("a <*> c d")
+	b := strings.Fields("a x y c d e")
+	pairs := commonSubsequence(a, b)
+	if !reflect.DeepEqual(pairs, [][2]int{{0, 0}, {2, 3}, {3, 4}}) {
+		t.Fatalf("Unexpected pairs %v", pairs)
+	}
+	if merged := strings.Join(mergeSubsequence(a, b, pairs), " "); merged != "a <*> c d <*>" {
+		t.Errorf("Unexpected merged template %q", merged)
+	}
+}
+
+func TestMatchTokenGlob(t *testing.T) {
+	for _, tc := range []struct {
+		template, line string
+		want           bool
+	}{
+		{"a <*> c", "a c", true},
+		{"a <*> c", "a x y c", true},
+		{"a <*> c", "a x y", false},
+		{"<*> c <*>", "x c c y", true},
+		{"a b", "a b c", false},
+	} {
+		if got := matchTokenGlob(strings.Fields(tc.template), strings.Fields(tc.line)); got != tc.want {
+			t.Errorf("matchTokenGlob(%q, %q) = %v, want %v", tc.template, tc.line, got, tc.want)
+		}
+	}
+}
+
+func TestSpellState(t *testing.T) {
+	s, _ := NewSpell(SpellConfig{SimilarityThreshold: 0.6})
+	s.Parse([]string{"ERROR Disk sda full", "ERROR Disk sdb full"})
+	var buf bytes.Buffer
+	if err := s.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	if _, err := LoadDrainState(bytes.NewReader(buf.Bytes())); err == nil {
+		t.Error("Expected LoadDrainState to reject a Spell state")
+	}
+	loaded, err := LoadParser(&buf)
+	if err != nil {
+		t.Fatalf("LoadParser error: %v", err)
+	}
+	restored, ok := loaded.(*SpellParser)
+	if !ok {
+		t.Fatalf("Expected a SpellParser, got %T", loaded)
+	}
+	if restored.config.SimilarityThreshold != 0.6 || !reflect.DeepEqual(restored.Templates(), s.Templates()) {
+		t.Errorf("Unexpected restored parser %+v %v", restored.config, restored.Templates())
+	}
+	if _, err := NewSpell(SpellConfig{SimilarityThreshold: 2}); err == nil {
+		t.Error("Expected an error for a similarity threshold above 1")
+	}
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// spillSegment locates the LogIDs of a template written by one spill
+type spillSegment struct {
+	offset int64
+	length int64
+}
+
+// logSpill stores LogIDs of results in a temporary file. Each spill writes
+// the LogIDs of every template as one segment of ascending, delta-encoded
+// uvarints; the index of segments per template stays in memory.
+type logSpill struct {
+	file     *os.File
+	writer   *bufio.Writer
+	offset   int64
+	segments map[string][]spillSegment
+}
+
+// newLogSpill creates the spill file in dir, os.TempDir() if empty
+func newLogSpill(dir string) (*logSpill, error) {
+	file, err := os.CreateTemp(dir, "brain-spill-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	return &logSpill{
+		file:     file,
+		writer:   bufio.NewWriterSize(file, 64*1024),
+		segments: make(map[string][]spillSegment),
+	}, nil
+}
+
+// write appends the LogIDs of results to the file and clears them
+func (s *logSpill) write(results []*ParseResult) error {
+	var buf [binary.MaxVarintLen64]byte
+	for _, result := range results {
+		if len(result.LogIDs) == 0 {
+			continue
+		}
+		slices.Sort(result.LogIDs)
+		segment := spillSegment{offset: s.offset}
+		previous := 0
+		for _, id := range result.LogIDs {
+			n := binary.PutUvarint(buf[:], uint64(id-previous))
+			if _, err := s.writer.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to write spill file: %w", err)
+			}
+			segment.length += int64(n)
+			previous = id
+		}
+		s.offset += segment.length
+		s.segments[result.Template] = append(s.segments[result.Template], segment)
+		result.LogIDs = result.LogIDs[:0]
+	}
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return nil
+}
+
+// each calls fn with the spilled LogIDs of template until fn returns false.
+// LogIDs are ascending within each spill.
+func (s *logSpill) each(template string, fn func(id int) bool) error {
+	for _, segment := range s.segments[template] {
+		reader := bufio.NewReader(io.NewSectionReader(s.file, segment.offset, segment.length))
+		id := 0
+		for {
+			delta, err := binary.ReadUvarint(reader)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read spill file: %w", err)
+			}
+			id += int(delta)
+			if !fn(id) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// remove closes and deletes the spill file
+func (s *logSpill) remove() error {
+	closeErr := s.file.Close()
+	if err := os.Remove(s.file.Name()); err != nil {
+		return fmt.Errorf("failed to remove spill file: %w", err)
+	}
+	return closeErr
+}
@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StateSchemaVersion is the version of the state document written by SaveState.
+const StateSchemaVersion = 1
+
+// savedState is the serialized form of a parser state
+type savedState struct {
+	Version   int                  `json:"version"`
+	Algorithm string               `json:"algorithm,omitempty"` // Empty for Brain, see LoadParser
+	Config    Config               `json:"config"`
+	Templates []SnapshotTemplate   `json:"templates"`
+	Approved  []string             `json:"approved,omitempty"`
+	LastSeen  map[string]time.Time `json:"last_seen,omitempty"` // Time of the last Parse call each template occurred in
+}
+
+// templateState holds the templates a parser learned across Parse calls
+type templateState struct {
+	mu      sync.Mutex
+	order   []string       // Templates in first-seen order
+	counts  map[string]int // Accumulated line counts per template
+	resume  bool           // Match known templates before learning (set by LoadState)
+	matcher *TemplateMatcher
+	slots   placeholderSlots       // Named placeholders of the templates
+	stats   map[string][]slotStats // Numeric value statistics per template slot (Config.VariableStatistics)
+	seen    map[string]time.Time   // Time of the last recorded lines per template
+
+	approved      map[string]bool     // Templates protected from automatic changes (see Approve)
+	approvedOrder []string            // Approved templates in approval order
+	violations    []TemplateViolation // Distinct automatic changes approved templates were protected from
+
+	// observe is called for every recorded template with its count before
+	// and after the update (previous 0 = new template)
+	observe func(template string, previous, count int)
+}
+
+// newTemplateState creates an empty template state for templates with the
+// named placeholders of slots
+func newTemplateState(slots placeholderSlots) *templateState {
+	return &templateState{slots: slots, counts: make(map[string]int), seen: make(map[string]time.Time)}
+}
+
+// record adds the counts of final results to the state with the time each
+// result was seen, aligned with results
+func (s *templateState) record(results []*ParseResult, seen []time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, result := range results {
+		if previous, ok := s.seen[result.Template]; result.Count > 0 && (!ok || seen[i].After(previous)) {
+			s.seen[result.Template] = seen[i]
+		}
+		previous, ok := s.counts[result.Template]
+		if !ok {
+			s.order = append(s.order, result.Template)
+			s.matcher = nil // Rebuilt on demand
+		}
+		s.counts[result.Template] += result.Count
+		if s.observe != nil {
+			s.observe(result.Template, previous, s.counts[result.Template])
+		}
+	}
+}
+
+// remove drops templates from the state and returns their counts
+func (s *templateState) remove(templates []string) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]int, len(templates))
+	for i, template := range templates {
+		counts[i] = s.counts[template]
+		delete(s.counts, template)
+		delete(s.stats, template)
+		delete(s.seen, template)
+	}
+	order := s.order[:0]
+	for _, template := range s.order {
+		if _, ok := s.counts[template]; ok {
+			order = append(order, template)
+		}
+	}
+	s.order = order
+	s.matcher = nil
+	return counts
+}
+
+// knownMatcher returns the known templates and their matcher, or nil if
+// there are none. The matcher is cached until new templates are recorded.
+func (s *templateState) knownMatcher() ([]string, *TemplateMatcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) == 0 {
+		return nil, nil
+	}
+	if s.matcher == nil {
+		s.matcher = newTemplateMatcher(s.order, s.slots)
+	}
+	return s.matcher.templates, s.matcher
+}
+
+// resumeMatcher returns the known templates and their matcher if Parse
+// should resume from them
+func (s *templateState) resumeMatcher() ([]string, *TemplateMatcher) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	resume := s.resume
+	s.mu.Unlock()
+	if !resume {
+		return nil, nil
+	}
+	return s.knownMatcher()
+}
+
+// Templates returns the templates learned by previous Parse calls (or loaded
+// with LoadState) in first-seen order.
+func (p *BrainParser) Templates() []string {
+	if p.state == nil {
+		return nil
+	}
+	p.state.mu.Lock()
+	defer p.state.mu.Unlock()
+	return append([]string(nil), p.state.order...)
+}
+
+// Snapshot returns the templates learned by previous Parse calls (or loaded
+// with LoadState) with their accumulated counts, sorted by count in
+// descending order. Severities are inferred from the template text and
+// LogIDs are not set.
+func (p *BrainParser) Snapshot() []*ParseResult {
+	if p.state == nil {
+		return nil
+	}
+	p.state.mu.Lock()
+	results := make([]*ParseResult, 0, len(p.state.order))
+	for _, template := range p.state.order {
+		if p.templateFilter != nil && !p.templateFilter.keep(template) {
+			continue
+		}
+		results = append(results, &ParseResult{
+			Template: template,
+			Count:    p.state.counts[template],
+			Severity: InferLineSeverity(template),
+		})
+	}
+	p.state.mu.Unlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Count > results[j].Count
+	})
+	p.assignTemplateIDs(results)
+	return results
+}
+
+// SaveState writes the configuration and the templates learned by all Parse
+// calls of this parser, with their accumulated counts, as JSON.
+func (p *BrainParser) SaveState(w io.Writer) error {
+	p.state.mu.Lock()
+	state := savedState{
+		Version:   StateSchemaVersion,
+		Config:    p.config,
+		Templates: make([]SnapshotTemplate, 0, len(p.state.order)),
+	}
+	for _, template := range p.state.order {
+		state.Templates = append(state.Templates, SnapshotTemplate{Template: template, Count: p.state.counts[template]})
+	}
+	state.Approved = append(state.Approved, p.state.approvedOrder...)
+	if len(p.state.seen) > 0 {
+		state.LastSeen = make(map[string]time.Time, len(p.state.seen))
+		for template, seen := range p.state.seen {
+			state.LastSeen[template] = seen.UTC()
+		}
+	}
+	p.state.mu.Unlock()
+
+	sort.SliceStable(state.Templates, func(i, j int) bool {
+		return state.Templates[i].Count > state.Templates[j].Count
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(state); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+// LoadState creates a parser from a state written by SaveState. Parse calls
+// of the returned parser assign lines matching a known template directly and
+// run the Brain algorithm only on the remaining lines.
+func LoadState(r io.Reader) (*BrainParser, error) {
+	var state savedState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if state.Algorithm != "" && state.Algorithm != AlgorithmBrain {
+		return nil, fmt.Errorf("state of algorithm %q is no Brain state", state.Algorithm)
+	}
+	if state.Version > StateSchemaVersion {
+		return nil, fmt.Errorf("unsupported state version %d (supported up to %d)", state.Version, StateSchemaVersion)
+	}
+
+	p, err := NewWithError(state.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state config: %w", err)
+	}
+	p.state.resume = true
+	for _, template := range state.Templates {
+		if _, ok := p.state.counts[template.Template]; !ok {
+			p.state.order = append(p.state.order, template.Template)
+		}
+		p.state.counts[template.Template] += template.Count
+	}
+	for template, seen := range state.LastSeen {
+		if _, ok := p.state.counts[template]; ok {
+			p.state.seen[template] = seen
+		}
+	}
+	if _, matcher := p.state.knownMatcher(); matcher == nil && len(state.Templates) > 0 {
+		return nil, fmt.Errorf("failed to compile templates of state")
+	}
+	if err := p.Approve(state.Approved...); err != nil {
+		return nil, fmt.Errorf("invalid approved templates of state: %w", err)
+	}
+	return p, nil
+}
+
+// parseResumed assigns lines to known templates and learns the rest
+func (p *BrainParser) parseResumed(ctx context.Context, logLines []string, weights []int, templates []string, matcher *TemplateMatcher, report *ParseReport) (*ParseReport, error) {
+	if _, err := p.matchThenLearn(ctx, logLines, weights, templates, matcher, p.newLearner(), report); err != nil {
+		return report, err
+	}
+	report.Violations = p.state.generalizations(report.Results)
+	report.Results = p.finalizeResults(report.Results, logLines)
+	return report, nil
+}
+
+// Match classifies a line against the templates learned by previous Parse
+// calls (or loaded with LoadState) without running the Brain algorithm.
+// The returned result holds the template, its accumulated count and the
+// severity of the line; LogIDs are not set. With Config.VariableStatistics,
+// Anomalies lists slot values that are outliers for the template. With
+// Config.ApproximateMatch, a line no template matches exactly is assigned to
+// the closest template by token edit distance and Similarity tells how close
+// it is. Templates rejected by the allow/deny lists never match.
+func (p *BrainParser) Match(line string) (*ParseResult, bool) {
+	if p.state == nil {
+		return nil, false
+	}
+	templates, matcher := p.state.knownMatcher()
+	if matcher == nil {
+		return nil, false
+	}
+	words := p.lineWords(line)
+	i, similarity := matcher.matchWords(words), 1.0
+	if i < 0 && p.config.ApproximateMatch > 0 {
+		i, similarity = matcher.closest(words, p.config.ApproximateMatch)
+	}
+	if i < 0 || (p.templateFilter != nil && !p.templateFilter.keep(templates[i])) {
+		return nil, false
+	}
+
+	p.state.mu.Lock()
+	count := p.state.counts[templates[i]]
+	p.state.mu.Unlock()
+	result := &ParseResult{
+		Template:   templates[i],
+		Count:      count,
+		Severity:   InferLineSeverity(line),
+		Similarity: similarity,
+	}
+	p.assignTemplateIDs([]*ParseResult{result})
+	if p.config.VariableStatistics {
+		result.Anomalies = p.slotAnomalies(templates[i], line)
+	}
+	return result, true
+}
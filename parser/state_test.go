@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadState(t *testing.T) {
+	config := Config{Delimiters: `\s+`, Deterministic: true}
+	parser := New(config)
+	first := parser.Parse([]string{
+		"User alice logged in from web",
+		"User bob logged in from web",
+		"User carol logged in from web",
+		"Backup finished in 10 seconds",
+		"Backup finished in 12 seconds",
+	})
+
+	var buf bytes.Buffer
+	if err := parser.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	var saved savedState
+	if err := json.Unmarshal(buf.Bytes(), &saved); err != nil {
+		t.Fatalf("Invalid state JSON: %v", err)
+	}
+	if saved.Version != StateSchemaVersion || len(saved.Templates) != len(first) || saved.Config.Delimiters != `\s+` {
+		t.Errorf("Unexpected saved state: %+v", saved)
+	}
+
+	resumed, err := LoadState(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	results := resumed.Parse([]string{
+		"User dave logged in from web",
+		"Backup finished in 7 seconds",
+		"Disk full on volume data",
+	})
+
+	known := make(map[string]bool)
+	for _, result := range first {
+		known[result.Template] = true
+	}
+	total := 0
+	for _, result := range results {
+		total += result.Count
+		if result.Template != "Disk full on volume data" && !known[result.Template] {
+			t.Errorf("Known line produced new template %q", result.Template)
+		}
+	}
+	if total != 3 {
+		t.Errorf("Expected 3 lines in results, got %d", total)
+	}
+
+	// Counts accumulate across runs and new templates are kept
+	buf.Reset()
+	if err := resumed.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Disk full on volume data") {
+		t.Error("Expected newly learned template in saved state")
+	}
+	if err := json.Unmarshal(buf.Bytes(), &saved); err != nil {
+		t.Fatalf("Invalid state JSON: %v", err)
+	}
+	sum := 0
+	for _, template := range saved.Templates {
+		sum += template.Count
+	}
+	if sum != 8 {
+		t.Errorf("Expected 8 accumulated lines, got %d", sum)
+	}
+
+	if _, err := LoadState(strings.NewReader(`{"version":99}`)); err == nil {
+		t.Error("Expected error for newer state version")
+	}
+}
+
+func TestMatchLearnedTemplates(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`, TemplateDenyPatterns: []string{`^Heartbeat`}})
+	if _, ok := parser.Match("User alice logged in from web"); ok {
+		t.Error("Expected no match before any template was learned")
+	}
+
+	learned := parser.Parse([]string{
+		"User alice logged in from web",
+		"User bob logged in from web",
+		"User carol logged in from web",
+		"Heartbeat ok",
+	})
+	if len(learned) == 0 {
+		t.Fatal("Expected learned templates")
+	}
+
+	result, ok := parser.Match("User dave logged in from web")
+	if !ok {
+		t.Fatal("Expected known line to match")
+	}
+	if result.Template != learned[0].Template || result.Count != 3 || result.LogIDs != nil {
+		t.Errorf("Unexpected match result: %+v", result)
+	}
+	if result, ok := parser.Match("ERROR User eve logged in from web"); ok {
+		t.Errorf("Expected no match for line with extra tokens, got %q", result.Template)
+	}
+	if result, ok := parser.Match("User dave logged in from web while reading response header"); ok {
+		t.Errorf("Expected no match for line with trailing extra tokens, got %q", result.Template)
+	}
+	if _, ok := parser.Match("Heartbeat ok"); ok {
+		t.Error("Denied template must not match")
+	}
+
+	// Matching does not change the learned counts
+	if result, _ := parser.Match("User dave logged in from web"); result.Count != 3 {
+		t.Errorf("Expected count to stay 3, got %d", result.Count)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`})
+	if templates := parser.Templates(); templates != nil {
+		t.Errorf("Expected no templates before parsing, got %q", templates)
+	}
+	parser.Parse([]string{"User alice logged in", "User bob logged in", "User carol logged in"})
+	parser.Parse([]string{"Disk full"})
+	expected := []string{"User <*> logged in", "Disk full"}
+	if templates := parser.Templates(); !reflect.DeepEqual(templates, expected) {
+		t.Errorf("Templates() = %q, want %q", templates, expected)
+	}
+}
+
+func TestMatchWordByWord(t *testing.T) {
+	// Default delimiters: lines are matched with the tokenization of parsing
+	parser := New(Config{})
+	parser.Parse([]string{
+		"Connection reset by peer 5",
+		"Connection reset by peer 7",
+		"Connection reset by peer 9",
+		"user=alice action=login",
+		"user=bob action=login",
+		"user=carol action=login",
+	})
+
+	if result, ok := parser.Match("Connection reset by peer 5 while reading response header from upstream"); ok {
+		t.Errorf("A longer line must not match, got %q", result.Template)
+	}
+	if result, ok := parser.Match("Connection reset by peer 12"); !ok || result.Similarity != 1 {
+		t.Errorf("Expected an exact match, got %+v", result)
+	}
+	if _, ok := parser.Match("user=dave action=login"); !ok {
+		t.Error("Expected a line split at '=' to match")
+	}
+}
+
+func TestDeniedTemplatesNotRecorded(t *testing.T) {
+	parser := New(Config{Delimiters: `\s+`, TemplateDenyPatterns: []string{`^Heartbeat`}})
+	lines := []string{
+		"User alice logged in",
+		"User bob logged in",
+		"User carol logged in",
+		"Heartbeat ok",
+		"Heartbeat ok",
+	}
+	parser.Parse(lines)
+
+	expected := []string{"User <*> logged in"}
+	if templates := parser.Templates(); !reflect.DeepEqual(templates, expected) {
+		t.Errorf("Templates() = %q, want %q", templates, expected)
+	}
+	if _, ok := parser.Match("Heartbeat ok"); ok {
+		t.Error("Denied template must not match")
+	}
+
+	var buf bytes.Buffer
+	if err := parser.SaveState(&buf); err != nil {
+		t.Fatalf("SaveState error: %v", err)
+	}
+	var saved savedState
+	if err := json.Unmarshal(buf.Bytes(), &saved); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if len(saved.Templates) != 1 || saved.Templates[0].Template != expected[0] {
+		t.Errorf("Expected only %q to be saved, got %+v", expected[0], saved.Templates)
+	}
+
+	// Resumed parses do not record denied templates either
+	loaded, err := LoadState(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("LoadState error: %v", err)
+	}
+	loaded.Parse(lines)
+	if templates := loaded.Templates(); !reflect.DeepEqual(templates, expected) {
+		t.Errorf("Templates() after resumed parse = %q, want %q", templates, expected)
+	}
+}
@@ -2,19 +2,47 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 )
 
+// defaultMaxLineSize is the default longest line ProcessReader accepts
+const defaultMaxLineSize = 1024 * 1024
+
 // StreamingProcessor handles large datasets efficiently using streaming approach
 type StreamingProcessor struct {
-	parser       *BrainParser
-	batchSize    int
-	maxWorkers   int
-	bufferPool   sync.Pool
-	resultBuffer chan *ParseResult
+	parser        *BrainParser
+	batchSize     int
+	maxWorkers    int
+	bufferPool    sync.Pool
+	resultBuffer  chan *ParseResult
+	onLineError   func(LineError)
+	maxLineSize   int
+	truncate      bool
+	learnInterval time.Duration
+	progress      ProgressFunc
+	spillLimit    int    // LogIDs held in memory before they spill (0: never)
+	spillDir      string // Directory of spill files
+
+	partialMu sync.Mutex     // Guards partial, live and spill
+	partial   []*ParseResult // Batch results of the running processing for snapshots
+	live      *OnlineParser  // Model of the last ProcessLive run (nil otherwise)
+	spill     *logSpill      // Spilled LogIDs of the last run (nil if it did not spill)
+}
+
+// streamBatch is a batch of lines with the index of its first line and,
+// once parsed, its results
+type streamBatch struct {
+	start   int
+	lines   []string
+	results []*ParseResult
 }
 
 // StreamingConfig contains configuration for streaming processing
@@ -23,22 +51,155 @@ type StreamingConfig struct {
 	MaxWorkers        int  // Maximum number of concurrent workers
 	EnableCompression bool // Enable compressed intermediate storage
 	MemoryThreshold   int  // Memory threshold in MB to switch to streaming
+	MaxLineSize       int  // Maximum line size in bytes for ProcessReader (default: 1MB)
+	TruncateLongLines bool // Cut lines above MaxLineSize instead of failing the scan
+
+	// SpillThreshold is the memory in MB for LogIDs of ProcessReader and
+	// ProcessLargeSlice results; beyond it they spill to a temporary file in
+	// SpillDir (default: os.TempDir()). 0 keeps all LogIDs in memory.
+	SpillThreshold int
+	SpillDir       string
+
+	// Progress is called with the lines of ProcessReader and
+	// ProcessLargeSlice parsed so far as batches finish, from the calling
+	// goroutine. Total is 0 while ProcessReader reads; the last call has
+	// processed == total. Defaults to Config.Progress, which is not called
+	// for the parses of single batches.
+	Progress ProgressFunc
+
+	// LowLatency tunes the defaults for ProcessLive: batches of 64 lines
+	// learned at least every 200ms, for sub-second assignment of new lines
+	LowLatency    bool
+	LearnInterval time.Duration // Longest wait before ProcessLive learns queued lines (default: 1s, 200ms with LowLatency)
+
+	// OnLineError is called for input lines of ProcessReader that cannot be
+	// processed as-is. It is called sequentially from the reading goroutine.
+	OnLineError func(LineError)
+}
+
+// Reasons reported in LineError.Reason
+const (
+	LineErrorTooLong     = "too_long"     // Line exceeds the maximum line size, scanning stops with an error
+	LineErrorTruncated   = "truncated"    // Line exceeds the maximum line size and was cut to it
+	LineErrorInvalidUTF8 = "invalid_utf8" // Line is not valid UTF-8, invalid bytes are replaced with U+FFFD
+)
+
+// LineError describes an input line that could not be processed as-is.
+type LineError struct {
+	Reason string // Why the line was affected (see LineError* constants)
+	Offset int64  // Byte offset of the line start in the input
+	Line   int    // 1-based line number
+	Length int    // Bytes of the line seen by the reader
+}
+
+// lineSplitter is a bufio.SplitFunc source that tracks line offsets and
+// fails on or truncates lines exceeding the maximum line size
+type lineSplitter struct {
+	maxLineSize int
+	truncate    bool
+	onError     func(LineError)
+	offset      int64 // Offset of the next unread byte
+	start       int64 // Offset of the last returned line
+	line        int   // Number of returned lines
+	skipping    bool  // Discarding the remainder of a truncated line
+}
+
+// split behaves like bufio.ScanLines
+func (ls *lineSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	if ls.skipping {
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			ls.skipping = false
+			ls.offset += int64(i + 1)
+			return i + 1, nil, nil
+		}
+		ls.offset += int64(len(data))
+		return len(data), nil, nil
+	}
+
+	advance, token, err := bufio.ScanLines(data, atEOF)
+	complete := len(token) > ls.maxLineSize                                        // Whole line is buffered
+	partial := err == nil && advance == 0 && !atEOF && len(data) >= ls.maxLineSize // Line end not seen yet
+	if complete || partial {
+		length := len(data)
+		if complete {
+			length = len(token)
+		}
+		if !ls.truncate {
+			ls.report(LineError{Reason: LineErrorTooLong, Offset: ls.offset, Line: ls.line + 1, Length: length})
+			return 0, nil, bufio.ErrTooLong
+		}
+		ls.report(LineError{Reason: LineErrorTruncated, Offset: ls.offset, Line: ls.line + 1, Length: length})
+
+		// Cut at a rune boundary so truncation does not corrupt UTF-8
+		cut := ls.maxLineSize
+		for i := cut - 1; i >= 0 && i >= ls.maxLineSize-utf8.UTFMax; i-- {
+			if utf8.RuneStart(data[i]) {
+				if !utf8.FullRune(data[i:cut]) && i > 0 {
+					cut = i
+				}
+				break
+			}
+		}
+		if complete {
+			token = token[:cut]
+		} else {
+			// The rest of the line is discarded by following calls
+			advance, token = cut, data[:cut]
+			ls.skipping = true
+		}
+	}
+	if advance > 0 {
+		ls.start = ls.offset
+		ls.offset += int64(advance)
+		ls.line++
+	}
+	return advance, token, err
+}
+
+// report passes a line error to the callback if one is set
+func (ls *lineSplitter) report(lineErr LineError) {
+	if ls.onError != nil {
+		ls.onError(lineErr)
+	}
 }
 
 // NewStreamingProcessor creates a new streaming processor
 func NewStreamingProcessor(config Config, streamConfig StreamingConfig) *StreamingProcessor {
 	if streamConfig.BatchSize == 0 {
 		streamConfig.BatchSize = 1000 // Default batch size
+		if streamConfig.LowLatency {
+			streamConfig.BatchSize = defaultLiveBatchSize
+		}
+	}
+	if streamConfig.LearnInterval <= 0 {
+		streamConfig.LearnInterval = time.Second
+		if streamConfig.LowLatency {
+			streamConfig.LearnInterval = defaultLiveLearnInterval
+		}
 	}
 	if streamConfig.MaxWorkers == 0 {
 		streamConfig.MaxWorkers = 4 // Default workers
 	}
+	if streamConfig.MaxLineSize <= 0 {
+		streamConfig.MaxLineSize = defaultMaxLineSize
+	}
+	if streamConfig.Progress == nil {
+		streamConfig.Progress = config.Progress
+	}
+	config.Progress = nil // Batches are reported as a whole
 
 	sp := &StreamingProcessor{
-		parser:       New(config),
-		batchSize:    streamConfig.BatchSize,
-		maxWorkers:   streamConfig.MaxWorkers,
-		resultBuffer: make(chan *ParseResult, streamConfig.MaxWorkers*2),
+		parser:        New(config),
+		batchSize:     streamConfig.BatchSize,
+		maxWorkers:    streamConfig.MaxWorkers,
+		resultBuffer:  make(chan *ParseResult, streamConfig.MaxWorkers*2),
+		onLineError:   streamConfig.OnLineError,
+		maxLineSize:   streamConfig.MaxLineSize,
+		truncate:      streamConfig.TruncateLongLines,
+		learnInterval: streamConfig.LearnInterval,
+		progress:      streamConfig.Progress,
+		spillLimit:    streamConfig.SpillThreshold * 1024 * 1024 / 8, // 8 bytes per LogID
+		spillDir:      streamConfig.SpillDir,
 	}
 
 	// Initialize buffer pool for line reading using pointer-safe wrapper
@@ -51,9 +212,17 @@ func NewStreamingProcessor(config Config, streamConfig StreamingConfig) *Streami
 	return sp
 }
 
-// ProcessReader processes logs from an io.Reader in streaming fashion
+// ProcessReader processes logs from an io.Reader in streaming fashion.
+// LogIDs of the results index the non-empty lines in order of reading.
+// Affected input lines are reported to StreamingConfig.OnLineError.
 func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reader) ([]*ParseResult, error) {
+	if err := sp.Close(); err != nil {
+		return nil, err
+	}
+
 	scanner := bufio.NewScanner(reader)
+	splitter := &lineSplitter{maxLineSize: sp.maxLineSize, truncate: sp.truncate, onError: sp.onLineError}
+	scanner.Split(splitter.split)
 
 	// Use pooled buffer for scanning with pointer-safe wrapper
 	wrapper, ok := sp.bufferPool.Get().(*PooledByteBuffer)
@@ -63,16 +232,15 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 		}
 	}
 	buffer := wrapper.Data
-	defer sp.bufferPool.Put(wrapper)  // ✅ No SA6002 warnings!
-	scanner.Buffer(buffer, 1024*1024) // 1MB max line size
+	defer sp.bufferPool.Put(wrapper) // ✅ No SA6002 warnings!
+	scanner.Buffer(buffer, sp.maxLineSize)
 
 	var batch []string
-	var allResults []*ParseResult
 	var wg sync.WaitGroup
 
 	// Channel for batches
-	batchChan := make(chan []string, sp.maxWorkers)
-	resultChan := make(chan []*ParseResult, sp.maxWorkers)
+	batchChan := make(chan streamBatch, sp.maxWorkers)
+	resultChan := make(chan streamBatch, sp.maxWorkers)
 
 	// Start worker goroutines
 	for i := 0; i < sp.maxWorkers; i++ {
@@ -84,8 +252,7 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 				case <-ctx.Done():
 					return
 				default:
-					results := sp.parser.Parse(batch)
-					resultChan <- results
+					resultChan <- sp.parseBatch(batch)
 				}
 			}
 		}()
@@ -94,12 +261,17 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 	// Process lines in batches
 	go func() {
 		defer close(batchChan)
+		start := 0
 		for scanner.Scan() {
 			select {
 			case <-ctx.Done():
 				return
 			default:
 				line := scanner.Text()
+				if !utf8.ValidString(line) {
+					splitter.report(LineError{Reason: LineErrorInvalidUTF8, Offset: splitter.start, Line: splitter.line, Length: len(line)})
+					line = strings.ToValidUTF8(line, "\uFFFD")
+				}
 				if line != "" { // Skip empty lines
 					batch = append(batch, line)
 
@@ -107,7 +279,8 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 						// Send batch for processing
 						batchCopy := make([]string, len(batch))
 						copy(batchCopy, batch)
-						batchChan <- batchCopy
+						batchChan <- streamBatch{start: start, lines: batchCopy}
+						start += len(batchCopy)
 						batch = batch[:0] // Reset batch
 					}
 				}
@@ -118,7 +291,7 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 		if len(batch) > 0 {
 			batchCopy := make([]string, len(batch))
 			copy(batchCopy, batch)
-			batchChan <- batchCopy
+			batchChan <- streamBatch{start: start, lines: batchCopy}
 		}
 	}()
 
@@ -128,31 +301,35 @@ func (sp *StreamingProcessor) ProcessReader(ctx context.Context, reader io.Reade
 		close(resultChan)
 	}()
 
-	for results := range resultChan {
-		allResults = append(allResults, results...)
+	results, err := sp.collect(resultChan, 0)
+	if err != nil {
+		return nil, err
 	}
-
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("scanner error during streaming processing: %w", err)
 	}
-
-	// Aggregate final results
-	return sp.parser.aggregateResults(allResults), nil
+	return results, nil
 }
 
 // ProcessLargeSlice processes very large slices efficiently using streaming approach
 func (sp *StreamingProcessor) ProcessLargeSlice(ctx context.Context, logs []string) ([]*ParseResult, error) {
+	if err := sp.Close(); err != nil {
+		return nil, err
+	}
 	if len(logs) < sp.batchSize {
 		// For small datasets, use regular processing
-		return sp.parser.Parse(logs), nil
+		results := sp.parser.Parse(logs)
+		if sp.progress != nil {
+			sp.progress(len(logs), len(logs), PhaseAggregation)
+		}
+		return results, nil
 	}
 
-	var allResults []*ParseResult
 	var wg sync.WaitGroup
 
 	// Channel for batches
-	batchChan := make(chan []string, sp.maxWorkers)
-	resultChan := make(chan []*ParseResult, sp.maxWorkers)
+	batchChan := make(chan streamBatch, sp.maxWorkers)
+	resultChan := make(chan streamBatch, sp.maxWorkers)
 
 	// Start worker goroutines
 	for i := 0; i < sp.maxWorkers; i++ {
@@ -164,8 +341,7 @@ func (sp *StreamingProcessor) ProcessLargeSlice(ctx context.Context, logs []stri
 				case <-ctx.Done():
 					return
 				default:
-					results := sp.parser.Parse(batch)
-					resultChan <- results
+					resultChan <- sp.parseBatch(batch)
 				}
 			}
 		}()
@@ -186,7 +362,7 @@ func (sp *StreamingProcessor) ProcessLargeSlice(ctx context.Context, logs []stri
 
 				batch := make([]string, end-i)
 				copy(batch, logs[i:end])
-				batchChan <- batch
+				batchChan <- streamBatch{start: i, lines: batch}
 			}
 		}
 	}()
@@ -197,12 +373,144 @@ func (sp *StreamingProcessor) ProcessLargeSlice(ctx context.Context, logs []stri
 		close(resultChan)
 	}()
 
-	for results := range resultChan {
-		allResults = append(allResults, results...)
+	return sp.collect(resultChan, len(logs))
+}
+
+// parseBatch parses a batch with LogIDs offset to the index of its lines in
+// the whole input
+func (sp *StreamingProcessor) parseBatch(batch streamBatch) streamBatch {
+	batch.results = sp.parser.Parse(batch.lines)
+	for _, result := range batch.results {
+		for i := range result.LogIDs {
+			result.LogIDs[i] += batch.start
+		}
+	}
+	return batch
+}
+
+// collect aggregates the batch results of a run. Whenever the LogIDs held in
+// memory exceed the spill limit, the results are aggregated and their LogIDs
+// moved to the spill file; if the run spilled, the LogIDs of the final
+// results are spilled too. Progress is reported against total lines (0 if
+// unknown). resultChan is drained even on errors so workers do not block.
+func (sp *StreamingProcessor) collect(resultChan <-chan streamBatch, total int) ([]*ParseResult, error) {
+	sp.resetPartial()
+	var allResults []*ParseResult
+	var spill *logSpill
+	var spillErr error
+	held, processed := 0, 0
+	for batch := range resultChan {
+		if spillErr != nil {
+			continue
+		}
+		allResults = append(allResults, batch.results...)
+		sp.appendPartial(batch.results)
+		for _, result := range batch.results {
+			held += len(result.LogIDs)
+		}
+		processed += len(batch.lines)
+		if sp.progress != nil {
+			sp.progress(processed, total, PhaseStreaming)
+		}
+		if sp.spillLimit <= 0 || held <= sp.spillLimit {
+			continue
+		}
+		if spill == nil {
+			if spill, spillErr = newLogSpill(sp.spillDir); spillErr != nil {
+				continue
+			}
+			sp.partialMu.Lock()
+			sp.spill = spill
+			sp.partialMu.Unlock()
+		}
+		allResults, spillErr = sp.spillResults(spill, allResults)
+		held = 0
+	}
+	if spillErr != nil {
+		return nil, spillErr
 	}
 
 	// Aggregate final results
-	return sp.parser.aggregateResults(allResults), nil
+	final := sp.parser.aggregateResults(allResults)
+	if spill != nil {
+		if err := spill.write(final); err != nil {
+			return nil, err
+		}
+	}
+	if sp.progress != nil {
+		sp.progress(processed, processed, PhaseAggregation)
+	}
+	return final, nil
+}
+
+// spillResults aggregates results and moves their LogIDs to spill. The
+// aggregated results replace the batch results kept for snapshots.
+func (sp *StreamingProcessor) spillResults(spill *logSpill, results []*ParseResult) ([]*ParseResult, error) {
+	aggregated := sp.parser.aggregateResults(results)
+	sp.partialMu.Lock()
+	defer sp.partialMu.Unlock()
+	if err := spill.write(aggregated); err != nil {
+		return nil, err
+	}
+	sp.partial = slices.Clip(aggregated) // Appends must not share the array
+	return aggregated, nil
+}
+
+// EachLogID calls fn with the LogIDs of a result of the last ProcessReader
+// or ProcessLargeSlice run until fn returns false. If the run spilled, the
+// LogIDs of its results are empty and read from the spill file instead.
+func (sp *StreamingProcessor) EachLogID(result *ParseResult, fn func(id int) bool) error {
+	for _, id := range result.LogIDs {
+		if !fn(id) {
+			return nil
+		}
+	}
+	sp.partialMu.Lock()
+	spill := sp.spill
+	sp.partialMu.Unlock()
+	if spill == nil {
+		return nil
+	}
+	return spill.each(result.Template, fn)
+}
+
+// Close removes the spill file of the last run, if any. The processor stays
+// usable; the next run removes the file as well.
+func (sp *StreamingProcessor) Close() error {
+	sp.partialMu.Lock()
+	spill := sp.spill
+	sp.spill = nil
+	sp.partialMu.Unlock()
+	if spill == nil {
+		return nil
+	}
+	return spill.remove()
+}
+
+// Snapshot returns the aggregated templates of the batches processed so far,
+// or the learned model of ProcessLive. It is safe to call concurrently with
+// ProcessReader, ProcessLargeSlice and ProcessLive.
+func (sp *StreamingProcessor) Snapshot() []*ParseResult {
+	sp.partialMu.Lock()
+	defer sp.partialMu.Unlock()
+	if sp.live != nil {
+		return sp.live.Snapshot()
+	}
+	return sp.parser.aggregateResults(sp.partial)
+}
+
+// resetPartial clears the batch results collected for snapshots
+func (sp *StreamingProcessor) resetPartial() {
+	sp.partialMu.Lock()
+	sp.partial, sp.live = nil, nil
+	sp.partialMu.Unlock()
+}
+
+// appendPartial records batch results for snapshots
+func (sp *StreamingProcessor) appendPartial(results []*ParseResult) {
+	sp.partialMu.Lock()
+	sp.partial = append(sp.partial, results...)
+	sp.partialMu.Unlock()
 }
 
 // AdaptiveProcessor automatically selects the best processing strategy
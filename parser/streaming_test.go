@@ -2,6 +2,8 @@ package parser
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"strings"
 	"testing"
 )
@@ -96,3 +98,141 @@ System startup finished`
 		t.Errorf("Expected 5 logs processed, got %d", totalCount)
 	}
 }
+
+// TestStreamingProcessorLineErrors verifies that affected lines are reported with offsets
+func TestStreamingProcessorLineErrors(t *testing.T) {
+	var lineErrors []LineError
+	processor := NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{
+		BatchSize:   2,
+		MaxWorkers:  1,
+		OnLineError: func(lineErr LineError) { lineErrors = append(lineErrors, lineErr) },
+	})
+
+	logData := "User alice logged in\nUser \xffbob logged in\nSystem started\n"
+	results, err := processor.ProcessReader(context.Background(), strings.NewReader(logData))
+	if err != nil {
+		t.Fatalf("ProcessReader failed: %v", err)
+	}
+	totalCount := 0
+	for _, result := range results {
+		totalCount += result.Count
+	}
+	if totalCount != 3 {
+		t.Errorf("Expected invalid UTF-8 line to be kept, got %d processed lines", totalCount)
+	}
+	expected := LineError{Reason: LineErrorInvalidUTF8, Offset: 21, Line: 2, Length: 19}
+	if len(lineErrors) != 1 || lineErrors[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, lineErrors)
+	}
+
+	// Lines above the limit are reported before the scan fails
+	lineErrors = nil
+	longData := "System started\n" + strings.Repeat("x", defaultMaxLineSize+10) + "\n"
+	if _, err := processor.ProcessReader(context.Background(), strings.NewReader(longData)); err == nil {
+		t.Fatal("Expected error for line exceeding the maximum size")
+	}
+	if len(lineErrors) != 1 || lineErrors[0].Reason != LineErrorTooLong || lineErrors[0].Offset != 15 || lineErrors[0].Line != 2 {
+		t.Errorf("Unexpected line errors: %+v", lineErrors)
+	}
+}
+
+// TestStreamingProcessorMaxLineSize verifies the configurable line limit and truncation
+func TestStreamingProcessorMaxLineSize(t *testing.T) {
+	logData := "User alice logged in\n" + strings.Repeat("é", 40) + " tail\nUser bob logged in\n"
+
+	processor := NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{MaxLineSize: 32})
+	if _, err := processor.ProcessReader(context.Background(), strings.NewReader(logData)); err == nil {
+		t.Fatal("Expected error for line exceeding MaxLineSize")
+	}
+
+	var lineErrors []LineError
+	processor = NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{
+		MaxLineSize:       32,
+		TruncateLongLines: true,
+		OnLineError:       func(lineErr LineError) { lineErrors = append(lineErrors, lineErr) },
+	})
+	results, err := processor.ProcessReader(context.Background(), strings.NewReader(logData))
+	if err != nil {
+		t.Fatalf("ProcessReader failed: %v", err)
+	}
+
+	totalCount := 0
+	for _, result := range results {
+		totalCount += result.Count
+		if len(result.Template) > 32 {
+			t.Errorf("Template %q exceeds the line limit", result.Template)
+		}
+	}
+	if totalCount != 3 {
+		t.Errorf("Expected 3 processed lines, got %d", totalCount)
+	}
+	// Only the truncation is reported, the cut respects rune boundaries
+	if len(lineErrors) != 1 || lineErrors[0].Reason != LineErrorTruncated || lineErrors[0].Offset != 21 || lineErrors[0].Line != 2 {
+		t.Errorf("Unexpected line errors: %+v", lineErrors)
+	}
+}
+
+// TestStreamingProcessorSpill verifies that LogIDs beyond the spill limit are
+// read back from the spill file and the file is removed by Close
+func TestStreamingProcessorSpill(t *testing.T) {
+	var lines []string
+	for i := 0; i < 200; i++ {
+		if i%2 == 0 {
+			lines = append(lines, fmt.Sprintf("User user%d logged in", i))
+		} else {
+			lines = append(lines, fmt.Sprintf("Disk sda%d is full", i))
+		}
+	}
+	input := strings.Join(lines, "\n\n") // Empty lines do not count as LogIDs
+
+	dir := t.TempDir()
+	processor := NewStreamingProcessor(Config{Delimiters: `\s+`}, StreamingConfig{
+		BatchSize:  10,
+		MaxWorkers: 3,
+		SpillDir:   dir,
+	})
+	processor.spillLimit = 25
+
+	results, err := processor.ProcessReader(context.Background(), strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ProcessReader failed: %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("Expected one spill file, got %d", len(files))
+	}
+
+	seen := make(map[int]bool)
+	for _, result := range results {
+		if len(result.LogIDs) != 0 {
+			t.Errorf("Expected spilled LogIDs for %q, got %d in memory", result.Template, len(result.LogIDs))
+		}
+		ids := 0
+		err := processor.EachLogID(result, func(id int) bool {
+			ids++
+			if seen[id] {
+				t.Errorf("LogID %d reported twice", id)
+			}
+			seen[id] = true
+			if want := strings.HasPrefix(lines[id], "User"); want != strings.HasPrefix(result.Template, "User") {
+				t.Errorf("LogID %d (%q) reported for %q", id, lines[id], result.Template)
+			}
+			return true
+		})
+		if err != nil {
+			t.Fatalf("EachLogID failed: %v", err)
+		}
+		if ids != result.Count {
+			t.Errorf("Expected %d LogIDs for %q, got %d", result.Count, result.Template, ids)
+		}
+	}
+	if len(seen) != len(lines) {
+		t.Errorf("Expected %d LogIDs, got %d", len(lines), len(seen))
+	}
+
+	if err := processor.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Expected spill file to be removed, got %d files", len(files))
+	}
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// mergeSubsumed merges every result into a result whose template is equal
+// but for a <*> at one position where the result has a constant, as enabled
+// by Config.MergeSubsumedTemplates. Specific templates are merged first, so
+// chains like "a b c" -> "a <*> c" -> "<*> <*> c" end in the most general
+// template. Of several targets the one with the highest count wins. Merges
+// are recorded in the audit log of report if it is not nil; merged results
+// are released.
+func (p *BrainParser) mergeSubsumed(results Results, report *ParseReport) Results {
+	if !p.config.MergeSubsumedTemplates || p.config.isReparsing || len(results) < 2 {
+		return results
+	}
+
+	byTemplate := make(map[string]*ParseResult, len(results))
+	tokens := make(map[*ParseResult][]string, len(results))
+	constants := make(map[*ParseResult]int, len(results))
+	for _, result := range results {
+		byTemplate[result.Template] = result
+		tokens[result] = strings.Fields(result.Template)
+		for _, token := range tokens[result] {
+			if !p.preprocessor.slots.isSlot(token) {
+				constants[result]++
+			}
+		}
+	}
+
+	order := make([]*ParseResult, len(results))
+	copy(order, results)
+	sort.SliceStable(order, func(i, j int) bool {
+		if constants[order[i]] != constants[order[j]] {
+			return constants[order[i]] > constants[order[j]]
+		}
+		return order[i].Template < order[j].Template
+	})
+
+	merged := make(map[*ParseResult]bool)
+	for _, result := range order {
+		words := tokens[result]
+		var target *ParseResult
+		for i, token := range words {
+			if p.preprocessor.slots.isSlot(token) {
+				continue
+			}
+			words[i] = "<*>"
+			candidate := byTemplate[strings.Join(words, " ")]
+			words[i] = token
+			if candidate != nil && (target == nil || candidate.Count > target.Count ||
+				candidate.Count == target.Count && candidate.Template < target.Template) {
+				target = candidate
+			}
+		}
+		if target == nil {
+			continue
+		}
+
+		if report != nil {
+			report.MergeAudit = append(report.MergeAudit, MergeAuditEntry{
+				Reason:  MergeReasonSubsumed,
+				Sources: []string{result.Template, target.Template},
+				Counts:  []int{result.Count, target.Count},
+				Result:  target.Template,
+			})
+		}
+		mergeExamples(target, result, p.config.ExamplesPerTemplate)
+		target.Count += result.Count
+		target.LogIDs = append(target.LogIDs, result.LogIDs...)
+		if result.Severity > target.Severity {
+			target.Severity = result.Severity
+		}
+		delete(byTemplate, result.Template)
+		merged[result] = true
+	}
+	if len(merged) == 0 {
+		return results
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if !merged[result] {
+			kept = append(kept, result)
+			continue
+		}
+		PutIntSlice(result.LogIDs)
+		result.LogIDs = nil
+		PutParseResult(result)
+	}
+	clear(results[len(kept):])
+	return kept
+}
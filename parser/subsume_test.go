@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMergeSubsumed(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`, MergeSubsumedTemplates: true})
+	results := Results{
+		{Template: "User <*> logged in", Count: 5, LogIDs: []int{0, 1, 2, 3, 4}, Severity: SeverityInfo},
+		{Template: "User admin logged in", Count: 3, LogIDs: []int{5, 6, 7}, Severity: SeverityWarning},
+		{Template: "User root logged <*>", Count: 2, LogIDs: []int{8, 9}},
+		{Template: "User <*> logged <*>", Count: 1, LogIDs: []int{10}},
+		{Template: "Disk <*> full", Count: 4, LogIDs: []int{11, 12, 13, 14}},
+		{Template: "Disk sda <*>", Count: 1, LogIDs: []int{15}}, // Differs in two positions
+	}
+	report := &ParseReport{}
+	merged := p.mergeSubsumed(results, report)
+
+	counts := make(map[string]int)
+	for _, result := range merged {
+		counts[result.Template] = result.Count
+	}
+	// "User admin logged in" joins "User <*> logged in", which then joins
+	// "User <*> logged <*>" together with "User root logged <*>"
+	expected := map[string]int{"User <*> logged <*>": 11, "Disk <*> full": 4, "Disk sda <*>": 1}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+	for _, result := range merged {
+		if result.Template == "User <*> logged <*>" {
+			if len(result.LogIDs) != 11 || result.Severity != SeverityWarning {
+				t.Errorf("Expected all LogIDs and the highest severity, got %v %v", result.LogIDs, result.Severity)
+			}
+		}
+	}
+	if len(report.MergeAudit) != 3 || report.MergeAudit[0].Reason != MergeReasonSubsumed {
+		t.Errorf("Expected 3 subsumed merges in the audit, got %+v", report.MergeAudit)
+	}
+}
+
+func TestMergeSubsumedStreaming(t *testing.T) {
+	// The first batch sees only one user, so its template keeps the constant
+	logLines := []string{
+		"User admin logged in", "User admin logged in", "User admin logged in",
+		"User alice logged in", "User bob logged in", "User carol logged in",
+	}
+	for _, merge := range []bool{false, true} {
+		processor := NewStreamingProcessor(Config{Delimiters: `\s+`, MergeSubsumedTemplates: merge},
+			StreamingConfig{BatchSize: 3, MaxWorkers: 1})
+		results, err := processor.ProcessLargeSlice(context.Background(), logLines)
+		if err != nil {
+			t.Fatalf("ProcessLargeSlice failed: %v", err)
+		}
+		expected := 2
+		if merge {
+			expected = 1
+		}
+		if len(results) != expected {
+			t.Errorf("merge=%v: expected %d templates, got %d", merge, expected, len(results))
+		}
+		if merge && (results[0].Template != "User <*> logged in" || results[0].Count != len(logLines)) {
+			t.Errorf("Expected all lines in the general template, got %q with %d", results[0].Template, results[0].Count)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syslogFacilities are the facility names by code (RFC 5424 section 6.2.1)
+var syslogFacilities = [...]string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+// syslogSeverities are the severity names by code (RFC 5424 section 6.2.1)
+var syslogSeverities = [...]string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+// syslogNil is the NILVALUE of RFC 5424 header fields
+const syslogNil = "-"
+
+// SyslogLogs is the content of a syslog file read by ReadSyslog.
+type SyslogLogs struct {
+	Lines    []string            // MSG parts in input order
+	Fields   []map[string]string // Header fields per line, aligned with Lines (see ParseSyslog)
+	Unparsed int                 // Lines without a syslog header, kept whole in Lines
+}
+
+// ReadSyslog reads RFC 3164 (BSD) and RFC 5424 syslog messages, one per
+// line, and returns only their MSG parts for parsing with the header fields
+// of every line as metadata. Lines without a syslog header are kept whole
+// without fields; lines with an empty MSG are skipped.
+func ReadSyslog(reader io.Reader) (*SyslogLogs, error) {
+	logs := &SyslogLogs{}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), defaultMaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		message, fields, ok := ParseSyslog(line)
+		if !ok {
+			logs.Unparsed++
+			message, fields = line, map[string]string{}
+		}
+		if message = strings.TrimSpace(message); message == "" {
+			continue
+		}
+		logs.Lines = append(logs.Lines, message)
+		logs.Fields = append(logs.Fields, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading syslog: %w", err)
+	}
+	return logs, nil
+}
+
+// ParseSyslog splits a syslog line into its MSG part and header fields.
+// Recognized are RFC 5424 lines ("<PRI>VERSION TIMESTAMP HOSTNAME APP-NAME
+// PROCID MSGID STRUCTURED-DATA MSG") and RFC 3164 lines with or without PRI
+// ("<PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG", also with an RFC 3339
+// timestamp as written by rsyslog). Fields are priority, facility, severity,
+// version, timestamp, hostname, app_name, procid and msgid as present, and
+// every structured data parameter as "SD-ID.name". ok is false if the line
+// has no syslog header.
+func ParseSyslog(line string) (message string, fields map[string]string, ok bool) {
+	fields = make(map[string]string)
+	rest := line
+	if strings.HasPrefix(rest, "<") {
+		end := strings.IndexByte(rest, '>')
+		if end < 2 || end > 4 {
+			return "", nil, false
+		}
+		priority, err := strconv.Atoi(rest[1:end])
+		if err != nil || priority < 0 || priority > 191 {
+			return "", nil, false
+		}
+		fields["priority"] = rest[1:end]
+		fields["facility"] = syslogFacilities[priority/8]
+		fields["severity"] = syslogSeverities[priority%8]
+		rest = rest[end+1:]
+
+		if version, after, found := strings.Cut(rest, " "); found && version != "" && isDigits(version) {
+			fields["version"] = version
+			return parseSyslog5424(after, fields)
+		}
+	}
+	return parseSyslog3164(rest, fields)
+}
+
+// parseSyslog5424 parses the RFC 5424 header after the version
+func parseSyslog5424(rest string, fields map[string]string) (string, map[string]string, bool) {
+	for _, name := range []string{"timestamp", "hostname", "app_name", "procid", "msgid"} {
+		value, after, found := strings.Cut(rest, " ")
+		if value == "" || (!found && name != "msgid") {
+			return "", nil, false
+		}
+		if name == "timestamp" && value != syslogNil {
+			if _, err := time.Parse(time.RFC3339Nano, value); err != nil {
+				return "", nil, false
+			}
+		}
+		if value != syslogNil {
+			fields[name] = value
+		}
+		rest = after
+	}
+
+	if strings.HasPrefix(rest, syslogNil) {
+		rest = rest[len(syslogNil):]
+	} else {
+		var ok bool
+		if rest, ok = parseStructuredData(rest, fields); !ok {
+			return "", nil, false
+		}
+	}
+	if rest != "" && rest[0] != ' ' {
+		return "", nil, false
+	}
+	message := strings.TrimPrefix(strings.TrimPrefix(rest, " "), "\ufeff") // MSG may start with a UTF-8 BOM
+	return message, fields, true
+}
+
+// parseStructuredData parses the SD-ELEMENTs at the start of rest into
+// fields and returns the remainder
+func parseStructuredData(rest string, fields map[string]string) (string, bool) {
+	if !strings.HasPrefix(rest, "[") {
+		return "", false
+	}
+	for strings.HasPrefix(rest, "[") {
+		i := 1
+		for i < len(rest) && rest[i] != ' ' && rest[i] != ']' {
+			i++
+		}
+		id := rest[1:i]
+		if id == "" || i >= len(rest) {
+			return "", false
+		}
+		for i < len(rest) && rest[i] == ' ' {
+			i++
+			start := i
+			for i < len(rest) && rest[i] != '=' {
+				i++
+			}
+			if i+1 >= len(rest) || rest[i+1] != '"' {
+				return "", false
+			}
+			name := rest[start:i]
+			var value strings.Builder
+			for i += 2; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) && strings.IndexByte(`"\]`, rest[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i >= len(rest) {
+				return "", false
+			}
+			fields[id+"."+name] = value.String()
+			i++ // Skip closing quote
+		}
+		if i >= len(rest) || rest[i] != ']' {
+			return "", false
+		}
+		rest = rest[i+1:]
+	}
+	return rest, true
+}
+
+// parseSyslog3164 parses the RFC 3164 header after the optional PRI. The
+// TAG is optional; without it the MSG starts after the hostname.
+func parseSyslog3164(rest string, fields map[string]string) (string, map[string]string, bool) {
+	timestamp, after, ok := cutSyslogTimestamp(rest)
+	if !ok {
+		return "", nil, false
+	}
+	hostname, after, _ := strings.Cut(after, " ")
+	if hostname == "" {
+		return "", nil, false
+	}
+	fields["timestamp"] = timestamp
+	fields["hostname"] = hostname
+
+	// TAG[PID]: is a single word followed by a colon and a space
+	tag, message, found := strings.Cut(after, ":")
+	if !found || tag == "" || strings.ContainsAny(tag, " \t") || (message != "" && message[0] != ' ') {
+		return after, fields, true
+	}
+	if open := strings.IndexByte(tag, '['); open >= 0 {
+		if open == 0 || !strings.HasSuffix(tag, "]") {
+			return after, fields, true
+		}
+		fields["procid"] = tag[open+1 : len(tag)-1]
+		tag = tag[:open]
+	}
+	fields["app_name"] = tag
+	return strings.TrimPrefix(message, " "), fields, true
+}
+
+// cutSyslogTimestamp cuts a BSD ("Jan  2 15:04:05") or RFC 3339 timestamp
+// and the following space from the start of s
+func cutSyslogTimestamp(s string) (string, string, bool) {
+	if n := len(time.Stamp); len(s) > n && s[n] == ' ' {
+		if _, err := time.Parse(time.Stamp, s[:n]); err == nil {
+			return s[:n], s[n+1:], true
+		}
+	}
+	timestamp, after, found := strings.Cut(s, " ")
+	if !found {
+		return "", "", false
+	}
+	if _, err := time.Parse(time.RFC3339Nano, timestamp); err != nil {
+		return "", "", false
+	}
+	return timestamp, after, true
+}
+
+// isDigits reports whether s consists of ASCII digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSyslog(t *testing.T) {
+	tests := []struct {
+		line    string
+		message string
+		fields  map[string]string
+	}{
+		{
+			"<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8",
+			"'su root' failed for lonvick on /dev/pts/8",
+			map[string]string{"priority": "34", "facility": "auth", "severity": "crit", "timestamp": "Oct 11 22:14:15",
+				"hostname": "mymachine", "app_name": "su", "procid": "230"},
+		},
+		{
+			"Jan  5 10:00:00 web1 CRON: (root) CMD (run-parts /etc/cron.hourly)",
+			"(root) CMD (run-parts /etc/cron.hourly)",
+			map[string]string{"timestamp": "Jan  5 10:00:00", "hostname": "web1", "app_name": "CRON"},
+		},
+		{
+			"2024-01-15T10:00:00.123+00:00 web1 kernel: Out of memory: Killed process 42",
+			"Out of memory: Killed process 42",
+			map[string]string{"timestamp": "2024-01-15T10:00:00.123+00:00", "hostname": "web1", "app_name": "kernel"},
+		},
+		{
+			"Jan  5 10:00:00 web1 connection from 10.0.0.1:22 closed",
+			"connection from 10.0.0.1:22 closed",
+			map[string]string{"timestamp": "Jan  5 10:00:00", "hostname": "web1"},
+		},
+		{
+			"<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 " +
+				`[exampleSDID@32473 iut="3" eventSource="Application"][meta x="a\"b\]"] ` + "\ufeffAn application event",
+			"An application event",
+			map[string]string{"priority": "165", "facility": "local4", "severity": "notice", "version": "1",
+				"timestamp": "2003-10-11T22:14:15.003Z", "hostname": "mymachine.example.com", "app_name": "evntslog",
+				"msgid": "ID47", "exampleSDID@32473.iut": "3", "exampleSDID@32473.eventSource": "Application", "meta.x": `a"b]`},
+		},
+		{
+			"<13>1 - - - - - -",
+			"",
+			map[string]string{"priority": "13", "facility": "user", "severity": "notice", "version": "1"},
+		},
+	}
+	for _, tt := range tests {
+		message, fields, ok := ParseSyslog(tt.line)
+		if !ok {
+			t.Errorf("ParseSyslog(%q) not recognized", tt.line)
+			continue
+		}
+		if message != tt.message {
+			t.Errorf("ParseSyslog(%q) message = %q, want %q", tt.line, message, tt.message)
+		}
+		if !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("ParseSyslog(%q) fields = %v, want %v", tt.line, fields, tt.fields)
+		}
+	}
+}
+
+func TestParseSyslogInvalid(t *testing.T) {
+	for _, line := range []string{
+		"User alice logged in",
+		"<999>Oct 11 22:14:15 host app: msg",
+		"<34>not a timestamp host app: msg",
+		"<165>1 yesterday host app - - - msg",
+		`<165>1 2003-10-11T22:14:15Z host app - - [unterminated x="1" msg`,
+	} {
+		if _, _, ok := ParseSyslog(line); ok {
+			t.Errorf("ParseSyslog(%q) recognized, want not", line)
+		}
+	}
+}
+
+func TestReadSyslog(t *testing.T) {
+	data := `<34>Oct 11 22:14:15 web1 sshd[101]: Accepted password for alice
+Oct 11 22:14:16 web1 sshd[102]: Accepted password for bob
+
+plain line without header
+<13>1 2024-01-15T10:00:00Z web1 app - - -
+`
+	logs, err := ReadSyslog(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadSyslog error: %v", err)
+	}
+	expectedLines := []string{"Accepted password for alice", "Accepted password for bob", "plain line without header"}
+	if !reflect.DeepEqual(logs.Lines, expectedLines) {
+		t.Errorf("Lines = %q, want %q", logs.Lines, expectedLines)
+	}
+	if len(logs.Fields) != len(logs.Lines) {
+		t.Fatalf("Got %d field maps for %d lines", len(logs.Fields), len(logs.Lines))
+	}
+	if logs.Fields[1]["procid"] != "102" || logs.Fields[1]["app_name"] != "sshd" || len(logs.Fields[2]) != 0 {
+		t.Errorf("Unexpected fields %v", logs.Fields)
+	}
+	if logs.Unparsed != 1 {
+		t.Errorf("Unparsed = %d, want 1", logs.Unparsed)
+	}
+
+}
@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// TabularFormat selects the field delimiter of tabular log exports.
+type TabularFormat int
+
+const (
+	TabularCSV TabularFormat = iota // Comma-separated values
+	TabularTSV                      // Tab-separated values
+	TabularPSV                      // Pipe-separated values
+)
+
+// String returns the short name of the format (csv, tsv, psv).
+func (f TabularFormat) String() string {
+	switch f {
+	case TabularTSV:
+		return "tsv"
+	case TabularPSV:
+		return "psv"
+	case TabularCSV:
+		return "csv"
+	}
+	return "csv"
+}
+
+// Delimiter returns the field delimiter of the format.
+func (f TabularFormat) Delimiter() rune {
+	switch f {
+	case TabularTSV:
+		return '\t'
+	case TabularPSV:
+		return '|'
+	case TabularCSV:
+		return ','
+	}
+	return ','
+}
+
+// ParseTabularFormat parses a format name: csv, tsv (or tab) and psv (or pipe).
+func ParseTabularFormat(name string) (TabularFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv":
+		return TabularCSV, nil
+	case "tsv", "tab":
+		return TabularTSV, nil
+	case "psv", "pipe":
+		return TabularPSV, nil
+	}
+	return TabularCSV, fmt.Errorf("unsupported tabular format: %s", name)
+}
+
+// ParseTabularDelimiter parses a field delimiter name: comma, tab, pipe,
+// semicolon or space, or a single character other than a quote or line break.
+func ParseTabularDelimiter(name string) (rune, error) {
+	switch strings.ToLower(name) {
+	case "comma":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	case "pipe":
+		return '|', nil
+	case "semicolon":
+		return ';', nil
+	case "space":
+		return ' ', nil
+	}
+	delimiter, size := utf8.DecodeRuneInString(name)
+	if size == 0 || size != len(name) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError {
+		return 0, fmt.Errorf("unsupported field delimiter: %q", name)
+	}
+	return delimiter, nil
+}
+
+// TabularOptions configures ReadTabular.
+type TabularOptions struct {
+	// Lenient accepts ragged rows and stray quotes in CSV exports too, joins
+	// lines of messages with embedded newlines, replaces invalid UTF-8 and
+	// skips malformed rows, reporting them in TabularColumn.Errors, instead
+	// of failing on the first one
+	Lenient bool
+
+	// Delimiter separates fields instead of the delimiter of the format,
+	// e.g. ';' for CSV exports of spreadsheets in many locales (default: 0 =
+	// the format's, see ParseTabularDelimiter)
+	Delimiter rune
+
+	// LazyQuotes keeps quotes inside unquoted fields and unescaped quotes in
+	// quoted fields of CSV exports as-is instead of failing, as TSV and PSV
+	// exports are always read
+	LazyQuotes bool
+
+	// NoHeader reads the first row as a record instead of a header row, as
+	// in database exports; the column must then be given by index
+	NoHeader bool
+}
+
+// TabularColumn is the message column of a tabular export read by ReadTabular.
+type TabularColumn struct {
+	Lines  []string          // Non-empty messages in input order
+	Errors []TabularRowError // Rows skipped in lenient mode
+}
+
+// TabularRowError describes a malformed row skipped in lenient mode.
+type TabularRowError struct {
+	Line int   // 1-based input line the row starts at
+	Err  error // What is wrong with the row
+}
+
+// Error implements the error interface.
+func (e TabularRowError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e TabularRowError) Unwrap() error {
+	return e.Err
+}
+
+// ReadTabularColumn reads a delimited export with a header row and returns the
+// non-empty values of the named column (matched case-insensitively). A
+// column name that is a positive number and no header selects the column by
+// its 1-based index.
+// TSV and PSV exports are read leniently: quotes inside fields are kept as-is
+// and rows may have a varying number of fields.
+func ReadTabularColumn(reader io.Reader, format TabularFormat, columnName string) ([]string, error) {
+	column, err := ReadTabular(reader, format, columnName, TabularOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return column.Lines, nil
+}
+
+// ReadTabular behaves like ReadTabularColumn with options. Exports starting
+// with a UTF-8 byte order mark or encoded as UTF-16 with a byte order mark,
+// as written by spreadsheet applications, are decoded in any mode.
+func ReadTabular(reader io.Reader, format TabularFormat, columnName string, opts TabularOptions) (*TabularColumn, error) {
+	decoded, err := decodeTabular(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding %s: %w", format, err)
+	}
+	tabReader := csv.NewReader(decoded)
+	tabReader.Comma = format.Delimiter()
+	if opts.Delimiter != 0 {
+		tabReader.Comma = opts.Delimiter
+	}
+	if format != TabularCSV || opts.Lenient {
+		// Database exports rarely follow CSV quoting rules
+		tabReader.LazyQuotes = true
+		tabReader.FieldsPerRecord = -1
+	}
+	if opts.LazyQuotes {
+		tabReader.LazyQuotes = true
+	}
+
+	// Find the message column index by name in the header or by number
+	messageIndex := -1
+	if number, err := strconv.Atoi(strings.TrimSpace(columnName)); err == nil && number > 0 {
+		messageIndex = number - 1
+	}
+	if opts.NoHeader && messageIndex == -1 {
+		return nil, fmt.Errorf("column '%s' is not a column number, %s has no header", columnName, strings.ToUpper(format.String()))
+	}
+	if !opts.NoHeader {
+		header, err := tabReader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s header: %w", format, err)
+		}
+		for i, col := range header {
+			if strings.EqualFold(strings.TrimSpace(col), columnName) {
+				messageIndex = i
+				break
+			}
+		}
+		if messageIndex == -1 {
+			return nil, fmt.Errorf("column '%s' not found in %s. Available columns: %v",
+				columnName, strings.ToUpper(format.String()), header)
+		}
+	}
+
+	// Read all records
+	column := &TabularColumn{}
+	for {
+		record, err := tabReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			if !opts.Lenient {
+				return nil, fmt.Errorf("error reading %s record: %w", format, err)
+			}
+			line, _ := tabReader.FieldPos(0)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line, err = parseErr.StartLine, parseErr.Err
+			}
+			column.Errors = append(column.Errors, TabularRowError{Line: line, Err: err})
+			continue
+		}
+
+		if messageIndex >= len(record) {
+			if opts.Lenient {
+				line, _ := tabReader.FieldPos(0)
+				column.Errors = append(column.Errors, TabularRowError{
+					Line: line,
+					Err:  fmt.Errorf("row has %d fields, no column '%s'", len(record), columnName),
+				})
+			}
+			continue
+		}
+		message := strings.TrimSpace(record[messageIndex])
+		if opts.Lenient {
+			message = strings.Join(strings.Fields(strings.ToValidUTF8(message, "\uFFFD")), " ")
+		}
+		if message != "" { // Skip empty messages
+			column.Lines = append(column.Lines, message)
+		}
+	}
+
+	return column, nil
+}
+
+// decodeTabular strips a UTF-8 byte order mark and converts UTF-16 input
+// with a byte order mark to UTF-8
+func decodeTabular(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	bom, _ := buffered.Peek(3)
+	switch {
+	case bytes.HasPrefix(bom, []byte{0xEF, 0xBB, 0xBF}):
+		_, _ = buffered.Discard(3)
+		return buffered, nil
+	case bytes.HasPrefix(bom, []byte{0xFF, 0xFE}), bytes.HasPrefix(bom, []byte{0xFE, 0xFF}):
+		data, err := io.ReadAll(buffered)
+		if err != nil {
+			return nil, err
+		}
+		order := binary.ByteOrder(binary.LittleEndian)
+		if data[0] == 0xFE {
+			order = binary.BigEndian
+		}
+		units := make([]uint16, (len(data)-2)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2+2*i:])
+		}
+		return strings.NewReader(string(utf16.Decode(units))), nil
+	}
+	return buffered, nil
+}
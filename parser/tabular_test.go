@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadTabularColumn(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   TabularFormat
+		data     string
+		expected []string
+	}{
+		{
+			name:     "csv",
+			format:   TabularCSV,
+			data:     "time,message\n1,\"User a, logged in\"\n2,System started\n",
+			expected: []string{"User a, logged in", "System started"},
+		},
+		{
+			name:     "tsv with stray quotes and ragged rows",
+			format:   TabularTSV,
+			data:     "time\tMessage\tlevel\n1\tUser \"a\" logged in\tinfo\n2\tSystem started\n3\t\n",
+			expected: []string{"User \"a\" logged in", "System started"},
+		},
+		{
+			name:     "psv",
+			format:   TabularPSV,
+			data:     "id|message\n1|Disk full, retrying\n2|Disk ok\n",
+			expected: []string{"Disk full, retrying", "Disk ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := ReadTabularColumn(strings.NewReader(tt.data), tt.format, "message")
+			if err != nil {
+				t.Fatalf("ReadTabularColumn failed: %v", err)
+			}
+			if !reflect.DeepEqual(lines, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, lines)
+			}
+		})
+	}
+
+	if _, err := ReadTabularColumn(strings.NewReader("a\tb\n1\t2\n"), TabularTSV, "message"); err == nil {
+		t.Error("Expected error for missing column")
+	}
+}
+
+func TestReadTabularColumnIndex(t *testing.T) {
+	// A numeric column selects by 1-based index, skipping the header
+	lines, err := ReadTabularColumn(strings.NewReader("time,msg\n1,Disk full\n2,Disk ok\n"), TabularCSV, "2")
+	if err != nil || !reflect.DeepEqual(lines, []string{"Disk full", "Disk ok"}) {
+		t.Errorf("Expected the second column, got %q %v", lines, err)
+	}
+
+	// Without a header the first row is a record
+	column, err := ReadTabular(strings.NewReader("1|User a logged in\n2|System started\n"), TabularPSV, "2", TabularOptions{NoHeader: true})
+	if err != nil || !reflect.DeepEqual(column.Lines, []string{"User a logged in", "System started"}) {
+		t.Errorf("Expected both rows, got %+v %v", column, err)
+	}
+
+	if _, err := ReadTabular(strings.NewReader("1,a\n"), TabularCSV, "message", TabularOptions{NoHeader: true}); err == nil {
+		t.Error("Expected error for a column name without header")
+	}
+}
+
+func TestReadTabularDelimiter(t *testing.T) {
+	data := "time;message\n1;User a, logged in\n2;Size 5\" disk\n"
+	if _, err := ReadTabular(strings.NewReader(data), TabularCSV, "message", TabularOptions{Delimiter: ';'}); err == nil {
+		t.Error("Expected error for a stray quote without LazyQuotes")
+	}
+	column, err := ReadTabular(strings.NewReader(data), TabularCSV, "message", TabularOptions{Delimiter: ';', LazyQuotes: true})
+	if err != nil || !reflect.DeepEqual(column.Lines, []string{"User a, logged in", "Size 5\" disk"}) {
+		t.Errorf("Expected semicolon-separated messages, got %+v %v", column, err)
+	}
+
+	for name, expected := range map[string]rune{"tab": '\t', "pipe": '|', "semicolon": ';', "comma": ',', "#": '#'} {
+		if delimiter, err := ParseTabularDelimiter(name); err != nil || delimiter != expected {
+			t.Errorf("ParseTabularDelimiter(%q) = %q, %v, want %q", name, delimiter, err, expected)
+		}
+	}
+	for _, name := range []string{"", "ab", `"`, "\n"} {
+		if _, err := ParseTabularDelimiter(name); err == nil {
+			t.Errorf("Expected error for delimiter %q", name)
+		}
+	}
+}
+
+func TestParseTabularFormat(t *testing.T) {
+	for name, expected := range map[string]TabularFormat{
+		"csv":  TabularCSV,
+		"TSV":  TabularTSV,
+		"tab":  TabularTSV,
+		"psv":  TabularPSV,
+		"pipe": TabularPSV,
+	} {
+		format, err := ParseTabularFormat(name)
+		if err != nil || format != expected {
+			t.Errorf("ParseTabularFormat(%q) = %v, %v; want %v", name, format, err, expected)
+		}
+	}
+	if _, err := ParseTabularFormat("xml"); err == nil {
+		t.Error("Expected error for unsupported format")
+	}
+}
+
+func TestReadTabularLenient(t *testing.T) {
+	data := "time,message\n1,\"multi\nline  message\"\n2,bare \"quote\" here\n3\n4,ok,extra\n5,bad \xff byte\n"
+	if _, err := ReadTabularColumn(strings.NewReader(data), TabularCSV, "message"); err == nil {
+		t.Error("Expected strict CSV reading to fail")
+	}
+
+	column, err := ReadTabular(strings.NewReader(data), TabularCSV, "message", TabularOptions{Lenient: true})
+	if err != nil {
+		t.Fatalf("ReadTabular failed: %v", err)
+	}
+	expected := []string{"multi line message", "bare \"quote\" here", "ok", "bad \uFFFD byte"}
+	if !reflect.DeepEqual(column.Lines, expected) {
+		t.Errorf("Lines = %q, want %q", column.Lines, expected)
+	}
+	if len(column.Errors) != 1 || column.Errors[0].Line != 5 {
+		t.Fatalf("Expected an error for the row on line 5, got %v", column.Errors)
+	}
+	if !strings.Contains(column.Errors[0].Error(), "line 5: row has 1 fields") {
+		t.Errorf("Unexpected error message %q", column.Errors[0].Error())
+	}
+}
+
+func TestReadTabularEncodings(t *testing.T) {
+	// UTF-16 LE with byte order mark as written by spreadsheet exports
+	utf16le := []byte{0xFF, 0xFE}
+	for _, r := range "message\tlevel\nDisk voll\tinfo\n" {
+		utf16le = append(utf16le, byte(r), 0)
+	}
+	utf16be := []byte{0xFE, 0xFF}
+	for _, r := range "message\nDisk voll\n" {
+		utf16be = append(utf16be, 0, byte(r))
+	}
+
+	tests := map[string]string{
+		"utf-8 bom": "\ufeffmessage\nDisk voll\n",
+		"utf-16le":  string(utf16le),
+		"utf-16be":  string(utf16be),
+	}
+	for name, data := range tests {
+		lines, err := ReadTabularColumn(strings.NewReader(data), TabularTSV, "message")
+		if err != nil {
+			t.Errorf("%s: ReadTabularColumn failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(lines, []string{"Disk voll"}) {
+			t.Errorf("%s: lines = %q", name, lines)
+		}
+	}
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"math"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 	"unique"
 )
 
@@ -28,6 +30,13 @@ func (p *BrainParser) GenerateTemplatesFromTree(tree *BidirectionalTree, allLogs
 
 	// Recursively traverse child nodes and collect templates
 	p.collectTemplatesFromNode(tree.ChildDirectionRoot, baseTemplate, make(map[int]string), &results)
+	var origin string
+	if len(results) > 0 {
+		origin = groupOrigin(tree, allLogs, results[0])
+		for _, result := range results {
+			result.origin = origin
+		}
+	}
 
 	// Filter results to improve quality if enhanced features are enabled
 	if p.config.UseEnhancedPostProcessing && !p.config.isReparsing {
@@ -36,6 +45,9 @@ func (p *BrainParser) GenerateTemplatesFromTree(tree *BidirectionalTree, allLogs
 		// Try to reparse bad results with relaxed settings
 		if len(badResults) > 0 {
 			reparsedResults := p.reparseWithRelaxedSettings(badResults, allLogs)
+			for _, result := range reparsedResults {
+				result.origin = origin
+			}
 			goodResults = append(goodResults, reparsedResults...)
 		}
 
@@ -45,6 +57,24 @@ func (p *BrainParser) GenerateTemplatesFromTree(tree *BidirectionalTree, allLogs
 	return results
 }
 
+// groupOrigin names the group of a tree for the merge audit by its pattern,
+// or by the overflow bucket of its line length if it has none
+func groupOrigin(tree *BidirectionalTree, allLogs []*LogMessage, result *ParseResult) string {
+	if len(tree.RootPattern.Words) > 0 {
+		return patternText(tree.RootPattern)
+	}
+	length := 0
+	if len(result.LogIDs) > 0 && result.LogIDs[0] < len(allLogs) {
+		length = len(allLogs[result.LogIDs[0]].Words)
+	}
+	sb := GetStringBuilder()
+	sb.WriteString("overflow-len:")
+	writeInt(sb, length)
+	origin := sb.String()
+	PutStringBuilder(sb)
+	return origin
+}
+
 func (p *BrainParser) collectTemplatesFromNode(node *Node, baseTemplate map[int]string, pathTemplate map[int]string, results *[]*ParseResult) {
 	if node == nil {
 		return
@@ -81,29 +111,42 @@ func (p *BrainParser) collectTemplatesFromNode(node *Node, baseTemplate map[int]
 			PutIntSlice(logIDs)
 			logIDs = make([]int, 0, len(node.Logs))
 		}
+		count := 0
 		for _, log := range node.Logs {
 			logIDs = append(logIDs, log.ID)
+			count += max(log.Weight, 1)
 		}
 
 		*results = append(*results, &ParseResult{
 			Template: finalTemplate,
-			Count:    len(node.Logs),
+			Count:    count,
 			LogIDs:   logIDs,
 		})
 		return
 	}
 
-	// Recursively traverse child nodes
-	for _, childNode := range node.Children {
-		// Create a copy of pathTemplate for each branch
-		newPathTemplate := make(map[int]string)
-		for k, v := range pathTemplate {
-			newPathTemplate[k] = v
+	// Recursively traverse child nodes (in key order for deterministic mode)
+	if p.ordered() {
+		for _, key := range sortedKeys(node.Children) {
+			p.collectTemplatesFromChild(node.Children[key], baseTemplate, pathTemplate, results)
 		}
-		p.collectTemplatesFromNode(childNode, baseTemplate, newPathTemplate, results)
+		return
+	}
+	for _, childNode := range node.Children {
+		p.collectTemplatesFromChild(childNode, baseTemplate, pathTemplate, results)
 	}
 }
 
+// collectTemplatesFromChild traverses a child node with its own copy of the path template
+func (p *BrainParser) collectTemplatesFromChild(childNode *Node, baseTemplate map[int]string, pathTemplate map[int]string, results *[]*ParseResult) {
+	// Create a copy of pathTemplate for each branch
+	newPathTemplate := make(map[int]string)
+	for k, v := range pathTemplate {
+		newPathTemplate[k] = v
+	}
+	p.collectTemplatesFromNode(childNode, baseTemplate, newPathTemplate, results)
+}
+
 // buildCompleteTemplate combines base template and path into final template.
 func (p *BrainParser) buildCompleteTemplate(baseTemplate, pathTemplate map[int]string) string {
 	// Merge templates
@@ -133,7 +176,7 @@ func (p *BrainParser) buildCompleteTemplate(baseTemplate, pathTemplate map[int]s
 		word, ok := completeTemplate[i]
 		if ok {
 			// Apply enhanced post-processing to catch missed variables
-			if word != "<*>" && p.shouldBeVariableWithConfig(word) {
+			if !p.preprocessor.slots.isSlot(word) && p.shouldBeVariableWithConfig(word) {
 				result[i] = "<*>"
 			} else {
 				result[i] = word
@@ -149,6 +192,9 @@ func (p *BrainParser) buildCompleteTemplate(baseTemplate, pathTemplate map[int]s
 
 // shouldBeVariableWithConfig wraps the variable detection logic with config consideration
 func (p *BrainParser) shouldBeVariableWithConfig(word string) bool {
+	if p.config.UnicodeDigits && isNumericVariableUnicode(word) {
+		return true
+	}
 	if p.config.UseEnhancedPostProcessing {
 		return p.shouldBeVariableEnhanced(word)
 	}
@@ -172,9 +218,10 @@ func shouldBeVariable(word string) bool {
 	return false
 }
 
-// containsMixedPatterns checks for patterns that typically indicate variables
+// containsMixedPatterns checks for patterns that typically indicate variables.
+// Letters of any script count as letters, lengths are measured in characters.
 func containsMixedPatterns(word string) bool {
-	if len(word) < 3 { // Too short to analyze patterns
+	if utf8.RuneCountInString(word) < 3 { // Too short to analyze patterns
 		return false
 	}
 
@@ -194,7 +241,7 @@ func containsMixedPatterns(word string) bool {
 
 	for _, ch := range word {
 		switch {
-		case (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z'):
+		case unicode.IsLetter(ch):
 			hasLetters = true
 			letterCount++
 		case ch >= '0' && ch <= '9':
@@ -267,7 +314,7 @@ func (p *BrainParser) shouldBeVariableEnhanced(word string) bool {
 
 // hasComplexPattern checks for complex alphanumeric patterns
 func hasComplexPattern(word string) bool {
-	if len(word) < 4 {
+	if utf8.RuneCountInString(word) < 4 {
 		return false
 	}
 
@@ -278,7 +325,7 @@ func hasComplexPattern(word string) bool {
 	for _, ch := range word {
 		currType := 0
 		switch {
-		case (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z'):
+		case unicode.IsLetter(ch):
 			currType = 1
 		case ch >= '0' && ch <= '9':
 			currType = 2
@@ -316,7 +363,8 @@ func (p *BrainParser) looksLikeTimestamp(word string) bool {
 
 // looksLikeHash checks for hash-like patterns
 func looksLikeHash(word string) bool {
-	if len(word) < 8 {
+	length := utf8.RuneCountInString(word)
+	if length < 8 {
 		return false
 	}
 
@@ -329,12 +377,13 @@ func looksLikeHash(word string) bool {
 	}
 
 	// If mostly hex characters and long enough, likely a hash
-	return float64(hexCount)/float64(len(word)) > 0.8 && len(word) >= 16
+	return float64(hexCount)/float64(length) > 0.8 && length >= 16
 }
 
 // looksLikeEncoded checks for base64 or other encoded patterns
 func looksLikeEncoded(word string) bool {
-	if len(word) < 8 {
+	length := utf8.RuneCountInString(word)
+	if length < 8 || hasUnspacedScript(word) {
 		return false
 	}
 
@@ -348,7 +397,7 @@ func looksLikeEncoded(word string) bool {
 	}
 
 	// High ratio of base64 chars and ends with = padding
-	isBase64Like := float64(validChars)/float64(len(word)) > 0.95 &&
+	isBase64Like := float64(validChars)/float64(length) > 0.95 &&
 		(strings.HasSuffix(word, "=") || strings.HasSuffix(word, "=="))
 
 	// Also check for high character diversity (typical in encoded data)
@@ -357,14 +406,29 @@ func looksLikeEncoded(word string) bool {
 		uniqueChars[ch] = true
 	}
 
-	highDiversity := float64(len(uniqueChars))/float64(len(word)) > 0.6
+	highDiversity := float64(len(uniqueChars))/float64(length) > 0.6
+
+	return isBase64Like || (length >= 16 && highDiversity)
+}
 
-	return isBase64Like || (len(word) >= 16 && highDiversity)
+// hasUnspacedScript reports whether word contains characters of a script
+// written without spaces between words (Han, Kana, Thai, ...), whose tokens
+// are whole phrases with naturally diverse characters
+func hasUnspacedScript(word string) bool {
+	for _, ch := range word {
+		if ch >= utf8.RuneSelf && unicode.In(ch, unicode.Han, unicode.Hiragana, unicode.Katakana,
+			unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar) {
+			return true
+		}
+	}
+	return false
 }
 
-// hasHighEntropy calculates Shannon entropy to detect random strings
+// hasHighEntropy calculates Shannon entropy over the characters of word to
+// detect random strings. Phrases of scripts without word spacing are skipped.
 func (p *BrainParser) hasHighEntropy(word string) bool {
-	if len(word) < p.config.MinEntropyLength {
+	length := utf8.RuneCountInString(word)
+	if length < p.config.MinEntropyLength || hasUnspacedScript(word) {
 		return false
 	}
 
@@ -376,7 +440,7 @@ func (p *BrainParser) hasHighEntropy(word string) bool {
 
 	// Calculate Shannon entropy
 	entropy := 0.0
-	wordLen := float64(len(word))
+	wordLen := float64(length)
 
 	for _, count := range freq {
 		probability := float64(count) / wordLen
@@ -419,7 +483,7 @@ func (p *BrainParser) isQualityTemplate(template string) bool {
 	contentWords := 0
 
 	for _, token := range tokens {
-		if token == "<*>" {
+		if p.preprocessor.slots.isSlot(token) {
 			wildcardCount++
 			currentConsecutive++
 			if currentConsecutive > maxConsecutiveWildcards {
@@ -469,7 +533,9 @@ func extractLogsFromResults(results []*ParseResult, allLogs []*LogMessage) []*Lo
 	return extractedLogs
 }
 
-// reparseWithRelaxedSettings attempts to reparse low-quality templates with progressively relaxed settings
+// reparseWithRelaxedSettings attempts to reparse low-quality templates with
+// progressively relaxed settings. Every re-parsed template records the
+// low-quality templates its lines came from for the merge audit.
 func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allLogs []*LogMessage) []*ParseResult {
 	if len(badResults) == 0 {
 		return nil
@@ -483,8 +549,10 @@ func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allL
 
 	// Convert LogMessage slice to string slice for reparsing
 	logLines := make([]string, len(logsToReparse))
+	weights := make([]int, len(logsToReparse))
 	for i, log := range logsToReparse {
 		logLines[i] = log.Content.Value()
+		weights[i] = max(log.Weight, 1)
 	}
 
 	var allGoodResults []*ParseResult
@@ -496,17 +564,11 @@ func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allL
 	relaxedConfig.TimestampMinDigits = 10
 	relaxedConfig.isReparsing = true
 
-	if results := p.tryReparseWithConfig(logLines, relaxedConfig); len(results) > 0 {
+	if results := p.tryReparseWithConfig(logLines, weights, logsToReparse, relaxedConfig); len(results) > 0 {
 		if goodResults, _ := p.filterLowQualityTemplatesWithConfig(results, relaxedConfig); len(goodResults) > 0 {
 			allGoodResults = append(allGoodResults, goodResults...)
 			// Remove processed logs and continue with remaining
-			processedLogIDs := make(map[int]bool)
-			for _, result := range goodResults {
-				for _, logID := range result.LogIDs {
-					processedLogIDs[logID] = true
-				}
-			}
-			logLines = p.removeProcessedLogs(logLines, logsToReparse, processedLogIDs)
+			logLines, weights, logsToReparse = p.removeProcessedLogs(logLines, weights, logsToReparse, goodResults)
 		}
 	}
 
@@ -516,17 +578,11 @@ func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allL
 		noEnhancedConfig.UseEnhancedPostProcessing = false
 		noEnhancedConfig.isReparsing = true
 
-		if results := p.tryReparseWithConfig(logLines, noEnhancedConfig); len(results) > 0 {
+		if results := p.tryReparseWithConfig(logLines, weights, logsToReparse, noEnhancedConfig); len(results) > 0 {
 			if goodResults, _ := p.filterLowQualityTemplatesWithConfig(results, noEnhancedConfig); len(goodResults) > 0 {
 				allGoodResults = append(allGoodResults, goodResults...)
 				// Remove processed logs and continue with remaining
-				processedLogIDs := make(map[int]bool)
-				for _, result := range goodResults {
-					for _, logID := range result.LogIDs {
-						processedLogIDs[logID] = true
-					}
-				}
-				logLines = p.removeProcessedLogs(logLines, logsToReparse, processedLogIDs)
+				logLines, weights, logsToReparse = p.removeProcessedLogs(logLines, weights, logsToReparse, goodResults)
 			}
 		}
 	}
@@ -538,7 +594,7 @@ func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allL
 		originalConfig.UseStatisticalThreshold = false
 		originalConfig.isReparsing = true
 
-		if results := p.tryReparseWithConfig(logLines, originalConfig); len(results) > 0 {
+		if results := p.tryReparseWithConfig(logLines, weights, logsToReparse, originalConfig); len(results) > 0 {
 			// For original Brain, accept any results (no further filtering)
 			allGoodResults = append(allGoodResults, results...)
 		}
@@ -546,32 +602,81 @@ func (p *BrainParser) reparseWithRelaxedSettings(badResults []*ParseResult, allL
 
 	// Return combined results, or original bad results if nothing worked
 	if len(allGoodResults) > 0 {
+		recordReparseSources(allGoodResults, badResults, allLogs)
 		return allGoodResults
 	}
 	return badResults
 }
 
-// removeProcessedLogs removes logs that have been successfully processed from the remaining log lines
-func (p *BrainParser) removeProcessedLogs(logLines []string, originalLogs []*LogMessage, processedLogIDs map[int]bool) []string {
+// recordReparseSources attaches to every re-parsed result an audit entry
+// listing the low-quality templates its lines came from with their counts
+func recordReparseSources(results, badResults []*ParseResult, allLogs []*LogMessage) {
+	source := make(map[int]int) // Log ID -> index into badResults
+	for i, bad := range badResults {
+		for _, logID := range bad.LogIDs {
+			source[logID] = i
+		}
+	}
+	for _, result := range results {
+		counts := make([]int, len(badResults))
+		for _, logID := range result.LogIDs {
+			if i, ok := source[logID]; ok && logID < len(allLogs) {
+				counts[i] += max(allLogs[logID].Weight, 1)
+			}
+		}
+		entry := &MergeAuditEntry{Reason: MergeReasonReparse}
+		for i, count := range counts {
+			if count > 0 {
+				entry.Sources = append(entry.Sources, badResults[i].Template)
+				entry.Counts = append(entry.Counts, count)
+			}
+		}
+		result.reparsed = entry
+	}
+}
+
+// removeProcessedLogs removes the logs of the results from the remaining log
+// lines, their weights and messages
+func (p *BrainParser) removeProcessedLogs(logLines []string, weights []int, logs []*LogMessage, results []*ParseResult) ([]string, []int, []*LogMessage) {
+	processed := make(map[int]bool)
+	for _, result := range results {
+		for _, logID := range result.LogIDs {
+			processed[logID] = true
+		}
+	}
 	var remaining []string
-	for i, log := range originalLogs {
-		if i < len(logLines) && !processedLogIDs[log.ID] {
+	var remainingWeights []int
+	var remainingLogs []*LogMessage
+	for i, log := range logs {
+		if !processed[log.ID] {
 			remaining = append(remaining, logLines[i])
+			remainingWeights = append(remainingWeights, lineWeight(weights, i))
+			remainingLogs = append(remainingLogs, log)
 		}
 	}
-	return remaining
+	return remaining, remainingWeights, remainingLogs
 }
 
-// tryReparseWithConfig attempts to reparse logs with given configuration
-func (p *BrainParser) tryReparseWithConfig(logLines []string, config Config) []*ParseResult {
+// tryReparseWithConfig attempts to reparse weighted logs with given
+// configuration. LogIDs of the results are mapped from line indices back to
+// the IDs of logs, which hold the messages of logLines.
+func (p *BrainParser) tryReparseWithConfig(logLines []string, weights []int, logs []*LogMessage, config Config) []*ParseResult {
 	// Create new parser with modified config
 	reparseParser := New(config)
-	return reparseParser.Parse(logLines)
+	results := reparseParser.ParseWeighted(logLines, weights).Results
+	for _, result := range results {
+		for j, line := range result.LogIDs {
+			if line < len(logs) {
+				result.LogIDs[j] = logs[line].ID
+			}
+		}
+	}
+	return results
 }
 
 // filterLowQualityTemplatesWithConfig is a helper for reparsing with specific config
 func (p *BrainParser) filterLowQualityTemplatesWithConfig(results []*ParseResult, config Config) (good []*ParseResult, bad []*ParseResult) {
 	// Create temporary parser with the config to use its quality check
-	tempParser := &BrainParser{config: config}
+	tempParser := &BrainParser{config: config, preprocessor: p.preprocessor}
 	return tempParser.filterLowQualityTemplates(results)
 }
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TemplateCluster is a group of similar templates, see ClusterTemplates.
+type TemplateCluster struct {
+	Representative   string   `json:"representative"`              // Most frequent template of the cluster
+	RepresentativeID string   `json:"representative_id,omitempty"` // ID of the representative
+	Templates        []string `json:"templates"`                   // Member templates, the representative first, then by similarity to it
+	Counts           []int    `json:"counts"`                      // Line counts aligned with Templates
+	Count            int      `json:"count"`                       // Lines of all members
+}
+
+// ClusterTemplates groups templates whose token-level similarity to the
+// representative of a cluster is at least threshold (0-1), to find logical
+// events fragmented into several templates, e.g. by different thresholds.
+// Similarity is 1 - the token edit distance relative to the longer template,
+// where <*> and the given named placeholders of Config.Placeholders, as
+// returned by BrainParser.Placeholders, substitute any token. Templates are
+// visited by count in descending order; each joins the most similar
+// representative reaching threshold or becomes one itself. Clusters are
+// sorted by count in descending order. It returns an error if threshold is
+// outside 0-1.
+func ClusterTemplates(results []*ParseResult, threshold float64, placeholders ...string) ([]TemplateCluster, error) {
+	if threshold < 0 || threshold > 1 {
+		return nil, fmt.Errorf("invalid cluster threshold %g: must be in [0, 1]", threshold)
+	}
+
+	slots := newPlaceholderSlots(placeholders)
+	ordered := make([]*ParseResult, len(results))
+	copy(ordered, results)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].Count != ordered[j].Count {
+			return ordered[i].Count > ordered[j].Count
+		}
+		return ordered[i].Template < ordered[j].Template
+	})
+
+	type member struct {
+		result     *ParseResult
+		similarity float64
+	}
+	var representatives [][]string
+	var members [][]member
+	for _, result := range ordered {
+		tokens := strings.Fields(result.Template)
+		best, bestSimilarity := -1, 0.0
+		for i, representative := range representatives {
+			// The distance is at least the difference in length
+			shortest := min(len(tokens), len(representative))
+			longest := max(len(tokens), len(representative))
+			if longest > 0 && float64(shortest)/float64(longest) < threshold {
+				continue
+			}
+			similarity := templateSimilarity(representative, tokens, slots)
+			if similarity >= threshold && similarity > bestSimilarity {
+				best, bestSimilarity = i, similarity
+			}
+		}
+		if best < 0 {
+			representatives = append(representatives, tokens)
+			members = append(members, []member{{result, 1}})
+			continue
+		}
+		members[best] = append(members[best], member{result, bestSimilarity})
+	}
+
+	clusters := make([]TemplateCluster, len(members))
+	for i, cluster := range members {
+		sort.SliceStable(cluster[1:], func(a, b int) bool {
+			return cluster[1+a].similarity > cluster[1+b].similarity
+		})
+		clusters[i] = TemplateCluster{
+			Representative:   cluster[0].result.Template,
+			RepresentativeID: cluster[0].result.ID,
+		}
+		for _, m := range cluster {
+			clusters[i].Templates = append(clusters[i].Templates, m.result.Template)
+			clusters[i].Counts = append(clusters[i].Counts, m.result.Count)
+			clusters[i].Count += m.result.Count
+		}
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Count > clusters[j].Count
+	})
+	return clusters, nil
+}
+
+// templateSimilarity returns 1 - the token edit distance between two
+// templates relative to the longer one, with <*> and named placeholders of
+// slots on either side substituting any token
+func templateSimilarity(a, b []string, slots placeholderSlots) float64 {
+	longest := max(len(a), len(b))
+	if longest == 0 {
+		return 1
+	}
+	distance := editDistance(a, b, func(x, y string) bool {
+		return x == y || slots.isSlot(x) || slots.isSlot(y)
+	})
+	return 1 - float64(distance)/float64(longest)
+}
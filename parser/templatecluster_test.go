@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClusterTemplates(t *testing.T) {
+	results := []*ParseResult{
+		{ID: "a", Template: "Connection to <*> failed", Count: 10},
+		{ID: "b", Template: "Connection to <*> failed after <*> retries", Count: 2},
+		{ID: "c", Template: "Connection to db1 failed", Count: 3},
+		{ID: "d", Template: "Disk <*> is full", Count: 4},
+		{ID: "e", Template: "Disk <ORDER> is full", Count: 1},
+	}
+	clusters, err := ClusterTemplates(results, 0.55)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %+v", clusters)
+	}
+	first := clusters[0]
+	if first.Representative != "Connection to <*> failed" || first.RepresentativeID != "a" || first.Count != 15 {
+		t.Errorf("Unexpected first cluster %+v", first)
+	}
+	// The identical-length template is more similar than the longer one
+	expected := []string{"Connection to <*> failed", "Connection to db1 failed", "Connection to <*> failed after <*> retries"}
+	if !reflect.DeepEqual(first.Templates, expected) || !reflect.DeepEqual(first.Counts, []int{10, 3, 2}) {
+		t.Errorf("Expected %v with counts 10 3 2, got %v %v", expected, first.Templates, first.Counts)
+	}
+	if clusters[1].Count != 5 || len(clusters[1].Templates) != 2 {
+		t.Errorf("Expected named placeholders to match <*>, got %+v", clusters[1])
+	}
+
+	// A threshold of 1 keeps only templates equal up to placeholders together
+	clusters, _ = ClusterTemplates(results, 1)
+	if len(clusters) != 3 {
+		t.Errorf("Expected 3 clusters at threshold 1, got %+v", clusters)
+	}
+
+	if _, err := ClusterTemplates(results, 1.5); err == nil {
+		t.Error("Expected an error for a threshold above 1")
+	}
+}
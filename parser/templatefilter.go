@@ -0,0 +1,64 @@
+package parser
+
+import "regexp"
+
+// templateFilter enforces Config.TemplateAllowPatterns and
+// Config.TemplateDenyPatterns on final templates.
+type templateFilter struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+// newTemplateFilter compiles the allow and deny lists, returning nil if both are empty
+func newTemplateFilter(allow, deny []string) *templateFilter {
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil
+	}
+	f := &templateFilter{}
+	for _, pattern := range allow {
+		f.allow = append(f.allow, regexp.MustCompile(pattern))
+	}
+	for _, pattern := range deny {
+		f.deny = append(f.deny, regexp.MustCompile(pattern))
+	}
+	return f
+}
+
+// keep reports whether a template passes the filter. Deny patterns take
+// precedence over allow patterns.
+func (f *templateFilter) keep(template string) bool {
+	for _, re := range f.deny {
+		if re.MatchString(template) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, re := range f.allow {
+		if re.MatchString(template) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply removes filtered templates in place, keeping the order of the rest.
+// Removed entries are released back to the pools.
+func (f *templateFilter) apply(results Results) Results {
+	if f == nil {
+		return results
+	}
+	kept := results[:0]
+	for _, result := range results {
+		if f.keep(result.Template) {
+			kept = append(kept, result)
+			continue
+		}
+		PutIntSlice(result.LogIDs)
+		result.LogIDs = nil
+		PutParseResult(result)
+	}
+	clear(results[len(kept):])
+	return kept
+}
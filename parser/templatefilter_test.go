@@ -0,0 +1,54 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateFilter(t *testing.T) {
+	logLines := []string{
+		"GET /healthcheck 200",
+		"GET /status 200",
+		"User alice logged in",
+		"User bob logged in",
+		"Disk full on volume data",
+	}
+
+	parser := New(Config{Delimiters: `\s+`, TemplateDenyPatterns: []string{`^GET `}})
+	for _, result := range parser.Parse(logLines) {
+		if strings.HasPrefix(result.Template, "GET ") {
+			t.Errorf("denied template %q returned", result.Template)
+		}
+	}
+
+	parser = New(Config{
+		Delimiters:            `\s+`,
+		TemplateAllowPatterns: []string{`^User `, `^GET `},
+		TemplateDenyPatterns:  []string{`^GET `}, // Deny wins over allow
+	})
+	userLines := func(results []*ParseResult) int {
+		total := 0
+		for _, result := range results {
+			if !strings.HasPrefix(result.Template, "User ") {
+				t.Errorf("template %q is not allowed", result.Template)
+			}
+			total += result.Count
+		}
+		return total
+	}
+	if got := userLines(parser.Parse(logLines)); got != 2 {
+		t.Errorf("Parse: expected 2 allowed lines, got %d", got)
+	}
+
+	// ParseInto and two-pass parsing apply the same filter
+	if got := userLines(parser.ParseInto(logLines, nil)); got != 2 {
+		t.Errorf("ParseInto: expected 2 allowed lines, got %d", got)
+	}
+	twoPass := parser.ParseTwoPass(append(logLines, logLines...), TwoPassOptions{SampleSize: 5})
+	if got := userLines(twoPass.Results); got != 4 {
+		t.Errorf("ParseTwoPass: expected 4 allowed lines, got %d", got)
+	}
+	if len(twoPass.Warnings) != 0 {
+		t.Errorf("ParseTwoPass: denied lines must not trigger a residual pass, got %v", twoPass.Warnings)
+	}
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// TemplateIDFunc returns the identifier of a template, e.g. a hash of its
+// canonical form or an ID from an external template registry. It is called
+// for every final template and must be safe for concurrent use.
+type TemplateIDFunc func(template string) string
+
+// HashTemplateID is the default TemplateIDFunc. It returns the 64-bit FNV-1a
+// hash of the template with normalized whitespace as 16 hex digits, so the
+// same template has the same ID across runs and processes.
+func HashTemplateID(template string) string {
+	h := fnv.New64a()
+	for i, token := range strings.Fields(template) {
+		if i > 0 {
+			h.Write([]byte{' '})
+		}
+		h.Write([]byte(token))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// SequentialTemplateIDs returns a TemplateIDFunc assigning prefix1, prefix2,
+// ... to templates in the order they are first seen.
+func SequentialTemplateIDs(prefix string) TemplateIDFunc {
+	var mu sync.Mutex
+	ids := make(map[string]string)
+	return func(template string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if id, ok := ids[template]; ok {
+			return id
+		}
+		id := fmt.Sprintf("%s%d", prefix, len(ids)+1)
+		ids[template] = id
+		return id
+	}
+}
+
+// assignTemplateIDs sets the ID of every result
+func (p *BrainParser) assignTemplateIDs(results []*ParseResult) {
+	for _, result := range results {
+		result.ID = p.config.TemplateID(result.Template)
+	}
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateIDGenerator(t *testing.T) {
+	logLines := []string{
+		"User alice logged in from web",
+		"User bob logged in from web",
+		"Backup finished",
+	}
+
+	ids := SequentialTemplateIDs("tpl-")
+	parser := New(Config{Delimiters: `\s+`, Deterministic: true, TemplateID: ids})
+	results := parser.Parse(logLines)
+
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if !strings.HasPrefix(result.ID, "tpl-") || seen[result.ID] {
+			t.Errorf("Unexpected or duplicate ID %q for %q", result.ID, result.Template)
+		}
+		seen[result.ID] = true
+	}
+
+	// IDs are stable across runs sharing the generator and set by Match
+	again := parser.Parse(logLines)
+	for i := range results {
+		if again[i].ID != results[i].ID {
+			t.Errorf("ID of %q changed from %q to %q", results[i].Template, results[i].ID, again[i].ID)
+		}
+	}
+	match, ok := parser.Match("Backup finished")
+	if !ok {
+		t.Fatal("Expected known line to match")
+	}
+	for _, result := range results {
+		if result.Template == match.Template && result.ID != match.ID {
+			t.Errorf("Expected match ID %q, got %q", result.ID, match.ID)
+		}
+	}
+
+	// Without a generator IDs are stable hashes of the template
+	for _, result := range New(Config{Delimiters: `\s+`}).Parse(logLines) {
+		if result.ID != HashTemplateID(result.Template) || len(result.ID) != 16 {
+			t.Errorf("Expected hash ID for %q, got %q", result.Template, result.ID)
+		}
+	}
+}
+
+func TestHashTemplateID(t *testing.T) {
+	id := HashTemplateID("User <*> logged in")
+	if id != HashTemplateID("User  <*>\tlogged in ") {
+		t.Error("Expected whitespace to be normalized")
+	}
+	if id == HashTemplateID("User <*> logged out") {
+		t.Error("Expected different templates to have different IDs")
+	}
+	if id != "04302501649c0393" {
+		t.Errorf("Expected ID to be stable across releases, got %q", id)
+	}
+}
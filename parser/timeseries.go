@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// maxTimeWindows limits the windows of a time series, so that a tiny window
+// over a long time span fails instead of exhausting memory
+const maxTimeWindows = 100000
+
+// TemplateTimeSeries is the count of every template per time window.
+type TemplateTimeSeries struct {
+	Window  time.Duration    // Length of the windows
+	Starts  []time.Time      // Start of every window in ascending order, without gaps
+	Series  []TemplateSeries // Counts per template, in the order of the results
+	Untimed int              // Lines without a timestamp, not counted in any window
+}
+
+// TemplateSeries is the count of a template per window of a
+// TemplateTimeSeries.
+type TemplateSeries struct {
+	TemplateID string `json:"template_id"`
+	Template   string `json:"template"`
+	Counts     []int  `json:"counts"` // Aligned with TemplateTimeSeries.Starts
+}
+
+// BucketByTime counts the lines of every result per window of the given
+// length, e.g. 5 minutes, by the timestamps extractor finds in lines.
+// Windows are aligned to multiples of window since the zero time, so 5
+// minute windows start at :00, :05 and so on in UTC. LogIDs of results must
+// index into lines, as returned by Parse.
+func BucketByTime(results []*ParseResult, lines []string, extractor TimestampExtractor, window time.Duration) (*TemplateTimeSeries, error) {
+	if extractor == nil {
+		return nil, errors.New("timestamp extractor is required")
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid time window %s: must be positive", window)
+	}
+
+	series := &TemplateTimeSeries{Window: window}
+	starts := make([][]time.Time, len(results)) // Window of every timed line per result
+	var first, last time.Time
+	for i, result := range results {
+		for _, id := range result.LogIDs {
+			if id < 0 || id >= len(lines) {
+				continue
+			}
+			timestamp, ok := extractor.Timestamp(lines[id])
+			if !ok {
+				series.Untimed++
+				continue
+			}
+			start := timestamp.UTC().Truncate(window)
+			if first.IsZero() || start.Before(first) {
+				first = start
+			}
+			if start.After(last) {
+				last = start
+			}
+			starts[i] = append(starts[i], start)
+		}
+	}
+
+	if !first.IsZero() {
+		windows := last.Sub(first)/window + 1
+		if windows > maxTimeWindows {
+			return nil, fmt.Errorf("time window %s is too small: %s to %s needs %d windows (at most %d)",
+				window, first.Format(time.RFC3339), last.Format(time.RFC3339), windows, maxTimeWindows)
+		}
+		series.Starts = make([]time.Time, windows)
+		for i := range series.Starts {
+			series.Starts[i] = first.Add(time.Duration(i) * window)
+		}
+	}
+	series.Series = make([]TemplateSeries, len(results))
+	for i, result := range results {
+		counts := make([]int, len(series.Starts))
+		for _, start := range starts[i] {
+			counts[start.Sub(first)/window]++
+		}
+		series.Series[i] = TemplateSeries{TemplateID: result.ID, Template: result.Template, Counts: counts}
+	}
+	return series, nil
+}
+
+// TimeSeries counts the lines of every result per window by the timestamps
+// of Config.Timestamps, see BucketByTime.
+func (p *BrainParser) TimeSeries(results []*ParseResult, lines []string, window time.Duration) (*TemplateTimeSeries, error) {
+	if p.config.Timestamps == nil {
+		return nil, errors.New("time series require Config.Timestamps")
+	}
+	return BucketByTime(results, lines, p.config.Timestamps, window)
+}
+
+// ExportTimeSeriesCSV writes a time series in long format, one row per
+// window and template including empty windows, with the columns
+// window_start (RFC 3339), template_id, template and count.
+func ExportTimeSeriesCSV(w io.Writer, series *TemplateTimeSeries) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"window_start", "template_id", "template", "count"}); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+	for i, start := range series.Starts {
+		windowStart := start.Format(time.RFC3339)
+		for _, template := range series.Series {
+			record := []string{windowStart, template.TemplateID, template.Template, strconv.Itoa(template.Counts[i])}
+			if err := writer.Write(record); err != nil {
+				return fmt.Errorf("failed to write time series: %w", err)
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+	return nil
+}
+
+// timeSeriesDocument is the JSON form of a TemplateTimeSeries
+type timeSeriesDocument struct {
+	Window  string           `json:"window"` // Go duration, e.g. "5m0s"
+	Starts  []time.Time      `json:"starts"`
+	Series  []TemplateSeries `json:"series"`
+	Untimed int              `json:"untimed"`
+}
+
+// ExportTimeSeriesJSON writes a time series as one JSON document with the
+// window length, the window starts and the counts of every template aligned
+// with them.
+func ExportTimeSeriesJSON(w io.Writer, series *TemplateTimeSeries) error {
+	document := timeSeriesDocument{
+		Window:  series.Window.String(),
+		Starts:  series.Starts,
+		Series:  series.Series,
+		Untimed: series.Untimed,
+	}
+	if document.Starts == nil {
+		document.Starts = []time.Time{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false) // Keep <*> readable
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return fmt.Errorf("failed to write time series: %w", err)
+	}
+	return nil
+}
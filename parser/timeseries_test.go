@@ -0,0 +1,71 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBucketByTime(t *testing.T) {
+	extractor, err := NewTimestampExtractor(`^\S+`, time.RFC3339)
+	if err != nil {
+		t.Fatalf("NewTimestampExtractor error: %v", err)
+	}
+	lines := []string{
+		"2024-05-01T12:01:00Z User alice logged in",
+		"2024-05-01T12:04:59Z User bob logged in",
+		"2024-05-01T12:16:00Z User carol logged in",
+		"2024-05-01T14:12:00+02:00 Disk full",
+		"Disk full without time",
+	}
+	results := []*ParseResult{
+		{ID: "a", Template: "<*> User <*> logged in", Count: 3, LogIDs: []int{0, 1, 2}},
+		{ID: "b", Template: "<*> Disk full", Count: 2, LogIDs: []int{3, 4}},
+	}
+	series, err := BucketByTime(results, lines, extractor, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("BucketByTime error: %v", err)
+	}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if len(series.Starts) != 4 || !series.Starts[0].Equal(start) || !series.Starts[3].Equal(start.Add(15*time.Minute)) {
+		t.Fatalf("Expected 4 contiguous windows from 12:00, got %v", series.Starts)
+	}
+	if got := series.Series[0].Counts; len(got) != 4 || got[0] != 2 || got[1] != 0 || got[3] != 1 {
+		t.Errorf("Unexpected user counts %v", got)
+	}
+	if got := series.Series[1].Counts; got[2] != 1 || series.Untimed != 1 {
+		t.Errorf("Unexpected disk counts %v with %d untimed lines", got, series.Untimed)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportTimeSeriesCSV(&buf, series); err != nil {
+		t.Fatalf("ExportTimeSeriesCSV error: %v", err)
+	}
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) != 9 || rows[1] != "2024-05-01T12:00:00Z,a,<*> User <*> logged in,2" {
+		t.Errorf("Unexpected CSV:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := ExportTimeSeriesJSON(&buf, series); err != nil {
+		t.Fatalf("ExportTimeSeriesJSON error: %v", err)
+	}
+	var document struct {
+		Window string `json:"window"`
+		Series []struct {
+			Counts []int `json:"counts"`
+		} `json:"series"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &document); err != nil || document.Window != "5m0s" || len(document.Series[1].Counts) != 4 {
+		t.Errorf("Unexpected JSON (%v):\n%s", err, buf.String())
+	}
+
+	if _, err := BucketByTime(results, lines, extractor, time.Millisecond); err == nil {
+		t.Error("Expected an error for too many windows")
+	}
+	if _, err := New(Config{}).TimeSeries(results, lines, time.Minute); err == nil {
+		t.Error("Expected an error without Config.Timestamps")
+	}
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Layouts of numeric timestamps for NewTimestampExtractor
+const (
+	LayoutUnix      = "unix"   // Seconds since the Unix epoch, optionally with a fraction
+	LayoutUnixMilli = "unixms" // Milliseconds since the Unix epoch
+)
+
+// TimestampExtractor returns the event time of a log line. It lets
+// time-based features follow the time written in the logs instead of the
+// clock (see Config.Timestamps) and must be safe for concurrent use.
+type TimestampExtractor interface {
+	Timestamp(line string) (time.Time, bool)
+}
+
+// TimestampFunc adapts a function to a TimestampExtractor.
+type TimestampFunc func(line string) (time.Time, bool)
+
+// Timestamp implements TimestampExtractor.
+func (f TimestampFunc) Timestamp(line string) (time.Time, bool) {
+	return f(line)
+}
+
+// regexTimestamps extracts timestamps with a regex and a layout
+type regexTimestamps struct {
+	regex  *regexp.Regexp
+	group  int // Submatch holding the timestamp (0 = whole match)
+	layout string
+}
+
+// NewTimestampExtractor returns a TimestampExtractor that finds the
+// timestamp of a line with pattern, in its "timestamp" capture group if it
+// has one and otherwise in the whole match, and parses it with layout: a
+// time.Parse layout like time.RFC3339, LayoutUnix or LayoutUnixMilli.
+// Timestamps without a zone are read as UTC.
+func NewTimestampExtractor(pattern, layout string) (TimestampExtractor, error) {
+	if layout == "" {
+		return nil, fmt.Errorf("timestamp layout is required")
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp regex: %w", err)
+	}
+	group := regex.SubexpIndex("timestamp")
+	if group < 0 {
+		group = 0
+	}
+	return &regexTimestamps{regex: regex, group: group, layout: layout}, nil
+}
+
+// Timestamp implements TimestampExtractor.
+func (rt *regexTimestamps) Timestamp(line string) (time.Time, bool) {
+	match := rt.regex.FindStringSubmatchIndex(line)
+	if match == nil || match[2*rt.group] < 0 {
+		return time.Time{}, false
+	}
+	value := line[match[2*rt.group]:match[2*rt.group+1]]
+
+	switch rt.layout {
+	case LayoutUnix:
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(int64(seconds * 1000)).UTC(), true
+	case LayoutUnixMilli:
+		milliseconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return time.UnixMilli(milliseconds).UTC(), true
+	}
+	timestamp, err := time.Parse(rt.layout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return timestamp, true
+}
+
+// now returns the current time of Config.Clock
+func (p *BrainParser) now() time.Time {
+	return p.config.Clock()
+}
+
+// latestTimestamp returns the latest event time of lines by
+// Config.Timestamps. ok is false if no extractor is set or no line has a
+// timestamp.
+func (p *BrainParser) latestTimestamp(lines []string) (latest time.Time, ok bool) {
+	if p.config.Timestamps == nil {
+		return time.Time{}, false
+	}
+	for _, line := range lines {
+		if timestamp, found := p.config.Timestamps.Timestamp(line); found && (!ok || timestamp.After(latest)) {
+			latest, ok = timestamp, true
+		}
+	}
+	return latest, ok
+}
+
+// seenTimes returns the time every result was last seen in lines: the
+// latest event time of its lines by Config.Timestamps, or the clock
+func (p *BrainParser) seenTimes(results []*ParseResult, lines []string) []time.Time {
+	if p.state == nil {
+		return nil // Nothing is recorded
+	}
+	now := p.now()
+	times := make([]time.Time, len(results))
+	var members []string
+	for i, result := range results {
+		times[i] = now
+		if p.config.Timestamps == nil {
+			continue
+		}
+		members = members[:0]
+		for _, id := range result.LogIDs {
+			if id >= 0 && id < len(lines) {
+				members = append(members, lines[id])
+			}
+		}
+		if timestamp, ok := p.latestTimestamp(members); ok {
+			times[i] = timestamp
+		}
+	}
+	return times
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampExtractor(t *testing.T) {
+	tests := []struct {
+		pattern, layout, line string
+		expected              time.Time
+	}{
+		{`^\S+`, time.RFC3339, "2026-05-01T10:00:00Z User alice logged in", time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{`\[(?P<timestamp>[^\]]+)\]`, "02/Jan/2006:15:04:05 -0700", `10.0.0.1 - - [01/May/2026:12:00:00 +0200] "GET /"`, time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{`ts=(?P<timestamp>\S+)`, LayoutUnix, "ts=1777629600.5 msg=done", time.UnixMilli(1777629600500)},
+		{`ts=(?P<timestamp>\d+)`, LayoutUnixMilli, "ts=1777629600500 msg=done", time.UnixMilli(1777629600500)},
+	}
+	for _, test := range tests {
+		extractor, err := NewTimestampExtractor(test.pattern, test.layout)
+		if err != nil {
+			t.Fatalf("NewTimestampExtractor(%q) error: %v", test.pattern, err)
+		}
+		timestamp, ok := extractor.Timestamp(test.line)
+		if !ok || !timestamp.Equal(test.expected) {
+			t.Errorf("Timestamp(%q) = %v, %v, expected %v", test.line, timestamp, ok, test.expected)
+		}
+	}
+
+	extractor, _ := NewTimestampExtractor(`^\S+`, time.RFC3339)
+	if _, ok := extractor.Timestamp("User alice logged in"); ok {
+		t.Error("Expected no timestamp for a line without one")
+	}
+	if _, err := NewTimestampExtractor(`(`, time.RFC3339); err == nil {
+		t.Error("Expected error for invalid regex")
+	}
+	if _, err := NewTimestampExtractor(`\S+`, ""); err == nil {
+		t.Error("Expected error for missing layout")
+	}
+}
+
+func TestConfigClock(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	parser := New(Config{Delimiters: `\s+`, Clock: func() time.Time { return now }})
+	parser.Parse([]string{"Job alpha done", "Job beta done", "Job gamma done"})
+
+	retired := parser.RetirementReport([]string{"Disk full"})
+	if len(retired) == 0 || retired[0].LastSeen == nil || !retired[0].LastSeen.Equal(now) {
+		t.Errorf("Expected last occurrence at the injected clock time, got %+v", retired)
+	}
+}
+
+func TestOnlineParserTimestamps(t *testing.T) {
+	extractor, err := NewTimestampExtractor(`^\S+`, time.RFC3339)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC) // Far after the replayed logs
+	op := NewOnlineParser(Config{
+		Delimiters: `\s+`,
+		Clock:      func() time.Time { return clock },
+		Timestamps: extractor,
+	})
+
+	op.Add([]string{
+		"2026-05-01T10:00:00Z Cache warmed in 12 ms",
+		"2026-05-01T10:00:01Z Cache warmed in 15 ms",
+		"2026-05-01T10:00:02Z Cache warmed in 17 ms",
+	})
+	op.Add([]string{
+		"2026-05-01T11:00:00Z User alice logged in",
+		"2026-05-01T11:00:01Z User bob logged in",
+		"2026-05-01T11:00:02Z User carol logged in",
+	})
+
+	var cache string
+	for _, result := range op.Snapshot() {
+		seen, ok := op.LastSeen(result.Template)
+		if !ok || seen.Year() != 2026 {
+			t.Errorf("Expected last occurrence by log time for %q, got %v", result.Template, seen)
+		}
+		if seen.Hour() == 10 {
+			cache = result.Template
+		}
+	}
+
+	// By log time the first template is idle for an hour; by the clock
+	// both would be idle for years
+	expired, err := op.Expire(30 * time.Minute)
+	if err != nil {
+		t.Fatalf("Expire error: %v", err)
+	}
+	if len(expired) != 1 || expired[0] != cache {
+		t.Errorf("Expected only %q to expire, got %v", cache, expired)
+	}
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tokenCluster is a learned template of DrainParser or SpellParser with its
+// accumulated count
+type tokenCluster struct {
+	tokens []string
+	count  int
+}
+
+// savedTokenTemplate is a saved tokenCluster, with its tokens as templates
+// may contain protected spaces of datetimes
+type savedTokenTemplate struct {
+	Tokens []string `json:"tokens"`
+	Count  int      `json:"count"`
+}
+
+// validateTokenPatterns checks the regexes a Preprocessor compiles, which
+// panics on invalid ones
+func validateTokenPatterns(delimiters string, commonVariables map[string]string) error {
+	if _, err := regexp.Compile(delimiters); err != nil {
+		return fmt.Errorf("invalid delimiters: %w", err)
+	}
+	for name, pattern := range commonVariables {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid common variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// maskedTokens splits a line into words and masks common variables
+func (p *Preprocessor) maskedTokens(line string) []string {
+	normalized, _ := p.normalizeLine(line)
+	tokens := p.splitWithoutFiltering(normalized)
+	for i, token := range tokens {
+		tokens[i] = p.filterCommonVariables(token)
+	}
+	return tokens
+}
+
+// clusterResults returns the results of a batch, with the lines of every
+// cluster in members, sorted by count in descending order
+func clusterResults(order []*tokenCluster, members map[*tokenCluster][]int, logLines []string, templateID func(string) string) []*ParseResult {
+	results := make([]*ParseResult, len(order))
+	for i, cluster := range order {
+		template := strings.Join(cluster.tokens, " ")
+		results[i] = &ParseResult{
+			ID:       templateID(template),
+			Template: template,
+			Count:    len(members[cluster]),
+			LogIDs:   members[cluster],
+		}
+	}
+	inferSeverities(results, logLines)
+	sortByCount(results)
+	return results
+}
+
+// snapshotClusters returns the clusters with their accumulated counts and
+// severities inferred from the templates, sorted by count in descending order
+func snapshotClusters(clusters []*tokenCluster, templateID func(string) string) []*ParseResult {
+	results := make([]*ParseResult, len(clusters))
+	for i, cluster := range clusters {
+		template := strings.Join(cluster.tokens, " ")
+		results[i] = &ParseResult{
+			ID:       templateID(template),
+			Template: template,
+			Count:    cluster.count,
+			Severity: InferLineSeverity(template),
+		}
+	}
+	sortByCount(results)
+	return results
+}
+
+// clusterTemplates returns the templates of the clusters
+func clusterTemplates(clusters []*tokenCluster) []string {
+	templates := make([]string, len(clusters))
+	for i, cluster := range clusters {
+		templates[i] = strings.Join(cluster.tokens, " ")
+	}
+	return templates
+}
+
+// saveClusters returns the saved form of the clusters
+func saveClusters(clusters []*tokenCluster) []savedTokenTemplate {
+	saved := make([]savedTokenTemplate, len(clusters))
+	for i, cluster := range clusters {
+		saved[i] = savedTokenTemplate{Tokens: cluster.tokens, Count: cluster.count}
+	}
+	return saved
+}
+
+// sortByCount sorts results by count in descending order, keeping the order
+// of equal counts
+func sortByCount(results []*ParseResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Count > results[j].Count
+	})
+}
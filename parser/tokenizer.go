@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"regexp/syntax"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// delimiterSet is the byte table of a Delimiters regex that matches one or
+// more characters of a plain ASCII class, like [\s,:=]+. Lines are then
+// split by scanning bytes instead of replacing the regex matches and
+// splitting the result again.
+type delimiterSet struct {
+	table  [utf8.RuneSelf]bool
+	spaces bool // Only whitespace separates words, scanned with the SIMD kernels
+}
+
+// wordCounter scans lines split at whitespace with the SSE4.2 kernels if the
+// CPU has them. Words are mostly shorter than the 32 bytes of an AVX2 vector,
+// so the AVX2 kernels would leave them to the Go loop.
+var wordCounter = &SIMDWordCounter{capabilities: SIMDCapabilities{
+	HasSSE42: DetectSIMDCapabilities().HasSSE42,
+	Platform: runtime.GOARCH,
+}}
+
+// compileDelimiterSet returns the set of a delimiter regex that is an ASCII
+// character class or literal, optionally repeated with +, or nil for all
+// other regexes. Classes repeated with * also match empty strings, which
+// splits every character, so they keep the regex.
+func compileDelimiterSet(pattern string) *delimiterSet {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return nil
+	}
+	re = re.Simplify()
+	if re.Op == syntax.OpPlus {
+		re = re.Sub[0]
+	}
+	var ranges []rune
+	switch {
+	case re.Op == syntax.OpCharClass:
+		ranges = re.Rune
+	case re.Op == syntax.OpLiteral && len(re.Rune) == 1 && re.Flags&syntax.FoldCase == 0:
+		ranges = []rune{re.Rune[0], re.Rune[0]}
+	default:
+		return nil
+	}
+
+	set := new(delimiterSet)
+	for i := 0; i+1 < len(ranges); i += 2 {
+		if ranges[i+1] >= utf8.RuneSelf {
+			return nil
+		}
+		for r := ranges[i]; r <= ranges[i+1]; r++ {
+			set.table[r] = true
+		}
+	}
+	// The regex path splits the replaced line with strings.Fields, which
+	// also splits at whitespace the delimiters do not match
+	for _, c := range "\t\n\v\f\r " {
+		set.table[c] = true
+	}
+	set.spaces = wordCounter.hasKernels()
+	for c, separator := range set.table {
+		if separator && !unicode.IsSpace(rune(c)) {
+			set.spaces = false
+		}
+	}
+	return set
+}
+
+// split returns the words of line between delimiters and Unicode spaces as
+// substrings of line, with a single allocation for the result.
+func (set *delimiterSet) split(line string) []string {
+	if set.spaces {
+		return set.splitSpaces(line)
+	}
+	n := 0
+	for start, end := set.next(line, 0); start >= 0; start, end = set.next(line, end) {
+		n++
+	}
+	if n == 0 {
+		return []string{}
+	}
+	words := make([]string, 0, n)
+	for start, end := set.next(line, 0); start >= 0; start, end = set.next(line, end) {
+		words = append(words, line[start:end])
+	}
+	return words
+}
+
+// splitSpaces splits line at whitespace like split: CountWords sizes the
+// result and IndexDelimiter finds the end of every word, which is then cut
+// at the vertical tabs, form feeds and Unicode spaces the kernels skip.
+func (set *delimiterSet) splitSpaces(line string) []string {
+	words := make([]string, 0, wordCounter.CountWords(line))
+	for i := 0; ; {
+		for i < len(line) {
+			separator, width := set.at(line, i)
+			if !separator {
+				break
+			}
+			i += width
+		}
+		if i == len(line) {
+			return words
+		}
+		end := len(line)
+		if j := wordCounter.IndexDelimiter(line[i:]); j >= 0 {
+			end = i + j
+		}
+		if j := indexOtherSpace(line[i:end]); j >= 0 {
+			end = i + j
+		}
+		words = append(words, line[i:end])
+		i = end
+	}
+}
+
+// indexOtherSpace returns the index of the first whitespace in word that is
+// no delimiter of IndexDelimiter, or -1
+func indexOtherSpace(word string) int {
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; {
+		case c == '\v' || c == '\f':
+			return i
+		case c >= utf8.RuneSelf:
+			if j := strings.IndexFunc(word[i:], unicode.IsSpace); j >= 0 {
+				return i + j
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// next returns the bounds of the first word of line at or after from, or
+// -1 if there is none
+func (set *delimiterSet) next(line string, from int) (start, end int) {
+	i := from
+	for i < len(line) {
+		separator, width := set.at(line, i)
+		if !separator {
+			break
+		}
+		i += width
+	}
+	if i == len(line) {
+		return -1, -1
+	}
+	start = i
+	for i < len(line) {
+		separator, width := set.at(line, i)
+		if separator {
+			break
+		}
+		i += width
+	}
+	return start, i
+}
+
+// at reports whether the character at line[i] separates words, and its width
+func (set *delimiterSet) at(line string, i int) (bool, int) {
+	if c := line[i]; c < utf8.RuneSelf {
+		return set.table[c], 1
+	}
+	r, width := utf8.DecodeRuneInString(line[i:])
+	return unicode.IsSpace(r), width
+}
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompileDelimiterSet(t *testing.T) {
+	for pattern, simple := range map[string]bool{
+		`[\s,:=]+`: true,
+		`[\s,:=]`:  true,
+		`\s+`:      true,
+		`,`:        true,
+		`[a-c|]+`:  true,
+		`[\s,:=]*`: false, // Matches empty strings
+		`[^a-z]+`:  false, // Non-ASCII runes
+		`\s+|--`:   false,
+		`[\s→]+`:   false,
+		`(?i)x`:    false,
+		`[`:        false,
+	} {
+		if got := compileDelimiterSet(pattern) != nil; got != simple {
+			t.Errorf("compileDelimiterSet(%q) simple = %v, want %v", pattern, got, simple)
+		}
+	}
+
+	// Only whitespace sets are scanned with the kernels
+	if set := compileDelimiterSet(`\s+`); set.spaces != wordCounter.hasKernels() {
+		t.Errorf("Expected kernels for \\s+ = %v, got %v", wordCounter.hasKernels(), set.spaces)
+	}
+	if compileDelimiterSet(`[\s,:=]+`).spaces {
+		t.Error("Expected no kernels for [\\s,:=]+")
+	}
+}
+
+func TestDelimiterSetSplitMatchesRegex(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "7", " ", "\t", "\v", ",", ":", "=", "|", "-", "é", " ", " ", "\xff", "_DTSPACE_"}
+	for _, pattern := range []string{`[\s,:=]+`, `[\s,:=]`, `\s+`, `,`, `[|-]+`} {
+		fast := NewPreprocessor(pattern, nil)
+		regex := NewPreprocessor(pattern, nil)
+		regex.delimiterSet = nil
+		if fast.delimiterSet == nil {
+			t.Fatalf("Expected a delimiter set for %q", pattern)
+		}
+		for n := 0; n < 2000; n++ {
+			var line strings.Builder
+			for k := rng.Intn(30); k > 0; k-- {
+				line.WriteString(alphabet[rng.Intn(len(alphabet))])
+			}
+			got, want := fast.splitWithoutFiltering(line.String()), regex.splitWithoutFiltering(line.String())
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("split of %q with %q = %q, want %q", line.String(), pattern, got, want)
+			}
+		}
+	}
+}
+
+func TestDelimiterSetSplitAllocations(t *testing.T) {
+	line := "2024-01-15T10:30:45Z INFO user=alice action=login status=200 duration=15ms"
+	for _, pattern := range []string{`[\s,:=]+`, `\s+`} {
+		p := NewPreprocessor(pattern, nil)
+		if allocs := testing.AllocsPerRun(100, func() { p.splitWithoutFiltering(line) }); allocs > 1 {
+			t.Errorf("Expected at most 1 allocation per split with %q, got %v", pattern, allocs)
+		}
+	}
+}
+
+func BenchmarkSplitWithoutFiltering(b *testing.B) {
+	line := "2024-01-15T10:30:45Z INFO user=alice action=login from 192.168.1.10:5432 status=200 duration=15ms"
+	for _, bench := range []struct {
+		name       string
+		delimiters string
+		simple     bool
+	}{{"scanner", `[\s,:=]+`, true}, {"regex", `[\s,:=]+`, false}, {"spaces", `\s+`, true}} {
+		b.Run(bench.name, func(b *testing.B) {
+			p := NewPreprocessor(bench.delimiters, nil)
+			if !bench.simple {
+				p.delimiterSet = nil
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				p.splitWithoutFiltering(line)
+			}
+		})
+	}
+}
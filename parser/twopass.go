@@ -0,0 +1,286 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultTwoPassSampleSize is the number of lines learned on in the first pass
+const defaultTwoPassSampleSize = 10000
+
+// cancelCheckInterval is the number of lines matched between context checks
+const cancelCheckInterval = 1024
+
+// TwoPassOptions contains options for ParseTwoPass.
+type TwoPassOptions struct {
+	SampleSize int // Lines used to learn templates in the first pass (default: 10000)
+}
+
+// TemplateMatcher assigns log lines to the most specific of a set of
+// templates. Lines are compared word by word: a constant template token must
+// equal its word, <*> matches exactly one word, a named placeholder one word
+// of its variable and a trailing VariadicPlaceholder any number of remaining
+// words. A line with more or fewer words than a template never matches it.
+type TemplateMatcher struct {
+	templates []string
+	slots     placeholderSlots     // Named placeholders
+	tokens    [][]string           // Template tokens
+	order     []int                // Template indexes, most specific first
+	rank      []int                // Position of every template in order
+	fixed     map[matcherKey][]int // Templates without VariadicPlaceholder by word count and first token, most specific first
+	variadic  []int                // Templates ending in VariadicPlaceholder, most specific first
+}
+
+// matcherKey indexes templates by word count and first token, "" if the first
+// token is a placeholder
+type matcherKey struct {
+	words int
+	first string
+}
+
+// NewTemplateMatcher indexes the given templates for matching.
+// A line matched by several templates is assigned to the one with the most
+// constant characters. The named placeholders of Config.Placeholders used in
+// templates, as returned by BrainParser.Placeholders, match any word; other
+// tokens in angle brackets are constants.
+func NewTemplateMatcher(templates []string, placeholders ...string) (*TemplateMatcher, error) {
+	return newTemplateMatcher(templates, newPlaceholderSlots(placeholders)), nil
+}
+
+// newTemplateMatcher indexes templates with the named placeholders of slots
+func newTemplateMatcher(templates []string, slots placeholderSlots) *TemplateMatcher {
+	m := &TemplateMatcher{
+		templates: templates,
+		slots:     slots,
+		tokens:    make([][]string, len(templates)),
+		order:     make([]int, len(templates)),
+		rank:      make([]int, len(templates)),
+		fixed:     make(map[matcherKey][]int),
+	}
+	specificity := make([]int, len(templates))
+	for i, template := range templates {
+		m.order[i] = i
+		m.tokens[i] = strings.Fields(template)
+		for _, token := range m.tokens[i] {
+			if !slots.isSlot(token) {
+				specificity[i] += len(token)
+			}
+		}
+	}
+
+	sort.SliceStable(m.order, func(a, b int) bool {
+		ia, ib := m.order[a], m.order[b]
+		if specificity[ia] != specificity[ib] {
+			return specificity[ia] > specificity[ib]
+		}
+		return templates[ia] < templates[ib]
+	})
+	for rank, i := range m.order {
+		m.rank[i] = rank
+		tokens := m.tokens[i]
+		if len(tokens) > 0 && tokens[len(tokens)-1] == VariadicPlaceholder {
+			m.variadic = append(m.variadic, i)
+			continue
+		}
+		key := matcherKey{words: len(tokens)}
+		if len(tokens) > 0 && !slots.isSlot(tokens[0]) {
+			key.first = tokens[0]
+		}
+		m.fixed[key] = append(m.fixed[key], i)
+	}
+	return m
+}
+
+// Match returns the index of the template assigned to line, or -1 if no
+// template matches. The line is split into words at whitespace and the
+// default delimiters; parsers match with their own tokenization.
+func (m *TemplateMatcher) Match(line string) int {
+	return m.matchWords(splitApprox(line))
+}
+
+// matchWords returns the index of the most specific template matching words,
+// or -1 if none does
+func (m *TemplateMatcher) matchWords(words []string) int {
+	best := -1
+	consider := func(candidates []int) {
+		for _, i := range candidates {
+			if best >= 0 && m.rank[i] > m.rank[best] {
+				return // Less specific than the match found so far
+			}
+			if tokensMatch(m.tokens[i], words, m.slots) {
+				best = i
+				return
+			}
+		}
+	}
+	if len(words) > 0 {
+		consider(m.fixed[matcherKey{words: len(words), first: words[0]}])
+	}
+	consider(m.fixed[matcherKey{words: len(words)}])
+	consider(m.variadic)
+	return best
+}
+
+// tokensMatch reports whether every template token matches its word: equal
+// constants, any word for <*>, a word of its variable for a named placeholder
+// of slots and any remaining words for a trailing VariadicPlaceholder
+func tokensMatch(tokens, words []string, slots placeholderSlots) bool {
+	if n := len(tokens); n > 0 && tokens[n-1] == VariadicPlaceholder {
+		if len(words) < n-1 {
+			return false
+		}
+		tokens, words = tokens[:n-1], words[:n-1]
+	}
+	if len(tokens) != len(words) {
+		return false
+	}
+	for i, token := range tokens {
+		if !slots.matches(token, words[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseTwoPass learns templates on an evenly spaced sample of logLines and
+// then assigns every line to a learned template, so counts and LogIDs are
+// exact while tree building is bounded by the sample size. Lines matching no
+// learned template are parsed in an additional residual pass and reported in
+// ParseReport.Warnings. Inputs not larger than the sample are parsed directly.
+func (p *BrainParser) ParseTwoPass(logLines []string, opts TwoPassOptions) *ParseReport {
+	report, _ := p.ParseTwoPassContext(context.Background(), logLines, opts)
+	return report
+}
+
+// ParseTwoPassContext behaves like ParseTwoPass but stops early when ctx is
+// canceled or its deadline expires, returning ctx.Err() and no report.
+func (p *BrainParser) ParseTwoPassContext(ctx context.Context, logLines []string, opts TwoPassOptions) (*ParseReport, error) {
+	sampleSize := opts.SampleSize
+	if sampleSize <= 0 {
+		sampleSize = defaultTwoPassSampleSize
+	}
+	if len(logLines) <= sampleSize {
+		return p.ParseWithReportContext(ctx, logLines)
+	}
+
+	// First pass: learn templates on the sample
+	sample := make([]string, sampleSize)
+	for i := range sample {
+		sample[i] = logLines[i*len(logLines)/sampleSize]
+	}
+	// The sample is not reported: progress counts every line once, when it is
+	// matched or parsed in the residual pass
+	report := &ParseReport{progress: newProgressTracker(p.config.Progress, len(logLines))}
+	defer report.progress.finish()
+	learner := p.newLearner()
+	learned, err := learner.ParseWithReportContext(ctx, sample)
+	if err != nil {
+		return nil, err
+	}
+	report.Warnings = learned.Warnings
+	report.MergeAudit = learned.MergeAudit
+
+	templates := make([]string, len(learned.Results))
+	for i, result := range learned.Results {
+		templates[i] = result.Template
+	}
+	Results(learned.Results).Release()
+
+	matcher := newTemplateMatcher(templates, p.preprocessor.slots)
+
+	// Second pass: count every line exactly
+	residual, err := p.matchThenLearn(ctx, logLines, nil, templates, matcher, learner, report)
+	if err != nil {
+		return nil, err
+	}
+	if residual > 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(
+			"%d lines matched no template learned from a sample of %d and were parsed in a residual pass",
+			residual, sampleSize))
+	}
+	report.Results = p.finalizeResults(report.Results, logLines)
+	return report, nil
+}
+
+// newLearner returns a copy of p for the learning passes of matchThenLearn.
+// It learns unfiltered templates, so lines of denied templates are not
+// mistaken for residual lines, and leaves enrichment and progress to the
+// top-level parse, which finalizes the combined results once.
+func (p *BrainParser) newLearner() *BrainParser {
+	learner := *p
+	learner.templateFilter = nil
+	learner.state = nil
+	learner.config.Progress = nil
+	learner.config.TemplatePositions = false
+	learner.config.VariableStatistics = false
+	learner.config.ExamplesPerTemplate = 0
+	return &learner
+}
+
+// matchThenLearn assigns every weighted line to a template of matcher and
+// parses the remaining lines with learner, reporting both to the progress
+// tracker of report. The aggregated results are stored in report, the number
+// of parsed residual lines is returned. If ctx is canceled, ctx.Err() is
+// returned and report.Results is left empty.
+func (p *BrainParser) matchThenLearn(ctx context.Context, logLines []string, weights []int, templates []string, matcher *TemplateMatcher, learner *BrainParser, report *ParseReport) (int, error) {
+	counted := make([]*ParseResult, len(templates))
+	var residual []string
+	var residualIDs, residualWeights []int
+	matched := 0 // Lines matched since the last progress report
+	for id, line := range logLines {
+		if id%cancelCheckInterval == 0 {
+			if ctx.Err() != nil {
+				return 0, ctx.Err()
+			}
+			report.advance(3*matched, PhaseMatching) // A matched line passes all three phases
+			matched = 0
+		}
+		i := matcher.matchWords(p.lineWords(line))
+		if i < 0 {
+			residual = append(residual, line)
+			residualIDs = append(residualIDs, id)
+			residualWeights = append(residualWeights, lineWeight(weights, id))
+			continue
+		}
+		if counted[i] == nil {
+			counted[i] = &ParseResult{Template: templates[i]}
+		}
+		counted[i].Count += lineWeight(weights, id)
+		counted[i].LogIDs = append(counted[i].LogIDs, id)
+		matched++
+	}
+	report.advance(3*matched, PhaseMatching)
+
+	var results []*ParseResult
+	for _, result := range counted {
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+
+	// Residual pass: learn templates of lines no known template covers
+	var extra *ParseReport
+	if len(residual) > 0 {
+		var err error
+		if extra, err = learner.parseReport(ctx, residual, residualWeights, &ParseReport{progress: report.progress}); err != nil {
+			return 0, err
+		}
+		report.Warnings = append(report.Warnings, extra.Warnings...)
+		report.MergeAudit = append(report.MergeAudit, extra.MergeAudit...)
+		for _, result := range extra.Results {
+			for j, id := range result.LogIDs {
+				result.LogIDs[j] = residualIDs[id]
+			}
+			results = append(results, result)
+		}
+	}
+
+	report.Results = p.aggregateResultsInto(results, nil, report)
+	if extra != nil {
+		// Residual results were copied during aggregation
+		Results(extra.Results).Release()
+	}
+	return len(residual), nil
+}
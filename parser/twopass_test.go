@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+func TestTemplateMatcher(t *testing.T) {
+	matcher, err := NewTemplateMatcher([]string{
+		"User <*> logged in",
+		"User admin logged in",
+		"Connection to <*> failed",
+		"Connection reset by peer <*>",
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateMatcher error: %v", err)
+	}
+
+	tests := []struct {
+		line     string
+		expected int
+	}{
+		{"User alice logged in", 0},
+		{"User admin logged in", 1}, // Most specific template wins
+		{"Connection to db-5432 failed", 2},
+		{"Connection to db:5432 failed", -1}, // Two words at the default delimiters
+		{"User alice logged in twice", -1},   // Anchored at both ends
+		{"Connection reset by peer 5", 3},
+		// A <*> stands for exactly one word, so longer lines do not match
+		{"Connection reset by peer 5 while reading response header from upstream", -1},
+		{"Connection reset by peer", -1},
+		{"Disk full", -1},
+	}
+	for _, tt := range tests {
+		if got := matcher.Match(tt.line); got != tt.expected {
+			t.Errorf("Match(%q) = %d, want %d", tt.line, got, tt.expected)
+		}
+	}
+
+	// Tokens in angle brackets are constants unless named as placeholders
+	matcher, _ = NewTemplateMatcher([]string{"Unexpected <EOF> in <ORDER>"}, "<ORDER>")
+	if got := matcher.Match("Unexpected token in ORD-1"); got != -1 {
+		t.Errorf("Expected <EOF> to be a constant, got %d", got)
+	}
+	if got := matcher.Match("Unexpected <EOF> in ORD-1"); got != 0 {
+		t.Errorf("Expected <ORDER> to match any word, got %d", got)
+	}
+}
+
+func TestParseTwoPassExactCounts(t *testing.T) {
+	var logLines []string
+	for i := 0; i < 300; i++ {
+		logLines = append(logLines, fmt.Sprintf("User user%d logged in from host%d", i, i%7))
+		if i%3 == 0 {
+			logLines = append(logLines, fmt.Sprintf("Connection to server%d failed after %d retries", i, i%5))
+		}
+	}
+	// Rare line far away from the sample points
+	logLines = append(logLines, "Disk quota exceeded on volume data")
+
+	parser := New(Config{Deterministic: true})
+	report := parser.ParseTwoPass(logLines, TwoPassOptions{SampleSize: 50})
+
+	total := 0
+	var ids []int
+	for _, result := range report.Results {
+		if result.Count != len(result.LogIDs) {
+			t.Errorf("template %q: count %d != %d log IDs", result.Template, result.Count, len(result.LogIDs))
+		}
+		total += result.Count
+		ids = append(ids, result.LogIDs...)
+	}
+	if total != len(logLines) {
+		t.Fatalf("expected %d counted lines, got %d", len(logLines), total)
+	}
+	sort.Ints(ids)
+	for i, id := range ids {
+		if id != i {
+			t.Fatalf("log ID %d assigned %s", i, map[bool]string{true: "twice", false: "never"}[id < i])
+		}
+	}
+
+	if len(report.Warnings) == 0 {
+		t.Error("expected residual pass warning for the rare line")
+	}
+	found := false
+	for _, result := range report.Results {
+		if result.Count == 1 && result.LogIDs[0] == len(logLines)-1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("rare line was not learned in the residual pass")
+	}
+}
+
+func TestParseTwoPassSmallInput(t *testing.T) {
+	logLines := []string{"a 1", "a 2", "b x"}
+	parser := New(Config{Deterministic: true})
+	direct := parser.Parse(logLines)
+	twoPass := parser.ParseTwoPass(logLines, TwoPassOptions{SampleSize: 10}).Results
+	if len(direct) != len(twoPass) {
+		t.Fatalf("expected %d templates, got %d", len(direct), len(twoPass))
+	}
+	for i := range direct {
+		if direct[i].Template != twoPass[i].Template || direct[i].Count != twoPass[i].Count {
+			t.Errorf("result %d: %q/%d vs %q/%d", i, direct[i].Template, direct[i].Count, twoPass[i].Template, twoPass[i].Count)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"time"
 	"unique"
 )
 
@@ -9,13 +10,14 @@ type LogMessage struct {
 	ID      int                   // Original log index
 	Content unique.Handle[string] // Original content (interned)
 	Words   []Word                // Words the log is split into
+	Weight  int                   // Multiplicity of the line (0 = 1, set by ParseWeighted)
 }
 
 // Word represents one word in a log with its metadata.
 type Word struct {
 	Value     unique.Handle[string] // Text value of the word (interned)
 	Position  int                   // Position (index) in the log line
-	Frequency int                   // Global frequency of the word across all logs
+	Frequency int                   // Global frequency of the word across all logs, 0 for ignored tokens
 }
 
 // WordCombination - is a set of words from one log with the same frequency.
@@ -66,26 +68,110 @@ type BidirectionalTree struct {
 	LogGroups          map[string][]*LogMessage // Final groups of logs by templates
 	ParentColumns      []int                    // Columns that are in parent direction for iterative updates
 	RootPattern        LogPattern               // Original root pattern for reference
+	HighCardinality    []int                    // Child columns marked variable by Config.HighCardinalityLimit without splitting
 }
 
 // ParseResult represents the final result of parsing.
 type ParseResult struct {
-	Template string
-	Count    int
-	LogIDs   []int
+	ID         string // Template identifier assigned by Config.TemplateID (default: HashTemplateID)
+	Template   string
+	Count      int
+	LogIDs     []int
+	Severity   Severity      // Highest severity inferred from member lines
+	Params     [][]string    // Values of the <*> slots per entry of LogIDs (set by ParseWithParams)
+	Positions  []TokenInfo   // Per-token metadata of Template (set with Config.TemplatePositions)
+	Anomalies  []SlotAnomaly // Outlier slot values of a matched line (set by Match with Config.VariableStatistics)
+	Similarity float64       // Token similarity of a line to the template, below 1 for approximate matches (set by Match)
+	Examples   []string      // Up to Config.ExamplesPerTemplate sampled member lines in input order
+
+	origin   string           // Pattern of the group the template was generated from, for the merge audit
+	reparsed *MergeAuditEntry // Low-quality templates whose lines were re-parsed into this one
+}
+
+// ParseReport contains the results of a Parse call together with run metadata.
+type ParseReport struct {
+	Results     []*ParseResult
+	Profile     *ParseProfile       // Per-phase self-profiling data (nil unless Config.EnableProfiling)
+	Warnings    []string            // Diagnostics about degraded processing (e.g. overflow group merging)
+	MergeAudit  []MergeAuditEntry   // Record of every automated merge of templates or groups
+	FoldedLines int                 // Lines changed by Config.FoldUnicode
+	Violations  []TemplateViolation // New automatic changes approved templates were protected from (see BrainParser.Approve)
+
+	profiler *phaseProfiler
+	progress *progressTracker
 }
 
+// Merge reasons recorded in MergeAuditEntry.Reason
+const (
+	MergeReasonAggregation    = "aggregation"     // Identical templates produced by different groups
+	MergeReasonOverflow       = "overflow"        // Initial groups merged into a length bucket by MaxInitialGroups
+	MergeReasonSubsumed       = "subsumed"        // Template merged into one with <*> where it has a constant (Config.MergeSubsumedTemplates)
+	MergeReasonVariableLength = "variable-length" // Templates extending a shorter one by trailing tokens (Config.VariableLengthTokens)
+	MergeReasonReparse        = "reparse"         // Lines of low-quality templates re-parsed with relaxed settings (Config.UseEnhancedPostProcessing)
+)
+
+// MergeAuditEntry describes one automated merge.
+type MergeAuditEntry struct {
+	Reason  string   // Why the sources were combined (see MergeReason* constants)
+	Sources []string // Templates or group patterns that were combined
+	Counts  []int    // Log counts of the sources
+	Result  string   // Resulting template or bucket
+}
+
+// TieBreakStrategy selects how the Longest Common Pattern search resolves ties
+// between word combinations with the same number of words.
+type TieBreakStrategy int
+
+const (
+	TieBreakLength     TieBreakStrategy = iota // Prefer the larger total token length (default)
+	TieBreakContiguity                         // Prefer combinations whose positions are adjacent
+	TieBreakFrequency                          // Prefer the lower-frequency combination
+)
+
 // Config contains the configuration of the Brain algorithm.
 type Config struct {
-	Delimiters                  string            // Regex for splitting tokens
-	CommonVariables             map[string]string // Map of patterns for filtering common variables: "name" -> "regex"
-	ChildBranchThreshold        int               // Threshold for creating new branches in child direction (fallback value)
-	Weight                      float64           // Weight parameter for frequency threshold (0.0-1.0)
-	UseDynamicThreshold         bool              // Whether to use dynamic threshold calculation
-	DynamicThresholdFactor      float64           // Factor for dynamic threshold (default: 2.0)
-	UseEnhancedPostProcessing   bool              // Enable enhanced post-processing from Drain+ (default: false)
-	UseStatisticalThreshold     bool              // Use statistical analysis for threshold calculation (default: false)
-	ParallelProcessingThreshold int               // Minimum log count in group to enable parallel processing (default: 1000)
+	Delimiters                  string             // Regex for splitting tokens
+	CommonVariables             map[string]string  // Map of patterns for filtering common variables: "name" -> "regex"
+	Placeholders                map[string]string  // Tokens replacing common variables by name instead of <*>, kept in templates: "order_id" -> "<ORDER>"
+	ChildBranchThreshold        int                // Threshold for creating new branches in child direction (fallback value)
+	Weight                      float64            // Weight parameter for frequency threshold (0.0-1.0)
+	UseDynamicThreshold         bool               // Whether to use dynamic threshold calculation
+	DynamicThresholdFactor      float64            // Factor for dynamic threshold (default: 2.0)
+	UseEnhancedPostProcessing   bool               // Enable enhanced post-processing from Drain+ (default: false)
+	UseStatisticalThreshold     bool               // Use statistical analysis for threshold calculation (default: false)
+	ParallelProcessingThreshold int                // Minimum log count in group to enable parallel processing (default: 1000)
+	LCPTieBreak                 TieBreakStrategy   // Tie-breaking strategy for Longest Common Pattern selection (default: TieBreakLength)
+	ContiguityBonus             float64            // Extra score per adjacent word pair when selecting the Longest Common Pattern (default: 0 = disabled)
+	FrequencyTolerance          float64            // Relative tolerance for bucketing near-equal word frequencies (default: 0 = exact match)
+	IgnorePositions             []int              // Token positions excluded from frequency computation and grouping, <*> in templates (e.g. thread ID column)
+	IgnoreTokenPatterns         []string           // Regexes of tokens excluded from frequency computation and grouping, <*> in templates
+	Deterministic               bool               // Produce identical results and ordering across runs (ordered traversal, stable sorting)
+	Seed                        int64              // With Deterministic, permutes the order of equally ranked child columns reproducibly (default: 0 = position order)
+	EnableProfiling             bool               // Record per-phase wall time, allocations and peak heap in ParseReport.Profile
+	MaxInitialGroups            int                // Soft cap on initial group count, lines of later patterns go into one bucket per length (default: 0 = no limit)
+	HighCardinalityLimit        int                // Distinct words from which a child column is marked variable without splitting its logs (default: 1000)
+	UnicodeDigits               bool               // Count Unicode digits and digit group separators in numeric detection (default: ASCII digits only)
+	FoldUnicode                 bool               // Fold Unicode spaces, full-width ASCII, smart quotes and dashes to ASCII before tokenizing (NFKC-style)
+	TemplatePositions           bool               // Fill ParseResult.Positions with per-token metadata and inferred variable types
+	VariableStatistics          bool               // Learn numeric value statistics per template slot so Match flags outliers in ParseResult.Anomalies
+	AnomalyThreshold            float64            // Log-scale z-score above which Match flags a slot value (default: 4)
+	ApproximateMatch            float64            // Minimum similarity (0-1) of the closest template Match falls back to when none matches exactly (default: 0 = exact only)
+	TemplateAllowPatterns       []string           // If set, only final templates matching one of these regexes are returned
+	TemplateDenyPatterns        []string           // Final templates matching any of these regexes are dropped (e.g. "healthcheck")
+	TemplateID                  TemplateIDFunc     // Generator for ParseResult.ID of final templates (default: HashTemplateID, not serialized)
+	Frequencies                 *FrequencyTable    // Word frequencies shared and accumulated across Parse calls and parsers (default: nil = per call, not serialized)
+	Hooks                       *Hooks             // Callbacks inspecting or changing intermediate data of the pipeline (default: nil, not serialized)
+	PreTokenizeHooks            []PreTokenizeHook  // Rewrite every line in order before it is split, e.g. to strip ANSI codes (default: nil, not serialized)
+	PostTokenizeHooks           []PostTokenizeHook // Rewrite the words of every line in order after splitting, before ignore rules and masking (default: nil, not serialized)
+	Clock                       func() time.Time   // Source of the current time of last-seen times, expiry and audit events (default: time.Now, not serialized)
+	Timestamps                  TimestampExtractor // Event time of lines, so last-seen times, expiry and audit events follow log time instead of Clock (default: nil, not serialized)
+	Progress                    ProgressFunc       // Called as the phases of top-level parses advance, from the calling goroutine (default: nil, not serialized)
+	StablePartitioning          bool               // Route each group to one of a fixed number of parallel workers by a stable hash of its key, processed in sorted key order; implies the ordered traversal of Deterministic
+	PruneConstantColumns        bool               // Exclude leading columns constant across all lines from processing and re-insert them into templates
+	MergeSubsumedTemplates      bool               // Merge templates into a template equal but for <*> at one position where they have a constant
+	ExamplesPerTemplate         int                // Fill ParseResult.Examples with up to N reservoir-sampled member lines (default: 0 = none)
+	VariableLengthTokens        int                // Merge templates extending a shorter template by up to N trailing tokens into it, ending in <*>... (default: 0 = off)
+	QualityPolicy               string             // Named QualityPolicy (strict, balanced, lenient) gating final templates by shape and support (default: "" = none)
 
 	// Enhanced Features Tuning Parameters
 	EntropyThreshold        float64 // Threshold for entropy-based variable detection (default: 0.85, lower = more aggressive)
@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Validate checks the regexes and value ranges of the configuration that New
+// would otherwise panic on or silently misuse. All problems are reported,
+// joined into one error; nil means the configuration is valid.
+func (c Config) Validate() error {
+	var errs []error
+	checkRegex := func(field, pattern string) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", field, err))
+		}
+	}
+
+	if c.Delimiters != "" {
+		checkRegex("Delimiters", c.Delimiters)
+	}
+	for _, name := range sortedKeys(c.CommonVariables) {
+		checkRegex(fmt.Sprintf("CommonVariables[%q]", name), c.CommonVariables[name])
+	}
+	variables := c.CommonVariables
+	if variables == nil {
+		variables = getDefaultCommonVariables()
+	}
+	for _, name := range sortedKeys(c.Placeholders) {
+		placeholder := c.Placeholders[name]
+		switch {
+		case !isPlaceholderName(placeholder):
+			errs = append(errs, fmt.Errorf("invalid Placeholders[%q]: %q is not an upper-case name in angle brackets like <ORDER>", name, placeholder))
+		case variables[name] == "":
+			errs = append(errs, fmt.Errorf("invalid Placeholders[%q]: no common variable of that name", name))
+		}
+	}
+	for i, pattern := range c.IgnoreTokenPatterns {
+		checkRegex(fmt.Sprintf("IgnoreTokenPatterns[%d]", i), pattern)
+	}
+	for i, pattern := range c.TemplateAllowPatterns {
+		checkRegex(fmt.Sprintf("TemplateAllowPatterns[%d]", i), pattern)
+	}
+	for i, pattern := range c.TemplateDenyPatterns {
+		checkRegex(fmt.Sprintf("TemplateDenyPatterns[%d]", i), pattern)
+	}
+
+	for _, pos := range c.IgnorePositions {
+		if pos < 0 {
+			errs = append(errs, fmt.Errorf("invalid IgnorePositions: negative position %d", pos))
+		}
+	}
+	if c.ChildBranchThreshold < 0 {
+		errs = append(errs, fmt.Errorf("invalid ChildBranchThreshold: %d is negative", c.ChildBranchThreshold))
+	}
+	if c.ExamplesPerTemplate < 0 {
+		errs = append(errs, fmt.Errorf("invalid ExamplesPerTemplate: %d is negative", c.ExamplesPerTemplate))
+	}
+	if c.VariableLengthTokens < 0 {
+		errs = append(errs, fmt.Errorf("invalid VariableLengthTokens: %d is negative", c.VariableLengthTokens))
+	}
+	if c.ApproximateMatch < 0 || c.ApproximateMatch > 1 {
+		errs = append(errs, fmt.Errorf("invalid ApproximateMatch: %g is outside 0-1", c.ApproximateMatch))
+	}
+	if _, ok := LookupQualityPolicy(c.QualityPolicy); c.QualityPolicy != "" && !ok {
+		errs = append(errs, fmt.Errorf("invalid QualityPolicy: unknown policy %q (known: %s)", c.QualityPolicy, strings.Join(QualityPolicyNames(), ", ")))
+	}
+	if c.AnomalyThreshold < 0 {
+		errs = append(errs, fmt.Errorf("invalid AnomalyThreshold: %g is negative", c.AnomalyThreshold))
+	}
+	return errors.Join(errs...)
+}
+
+// NewWithError is like New but returns the error of Config.Validate instead
+// of panicking on an invalid configuration.
+func NewWithError(config Config) (*BrainParser, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return New(config), nil
+}
@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	if err := (Config{}).Validate(); err != nil {
+		t.Errorf("Expected zero config to be valid, got %v", err)
+	}
+	valid := Config{
+		Delimiters:            `[\s,;]+`,
+		CommonVariables:       getDefaultCommonVariables(),
+		IgnoreTokenPatterns:   []string{`^tid-\d+$`},
+		TemplateDenyPatterns:  []string{"healthcheck"},
+		TemplateAllowPatterns: []string{"^User"},
+		IgnorePositions:       []int{0, 2},
+		ApproximateMatch:      0.8,
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected config to be valid, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		field  string
+	}{
+		{"delimiters", Config{Delimiters: `[\s`}, "Delimiters"},
+		{"common variable", Config{CommonVariables: map[string]string{"ip": `(\d+`}}, `CommonVariables["ip"]`},
+		{"ignore tokens", Config{IgnoreTokenPatterns: []string{"ok", "*"}}, "IgnoreTokenPatterns[1]"},
+		{"allow", Config{TemplateAllowPatterns: []string{"("}}, "TemplateAllowPatterns[0]"},
+		{"deny", Config{TemplateDenyPatterns: []string{"["}}, "TemplateDenyPatterns[0]"},
+		{"ignore positions", Config{IgnorePositions: []int{-1}}, "IgnorePositions"},
+		{"approximate match", Config{ApproximateMatch: 1.5}, "ApproximateMatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if err == nil || !strings.Contains(err.Error(), "invalid "+tt.field) {
+				t.Errorf("Expected error about %s, got %v", tt.field, err)
+			}
+		})
+	}
+
+	// All problems are reported at once
+	err := Config{Delimiters: "(", TemplateDenyPatterns: []string{"["}}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Delimiters") || !strings.Contains(err.Error(), "TemplateDenyPatterns") {
+		t.Errorf("Expected both errors, got %v", err)
+	}
+}
+
+func TestNewWithError(t *testing.T) {
+	if _, err := NewWithError(Config{Delimiters: `[\s`}); err == nil {
+		t.Error("Expected error for invalid delimiters")
+	}
+	p, err := NewWithError(Config{Delimiters: `\s+`})
+	if err != nil {
+		t.Fatalf("NewWithError error: %v", err)
+	}
+	if results := p.Parse([]string{"User alice logged in", "User bob logged in"}); len(results) == 0 {
+		t.Error("Expected parser to work")
+	}
+
+	// LoadState reports an invalid saved config instead of panicking
+	if _, err := LoadState(strings.NewReader(`{"version":1,"config":{"delimiters":"[\\s"}}`)); err == nil {
+		t.Error("Expected LoadState error for invalid delimiters")
+	}
+}
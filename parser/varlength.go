@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// VariadicPlaceholder ends templates merged by Config.VariableLengthTokens
+// and stands for zero or more trailing tokens.
+const VariadicPlaceholder = "<*>..."
+
+// mergeVariableLength merges every result whose template extends a shorter
+// template by up to Config.VariableLengthTokens trailing tokens into it, as
+// the same event with optional trailing fields. The shorter template must
+// have at least one constant and match the start of the longer one, where
+// its placeholders match any token of their variable. Of several candidates the longest, then
+// the most frequent wins; chains end in the shortest template, which gets a
+// trailing VariadicPlaceholder. Merges are recorded in the audit log of
+// report if it is not nil; merged results are released.
+func (p *BrainParser) mergeVariableLength(results Results, report *ParseReport) Results {
+	if p.config.VariableLengthTokens <= 0 || p.config.isReparsing || len(results) < 2 {
+		return results
+	}
+
+	order := make([]*ParseResult, len(results))
+	copy(order, results)
+	tokens := make(map[*ParseResult][]string, len(results))
+	for _, result := range results {
+		tokens[result] = strings.Fields(result.Template)
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if len(tokens[a]) != len(tokens[b]) {
+			return len(tokens[a]) < len(tokens[b])
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Template < b.Template
+	})
+
+	root := make(map[*ParseResult]*ParseResult)
+	members := make(map[*ParseResult][]*ParseResult)
+	var roots []*ParseResult
+	for i, result := range order {
+		words := tokens[result]
+		var base *ParseResult
+		for _, candidate := range order[:i] {
+			prefix := tokens[candidate]
+			if len(prefix) == len(words) || len(words)-len(prefix) > p.config.VariableLengthTokens {
+				continue
+			}
+			if isVariablePrefix(prefix, words, p.preprocessor.slots) && (base == nil || len(prefix) > len(tokens[base]) ||
+				len(prefix) == len(tokens[base]) && candidate.Count > base.Count) {
+				base = candidate
+			}
+		}
+		if base == nil {
+			root[result] = result
+			roots = append(roots, result)
+			continue
+		}
+		root[result] = root[base]
+		members[root[base]] = append(members[root[base]], result)
+	}
+	if len(members) == 0 {
+		return results
+	}
+
+	for _, target := range roots {
+		merged := members[target]
+		if len(merged) == 0 {
+			continue
+		}
+		entry := MergeAuditEntry{
+			Reason:  MergeReasonVariableLength,
+			Sources: []string{target.Template},
+			Counts:  []int{target.Count},
+		}
+		target.Template += " " + VariadicPlaceholder
+		for _, result := range merged {
+			entry.Sources = append(entry.Sources, result.Template)
+			entry.Counts = append(entry.Counts, result.Count)
+			mergeExamples(target, result, p.config.ExamplesPerTemplate)
+			target.Count += result.Count
+			target.LogIDs = append(target.LogIDs, result.LogIDs...)
+			if result.Severity > target.Severity {
+				target.Severity = result.Severity
+			}
+		}
+		entry.Result = target.Template
+		if report != nil {
+			report.MergeAudit = append(report.MergeAudit, entry)
+		}
+	}
+
+	kept := results[:0]
+	for _, result := range results {
+		if root[result] == result {
+			kept = append(kept, result)
+			continue
+		}
+		PutIntSlice(result.LogIDs)
+		result.LogIDs = nil
+		PutParseResult(result)
+	}
+	clear(results[len(kept):])
+	return kept
+}
+
+// isVariablePrefix reports whether prefix has a constant and matches the
+// start of words, its <*> matching any token and its named placeholders of
+// slots the tokens of their variable
+func isVariablePrefix(prefix, words []string, slots placeholderSlots) bool {
+	constant := false
+	for i, token := range prefix {
+		if !slots.matches(token, words[i]) {
+			return false
+		}
+		if !slots.isSlot(token) {
+			constant = true
+		}
+	}
+	return constant
+}
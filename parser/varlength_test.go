@@ -0,0 +1,70 @@
+package parser
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestMergeVariableLength(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`, VariableLengthTokens: 2})
+	results := Results{
+		{Template: "User <*> logged in", Count: 5, LogIDs: []int{0, 1, 2, 3, 4}, Severity: SeverityInfo},
+		{Template: "User <*> logged in from <*>", Count: 3, LogIDs: []int{5, 6, 7}, Severity: SeverityWarning},
+		{Template: "User <*> logged in from <*> via ssh", Count: 1, LogIDs: []int{8}},
+		{Template: "User <*> logged out now and then", Count: 2, LogIDs: []int{9, 10}}, // Differs inside the prefix
+		{Template: "<*> <*>", Count: 4, LogIDs: []int{11, 12, 13, 14}},                 // No constant to anchor on
+	}
+	report := &ParseReport{}
+	merged := p.mergeVariableLength(results, report)
+
+	counts := make(map[string]int)
+	for _, result := range merged {
+		counts[result.Template] = result.Count
+	}
+	// The 7-token template joins the 6-token one, which joins the shortest
+	expected := map[string]int{"User <*> logged in <*>...": 9, "User <*> logged out now and then": 2, "<*> <*>": 4}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected %v, got %v", expected, counts)
+	}
+	for _, result := range merged {
+		if result.Template == "User <*> logged in <*>..." && (len(result.LogIDs) != 9 || result.Severity != SeverityWarning) {
+			t.Errorf("Expected all LogIDs and the highest severity, got %v %v", result.LogIDs, result.Severity)
+		}
+	}
+	if len(report.MergeAudit) != 1 || report.MergeAudit[0].Reason != MergeReasonVariableLength ||
+		len(report.MergeAudit[0].Sources) != 3 {
+		t.Errorf("Expected one variable-length merge of 3 templates in the audit, got %+v", report.MergeAudit)
+	}
+}
+
+func TestBrain_VariableLength(t *testing.T) {
+	lines := []string{
+		"User alice logged in",
+		"User bob logged in",
+		"User carol logged in",
+		"User dave logged in from 10.0.0.1",
+		"User erin logged in from 10.0.0.2",
+	}
+	p := New(Config{Delimiters: `\s+`, CommonVariables: map[string]string{}, VariableLengthTokens: 2})
+	results := p.Parse(lines)
+	if len(results) != 1 || results[0].Template != "User <*> logged in <*>..." || results[0].Count != 5 {
+		t.Fatalf("Expected one variable-length template, got %+v", results)
+	}
+
+	regex := regexp.MustCompile(`^\W*(?:` + TemplateToRegex(results[0].Template) + `)\W*$`)
+	for _, line := range lines {
+		if !regex.MatchString(line) {
+			t.Errorf("Expected the template regex to match %q", line)
+		}
+	}
+
+	params, ok := p.ExtractParams(results[0].Template, "User alice logged in")
+	if !ok || !reflect.DeepEqual(params, []string{"alice", ""}) {
+		t.Errorf("Expected an empty variadic value, got %v %v", params, ok)
+	}
+	params, ok = p.ExtractParams(results[0].Template, "User dave logged in from 10.0.0.1")
+	if !ok || !reflect.DeepEqual(params, []string{"dave", "from 10.0.0.1"}) {
+		t.Errorf("Expected the trailing tokens as variadic value, got %v %v", params, ok)
+	}
+}
@@ -0,0 +1,27 @@
+package parser
+
+import "context"
+
+// ParseWeighted behaves like ParseWithReport for lines with a multiplicity,
+// e.g. pre-aggregated counts of an upstream deduplication. A line with
+// weight n counts n times in word frequencies and template counts, so the
+// result matches parsing n copies of it; LogIDs still list each line once.
+// Missing or non-positive weights count as 1.
+func (p *BrainParser) ParseWeighted(logLines []string, weights []int) *ParseReport {
+	report, _ := p.parseReport(context.Background(), logLines, weights, &ParseReport{})
+	return report
+}
+
+// ParseWeightedContext behaves like ParseWeighted but stops early when ctx is
+// canceled or its deadline expires, returning ctx.Err() and no report.
+func (p *BrainParser) ParseWeightedContext(ctx context.Context, logLines []string, weights []int) (*ParseReport, error) {
+	return p.parseWeightedReport(ctx, logLines, weights)
+}
+
+// lineWeight returns the weight of line i (1 if not set)
+func lineWeight(weights []int, i int) int {
+	if i < len(weights) && weights[i] > 0 {
+		return weights[i]
+	}
+	return 1
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseWeightedMatchesRawParse(t *testing.T) {
+	var raw, unique []string
+	var weights []int
+	for i := 0; i < 30; i++ {
+		lines := []string{
+			fmt.Sprintf("User user%d logged in from web", i%10),
+			fmt.Sprintf("Connection to db%d failed after %d ms", i%3, i%4*100),
+			"Backup finished",
+		}
+		for j, line := range lines {
+			weight := 1 + (i+j)%4
+			unique = append(unique, line)
+			weights = append(weights, weight)
+			for range weight {
+				raw = append(raw, line)
+			}
+		}
+	}
+
+	for _, enhanced := range []bool{false, true} {
+		config := Config{Delimiters: `\s+`, Deterministic: true, UseEnhancedPostProcessing: enhanced}
+		expected := make(map[string]int)
+		for _, result := range New(config).Parse(raw) {
+			expected[result.Template] = result.Count
+		}
+
+		report := New(config).ParseWeighted(unique, weights)
+		got := make(map[string]int)
+		total := 0
+		for _, result := range report.Results {
+			got[result.Template] = result.Count
+			total += len(result.LogIDs)
+		}
+		if total != len(unique) {
+			t.Errorf("enhanced=%v: expected %d log IDs, got %d", enhanced, len(unique), total)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("enhanced=%v: weighted templates differ from raw parse:\n got %v\nwant %v", enhanced, got, expected)
+		}
+	}
+}
+
+func TestParseWeightedResumed(t *testing.T) {
+	p := New(Config{Delimiters: `\s+`})
+	p.Parse([]string{"User alice logged in", "User bob logged in", "User carl logged in"})
+	p.state.resume = true
+
+	report := p.ParseWeighted([]string{"User dave logged in", "Disk full"}, []int{5, 2})
+	counts := make(map[string]int)
+	for _, result := range report.Results {
+		counts[result.Template] = result.Count
+	}
+	if counts["User <*> logged in"] != 5 || counts["Disk full"] != 2 {
+		t.Errorf("Unexpected resumed weighted counts %v", counts)
+	}
+}